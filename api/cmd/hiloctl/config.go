@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hilo-api/pkg/config"
+	"strconv"
+)
+
+func runConfigPrint(a *app, args []string) error {
+	const usage = "config print [-redacted]"
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "mask passwords and keys")
+	if _, err := parse(flags, args, 0, usage); err != nil {
+		return err
+	}
+
+	// an invalid configuration is still printed, it is what needs looking at
+	set, err := config.NewSet()
+	validation := &config.ValidationError{}
+	if err != nil && !errors.As(err, &validation) {
+		return err
+	}
+
+	sections := set.Values(*redacted)
+	if a.format == "json" {
+		if printErr := a.print(sections, table{}); printErr != nil {
+			return printErr
+		}
+		return err
+	}
+
+	// YAML that CONFIG_FILE accepts back
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(a.out)
+		}
+		fmt.Fprintf(a.out, "%s:\n", section.Name)
+		for _, value := range section.Values {
+			fmt.Fprintf(a.out, "  %s: %s\n", value.Key, strconv.Quote(value.Value))
+		}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hilo-api/pkg/jwt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keysView is the printed form of a generated key pair
+type keysView struct {
+	Algorithm   string `json:"algorithm"`
+	PrivatePath string `json:"private_path,omitempty"`
+	PublicPath  string `json:"public_path,omitempty"`
+	PrivatePEM  string `json:"private_pem,omitempty"`
+	PublicPEM   string `json:"public_pem"`
+}
+
+func runKeysGenerate(a *app, args []string) error {
+	const usage = "keys generate [-alg ES256|Ed25519] [-out DIR]"
+	flags := flag.NewFlagSet("keys generate", flag.ContinueOnError)
+	algorithm := flags.String("alg", string(jwt.KeyES256), "ES256 or Ed25519")
+	out := flags.String("out", "", "directory to write <alg>_private.pem and <alg>_public.pem to, stdout when empty")
+	if _, err := parse(flags, args, 0, usage); err != nil {
+		return err
+	}
+
+	pair, err := jwt.GenerateKeyPair(jwt.KeyAlgorithm(*algorithm))
+	if errors.Is(err, jwt.ErrUnknownKeyAlgorithm) {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	view := keysView{Algorithm: string(pair.Algorithm), PublicPEM: string(pair.PublicPEM)}
+	if *out == "" {
+		view.PrivatePEM = string(pair.PrivatePEM)
+		if a.format == "table" {
+			// PEM blocks are already plain text
+			_, err := fmt.Fprint(a.out, view.PrivatePEM, view.PublicPEM)
+			return err
+		}
+		return a.print(view, table{})
+	}
+
+	prefix := filepath.Join(*out, strings.ToLower(string(pair.Algorithm)))
+	view.PrivatePath, view.PublicPath = prefix+"_private.pem", prefix+"_public.pem"
+	if err := writeNew(view.PrivatePath, pair.PrivatePEM, 0o600); err != nil {
+		return err
+	}
+	if err := writeNew(view.PublicPath, pair.PublicPEM, 0o644); err != nil {
+		return err
+	}
+	return a.print(view, keyValues(
+		"algorithm", view.Algorithm,
+		"private_path", view.PrivatePath,
+		"public_path", view.PublicPath,
+	))
+}
+
+// writeNew refuses to overwrite, a replaced signing key invalidates every issued token
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists, remove it first to rotate the key", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
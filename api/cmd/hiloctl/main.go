@@ -0,0 +1,176 @@
+// Command hiloctl is the administrative CLI of the service.
+//
+//	hiloctl [-o table|json] user create -email E -username U [-password P]
+//	hiloctl [-o table|json] user suspend|reinstate USER
+//	hiloctl [-o table|json] user delete -yes USER
+//	hiloctl [-o table|json] user reset-password [-password P] USER
+//	hiloctl [-o table|json] token mint -user USER [-permission PREFIX]... [-expires 24h]
+//	hiloctl [-o table|json] token inspect TOKEN
+//	hiloctl [-o table|json] token verify TOKEN
+//	hiloctl [-o table|json] keys generate [-alg ES256|Ed25519] [-out DIR]
+//	hiloctl [-o table|json] stats [-window 24h]
+//	hiloctl [-o table|json] config print [-redacted]
+//
+// USER is an id or an email. Connections and keys come from the same
+// environment as the restful server.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/database/postgres"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var ErrUsage = errors.New("usage")
+
+// command is one `hiloctl <group> <name>` entry
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"user create":         {"user create -email E -username U [-password P]", runUserCreate},
+	"user suspend":        {"user suspend USER", runUserSuspend(true)},
+	"user reinstate":      {"user reinstate USER", runUserSuspend(false)},
+	"user delete":         {"user delete -yes USER", runUserDelete},
+	"user reset-password": {"user reset-password [-password P] USER", runUserResetPassword},
+	"token mint":          {"token mint -user USER [-permission PREFIX]... [-expires 24h] [-key PEM]", runTokenMint},
+	"token inspect":       {"token inspect TOKEN", runTokenInspect},
+	"token verify":        {"token verify [-key PEM] TOKEN", runTokenVerify},
+	"keys generate":       {"keys generate [-alg ES256|Ed25519] [-out DIR]", runKeysGenerate},
+	"stats":               {"stats [-window 24h]", runStats},
+	"config print":        {"config print [-redacted]", runConfigPrint},
+}
+
+func main() {
+	a := &app{out: os.Stdout}
+	err := a.run(os.Args[1:])
+	a.close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hiloctl:", err)
+		if errors.Is(err, ErrUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// app carries what commands share and opens the database only when asked
+type app struct {
+	out    io.Writer
+	format string
+
+	set     *config.Set
+	logger  *zap.Logger
+	db      *sqlx.DB
+	closers []func()
+}
+
+func (a *app) run(args []string) error {
+	flags := flag.NewFlagSet("hiloctl", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&a.format, "o", "table", "output format, table or json")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w\n%s", ErrUsage, err, usage())
+	}
+	if a.format != "table" && a.format != "json" {
+		return fmt.Errorf("%w: -o must be table or json, got %q", ErrUsage, a.format)
+	}
+
+	args = flags.Args()
+	for _, n := range []int{2, 1} {
+		if len(args) < n {
+			continue
+		}
+		if cmd, ok := commands[strings.Join(args[:n], " ")]; ok {
+			return cmd.run(a, args[n:])
+		}
+	}
+	return fmt.Errorf("%w: unknown command %q\n%s", ErrUsage, strings.Join(args, " "), usage())
+}
+
+func usage() string {
+	lines := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		lines = append(lines, "  hiloctl [-o table|json] "+cmd.usage)
+	}
+	sort.Strings(lines)
+	return "commands:\n" + strings.Join(lines, "\n")
+}
+
+// config loads the same environment the restful server reads
+func (a *app) config() (config.Set, error) {
+	if a.set == nil {
+		set, err := config.NewSet()
+		if err != nil {
+			return config.Set{}, err
+		}
+		a.set = &set
+	}
+	return *a.set, nil
+}
+
+func (a *app) database() (*sqlx.DB, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+	set, err := a.config()
+	if err != nil {
+		return nil, err
+	}
+
+	// only warnings reach stderr, stdout stays parseable
+	logConfig := zap.NewProductionConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	if a.logger, err = logConfig.Build(); err != nil {
+		return nil, err
+	}
+
+	db, cleanup, err := postgres.NewPostgresDB(a.logger, set.Postgres)
+	if err != nil {
+		return nil, err
+	}
+	a.db = db
+	a.closers = append(a.closers, cleanup)
+	return db, nil
+}
+
+func (a *app) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	if a.logger != nil {
+		_ = a.logger.Sync()
+	}
+}
+
+// parse parses flags that may follow or precede the positional arguments
+func parse(flags *flag.FlagSet, args []string, positional int, usage string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	var rest []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrUsage, usage, err)
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest, args = append(rest, args[0]), args[1:]
+	}
+	if len(rest) != positional {
+		return nil, fmt.Errorf("%w: %s", ErrUsage, usage)
+	}
+	return rest, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table is how a result renders with -o table
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// print renders data as indented JSON or t as an aligned table
+func (a *app) print(data any, t table) error {
+	if a.format == "json" {
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// keyValues is a two column table of ordered fields
+func keyValues(pairs ...string) table {
+	t := table{header: []string{"FIELD", "VALUE"}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		t.rows = append(t.rows, []string{pairs[i], pairs[i+1]})
+	}
+	return t
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
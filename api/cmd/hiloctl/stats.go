@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"hilo-api/internal/application/admin"
+	infra "hilo-api/internal/infrastructure/postgres"
+	"strconv"
+	"time"
+)
+
+// statsView is the printed form of do.Statistics
+type statsView struct {
+	Users               int       `json:"users"`
+	SuspendedUsers      int       `json:"suspended_users"`
+	Messages            int       `json:"messages"`
+	UnreadMessages      int       `json:"unread_messages"`
+	Conversations       int       `json:"conversations"`
+	Since               time.Time `json:"since"`
+	MessagesSince       int       `json:"messages_since"`
+	ActiveConversations int       `json:"active_conversations"`
+}
+
+func runStats(a *app, args []string) error {
+	const usage = "stats [-window 24h]"
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	window := flags.Duration("window", 24*time.Hour, "how far back recent activity is counted")
+	if _, err := parse(flags, args, 0, usage); err != nil {
+		return err
+	}
+
+	db, err := a.database()
+	if err != nil {
+		return err
+	}
+	stats, err := admin.NewStatisticsUseCase(infra.NewMessageRepository(db)).Execute(context.Background(), *window)
+	if err != nil {
+		return err
+	}
+
+	view := statsView(*stats)
+	return a.print(view, keyValues(
+		"users", strconv.Itoa(view.Users),
+		"suspended_users", strconv.Itoa(view.SuspendedUsers),
+		"messages", strconv.Itoa(view.Messages),
+		"unread_messages", strconv.Itoa(view.UnreadMessages),
+		"conversations", strconv.Itoa(view.Conversations),
+		"since", formatTime(&view.Since),
+		"messages_since", strconv.Itoa(view.MessagesSince),
+		"active_conversations", strconv.Itoa(view.ActiveConversations),
+	))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"hilo-api/internal/application/admin"
+	"hilo-api/internal/application/auth"
+	"hilo-api/internal/domain/do"
+	infra "hilo-api/internal/infrastructure/postgres"
+	"hilo-api/pkg/database/postgres"
+	"time"
+)
+
+// userView is the printed form of a user, never including the password hash
+type userView struct {
+	ID          string     `json:"id"`
+	Email       string     `json:"email"`
+	Username    string     `json:"username"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+	Password    string     `json:"password,omitempty"`
+}
+
+func newUserView(user *do.User) userView {
+	return userView{
+		ID:          user.ID().String(),
+		Email:       user.Email(),
+		Username:    user.Username(),
+		CreatedAt:   user.CreatedAt(),
+		SuspendedAt: user.SuspendedAt(),
+	}
+}
+
+func (a *app) printUser(view userView) error {
+	t := keyValues(
+		"id", view.ID,
+		"email", view.Email,
+		"username", view.Username,
+		"created_at", formatTime(&view.CreatedAt),
+		"suspended_at", formatTime(view.SuspendedAt),
+	)
+	if view.Password != "" {
+		t.rows = append(t.rows, []string{"password", view.Password})
+	}
+	return a.print(view, t)
+}
+
+func (a *app) users() (*infra.UserRepository, error) {
+	db, err := a.database()
+	if err != nil {
+		return nil, err
+	}
+	return infra.NewUserRepository(db), nil
+}
+
+func (a *app) findUser(ctx context.Context, ref string) (*do.User, error) {
+	users, err := a.users()
+	if err != nil {
+		return nil, err
+	}
+	return admin.NewFindUserUseCase(users).Execute(ctx, ref)
+}
+
+func runUserCreate(a *app, args []string) error {
+	const usage = "user create -email E -username U [-password P]"
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the account")
+	username := flags.String("username", "", "unique display name")
+	password := flags.String("password", "", "initial password, generated and printed when empty")
+	if _, err := parse(flags, args, 0, usage); err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+
+	db, err := a.database()
+	if err != nil {
+		return err
+	}
+	set, err := a.config()
+	if err != nil {
+		return err
+	}
+	register := auth.NewRegisterUseCase(infra.NewUserRepository(db), infra.NewTransactor(postgres.NewTxManager(db, set.Postgres)))
+	user, err := register.Execute(context.Background(), *email, *password, *username)
+	if err != nil {
+		return err
+	}
+
+	view := newUserView(user)
+	if generated {
+		view.Password = *password
+	}
+	return a.printUser(view)
+}
+
+func runUserSuspend(suspend bool) func(a *app, args []string) error {
+	usage := "user reinstate USER"
+	if suspend {
+		usage = "user suspend USER"
+	}
+	return func(a *app, args []string) error {
+		rest, err := parse(flag.NewFlagSet(usage, flag.ContinueOnError), args, 1, usage)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		user, err := a.findUser(ctx, rest[0])
+		if err != nil {
+			return err
+		}
+		users, err := a.users()
+		if err != nil {
+			return err
+		}
+		user, err = admin.NewSuspendUserUseCase(users).Execute(ctx, user.ID(), suspend)
+		if err != nil {
+			return err
+		}
+		return a.printUser(newUserView(user))
+	}
+}
+
+func runUserDelete(a *app, args []string) error {
+	const usage = "user delete -yes USER"
+	flags := flag.NewFlagSet("user delete", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "confirm deleting the user and all of their messages")
+	rest, err := parse(flags, args, 1, usage)
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		return fmt.Errorf("%w: deleting a user also deletes their messages, pass -yes to confirm", ErrUsage)
+	}
+
+	ctx := context.Background()
+	user, err := a.findUser(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	users, err := a.users()
+	if err != nil {
+		return err
+	}
+	if err := admin.NewDeleteUserUseCase(users).Execute(ctx, user.ID()); err != nil {
+		return err
+	}
+	return a.printUser(newUserView(user))
+}
+
+func runUserResetPassword(a *app, args []string) error {
+	const usage = "user reset-password [-password P] USER"
+	flags := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	password := flags.String("password", "", "new password, generated and printed when empty")
+	rest, err := parse(flags, args, 1, usage)
+	if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+
+	ctx := context.Background()
+	user, err := a.findUser(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	users, err := a.users()
+	if err != nil {
+		return err
+	}
+	if err := admin.NewResetPasswordUseCase(users).Execute(ctx, user.ID(), *password); err != nil {
+		return err
+	}
+
+	view := newUserView(user)
+	if generated {
+		view.Password = *password
+	}
+	return a.printUser(view)
+}
+
+// randomPassword returns 128 random bits, comfortably above do.MinPasswordLength
+func randomPassword() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
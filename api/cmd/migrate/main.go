@@ -0,0 +1,177 @@
+// Command migrate applies the migrations embedded in the binary.
+//
+//	migrate [-database url] up
+//	migrate [-database url] down N
+//	migrate [-database url] goto VERSION
+//	migrate [-database url] version
+//	migrate [-database url] force VERSION
+//	migrate [-database url] status
+//	migrate [-dir path] create NAME
+//
+// force -1 clears the version when the very first migration left the schema
+// dirty. The connection comes from the POSTGRES_* settings, layered from
+// CONFIG_FILE and the environment like the restful server, unless -database
+// is given.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hilo-api/deployments/migrations"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/database/postgres"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrUsage = errors.New("usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		if errors.Is(err, ErrUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	databaseURL := flags.String("database", "", "postgres url, overrides POSTGRES_URL")
+	dir := flags.String("dir", "deployments/migrations/postgresql", "directory create writes new migrations to")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("%w: missing command, one of up, down, goto, version, force, status, create", ErrUsage)
+	}
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	if command == "create" {
+		if len(rest) != 1 {
+			return fmt.Errorf("%w: create NAME", ErrUsage)
+		}
+		up, down, err := migrations.Create(*dir, rest[0], time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, up)
+		fmt.Fprintln(out, down)
+		return nil
+	}
+
+	sources, err := config.Sources()
+	if err != nil {
+		return err
+	}
+	opt := config.Postgres{}
+	if err := config.Load(&opt, sources...); err != nil {
+		return err
+	}
+	if *databaseURL != "" {
+		opt.PostgresURL = *databaseURL
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	migrator, err := postgres.NewMigrator(logger, opt, migrations.FS, migrations.Dir)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		return migrator.Up()
+	case "down":
+		n, err := intArg(rest, "down N")
+		if err != nil {
+			return err
+		}
+		return migrator.Down(n)
+	case "goto":
+		version, err := intArg(rest, "goto VERSION")
+		if err != nil {
+			return err
+		}
+		return migrator.Goto(uint(version))
+	case "force":
+		version, err := forceArg(rest)
+		if err != nil {
+			return err
+		}
+		return migrator.Force(version)
+	case "version":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d dirty=%t\n", version, dirty)
+		return nil
+	case "status":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		list, err := migrations.List()
+		if err != nil {
+			return err
+		}
+		return printStatus(out, list, version, dirty)
+	}
+	return fmt.Errorf("%w: unknown command %q", ErrUsage, command)
+}
+
+func intArg(args []string, usage string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%w: %s", ErrUsage, usage)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: %s, got %q", ErrUsage, usage, args[0])
+	}
+	return n, nil
+}
+
+// forceArg accepts -1 on top of what intArg does, which forces the schema
+// back to no version when the very first migration failed halfway
+func forceArg(args []string) (int, error) {
+	if len(args) == 1 && args[0] == "-1" {
+		return -1, nil
+	}
+	return intArg(args, "force VERSION")
+}
+
+// printStatus lists the embedded migrations against the applied version
+func printStatus(out io.Writer, list []migrations.Migration, version uint, dirty bool) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	known := false
+	for _, migration := range list {
+		state := "pending"
+		switch {
+		case migration.Version == version && dirty:
+			state = "dirty"
+		case migration.Version <= version:
+			state = "applied"
+		}
+		known = known || migration.Version == version
+		fmt.Fprintf(w, "%d\t%s\t%s\n", migration.Version, migration.Name, state)
+	}
+	if version != 0 && !known {
+		fmt.Fprintf(w, "%d\t?\tapplied, not shipped with this binary\n", version)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"hilo-api/deployments/migrations"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUsage(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.ErrorIs(t, run(nil, out), ErrUsage)
+	assert.ErrorIs(t, run([]string{"create"}, out), ErrUsage)
+	assert.ErrorIs(t, run([]string{"-unknown"}, out), ErrUsage)
+}
+
+func TestRunCreate(t *testing.T) {
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+	require.NoError(t, run([]string{"-dir", dir, "create", "add_reactions"}, out))
+
+	paths := strings.Fields(out.String())
+	require.Len(t, paths, 2)
+	assert.True(t, strings.HasSuffix(paths[0], "_add_reactions.up.sql"))
+	assert.True(t, strings.HasSuffix(paths[1], "_add_reactions.down.sql"))
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		assert.NoError(t, err)
+	}
+}
+
+func TestIntArg(t *testing.T) {
+	n, err := intArg([]string{"3"}, "down N")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	_, err = intArg(nil, "down N")
+	assert.ErrorIs(t, err, ErrUsage)
+	_, err = intArg([]string{"x"}, "down N")
+	assert.ErrorIs(t, err, ErrUsage)
+	_, err = intArg([]string{"-1"}, "down N")
+	assert.ErrorIs(t, err, ErrUsage)
+}
+
+func TestForceArg(t *testing.T) {
+	n, err := forceArg([]string{"-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, -1, n)
+
+	n, err = forceArg([]string{"20251104031423"})
+	assert.NoError(t, err)
+	assert.Equal(t, 20251104031423, n)
+
+	_, err = forceArg([]string{"-2"})
+	assert.ErrorIs(t, err, ErrUsage)
+	_, err = forceArg(nil)
+	assert.ErrorIs(t, err, ErrUsage)
+}
+
+func TestPrintStatus(t *testing.T) {
+	list := []migrations.Migration{{Version: 1, Name: "extension"}, {Version: 2, Name: "database"}, {Version: 3, Name: "reactions"}}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, printStatus(out, list, 2, true))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "applied")
+	assert.Contains(t, lines[2], "dirty")
+	assert.Contains(t, lines[3], "pending")
+
+	out.Reset()
+	require.NoError(t, printStatus(out, list, 9, false))
+	assert.Contains(t, out.String(), "not shipped with this binary")
+}
@@ -2,8 +2,6 @@ package main
 
 import (
 	"hilo-api/pkg/errorCatcher"
-	"hilo-api/pkg/shutdown"
-	"os"
 
 	"go.uber.org/zap"
 )
@@ -15,15 +13,11 @@ func main() {
 	}
 	defer errorCatcher.PanicErrorHandler(logger, "Restful server interrupt => \n")
 
-	_, cleanup, err := RestfulRunner()
+	// everything that needs stopping registered its own shutdown hook; wire
+	// runs its cleanup itself only when building fails
+	runner, _, err := RestfulRunner()
 	if err != nil {
 		panic(err)
 	}
-	defer cleanup()
-
-	quit := make(chan os.Signal)
-	defer close(quit)
-	shutdown.NewShutdown(
-		shutdown.WithQuit(quit),
-	).Shutdown()
+	runner.Shutdown.Shutdown()
 }
@@ -6,19 +6,38 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
-	"hilo-api/internal/domains/definition"
+	"hilo-api/deployments/migrations"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/application/attachment"
+	"hilo-api/internal/application/auth"
+	"hilo-api/internal/application/message"
+	"hilo-api/internal/application/presence"
+	"hilo-api/internal/domain/definition"
+	"hilo-api/internal/domain/repository"
+	infra "hilo-api/internal/infrastructure/postgres"
 	restfulRouter "hilo-api/internal/presentation/restful"
+	"hilo-api/pkg/blob"
 	"hilo-api/pkg/config"
 	"hilo-api/pkg/database/postgres"
 	"hilo-api/pkg/jwt"
 	"hilo-api/pkg/logger"
+	"hilo-api/pkg/ratelimit"
+	"hilo-api/pkg/realtime"
 	"hilo-api/pkg/restful"
+	"hilo-api/pkg/shutdown"
+	"hilo-api/pkg/tracing"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"github.com/jmoiron/sqlx"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -29,11 +48,272 @@ func ctx() context.Context {
 }
 
 var ctxSet = wire.NewSet(ctx)
-var LoggerSet = wire.NewSet(logger.NewZap)
+var LoggerSet = wire.NewSet(logger.NewAtomicLevel, logger.NewZapWithLevel)
 
 type Empty struct{}
 
-func RunRestfulServer(logger *zap.Logger, coreOptions config.Set, route *gin.Engine, commonHandler restful.CommonHandler, handlers restfulRouter.HandlerSet) (Empty, func(), error) {
+// Runner is what main needs to keep the server running and stop it in order
+type Runner struct {
+	Server   Empty
+	Shutdown *shutdown.Shutdown
+}
+
+// Schema is the migration version the server checked the database against
+type Schema struct {
+	Version uint
+}
+
+// NewSchema method
+// applies pending migrations when POSTGRES_MIGRATE_ON_STARTUP is set, then
+// refuses to start on a dirty schema or one newer than the binary
+func NewSchema(logger *zap.Logger, opt config.Postgres, db *sqlx.DB) (Schema, error) {
+	want, err := migrations.LatestVersion()
+	if err != nil {
+		return Schema{}, err
+	}
+
+	if opt.PostgresMigrateOnStartup {
+		migrator, err := postgres.NewMigrator(logger, opt, migrations.FS, migrations.Dir)
+		if err != nil {
+			return Schema{}, err
+		}
+		err = migrator.Up()
+		if closeErr := migrator.Close(); closeErr != nil {
+			logger.Warn("postgres migrator close failed", zap.Error(closeErr))
+		}
+		if err != nil {
+			return Schema{}, err
+		}
+	}
+
+	if err := postgres.CheckSchema(context.Background(), logger, db, want); err != nil {
+		return Schema{}, err
+	}
+	return Schema{Version: want}, nil
+}
+
+// NewReloader method
+// the log level follows LOG_LEVEL here, CORS and the JWT allow list
+// subscribe in restful.NewGin
+func NewReloader(zapLogger *zap.Logger, set config.Set, level zap.AtomicLevel) *config.Reloader {
+	reloader := config.NewReloader(set, config.WithReloadLogger(zapLogger))
+	logger.FollowLevel(reloader, level)
+	return reloader
+}
+
+// every runs fn at interval in the background until the shutdown hook
+// named name stops it in PhaseDrainConnections, before the database closes.
+// The returned cleanup stops it too, for when wire fails to build the rest.
+func every(sd *shutdown.Shutdown, name string, interval time.Duration, fn func(ctx context.Context)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		<-stopped
+	}
+	sd.Register(shutdown.Hook{
+		Name:  name,
+		Phase: shutdown.PhaseDrainConnections,
+		Fn: func(context.Context) error {
+			stop()
+			return nil
+		},
+	})
+	return stop
+}
+
+// NewTracerProvider method
+// spans still buffered are flushed in PhaseFlush
+func NewTracerProvider(sd *shutdown.Shutdown, core config.Core, opt config.Tracing) (*sdktrace.TracerProvider, func(), error) {
+	provider, cleanup, err := tracing.NewTracerProvider(core, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sd.Register(shutdown.Hook{
+		Name:  "tracer",
+		Phase: shutdown.PhaseFlush,
+		Fn:    provider.Shutdown,
+	})
+	return provider, cleanup, nil
+}
+
+// NewPostgresDB method
+// the pool closes in PhaseCloseResources, after everything using it stopped
+func NewPostgresDB(sd *shutdown.Shutdown, logger *zap.Logger, opt config.Postgres) (*sqlx.DB, func(), error) {
+	db, cleanup, err := postgres.NewPostgresDB(logger, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sd.Register(shutdown.Hook{
+		Name:  "database",
+		Phase: shutdown.PhaseCloseResources,
+		Fn: func(context.Context) error {
+			return db.Close()
+		},
+	})
+	return db, cleanup, nil
+}
+
+// NewRateLimitStore method
+// the postgres backend prunes refilled buckets in the background until
+// shutdown
+func NewRateLimitStore(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.RateLimit, db *sqlx.DB) (ratelimit.Store, func(), error) {
+	if cfg.RateLimitBackend != "postgres" {
+		return ratelimit.NewMemory(), func() {}, nil
+	}
+
+	// a bucket untouched for its period is full again, prune after the longest
+	var longest time.Duration
+	for _, spec := range []string{cfg.RateLimitDefault, cfg.RateLimitMessages} {
+		policy, err := ratelimit.ParsePolicy("", spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		longest = max(longest, policy.Period)
+	}
+
+	store := postgres.NewRateLimitStore(db)
+	cleanup := every(sd, "rate limit pruner", time.Minute, func(ctx context.Context) {
+		if _, err := store.Prune(ctx, longest); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("rate limit prune failed", zap.String("system", "RateLimit"), zap.Error(err))
+		}
+	})
+	return store, cleanup, nil
+}
+
+// IdempotencyPruner drops expired idempotency keys in the background
+type IdempotencyPruner struct{}
+
+// NewIdempotencyPruner method
+func NewIdempotencyPruner(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.Message, repo repository.IdempotencyRepository) (IdempotencyPruner, func()) {
+	cleanup := every(sd, "idempotency pruner", time.Hour, func(ctx context.Context) {
+		if _, err := repo.DeleteExpired(ctx, time.Now().Add(-cfg.MessageIdempotencyTTL)); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("idempotency key prune failed", zap.String("system", "Message"), zap.Error(err))
+		}
+	})
+	return IdempotencyPruner{}, cleanup
+}
+
+// NewLocalBlobs method
+// nil unless ATTACHMENT_BACKEND is local; without ATTACHMENT_URL_SECRET the
+// signing secret is random, so URLs die with the process and do not work
+// across replicas
+func NewLocalBlobs(zapLogger *zap.Logger, cfg config.Attachment) (*blob.Local, error) {
+	if cfg.AttachmentBackend != "local" {
+		return nil, nil
+	}
+
+	secret := []byte(cfg.AttachmentURLSecret)
+	if len(secret) == 0 {
+		zapLogger.Warn("ATTACHMENT_URL_SECRET is empty, signing attachment URLs with a random secret",
+			zap.String("system", "Attachment"))
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return blob.NewLocal(cfg.AttachmentLocalDir, secret, blob.WithBaseURL(cfg.AttachmentPublicURL))
+}
+
+// NewBlobStore method
+func NewBlobStore(cfg config.Attachment, local *blob.Local) (attachment.BlobStore, error) {
+	if local != nil {
+		return local, nil
+	}
+
+	var options []blob.S3Option
+	if !cfg.AttachmentS3PathStyle {
+		options = append(options, blob.WithVirtualHostedStyle())
+	}
+	return blob.NewS3(cfg.AttachmentS3Endpoint, cfg.AttachmentS3Region, cfg.AttachmentS3Bucket,
+		cfg.AttachmentS3AccessKey, cfg.AttachmentS3SecretKey, options...)
+}
+
+// NewStaging method
+func NewStaging(cfg config.Attachment) (*blob.Staging, error) {
+	return blob.NewStaging(cfg.AttachmentUploadDir)
+}
+
+// AttachmentPruner drops unreachable attachments and expired uploads in the
+// background
+type AttachmentPruner struct{}
+
+// NewAttachmentPruner method
+func NewAttachmentPruner(zapLogger *zap.Logger, sd *shutdown.Shutdown, prune *attachment.PruneAttachmentsUseCase) (AttachmentPruner, func()) {
+	cleanup := every(sd, "attachment pruner", time.Hour, func(ctx context.Context) {
+		if _, err := prune.Execute(ctx); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("attachment prune failed", zap.String("system", "Attachment"), zap.Error(err))
+		}
+	})
+	return AttachmentPruner{}, cleanup
+}
+
+// AttachmentProcessor makes thumbnails of image attachments in the
+// background
+type AttachmentProcessor struct{}
+
+// NewAttachmentProcessor method
+func NewAttachmentProcessor(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.Attachment, process *attachment.ProcessAttachmentsUseCase) (AttachmentProcessor, func()) {
+	cleanup := every(sd, "attachment processor", cfg.AttachmentProcessInterval, func(ctx context.Context) {
+		if _, err := process.Execute(ctx); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("attachment processing failed", zap.String("system", "Attachment"), zap.Error(err))
+		}
+	})
+	return AttachmentProcessor{}, cleanup
+}
+
+// PresenceSweeper ends sessions that stopped beating and typing nobody
+// stopped in the background
+type PresenceSweeper struct{}
+
+// NewPresenceSweeper method
+func NewPresenceSweeper(zapLogger *zap.Logger, sd *shutdown.Shutdown, sweep *presence.SweepUseCase) (PresenceSweeper, func()) {
+	cleanup := every(sd, "presence sweeper", time.Second, func(ctx context.Context) {
+		if _, err := sweep.Execute(ctx); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("presence sweep failed", zap.String("system", "Presence"), zap.Error(err))
+		}
+	})
+	return PresenceSweeper{}, cleanup
+}
+
+// NewPresenceTracker method
+func NewPresenceTracker(cfg config.Presence) *realtime.Presence {
+	return realtime.NewPresence(cfg.PresenceTimeout)
+}
+
+// NewTypingTracker method
+func NewTypingTracker(cfg config.Presence) *realtime.Typing {
+	return realtime.NewTyping(cfg.TypingThrottle, cfg.TypingTimeout)
+}
+
+// NewHub method
+func NewHub() *realtime.Hub {
+	return realtime.NewHub()
+}
+
+// NewShutdown method
+func NewShutdown(logger *zap.Logger, opt config.Server) *shutdown.Shutdown {
+	return shutdown.NewShutdown(
+		shutdown.WithLogger(logger),
+		shutdown.WithServerTimeout(opt.ShutdownTimeout),
+	)
+}
+
+func RunRestfulServer(logger *zap.Logger, coreOptions config.Set, _ *sdktrace.TracerProvider, _ Schema, _ IdempotencyPruner, _ AttachmentPruner, _ AttachmentProcessor, _ PresenceSweeper, route *gin.Engine, commonHandler restful.CommonHandler, handlers restfulRouter.HandlerSet, reloader *config.Reloader, hub *realtime.Hub, sd *shutdown.Shutdown) (Empty, error) {
 	restfulRouter.AddRoutes(route, commonHandler, handlers)
 	if !coreOptions.Core.IsReleaseMode {
 		pprof.Register(route)
@@ -46,31 +326,95 @@ func RunRestfulServer(logger *zap.Logger, coreOptions config.Set, route *gin.Eng
 		Handler: h2c.NewHandler(route, h2s),
 	}
 
+	listener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		return Empty{}, err
+	}
+	listener = &onceCloseListener{Listener: listener}
+
 	go func(s *http.Server) {
 		logger.Info("start restful server",
 			zap.String("system", coreOptions.Core.SystemName),
 			zap.String("port", coreOptions.Server.Port),
 		)
-		if err := s.ListenAndServe(); err != nil {
+		if err := s.Serve(listener); err != nil {
 			logger.Warn("restful server error or closed",
 				zap.String("system", coreOptions.Core.SystemName),
 				zap.Error(err),
 			)
 		}
 	}(httpServer)
-	return Empty{}, func() {
-		ctx, cancel := context.WithTimeout(context.Background(), coreOptions.Server.ServerTimeout)
-		defer cancel()
-		if err := httpServer.Shutdown(ctx); err != nil {
-			logger.Warn("restful server Failed to Shutdown",
-				zap.String("system", coreOptions.Core.SystemName),
-				zap.Error(err),
-			)
-		}
-	}, nil
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	go reloader.Watch(watchCtx)
+
+	sd.Register(
+		shutdown.Hook{
+			Name:  "config reloader",
+			Phase: shutdown.PhaseNotReady,
+			Fn: func(ctx context.Context) error {
+				stopWatch()
+				return nil
+			},
+		},
+		shutdown.Hook{
+			// report not-ready first so load balancers stop routing here
+			// before the listener closes
+			Name:  "readiness",
+			Phase: shutdown.PhaseNotReady,
+			Fn: func(ctx context.Context) error {
+				handlers.Health.Drain()
+				select {
+				case <-time.After(coreOptions.Server.ShutdownDrainDelay):
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		},
+		shutdown.Hook{
+			Name:  "restful listener",
+			Phase: shutdown.PhaseStopAccepting,
+			Fn: func(ctx context.Context) error {
+				httpServer.SetKeepAlivesEnabled(false)
+				return listener.Close()
+			},
+		},
+		shutdown.Hook{
+			// waits for in-flight requests, event streams included, so it
+			// runs alongside the hook ending those
+			Name:    "restful server",
+			Phase:   shutdown.PhaseDrainConnections,
+			Timeout: coreOptions.Server.ServerTimeout,
+			Fn:      httpServer.Shutdown,
+		},
+		shutdown.Hook{
+			// event streams only end on their own when the client leaves
+			Name:  "event streams",
+			Phase: shutdown.PhaseDrainConnections,
+			Fn: func(ctx context.Context) error {
+				hub.Close()
+				return nil
+			},
+		},
+	)
+	return Empty{}, nil
+}
+
+// onceCloseListener lets the listener hook and http.Server.Shutdown both
+// close the listener without the second close failing
+type onceCloseListener struct {
+	net.Listener
+	once sync.Once
+	err  error
+}
+
+// Close method
+func (l *onceCloseListener) Close() error {
+	l.once.Do(func() { l.err = l.Listener.Close() })
+	return l.err
 }
 
-func RestfulRunner() (Empty, func(), error) {
+func RestfulRunner() (Runner, func(), error) {
 	panic(wire.Build(wire.NewSet(
 		ctxSet,
 		wire.NewSet(
@@ -79,24 +423,82 @@ func RestfulRunner() (Empty, func(), error) {
 			config.NewJWT,
 			config.NewPostgres,
 			config.NewServer,
+			config.NewMetrics,
+			config.NewTracing,
+			config.NewRateLimit,
+			config.NewMessage,
+			config.NewAttachment,
+			config.NewPresence,
 		),
 		LoggerSet,
-		postgres.NewPostgresDB,
-		// wire.NewSet(
-		// 	wire.Struct(new(repository.Set), "*")),
+		NewReloader,
+		NewTracerProvider,
+		NewPostgresDB,
+		NewSchema,
+		postgres.NewTxManager,
+		wire.NewSet(infra.NewTransactor, wire.Bind(new(repository.Transactor), new(*infra.Transactor))),
+		wire.NewSet(infra.NewUserRepository, wire.Bind(new(repository.UserRepository), new(*infra.UserRepository))),
+		wire.NewSet(infra.NewMessageRepository, wire.Bind(new(repository.MessageRepository), new(*infra.MessageRepository))),
+		wire.NewSet(infra.NewIdempotencyRepository, wire.Bind(new(repository.IdempotencyRepository), new(*infra.IdempotencyRepository))),
+		wire.NewSet(infra.NewReactionRepository, wire.Bind(new(repository.ReactionRepository), new(*infra.ReactionRepository))),
+		wire.NewSet(infra.NewAttachmentRepository, wire.Bind(new(repository.AttachmentRepository), new(*infra.AttachmentRepository))),
+		wire.NewSet(infra.NewUploadRepository, wire.Bind(new(repository.UploadRepository), new(*infra.UploadRepository))),
+		wire.NewSet(infra.NewPresenceRepository, wire.Bind(new(repository.PresenceRepository), new(*infra.PresenceRepository))),
+		NewIdempotencyPruner,
+		NewLocalBlobs,
+		NewBlobStore,
+		wire.NewSet(NewStaging, wire.Bind(new(attachment.UploadStaging), new(*blob.Staging))),
 		wire.NewSet(jwt.NewES256JWTFromOptions, wire.Bind(new(definition.ES256JWT), new(*jwt.ES256JWT))),
-		// wire.NewSet(
-		// 	wire.Struct(new(usecase.Set), "*")),
+		wire.NewSet(NewHub, wire.Bind(new(usecase.Publisher), new(*realtime.Hub))),
+		message.NewSendMessageUseCase,
+		message.NewEditMessageUseCase,
+		message.NewListRevisionsUseCase,
+		message.NewDeleteMessageUseCase,
+		message.NewListRepliesUseCase,
+		message.NewListConversationUseCase,
+		message.NewToggleReactionUseCase,
+		message.NewSearchMessagesUseCase,
+		message.NewAcknowledgeDeliveryUseCase,
+		message.NewMarkAsReadUseCase,
+		message.NewMarkConversationReadUseCase,
+		attachment.NewUploadAttachmentUseCase,
+		attachment.NewGetAttachmentUseCase,
+		attachment.NewCreateUploadUseCase,
+		attachment.NewAppendUploadUseCase,
+		attachment.NewGetUploadUseCase,
+		attachment.NewCancelUploadUseCase,
+		attachment.NewPruneAttachmentsUseCase,
+		NewAttachmentPruner,
+		attachment.NewProcessAttachmentsUseCase,
+		NewAttachmentProcessor,
+		NewPresenceTracker,
+		NewTypingTracker,
+		presence.NewConnectUseCase,
+		presence.NewDisconnectUseCase,
+		presence.NewGetPresenceUseCase,
+		presence.NewHideLastSeenUseCase,
+		presence.NewTypingUseCase,
+		presence.NewSweepUseCase,
+		NewPresenceSweeper,
+		auth.NewCheckActiveUseCase,
 		wire.NewSet(restfulRouter.NewAPIGuardValidator, wire.Bind(new(restful.GuarderValidator), new(*restfulRouter.APIGuardValidator))),
 		wire.NewSet(restful.NewJWTGuarder),
 		wire.NewSet(restful.NewGin),
-		wire.Value(restful.CommonHandler{
-			Error404:   restful.Error404Set,
-			QuickReply: restful.QuickReplySet,
-			PromHTTP:   restful.NewPromHTTPSet,
-		}),
+		restful.NewCommonHandler,
+		restfulRouter.NewHealth,
+		restfulRouter.NewAdmin,
+		restfulRouter.NewMessage,
+		restfulRouter.NewAttachment,
+		restfulRouter.NewBlobs,
+		restfulRouter.NewEvents,
+		restfulRouter.NewPresence,
+		NewRateLimitStore,
+		restful.NewRateLimiter,
+		restfulRouter.NewRateLimits,
 		wire.NewSet(
 			wire.Struct(new(restfulRouter.HandlerSet), "*")),
+		NewShutdown,
 		RunRestfulServer,
+		wire.Struct(new(Runner), "*"),
 	)))
 }
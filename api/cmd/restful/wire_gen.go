@@ -8,64 +8,248 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"hilo-api/deployments/migrations"
+	"hilo-api/internal/application/attachment"
+	"hilo-api/internal/application/auth"
+	"hilo-api/internal/application/message"
+	"hilo-api/internal/application/presence"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/infrastructure/postgres"
 	"hilo-api/internal/presentation/restful"
+	"hilo-api/pkg/blob"
 	"hilo-api/pkg/config"
+	postgres2 "hilo-api/pkg/database/postgres"
 	"hilo-api/pkg/jwt"
 	"hilo-api/pkg/logger"
+	"hilo-api/pkg/ratelimit"
+	"hilo-api/pkg/realtime"
 	restful2 "hilo-api/pkg/restful"
+	"hilo-api/pkg/shutdown"
+	"hilo-api/pkg/tracing"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // Injectors from wire.go:
 
-func RestfulRunner() (Empty, func(), error) {
+func RestfulRunner() (Runner, func(), error) {
 	set, err := config.NewSet()
 	if err != nil {
-		return Empty{}, nil, err
+		return Runner{}, nil, err
 	}
 	core := config.NewCore(set)
-	zapLogger, err := logger.NewZap(core)
+	atomicLevel := logger.NewAtomicLevel(core)
+	zapLogger, err := logger.NewZapWithLevel(core, atomicLevel)
 	if err != nil {
-		return Empty{}, nil, err
+		return Runner{}, nil, err
 	}
 	server := config.NewServer(set)
+	shutdown := NewShutdown(zapLogger, server)
+	tracing := config.NewTracing(set)
+	tracerProvider, cleanup, err := NewTracerProvider(shutdown, core, tracing)
+	if err != nil {
+		return Runner{}, nil, err
+	}
+	configPostgres := config.NewPostgres(set)
+	db, cleanup2, err := NewPostgresDB(shutdown, zapLogger, configPostgres)
+	if err != nil {
+		cleanup()
+		return Runner{}, nil, err
+	}
+	schema, err := NewSchema(zapLogger, configPostgres, db)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	configMessage := config.NewMessage(set)
+	idempotencyRepository := postgres.NewIdempotencyRepository(db)
+	idempotencyPruner, cleanup3 := NewIdempotencyPruner(zapLogger, shutdown, configMessage, idempotencyRepository)
+	attachmentRepository := postgres.NewAttachmentRepository(db)
+	uploadRepository := postgres.NewUploadRepository(db)
+	configAttachment := config.NewAttachment(set)
+	local, err := NewLocalBlobs(zapLogger, configAttachment)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	blobStore, err := NewBlobStore(configAttachment, local)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	staging, err := NewStaging(configAttachment)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	pruneAttachmentsUseCase := attachment.NewPruneAttachmentsUseCase(attachmentRepository, uploadRepository, blobStore, staging, configAttachment)
+	attachmentPruner, cleanup4 := NewAttachmentPruner(zapLogger, shutdown, pruneAttachmentsUseCase)
+	messageRepository := postgres.NewMessageRepository(db)
+	hub := NewHub()
+	processAttachmentsUseCase := attachment.NewProcessAttachmentsUseCase(attachmentRepository, messageRepository, blobStore, hub, configAttachment)
+	attachmentProcessor, cleanup5 := NewAttachmentProcessor(zapLogger, shutdown, configAttachment, processAttachmentsUseCase)
+	presenceRepository := postgres.NewPresenceRepository(db)
+	configPresence := config.NewPresence(set)
+	realtimePresence := NewPresenceTracker(configPresence)
+	typing := NewTypingTracker(configPresence)
+	sweepUseCase := presence.NewSweepUseCase(presenceRepository, realtimePresence, typing, hub)
+	presenceSweeper, cleanup6 := NewPresenceSweeper(zapLogger, shutdown, sweepUseCase)
+	metrics := config.NewMetrics(set)
 	configJWT := config.NewJWT(set)
 	es256JWT, err := jwt.NewES256JWTFromOptions(configJWT)
 	if err != nil {
-		return Empty{}, nil, err
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
 	}
-	apiGuardValidator := restful.NewAPIGuardValidator(es256JWT)
+	userRepository := postgres.NewUserRepository(db)
+	checkActiveUseCase := auth.NewCheckActiveUseCase(userRepository)
+	apiGuardValidator := restful.NewAPIGuardValidator(es256JWT, checkActiveUseCase)
 	jwtGuarder := restful2.NewJWTGuarder(apiGuardValidator)
-	engine, err := restful2.NewGin(zapLogger, server, jwtGuarder)
+	reloader := NewReloader(zapLogger, set, atomicLevel)
+	engine, err := restful2.NewGin(zapLogger, server, metrics, jwtGuarder, reloader)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	commonHandler, err := restful2.NewCommonHandler(metrics)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	health, err := restful.NewHealth(db, es256JWT, server)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	admin := restful.NewAdmin(zapLogger, atomicLevel)
+	txManager := postgres2.NewTxManager(db, configPostgres)
+	transactor := postgres.NewTransactor(txManager)
+	sendMessageUseCase := message.NewSendMessageUseCase(messageRepository, userRepository, idempotencyRepository, attachmentRepository, transactor, configMessage)
+	editMessageUseCase := message.NewEditMessageUseCase(messageRepository, hub, configMessage)
+	listRevisionsUseCase := message.NewListRevisionsUseCase(messageRepository)
+	deleteMessageUseCase := message.NewDeleteMessageUseCase(messageRepository, hub, configMessage)
+	reactionRepository := postgres.NewReactionRepository(db)
+	listRepliesUseCase := message.NewListRepliesUseCase(messageRepository, reactionRepository)
+	listConversationUseCase := message.NewListConversationUseCase(messageRepository, reactionRepository)
+	toggleReactionUseCase := message.NewToggleReactionUseCase(messageRepository, reactionRepository, hub)
+	searchMessagesUseCase := message.NewSearchMessagesUseCase(messageRepository, reactionRepository)
+	acknowledgeDeliveryUseCase := message.NewAcknowledgeDeliveryUseCase(messageRepository, hub)
+	markAsReadUseCase := message.NewMarkAsReadUseCase(messageRepository, hub)
+	markConversationReadUseCase := message.NewMarkConversationReadUseCase(messageRepository, hub)
+	restfulMessage := restful.NewMessage(sendMessageUseCase, editMessageUseCase, listRevisionsUseCase, deleteMessageUseCase, listRepliesUseCase, listConversationUseCase, toggleReactionUseCase, searchMessagesUseCase, acknowledgeDeliveryUseCase, markAsReadUseCase, markConversationReadUseCase)
+	uploadAttachmentUseCase := attachment.NewUploadAttachmentUseCase(attachmentRepository, blobStore, configAttachment)
+	getAttachmentUseCase := attachment.NewGetAttachmentUseCase(attachmentRepository, messageRepository, blobStore, configAttachment)
+	createUploadUseCase := attachment.NewCreateUploadUseCase(uploadRepository, configAttachment)
+	appendUploadUseCase := attachment.NewAppendUploadUseCase(uploadRepository, attachmentRepository, staging, blobStore, configAttachment)
+	getUploadUseCase := attachment.NewGetUploadUseCase(uploadRepository, configAttachment)
+	cancelUploadUseCase := attachment.NewCancelUploadUseCase(uploadRepository, staging, configAttachment)
+	restfulAttachment := restful.NewAttachment(configAttachment, uploadAttachmentUseCase, getAttachmentUseCase, createUploadUseCase, appendUploadUseCase, getUploadUseCase, cancelUploadUseCase)
+	blobs := restful.NewBlobs(local)
+	connectUseCase := presence.NewConnectUseCase(realtimePresence)
+	disconnectUseCase := presence.NewDisconnectUseCase(presenceRepository, typing, hub)
+	events := restful.NewEvents(hub, connectUseCase, disconnectUseCase, configPresence)
+	getPresenceUseCase := presence.NewGetPresenceUseCase(userRepository, presenceRepository, realtimePresence)
+	hideLastSeenUseCase := presence.NewHideLastSeenUseCase(presenceRepository)
+	typingUseCase := presence.NewTypingUseCase(userRepository, typing, hub)
+	restfulPresence := restful.NewPresence(getPresenceUseCase, hideLastSeenUseCase, typingUseCase)
+	rateLimit := config.NewRateLimit(set)
+	store, cleanup7, err := NewRateLimitStore(zapLogger, shutdown, rateLimit, db)
 	if err != nil {
-		return Empty{}, nil, err
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
 	}
-	commonHandler := _wireCommonHandlerValue
-	handlerSet := restful.HandlerSet{}
-	empty, cleanup, err := RunRestfulServer(zapLogger, set, engine, commonHandler, handlerSet)
+	rateLimiter := restful2.NewRateLimiter(zapLogger, store)
+	rateLimits, err := restful.NewRateLimits(rateLimit, rateLimiter)
 	if err != nil {
-		return Empty{}, nil, err
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
 	}
-	return empty, func() {
+	handlerSet := restful.HandlerSet{
+		Health:     health,
+		Admin:      admin,
+		Message:    restfulMessage,
+		Attachment: restfulAttachment,
+		Blobs:      blobs,
+		Events:     events,
+		Presence:   restfulPresence,
+		RateLimits: rateLimits,
+	}
+	empty, err := RunRestfulServer(zapLogger, set, tracerProvider, schema, idempotencyPruner, attachmentPruner, attachmentProcessor, presenceSweeper, engine, commonHandler, handlerSet, reloader, hub, shutdown)
+	if err != nil {
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return Runner{}, nil, err
+	}
+	runner := Runner{
+		Server:   empty,
+		Shutdown: shutdown,
+	}
+	return runner, func() {
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
 		cleanup()
 	}, nil
 }
 
-var (
-	_wireCommonHandlerValue = restful2.CommonHandler{
-		Error404:   restful2.Error404Set,
-		QuickReply: restful2.QuickReplySet,
-		PromHTTP:   restful2.NewPromHTTPSet,
-	}
-)
-
 // wire.go:
 
 func ctx() context.Context {
@@ -74,11 +258,269 @@ func ctx() context.Context {
 
 var ctxSet = wire.NewSet(ctx)
 
-var LoggerSet = wire.NewSet(logger.NewZap)
+var LoggerSet = wire.NewSet(logger.NewAtomicLevel, logger.NewZapWithLevel)
 
 type Empty struct{}
 
-func RunRestfulServer(logger2 *zap.Logger, coreOptions config.Set, route *gin.Engine, commonHandler restful2.CommonHandler, handlers restful.HandlerSet) (Empty, func(), error) {
+// Runner is what main needs to keep the server running and stop it in order
+type Runner struct {
+	Server   Empty
+	Shutdown *shutdown.Shutdown
+}
+
+// Schema is the migration version the server checked the database against
+type Schema struct {
+	Version uint
+}
+
+// NewSchema method
+// applies pending migrations when POSTGRES_MIGRATE_ON_STARTUP is set, then
+// refuses to start on a dirty schema or one newer than the binary
+func NewSchema(logger2 *zap.Logger, opt config.Postgres, db *sqlx.DB) (Schema, error) {
+	want, err := migrations.LatestVersion()
+	if err != nil {
+		return Schema{}, err
+	}
+
+	if opt.PostgresMigrateOnStartup {
+		migrator, err := postgres2.NewMigrator(logger2, opt, migrations.FS, migrations.Dir)
+		if err != nil {
+			return Schema{}, err
+		}
+		err = migrator.Up()
+		if closeErr := migrator.Close(); closeErr != nil {
+			logger2.
+				Warn("postgres migrator close failed", zap.Error(closeErr))
+		}
+		if err != nil {
+			return Schema{}, err
+		}
+	}
+
+	if err := postgres2.CheckSchema(context.Background(), logger2, db, want); err != nil {
+		return Schema{}, err
+	}
+	return Schema{Version: want}, nil
+}
+
+// NewReloader method
+// the log level follows LOG_LEVEL here, CORS and the JWT allow list
+// subscribe in restful.NewGin
+func NewReloader(zapLogger *zap.Logger, set config.Set, level zap.AtomicLevel) *config.Reloader {
+	reloader := config.NewReloader(set, config.WithReloadLogger(zapLogger))
+	logger.FollowLevel(reloader, level)
+	return reloader
+}
+
+// every runs fn at interval in the background until the shutdown hook
+// named name stops it in PhaseDrainConnections, before the database closes.
+// The returned cleanup stops it too, for when wire fails to build the rest.
+func every(sd *shutdown.Shutdown, name string, interval time.Duration, fn func(ctx2 context.Context)) func() {
+	ctx3, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx3.Done():
+				return
+			case <-ticker.C:
+				fn(ctx3)
+			}
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		<-stopped
+	}
+	sd.Register(shutdown.Hook{
+		Name:  name,
+		Phase: shutdown.PhaseDrainConnections,
+		Fn: func(context.Context) error {
+			stop()
+			return nil
+		},
+	})
+	return stop
+}
+
+// NewTracerProvider method
+// spans still buffered are flushed in PhaseFlush
+func NewTracerProvider(sd *shutdown.Shutdown, core config.Core, opt config.Tracing) (*trace.TracerProvider, func(), error) {
+	provider, cleanup, err := tracing.NewTracerProvider(core, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sd.Register(shutdown.Hook{
+		Name:  "tracer",
+		Phase: shutdown.PhaseFlush,
+		Fn:    provider.Shutdown,
+	})
+	return provider, cleanup, nil
+}
+
+// NewPostgresDB method
+// the pool closes in PhaseCloseResources, after everything using it stopped
+func NewPostgresDB(sd *shutdown.Shutdown, logger2 *zap.Logger, opt config.Postgres) (*sqlx.DB, func(), error) {
+	db, cleanup, err := postgres2.NewPostgresDB(logger2, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sd.Register(shutdown.Hook{
+		Name:  "database",
+		Phase: shutdown.PhaseCloseResources,
+		Fn: func(context.Context) error {
+			return db.Close()
+		},
+	})
+	return db, cleanup, nil
+}
+
+// NewRateLimitStore method
+// the postgres backend prunes refilled buckets in the background until
+// shutdown
+func NewRateLimitStore(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.RateLimit, db *sqlx.DB) (ratelimit.Store, func(), error) {
+	if cfg.RateLimitBackend != "postgres" {
+		return ratelimit.NewMemory(), func() {}, nil
+	}
+
+	// a bucket untouched for its period is full again, prune after the longest
+	var longest time.Duration
+	for _, spec := range []string{cfg.RateLimitDefault, cfg.RateLimitMessages} {
+		policy, err := ratelimit.ParsePolicy("", spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		longest = max(longest, policy.Period)
+	}
+
+	store := postgres2.NewRateLimitStore(db)
+	cleanup := every(sd, "rate limit pruner", time.Minute, func(ctx2 context.Context) {
+		if _, err := store.Prune(ctx2, longest); err != nil && ctx2.Err() == nil {
+			zapLogger.Warn("rate limit prune failed", zap.String("system", "RateLimit"), zap.Error(err))
+		}
+	})
+	return store, cleanup, nil
+}
+
+// IdempotencyPruner drops expired idempotency keys in the background
+type IdempotencyPruner struct{}
+
+// NewIdempotencyPruner method
+func NewIdempotencyPruner(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.Message, repo repository.IdempotencyRepository) (IdempotencyPruner, func()) {
+	cleanup := every(sd, "idempotency pruner", time.Hour, func(ctx2 context.Context) {
+		if _, err := repo.DeleteExpired(ctx2, time.Now().Add(-cfg.MessageIdempotencyTTL)); err != nil && ctx2.Err() == nil {
+			zapLogger.Warn("idempotency key prune failed", zap.String("system", "Message"), zap.Error(err))
+		}
+	})
+	return IdempotencyPruner{}, cleanup
+}
+
+// NewLocalBlobs method
+// nil unless ATTACHMENT_BACKEND is local; without ATTACHMENT_URL_SECRET the
+// signing secret is random, so URLs die with the process and do not work
+// across replicas
+func NewLocalBlobs(zapLogger *zap.Logger, cfg config.Attachment) (*blob.Local, error) {
+	if cfg.AttachmentBackend != "local" {
+		return nil, nil
+	}
+
+	secret := []byte(cfg.AttachmentURLSecret)
+	if len(secret) == 0 {
+		zapLogger.Warn("ATTACHMENT_URL_SECRET is empty, signing attachment URLs with a random secret", zap.String("system", "Attachment"))
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return blob.NewLocal(cfg.AttachmentLocalDir, secret, blob.WithBaseURL(cfg.AttachmentPublicURL))
+}
+
+// NewBlobStore method
+func NewBlobStore(cfg config.Attachment, local *blob.Local) (attachment.BlobStore, error) {
+	if local != nil {
+		return local, nil
+	}
+
+	var options []blob.S3Option
+	if !cfg.AttachmentS3PathStyle {
+		options = append(options, blob.WithVirtualHostedStyle())
+	}
+	return blob.NewS3(cfg.AttachmentS3Endpoint, cfg.AttachmentS3Region, cfg.AttachmentS3Bucket,
+		cfg.AttachmentS3AccessKey, cfg.AttachmentS3SecretKey, options...)
+}
+
+// NewStaging method
+func NewStaging(cfg config.Attachment) (*blob.Staging, error) {
+	return blob.NewStaging(cfg.AttachmentUploadDir)
+}
+
+// AttachmentPruner drops unreachable attachments and expired uploads in the
+// background
+type AttachmentPruner struct{}
+
+// NewAttachmentPruner method
+func NewAttachmentPruner(zapLogger *zap.Logger, sd *shutdown.Shutdown, prune *attachment.PruneAttachmentsUseCase) (AttachmentPruner, func()) {
+	cleanup := every(sd, "attachment pruner", time.Hour, func(ctx2 context.Context) {
+		if _, err := prune.Execute(ctx2); err != nil && ctx2.Err() == nil {
+			zapLogger.Warn("attachment prune failed", zap.String("system", "Attachment"), zap.Error(err))
+		}
+	})
+	return AttachmentPruner{}, cleanup
+}
+
+// AttachmentProcessor makes thumbnails of image attachments in the
+// background
+type AttachmentProcessor struct{}
+
+// NewAttachmentProcessor method
+func NewAttachmentProcessor(zapLogger *zap.Logger, sd *shutdown.Shutdown, cfg config.Attachment, process *attachment.ProcessAttachmentsUseCase) (AttachmentProcessor, func()) {
+	cleanup := every(sd, "attachment processor", cfg.AttachmentProcessInterval, func(ctx2 context.Context) {
+		if _, err := process.Execute(ctx2); err != nil && ctx2.Err() == nil {
+			zapLogger.Warn("attachment processing failed", zap.String("system", "Attachment"), zap.Error(err))
+		}
+	})
+	return AttachmentProcessor{}, cleanup
+}
+
+// PresenceSweeper ends sessions that stopped beating and typing nobody
+// stopped in the background
+type PresenceSweeper struct{}
+
+// NewPresenceSweeper method
+func NewPresenceSweeper(zapLogger *zap.Logger, sd *shutdown.Shutdown, sweep *presence.SweepUseCase) (PresenceSweeper, func()) {
+	cleanup := every(sd, "presence sweeper", time.Second, func(ctx2 context.Context) {
+		if _, err := sweep.Execute(ctx2); err != nil && ctx2.Err() == nil {
+			zapLogger.Warn("presence sweep failed", zap.String("system", "Presence"), zap.Error(err))
+		}
+	})
+	return PresenceSweeper{}, cleanup
+}
+
+// NewPresenceTracker method
+func NewPresenceTracker(cfg config.Presence) *realtime.Presence {
+	return realtime.NewPresence(cfg.PresenceTimeout)
+}
+
+// NewTypingTracker method
+func NewTypingTracker(cfg config.Presence) *realtime.Typing {
+	return realtime.NewTyping(cfg.TypingThrottle, cfg.TypingTimeout)
+}
+
+// NewHub method
+func NewHub() *realtime.Hub {
+	return realtime.NewHub()
+}
+
+// NewShutdown method
+func NewShutdown(logger2 *zap.Logger, opt config.Server) *shutdown.Shutdown {
+	return shutdown.NewShutdown(shutdown.WithLogger(logger2), shutdown.WithServerTimeout(opt.ShutdownTimeout))
+}
+
+func RunRestfulServer(logger2 *zap.Logger, coreOptions config.Set, _ *trace.TracerProvider, _ Schema, _ IdempotencyPruner, _ AttachmentPruner, _ AttachmentProcessor, _ PresenceSweeper, route *gin.Engine, commonHandler restful2.CommonHandler, handlers restful.HandlerSet, reloader *config.Reloader, hub *realtime.Hub, sd *shutdown.Shutdown) (Empty, error) {
 	restful.AddRoutes(route, commonHandler, handlers)
 	if !coreOptions.Core.IsReleaseMode {
 		pprof.Register(route)
@@ -91,20 +533,80 @@ func RunRestfulServer(logger2 *zap.Logger, coreOptions config.Set, route *gin.En
 		Handler: h2c.NewHandler(route, h2s),
 	}
 
+	listener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		return Empty{}, err
+	}
+	listener = &onceCloseListener{Listener: listener}
+
 	go func(s *http.Server) {
 		logger2.
 			Info("start restful server", zap.String("system", coreOptions.Core.SystemName), zap.String("port", coreOptions.Server.Port))
-		if err := s.ListenAndServe(); err != nil {
+		if err := s.Serve(listener); err != nil {
 			logger2.
 				Warn("restful server error or closed", zap.String("system", coreOptions.Core.SystemName), zap.Error(err))
 		}
 	}(httpServer)
-	return Empty{}, func() {
-		ctx2, cancel := context.WithTimeout(context.Background(), coreOptions.Server.ServerTimeout)
-		defer cancel()
-		if err := httpServer.Shutdown(ctx2); err != nil {
-			logger2.
-				Warn("restful server Failed to Shutdown", zap.String("system", coreOptions.Core.SystemName), zap.Error(err))
-		}
-	}, nil
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	go reloader.Watch(watchCtx)
+
+	sd.Register(shutdown.Hook{
+		Name:  "config reloader",
+		Phase: shutdown.PhaseNotReady,
+		Fn: func(ctx2 context.Context) error {
+			stopWatch()
+			return nil
+		},
+	}, shutdown.Hook{
+
+		Name:  "readiness",
+		Phase: shutdown.PhaseNotReady,
+		Fn: func(ctx3 context.Context) error {
+			handlers.Health.Drain()
+			select {
+			case <-time.After(coreOptions.Server.ShutdownDrainDelay):
+			case <-ctx3.Done():
+			}
+			return nil
+		},
+	}, shutdown.Hook{
+		Name:  "restful listener",
+		Phase: shutdown.PhaseStopAccepting,
+		Fn: func(ctx4 context.Context) error {
+			httpServer.SetKeepAlivesEnabled(false)
+			return listener.Close()
+		},
+	}, shutdown.Hook{
+
+		Name:    "restful server",
+		Phase:   shutdown.PhaseDrainConnections,
+		Timeout: coreOptions.Server.ServerTimeout,
+		Fn:      httpServer.Shutdown,
+	}, shutdown.Hook{
+
+		Name:  "event streams",
+		Phase: shutdown.PhaseDrainConnections,
+		Fn: func(ctx5 context.Context) error {
+			hub.Close()
+			return nil
+		},
+	},
+	)
+	return Empty{}, nil
+}
+
+// onceCloseListener lets the listener hook and http.Server.Shutdown both
+// close the listener without the second close failing
+type onceCloseListener struct {
+	net.Listener
+
+	once sync.Once
+	err  error
+}
+
+// Close method
+func (l *onceCloseListener) Close() error {
+	l.once.Do(func() { l.err = l.Listener.Close() })
+	return l.err
 }
@@ -0,0 +1,100 @@
+// Package migrations embeds the golang-migrate SQL files so the binary knows
+// which schema version it was built against.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Dir = "postgresql"
+
+// VersionLayout is the timestamp format used as the version of new migrations
+const VersionLayout = "20060102150405"
+
+//go:embed postgresql/*.sql
+var FS embed.FS
+
+var (
+	ErrNoMigrations         = errors.New("no migrations embedded")
+	ErrInvalidMigrationName = errors.New("migration name must be lower snake case")
+	ErrMigrationExists      = errors.New("migration already exists")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// Migration is one up/down pair shipped with the binary
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// List returns the embedded migrations ordered by version
+func List() ([]Migration, error) {
+	entries, err := fs.ReadDir(FS, Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	var list []Migration
+	for _, entry := range entries {
+		prefix, rest, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil || seen[uint(version)] {
+			continue
+		}
+		seen[uint(version)] = true
+		name, _, _ := strings.Cut(rest, ".")
+		list = append(list, Migration{Version: uint(version), Name: name})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// LatestVersion returns the highest migration version shipped with the binary
+func LatestVersion() (uint, error) {
+	list, err := List()
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, ErrNoMigrations
+	}
+	return list[len(list)-1].Version, nil
+}
+
+// Create writes an empty up/down pair named after now into dir and returns their paths
+func Create(dir, name string, now time.Time) (up, down string, err error) {
+	if !namePattern.MatchString(name) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidMigrationName, name)
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%s_%s", now.UTC().Format(VersionLayout), name))
+	up, down = base+".up.sql", base+".down.sql"
+	for _, path := range []string{up, down} {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			return "", "", fmt.Errorf("%w: %s", ErrMigrationExists, path)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if err := file.Close(); err != nil {
+			return "", "", err
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := LatestVersion()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, version, uint(20251104031532))
+}
+
+func TestList(t *testing.T) {
+	list, err := List()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(list), 2)
+	assert.Equal(t, Migration{Version: 20251104031423, Name: "extension"}, list[0])
+	assert.Equal(t, Migration{Version: 20251104031532, Name: "database"}, list[1])
+	for i := 1; i < len(list); i++ {
+		assert.Less(t, list[i-1].Version, list[i].Version)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	up, down, err := Create(dir, "add_reactions", now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20260102030405_add_reactions.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "20260102030405_add_reactions.down.sql"), down)
+	for _, path := range []string{up, down} {
+		_, err := os.Stat(path)
+		assert.NoError(t, err)
+	}
+
+	_, _, err = Create(dir, "add_reactions", now)
+	assert.ErrorIs(t, err, ErrMigrationExists)
+
+	_, _, err = Create(dir, "Add Reactions", now)
+	assert.ErrorIs(t, err, ErrInvalidMigrationName)
+}
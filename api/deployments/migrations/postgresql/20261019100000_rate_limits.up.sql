@@ -0,0 +1,9 @@
+-- token buckets shared by every node; unlogged because losing them on a
+-- crash only resets the limits
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_updated_at ON rate_limits (updated_at);
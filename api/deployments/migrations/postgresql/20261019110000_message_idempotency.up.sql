@@ -0,0 +1,12 @@
+-- the message a sender's request created, so a retried send with the same
+-- Idempotency-Key or client message id returns it instead of a duplicate
+CREATE TABLE message_idempotency_keys (
+    sender_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key          VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    message_id   UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (sender_id, key)
+);
+
+CREATE INDEX idx_message_idempotency_keys_created_at ON message_idempotency_keys(created_at);
@@ -0,0 +1,11 @@
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE TABLE message_revisions (
+    id          BIGSERIAL PRIMARY KEY,
+    message_id  UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,  -- when this version was written
+    replaced_at TIMESTAMPTZ NOT NULL   -- when an edit replaced it
+);
+
+CREATE INDEX idx_message_revisions_message ON message_revisions(message_id, replaced_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS message_hidden;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- deleted for everyone: content is emptied, the row stays as a placeholder
+ALTER TABLE messages ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- deleted for me: the message disappears for user_id only
+CREATE TABLE message_hidden (
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    hidden_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, message_id)
+);
@@ -0,0 +1,4 @@
+-- the message this one replies to, in the same conversation
+ALTER TABLE messages ADD COLUMN reply_to_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_messages_reply_to ON messages(reply_to_id, created_at) WHERE reply_to_id IS NOT NULL;
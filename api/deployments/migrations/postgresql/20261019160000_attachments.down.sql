@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS uploads;
+DROP TABLE IF EXISTS attachment_usage;
+DROP TABLE IF EXISTS attachments;
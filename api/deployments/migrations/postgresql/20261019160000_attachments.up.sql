@@ -0,0 +1,33 @@
+-- files uploaded by a user, message_id stays NULL until they are sent
+CREATE TABLE attachments (
+    id           UUID PRIMARY KEY,
+    owner_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id   UUID REFERENCES messages(id) ON DELETE CASCADE,
+    position     SMALLINT NOT NULL DEFAULT 0,
+    filename     VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size         BIGINT NOT NULL CHECK (size > 0),
+    checksum     CHAR(64) NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_message ON attachments (message_id, position) WHERE message_id IS NOT NULL;
+CREATE INDEX idx_attachments_unsent ON attachments (created_at) WHERE message_id IS NULL;
+
+-- bytes each user keeps in attachments, charged against their quota
+CREATE TABLE attachment_usage (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bytes   BIGINT NOT NULL DEFAULT 0 CHECK (bytes >= 0)
+);
+
+-- resumable uploads in progress, the bytes are staged on disk
+CREATE TABLE uploads (
+    id         UUID PRIMARY KEY,
+    owner_id   UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename   VARCHAR(255) NOT NULL,
+    size       BIGINT NOT NULL CHECK (size > 0),
+    received   BIGINT NOT NULL DEFAULT 0 CHECK (received >= 0 AND received <= size),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_uploads_created_at ON uploads (created_at);
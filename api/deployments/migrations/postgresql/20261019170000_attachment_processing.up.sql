@@ -0,0 +1,17 @@
+-- thumbnailing of image attachments, done by a background worker
+ALTER TABLE attachments
+    ADD COLUMN processing_state    VARCHAR(16) NOT NULL DEFAULT ''
+        CHECK (processing_state IN ('', 'pending', 'ready', 'failed')),
+    ADD COLUMN processing_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN processing_next_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN width               INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN height              INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN blurhash            VARCHAR(128) NOT NULL DEFAULT '',
+    ADD COLUMN variants            JSONB NOT NULL DEFAULT '[]';
+
+-- images already stored get thumbnails too
+UPDATE attachments
+SET processing_state = 'pending'
+WHERE content_type IN ('image/jpeg', 'image/png', 'image/gif', 'image/webp');
+
+CREATE INDEX idx_attachments_processing ON attachments (processing_next_at) WHERE processing_state = 'pending';
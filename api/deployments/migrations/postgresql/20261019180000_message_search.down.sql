@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_messages_content_trgm;
+DROP INDEX IF EXISTS idx_messages_search;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
+
+-- DO NOT DROP EXTENSION pg_trgm, see 20251104031423_extension.down.sql
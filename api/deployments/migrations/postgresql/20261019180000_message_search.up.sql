@@ -0,0 +1,9 @@
+-- full-text search: words are stemmed as English, text in scripts without
+-- spaces between words falls back to substring matching on trigrams
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE messages
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;
+
+CREATE INDEX idx_messages_search ON messages USING GIN (search_vector);
+CREATE INDEX idx_messages_content_trgm ON messages USING GIN (content gin_trgm_ops);
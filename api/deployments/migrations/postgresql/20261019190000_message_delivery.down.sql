@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP CONSTRAINT IF EXISTS messages_delivered_before_read,
+    DROP COLUMN IF EXISTS delivered_at;
@@ -0,0 +1,11 @@
+-- delivery receipts: a message is sent, then delivered to the receiver's
+-- device, then read; messages read before count as delivered when read
+ALTER TABLE messages ADD COLUMN delivered_at TIMESTAMPTZ;
+
+UPDATE messages
+SET delivered_at = read_at
+WHERE read_at IS NOT NULL;
+
+ALTER TABLE messages
+    ADD CONSTRAINT messages_delivered_before_read
+        CHECK (read_at IS NULL OR (delivered_at IS NOT NULL AND delivered_at <= read_at));
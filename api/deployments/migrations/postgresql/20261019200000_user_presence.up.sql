@@ -0,0 +1,7 @@
+-- when each user was last online, written as their last session ends;
+-- online itself is only known in memory
+CREATE TABLE user_presence (
+    user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seen_at   TIMESTAMPTZ,
+    hide_last_seen BOOLEAN NOT NULL DEFAULT FALSE
+);
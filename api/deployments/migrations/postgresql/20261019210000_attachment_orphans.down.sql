@@ -0,0 +1,19 @@
+-- orphans cannot go back under NOT NULL; their files are left on disk
+DELETE FROM uploads WHERE owner_id IS NULL;
+DELETE FROM attachments WHERE owner_id IS NULL OR (message_id IS NULL AND sent_at IS NOT NULL);
+
+ALTER TABLE uploads
+    DROP CONSTRAINT uploads_owner_id_fkey,
+    ADD CONSTRAINT uploads_owner_id_fkey
+        FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    ALTER COLUMN owner_id SET NOT NULL;
+
+ALTER TABLE attachments
+    DROP CONSTRAINT attachments_message_id_fkey,
+    ADD CONSTRAINT attachments_message_id_fkey
+        FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    DROP CONSTRAINT attachments_owner_id_fkey,
+    ADD CONSTRAINT attachments_owner_id_fkey
+        FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+    ALTER COLUMN owner_id SET NOT NULL,
+    DROP COLUMN IF EXISTS sent_at;
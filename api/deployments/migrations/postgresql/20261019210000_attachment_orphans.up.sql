@@ -0,0 +1,20 @@
+-- deleting a user or a message leaves its files to the pruner instead of
+-- cascading past it, so their bytes are removed and their size refunded
+ALTER TABLE attachments ADD COLUMN sent_at TIMESTAMPTZ;
+
+UPDATE attachments SET sent_at = created_at WHERE message_id IS NOT NULL;
+
+ALTER TABLE attachments
+    ALTER COLUMN owner_id DROP NOT NULL,
+    DROP CONSTRAINT attachments_owner_id_fkey,
+    ADD CONSTRAINT attachments_owner_id_fkey
+        FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE SET NULL,
+    DROP CONSTRAINT attachments_message_id_fkey,
+    ADD CONSTRAINT attachments_message_id_fkey
+        FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE SET NULL;
+
+ALTER TABLE uploads
+    ALTER COLUMN owner_id DROP NOT NULL,
+    DROP CONSTRAINT uploads_owner_id_fkey,
+    ADD CONSTRAINT uploads_owner_id_fkey
+        FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// DeleteUserUseCase handles removing accounts
+type DeleteUserUseCase struct {
+	userRepo repository.UserRepository
+}
+
+// NewDeleteUserUseCase creates a new delete user use case
+func NewDeleteUserUseCase(userRepo repository.UserRepository) *DeleteUserUseCase {
+	return &DeleteUserUseCase{
+		userRepo: userRepo,
+	}
+}
+
+// Execute deletes the user and, through the foreign keys, their messages;
+// the files of their conversations are left to the attachment pruner,
+// which refunds what the other user is charged for them
+func (uc *DeleteUserUseCase) Execute(ctx context.Context, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "admin.DeleteUser")
+	defer tracing.End(span, &err)
+
+	err = uc.userRepo.Delete(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// FindUserUseCase resolves an operator-supplied reference to a user
+type FindUserUseCase struct {
+	userRepo repository.UserRepository
+}
+
+// NewFindUserUseCase creates a new find user use case
+func NewFindUserUseCase(userRepo repository.UserRepository) *FindUserUseCase {
+	return &FindUserUseCase{
+		userRepo: userRepo,
+	}
+}
+
+// Execute finds a user by id, or by email when ref contains an @
+func (uc *FindUserUseCase) Execute(ctx context.Context, ref string) (_ *do.User, err error) {
+	ctx, span := tracing.Start(ctx, "admin.FindUser")
+	defer tracing.End(span, &err)
+
+	var user *do.User
+	if strings.Contains(ref, "@") {
+		user, err = uc.userRepo.FindByEmail(ctx, ref)
+	} else {
+		id, parseErr := uuid.Parse(ref)
+		if parseErr != nil {
+			return nil, fmt.Errorf("%w: %q is neither an id nor an email", usecase.ErrUserNotFound, ref)
+		}
+		user, err = uc.userRepo.FindByID(ctx, id)
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	return user, err
+}
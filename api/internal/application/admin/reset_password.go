@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// ResetPasswordUseCase handles operator password resets
+type ResetPasswordUseCase struct {
+	userRepo repository.UserRepository
+}
+
+// NewResetPasswordUseCase creates a new reset password use case
+func NewResetPasswordUseCase(userRepo repository.UserRepository) *ResetPasswordUseCase {
+	return &ResetPasswordUseCase{
+		userRepo: userRepo,
+	}
+}
+
+// Execute replaces the password of a user
+func (uc *ResetPasswordUseCase) Execute(ctx context.Context, userID uuid.UUID, password string) (err error) {
+	ctx, span := tracing.Start(ctx, "admin.ResetPassword")
+	defer tracing.End(span, &err)
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Apply business rule
+	if err := user.ChangePassword(password); err != nil {
+		return err
+	}
+
+	return uc.userRepo.Update(ctx, user)
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+	"time"
+)
+
+// StatisticsUseCase handles system-wide conversation statistics
+type StatisticsUseCase struct {
+	messageRepo repository.MessageRepository
+}
+
+// NewStatisticsUseCase creates a new statistics use case
+func NewStatisticsUseCase(messageRepo repository.MessageRepository) *StatisticsUseCase {
+	return &StatisticsUseCase{
+		messageRepo: messageRepo,
+	}
+}
+
+// Execute aggregates the statistics, counting recent activity over window
+func (uc *StatisticsUseCase) Execute(ctx context.Context, window time.Duration) (_ *do.Statistics, err error) {
+	ctx, span := tracing.Start(ctx, "admin.Statistics")
+	defer tracing.End(span, &err)
+
+	return uc.messageRepo.Statistics(ctx, time.Now().Add(-window))
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// SuspendUserUseCase handles suspending and reinstating accounts
+type SuspendUserUseCase struct {
+	userRepo repository.UserRepository
+}
+
+// NewSuspendUserUseCase creates a new suspend user use case
+func NewSuspendUserUseCase(userRepo repository.UserRepository) *SuspendUserUseCase {
+	return &SuspendUserUseCase{
+		userRepo: userRepo,
+	}
+}
+
+// Execute suspends the user, or reinstates them when suspend is false
+func (uc *SuspendUserUseCase) Execute(ctx context.Context, userID uuid.UUID, suspend bool) (_ *do.User, err error) {
+	ctx, span := tracing.Start(ctx, "admin.SuspendUser")
+	defer tracing.End(span, &err)
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if suspend {
+		user.Suspend()
+	} else {
+		user.Reinstate()
+	}
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,482 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/imaging"
+	"hilo-api/pkg/realtime"
+	"image"
+	"image/jpeg"
+	pngenc "image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeAttachments struct {
+	repository.AttachmentRepository
+	attachments map[uuid.UUID]*do.Attachment
+	usage       map[uuid.UUID]int64
+	retryAt     map[uuid.UUID]time.Time
+}
+
+func (f *fakeAttachments) Create(_ context.Context, attachment *do.Attachment, quota int64) error {
+	if f.usage[attachment.OwnerID()]+attachment.Size() > quota {
+		return repository.ErrQuotaExceeded
+	}
+	f.usage[attachment.OwnerID()] += attachment.Size()
+	f.attachments[attachment.ID()] = attachment
+	return nil
+}
+
+func (f *fakeAttachments) FindByID(_ context.Context, id uuid.UUID) (*do.Attachment, error) {
+	if attachment, ok := f.attachments[id]; ok {
+		return attachment, nil
+	}
+	return nil, repository.ErrAttachmentNotFound
+}
+
+func (f *fakeAttachments) ListGarbage(_ context.Context, unsentBefore time.Time, limit int) ([]*do.Attachment, error) {
+	var garbage []*do.Attachment
+	for _, attachment := range f.attachments {
+		if !attachment.IsSent() && attachment.CreatedAt().Before(unsentBefore) && len(garbage) < limit {
+			garbage = append(garbage, attachment)
+		}
+	}
+	return garbage, nil
+}
+
+func (f *fakeAttachments) Delete(_ context.Context, attachment *do.Attachment) error {
+	if _, ok := f.attachments[attachment.ID()]; ok {
+		f.usage[attachment.OwnerID()] -= attachment.Size()
+		delete(f.attachments, attachment.ID())
+	}
+	return nil
+}
+
+func (f *fakeAttachments) ClaimProcessing(_ context.Context, limit int, _ time.Duration) ([]*do.Attachment, error) {
+	var claimed []*do.Attachment
+	for _, attachment := range f.attachments {
+		if attachment.Processing() == do.ProcessingPending && !f.retryAt[attachment.ID()].After(time.Now()) && len(claimed) < limit {
+			attachment.RestoreProcessing(do.ProcessingPending, attachment.ProcessingAttempts()+1, attachment.Image())
+			claimed = append(claimed, attachment)
+		}
+	}
+	return claimed, nil
+}
+
+func (f *fakeAttachments) SaveProcessing(_ context.Context, attachment *do.Attachment, retryAt time.Time) error {
+	if _, ok := f.attachments[attachment.ID()]; !ok {
+		return repository.ErrAttachmentNotFound
+	}
+	f.retryAt[attachment.ID()] = retryAt
+	return nil
+}
+
+type fakeUploads struct {
+	repository.UploadRepository
+	uploads map[uuid.UUID]*do.Upload
+}
+
+func (f *fakeUploads) Create(_ context.Context, upload *do.Upload) error {
+	f.uploads[upload.ID()] = upload
+	return nil
+}
+
+func (f *fakeUploads) FindByID(_ context.Context, id uuid.UUID) (*do.Upload, error) {
+	upload, ok := f.uploads[id]
+	if !ok {
+		return nil, repository.ErrUploadNotFound
+	}
+	return do.ReconstructUpload(upload.ID(), upload.OwnerID(), upload.Filename(), upload.Size(), upload.Received(), upload.CreatedAt()), nil
+}
+
+func (f *fakeUploads) Advance(_ context.Context, upload *do.Upload, from int64) error {
+	if f.uploads[upload.ID()].Received() != from {
+		return repository.ErrUploadConflict
+	}
+	f.uploads[upload.ID()] = upload
+	return nil
+}
+
+func (f *fakeUploads) ListExpired(_ context.Context, expiredBefore time.Time, limit int) ([]*do.Upload, error) {
+	var expired []*do.Upload
+	for _, upload := range f.uploads {
+		if upload.CreatedAt().Before(expiredBefore) && len(expired) < limit {
+			expired = append(expired, upload)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeUploads) Delete(_ context.Context, id uuid.UUID) error {
+	delete(f.uploads, id)
+	return nil
+}
+
+type fakeMessages struct {
+	repository.MessageRepository
+	messages map[uuid.UUID]*do.Message
+}
+
+func (f *fakeMessages) FindByID(_ context.Context, id uuid.UUID) (*do.Message, error) {
+	if msg, ok := f.messages[id]; ok {
+		return msg, nil
+	}
+	return nil, repository.ErrMessageNotFound
+}
+
+type fakePublisher struct {
+	events map[uuid.UUID][]realtime.Event
+}
+
+func (f *fakePublisher) Publish(userID uuid.UUID, event realtime.Event) {
+	f.events[userID] = append(f.events[userID], event)
+}
+
+// brokenReader fails after handing out its bytes, like a connection that drops
+type brokenReader struct {
+	io.Reader
+}
+
+func (r brokenReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// photo is a JPEG of width by height taken with the camera turned right,
+// EXIF orientation 6, somewhere north
+func photo(width, height int) []byte {
+	var buf bytes.Buffer
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		panic(err)
+	}
+
+	// IFD0 with the orientation and a pointer to the GPS IFD at 38, which
+	// holds the latitude reference
+	le := binary.LittleEndian
+	tiff := le.AppendUint32([]byte("II*\x00"), 8)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = append(le.AppendUint32(le.AppendUint16(le.AppendUint16(tiff, 0x0112), 3), 1), 6, 0, 0, 0)
+	tiff = le.AppendUint32(le.AppendUint32(le.AppendUint16(le.AppendUint16(tiff, 0x8825), 4), 1), 38)
+	tiff = le.AppendUint32(tiff, 0)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = append(le.AppendUint32(le.AppendUint16(le.AppendUint16(tiff, 1), 2), 2), 'N', 0, 0, 0)
+	tiff = le.AppendUint32(tiff, 0)
+
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	data := binary.BigEndian.AppendUint16([]byte{0xFF, 0xD8, 0xFF, 0xE1}, uint16(len(exif)+2))
+	return append(append(data, exif...), buf.Bytes()[2:]...)
+}
+
+// encodedPNG is a blank PNG of width by height
+func encodedPNG(width, height int) []byte {
+	var buf bytes.Buffer
+	if err := pngenc.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// png is the smallest body the type sniffer takes for a PNG image
+var png = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 24)...)
+
+type AttachmentSuite struct {
+	suite.Suite
+	ctx         context.Context
+	owner       uuid.UUID
+	cfg         config.Attachment
+	attachments *fakeAttachments
+	uploads     *fakeUploads
+	blobs       *blob.Local
+	staging     *blob.Staging
+}
+
+func (suite *AttachmentSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.owner = uuid.New()
+	suite.cfg = config.Attachment{
+		AttachmentMaxSizeMB:    1,
+		AttachmentQuotaMB:      1,
+		AttachmentAllowedTypes: []string{"image/*", "text/plain"},
+		AttachmentURLExpiry:    time.Minute,
+		AttachmentUploadTTL:    time.Hour,
+	}
+	suite.attachments = &fakeAttachments{attachments: map[uuid.UUID]*do.Attachment{}, usage: map[uuid.UUID]int64{}, retryAt: map[uuid.UUID]time.Time{}}
+	suite.uploads = &fakeUploads{uploads: map[uuid.UUID]*do.Upload{}}
+
+	var err error
+	suite.blobs, err = blob.NewLocal(suite.T().TempDir(), []byte("secret"))
+	suite.Require().NoError(err)
+	suite.staging, err = blob.NewStaging(suite.T().TempDir())
+	suite.Require().NoError(err)
+}
+
+func (suite *AttachmentSuite) upload(filename string, body []byte) (*do.Attachment, error) {
+	uc := NewUploadAttachmentUseCase(suite.attachments, suite.blobs, suite.cfg)
+	return uc.Execute(suite.ctx, suite.owner, filename, bytes.NewReader(body), int64(len(body)))
+}
+
+func (suite *AttachmentSuite) TestUploadSniffsType() {
+	attachment, err := suite.upload("../cat.txt", png)
+	suite.Require().NoError(err)
+	suite.Equal("image/png", attachment.ContentType(), "the content decides, not the name")
+	suite.Equal("cat.txt", attachment.Filename())
+	suite.Len(attachment.Checksum(), 64)
+
+	stored, err := suite.blobs.Open(suite.ctx, attachment.StorageKey())
+	suite.Require().NoError(err)
+	body, _ := io.ReadAll(stored)
+	suite.NoError(stored.Close())
+	suite.Equal(png, body)
+
+	_, err = suite.upload("cat.png", []byte("%PDF-1.7\n"))
+	suite.ErrorIs(err, do.ErrAttachmentTypeNotAllowed)
+}
+
+func (suite *AttachmentSuite) TestQuota() {
+	suite.cfg.AttachmentMaxSizeMB = 2
+	big := append(bytes.Clone(png), bytes.Repeat([]byte{0}, 600<<10)...)
+	first, err := suite.upload("a.png", big)
+	suite.Require().NoError(err)
+
+	_, err = suite.upload("b.png", big)
+	suite.ErrorIs(err, usecase.ErrAttachmentQuotaExceeded)
+	suite.Len(suite.attachments.attachments, 1)
+
+	suite.Require().NoError(suite.attachments.Delete(suite.ctx, first))
+	_, err = suite.upload("b.png", big)
+	suite.NoError(err)
+}
+
+func (suite *AttachmentSuite) TestResumableUpload() {
+	body := []byte("hello, resumable world")
+	upload, err := NewCreateUploadUseCase(suite.uploads, suite.cfg).Execute(suite.ctx, suite.owner, "note.txt", int64(len(body)))
+	suite.Require().NoError(err)
+
+	appendUC := NewAppendUploadUseCase(suite.uploads, suite.attachments, suite.staging, suite.blobs, suite.cfg)
+
+	// the connection drops after 5 bytes, the client resumes from there
+	progress, attachment, err := appendUC.Execute(suite.ctx, upload.ID(), suite.owner, 0, brokenReader{bytes.NewReader(body[:5])}, -1)
+	suite.ErrorIs(err, io.ErrUnexpectedEOF)
+	suite.Nil(attachment)
+	suite.Equal(int64(5), progress.Received())
+
+	_, _, err = appendUC.Execute(suite.ctx, upload.ID(), suite.owner, 0, bytes.NewReader(body), -1)
+	suite.ErrorIs(err, do.ErrUploadOffsetMismatch)
+
+	_, _, err = appendUC.Execute(suite.ctx, upload.ID(), suite.owner, 5, bytes.NewReader(append(bytes.Clone(body[5:]), '!')), -1)
+	suite.ErrorIs(err, do.ErrUploadTooLong)
+
+	_, _, err = appendUC.Execute(suite.ctx, upload.ID(), uuid.New(), 5, bytes.NewReader(body[5:]), -1)
+	suite.ErrorIs(err, usecase.ErrUploadNotFound)
+
+	current, err := NewGetUploadUseCase(suite.uploads, suite.cfg).Execute(suite.ctx, upload.ID(), suite.owner)
+	suite.Require().NoError(err)
+	suite.Equal(int64(5), current.Received())
+
+	progress, attachment, err = appendUC.Execute(suite.ctx, upload.ID(), suite.owner, 5, bytes.NewReader(body[5:]), int64(len(body)-5))
+	suite.Require().NoError(err)
+	suite.True(progress.IsComplete())
+	suite.Require().NotNil(attachment)
+	suite.Equal("text/plain; charset=utf-8", attachment.ContentType())
+	suite.Empty(suite.uploads.uploads, "a finished upload is dropped")
+}
+
+func (suite *AttachmentSuite) TestCancelUpload() {
+	upload, err := NewCreateUploadUseCase(suite.uploads, suite.cfg).Execute(suite.ctx, suite.owner, "note.txt", 10)
+	suite.Require().NoError(err)
+	appendUC := NewAppendUploadUseCase(suite.uploads, suite.attachments, suite.staging, suite.blobs, suite.cfg)
+	_, _, err = appendUC.Execute(suite.ctx, upload.ID(), suite.owner, 0, strings.NewReader("hello"), 5)
+	suite.Require().NoError(err)
+
+	cancelUC := NewCancelUploadUseCase(suite.uploads, suite.staging, suite.cfg)
+	suite.ErrorIs(cancelUC.Execute(suite.ctx, upload.ID(), uuid.New()), usecase.ErrUploadNotFound)
+	suite.NoError(cancelUC.Execute(suite.ctx, upload.ID(), suite.owner))
+	suite.Empty(suite.uploads.uploads)
+	_, err = suite.staging.Open(upload.StagingKey())
+	suite.ErrorIs(err, blob.ErrNotFound)
+}
+
+func (suite *AttachmentSuite) TestGetAttachment() {
+	receiver, stranger := uuid.New(), uuid.New()
+	attachment, err := suite.upload("cat.png", png)
+	suite.Require().NoError(err)
+	messages := &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	uc := NewGetAttachmentUseCase(suite.attachments, messages, suite.blobs, suite.cfg)
+
+	_, links, err := uc.Execute(suite.ctx, attachment.ID(), suite.owner)
+	suite.Require().NoError(err)
+	suite.Contains(links.URL, attachment.StorageKey())
+	suite.Empty(links.Variants, "not processed yet")
+	suite.WithinDuration(time.Now().Add(time.Minute), links.ExpiresAt, time.Second)
+
+	attachment.CompleteProcessing(do.ImageInfo{Width: 1, Height: 1, Variants: []do.AttachmentVariant{{Name: "small", ContentType: "image/jpeg"}}})
+	_, links, err = uc.Execute(suite.ctx, attachment.ID(), suite.owner)
+	suite.Require().NoError(err)
+	suite.Contains(links.Variants["small"], attachment.VariantKey("small"))
+
+	_, _, err = uc.Execute(suite.ctx, attachment.ID(), receiver)
+	suite.ErrorIs(err, usecase.ErrAttachmentNotFound, "unsent uploads are private")
+
+	msg, err := do.NewMessageWithID(uuid.Nil, suite.owner, receiver, "", attachment)
+	suite.Require().NoError(err)
+	messages.messages[msg.ID()] = msg
+	_, _, err = uc.Execute(suite.ctx, attachment.ID(), receiver)
+	suite.NoError(err)
+	_, _, err = uc.Execute(suite.ctx, attachment.ID(), stranger)
+	suite.ErrorIs(err, usecase.ErrAttachmentNotFound)
+
+	suite.Require().NoError(msg.Retract(suite.owner, time.Hour))
+	_, _, err = uc.Execute(suite.ctx, attachment.ID(), receiver)
+	suite.ErrorIs(err, usecase.ErrAttachmentNotFound)
+}
+
+func (suite *AttachmentSuite) TestPrune() {
+	suite.cfg.AttachmentUploadTTL = time.Nanosecond
+	attachment, err := suite.upload("cat.png", png)
+	suite.Require().NoError(err)
+	upload, err := NewCreateUploadUseCase(suite.uploads, suite.cfg).Execute(suite.ctx, suite.owner, "note.txt", 10)
+	suite.Require().NoError(err)
+	_, err = suite.staging.Append(upload.StagingKey(), 0, strings.NewReader("hello"), 10)
+	suite.Require().NoError(err)
+	time.Sleep(time.Millisecond)
+
+	uc := NewPruneAttachmentsUseCase(suite.attachments, suite.uploads, suite.blobs, suite.staging, suite.cfg)
+	removed, err := uc.Execute(suite.ctx)
+	suite.NoError(err)
+	suite.Equal(2, removed)
+	suite.Empty(suite.uploads.uploads)
+	suite.Zero(suite.attachments.usage[suite.owner])
+	_, err = suite.blobs.Open(suite.ctx, attachment.StorageKey())
+	suite.ErrorIs(err, blob.ErrNotFound)
+}
+
+func (suite *AttachmentSuite) TestUploadScrubsLocation() {
+	body := photo(30, 20)
+	suite.Equal(6, imaging.Orientation(body))
+	suite.Contains(string(body), "N\x00\x00\x00")
+
+	attachment, err := suite.upload("IMG_0001.jpg", body)
+	suite.Require().NoError(err)
+	suite.Equal(do.ProcessingPending, attachment.Processing())
+
+	stored, err := suite.blobs.Open(suite.ctx, attachment.StorageKey())
+	suite.Require().NoError(err)
+	scrubbed, _ := io.ReadAll(stored)
+	suite.NoError(stored.Close())
+	suite.Len(scrubbed, len(body))
+	suite.NotContains(string(scrubbed), "N\x00\x00\x00")
+	suite.Equal(6, imaging.Orientation(scrubbed), "the orientation stays")
+
+	sum := sha256.Sum256(scrubbed)
+	suite.Equal(hex.EncodeToString(sum[:]), attachment.Checksum(), "the checksum is of the stored bytes")
+}
+
+func (suite *AttachmentSuite) TestProcess() {
+	receiver := uuid.New()
+	publisher := &fakePublisher{events: map[uuid.UUID][]realtime.Event{}}
+	messages := &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	uc := NewProcessAttachmentsUseCase(suite.attachments, messages, suite.blobs, publisher, config.Attachment{
+		AttachmentProcessAttempts: 3,
+		AttachmentMaxMegapixels:   1,
+	})
+
+	landscape, err := suite.upload("IMG_0001.jpg", photo(300, 200))
+	suite.Require().NoError(err)
+	msg, err := do.NewMessageWithID(uuid.Nil, suite.owner, receiver, "", landscape)
+	suite.Require().NoError(err)
+	messages.messages[msg.ID()] = msg
+
+	broken, err := suite.upload("broken.png", png)
+	suite.Require().NoError(err)
+	missing, err := suite.upload("missing.jpg", photo(10, 10))
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.blobs.Delete(suite.ctx, missing.StorageKey()))
+	text, err := suite.upload("note.txt", []byte("hello"))
+	suite.Require().NoError(err)
+
+	processed, err := uc.Execute(suite.ctx)
+	suite.Equal(1, processed)
+	suite.ErrorIs(err, imaging.ErrUndecodable)
+	suite.ErrorIs(err, blob.ErrNotFound)
+
+	// turned upright, the photo is a portrait
+	suite.Equal(do.ProcessingReady, landscape.Processing())
+	info := landscape.Image()
+	suite.Equal(200, info.Width)
+	suite.Equal(300, info.Height)
+	suite.Equal("T", info.Blurhash[:1], "three by four components")
+	suite.Require().Len(info.Variants, 2)
+	suite.Equal(do.AttachmentVariant{Name: "small", ContentType: "image/jpeg", Width: 106, Height: 160, Size: info.Variants[0].Size}, info.Variants[0])
+	suite.Equal(200, info.Variants[1].Width, "never scaled up")
+	thumbnail, err := suite.blobs.Open(suite.ctx, landscape.VariantKey("small"))
+	suite.Require().NoError(err)
+	decoded, err := jpeg.DecodeConfig(thumbnail)
+	suite.NoError(thumbnail.Close())
+	suite.Require().NoError(err)
+	suite.Equal(106, decoded.Width)
+
+	suite.Equal(do.ProcessingFailed, broken.Processing(), "no image, no retry")
+	suite.Equal(do.ProcessingPending, missing.Processing())
+	suite.WithinDuration(time.Now().Add(30*time.Second), suite.attachments.retryAt[missing.ID()], time.Second)
+	suite.Equal(do.ProcessingNone, text.Processing())
+
+	suite.Len(publisher.events[suite.owner], 2, "ready and failed")
+	suite.Require().Len(publisher.events[receiver], 1)
+	suite.Equal(usecase.EventAttachmentProcessed, publisher.events[receiver][0].Type)
+
+	// a retry not yet due is left alone, the last attempt gives up
+	processed, err = uc.Execute(suite.ctx)
+	suite.Zero(processed)
+	suite.NoError(err)
+	missing.RestoreProcessing(do.ProcessingPending, 2, do.ImageInfo{})
+	suite.attachments.retryAt[missing.ID()] = time.Time{}
+	_, err = uc.Execute(suite.ctx)
+	suite.ErrorIs(err, blob.ErrNotFound)
+	suite.Equal(do.ProcessingFailed, missing.Processing())
+}
+
+func (suite *AttachmentSuite) TestProcessTooManyPixels() {
+	uc := NewProcessAttachmentsUseCase(suite.attachments, &fakeMessages{}, suite.blobs, &fakePublisher{events: map[uuid.UUID][]realtime.Event{}}, config.Attachment{
+		AttachmentProcessAttempts: 3,
+		AttachmentMaxMegapixels:   1,
+	})
+	huge, err := suite.upload("huge.png", encodedPNG(1001, 1000))
+	suite.Require().NoError(err)
+
+	_, err = uc.Execute(suite.ctx)
+	suite.ErrorIs(err, imaging.ErrTooManyPixels)
+	suite.Equal(do.ProcessingFailed, huge.Processing())
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, 2*time.Minute, backoff(3))
+	assert.Equal(t, time.Hour, backoff(20))
+}
+
+func TestAttachmentSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentSuite))
+}
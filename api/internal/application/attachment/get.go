@@ -0,0 +1,110 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Links are signed URLs to download an attachment and its thumbnails,
+// valid until ExpiresAt
+type Links struct {
+	URL string
+	// Variants maps thumbnail names to their URLs
+	Variants  map[string]string
+	ExpiresAt time.Time
+}
+
+// GetAttachmentUseCase handles handing out download links for attachments
+type GetAttachmentUseCase struct {
+	attachmentRepo repository.AttachmentRepository
+	messageRepo    repository.MessageRepository
+	blobs          BlobStore
+	expiry         time.Duration
+}
+
+// NewGetAttachmentUseCase creates a new get attachment use case
+func NewGetAttachmentUseCase(attachmentRepo repository.AttachmentRepository, messageRepo repository.MessageRepository, blobs BlobStore, cfg config.Attachment) *GetAttachmentUseCase {
+	return &GetAttachmentUseCase{
+		attachmentRepo: attachmentRepo,
+		messageRepo:    messageRepo,
+		blobs:          blobs,
+		expiry:         cfg.AttachmentURLExpiry,
+	}
+}
+
+// Execute returns the attachment with signed URLs to download it and its
+// thumbnails. The owner always sees their uploads, the receiver once they
+// were sent; a message deleted for everyone takes its attachments with it.
+func (uc *GetAttachmentUseCase) Execute(ctx context.Context, attachmentID, viewerID uuid.UUID) (_ *do.Attachment, links Links, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Get")
+	defer tracing.End(span, &err)
+
+	attachment, err := uc.attachmentRepo.FindByID(ctx, attachmentID)
+	if errors.Is(err, repository.ErrAttachmentNotFound) {
+		return nil, Links{}, fmt.Errorf("%w: %w", usecase.ErrAttachmentNotFound, err)
+	}
+	if err != nil {
+		return nil, Links{}, err
+	}
+
+	if attachment.IsSent() {
+		msg, err := uc.messageRepo.FindByID(ctx, attachment.MessageID())
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			return nil, Links{}, fmt.Errorf("%w: %w", usecase.ErrAttachmentNotFound, err)
+		}
+		if err != nil {
+			return nil, Links{}, err
+		}
+		if !msg.IsParticipant(viewerID) || msg.IsDeleted() {
+			return nil, Links{}, usecase.ErrAttachmentNotFound
+		}
+	} else if attachment.OwnerID() != viewerID {
+		return nil, Links{}, usecase.ErrAttachmentNotFound
+	}
+
+	links, err = uc.sign(ctx, attachment)
+	if err != nil {
+		return nil, Links{}, err
+	}
+	return attachment, links, nil
+}
+
+// sign signs the URLs of the file and of the thumbnails made so far; a
+// thumbnail is shown inline, so it is offered under its own type
+func (uc *GetAttachmentUseCase) sign(ctx context.Context, attachment *do.Attachment) (Links, error) {
+	links := Links{ExpiresAt: time.Now().Add(uc.expiry)}
+
+	var err error
+	links.URL, err = uc.blobs.SignURL(ctx, attachment.StorageKey(), blob.Download{
+		ContentType: attachment.ContentType(),
+		Filename:    attachment.Filename(),
+	}, uc.expiry)
+	if err != nil {
+		return Links{}, err
+	}
+
+	for _, variant := range attachment.Image().Variants {
+		url, err := uc.blobs.SignURL(ctx, attachment.VariantKey(variant.Name), blob.Download{
+			ContentType: variant.ContentType,
+			Filename:    variant.Name + "-" + attachment.Filename(),
+		}, uc.expiry)
+		if err != nil {
+			return Links{}, err
+		}
+		if links.Variants == nil {
+			links.Variants = map[string]string{}
+		}
+		links.Variants[variant.Name] = url
+	}
+	return links, nil
+}
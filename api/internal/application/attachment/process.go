@@ -0,0 +1,205 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/imaging"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"image"
+	"io"
+	"time"
+)
+
+const (
+	// processBatch bounds how many images one run claims
+	processBatch = 20
+	// processLease is how long a claimed image is left to its worker before
+	// another may take it, in case the first one died
+	processLease = 5 * time.Minute
+	// retryBackoff doubles after each failed attempt up to maxRetryBackoff
+	retryBackoff    = 30 * time.Second
+	maxRetryBackoff = time.Hour
+)
+
+// ProcessAttachmentsUseCase handles making thumbnails of image attachments
+type ProcessAttachmentsUseCase struct {
+	attachmentRepo repository.AttachmentRepository
+	messageRepo    repository.MessageRepository
+	blobs          BlobStore
+	publisher      usecase.Publisher
+	maxAttempts    int
+	maxPixels      int
+}
+
+// NewProcessAttachmentsUseCase creates a new process attachments use case
+func NewProcessAttachmentsUseCase(attachmentRepo repository.AttachmentRepository, messageRepo repository.MessageRepository, blobs BlobStore, publisher usecase.Publisher, cfg config.Attachment) *ProcessAttachmentsUseCase {
+	return &ProcessAttachmentsUseCase{
+		attachmentRepo: attachmentRepo,
+		messageRepo:    messageRepo,
+		blobs:          blobs,
+		publisher:      publisher,
+		maxAttempts:    cfg.AttachmentProcessAttempts,
+		maxPixels:      cfg.MaxPixels(),
+	}
+}
+
+// Execute makes the thumbnails and blurhash of the images due and returns
+// how many it finished. One failing image does not hold up the others; its
+// error is joined into err and it is retried later, unless it is no image
+// this can decode or it ran out of attempts.
+func (uc *ProcessAttachmentsUseCase) Execute(ctx context.Context) (processed int, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Process")
+	defer tracing.End(span, &err)
+
+	claimed, err := uc.attachmentRepo.ClaimProcessing(ctx, processBatch, processLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, attachment := range claimed {
+		if err := uc.process(ctx, attachment); err != nil {
+			errs = append(errs, fmt.Errorf("attachment %s: %w", attachment.ID(), err))
+		}
+		if attachment.Processing() == do.ProcessingReady {
+			processed++
+		}
+	}
+	return processed, errors.Join(errs...)
+}
+
+func (uc *ProcessAttachmentsUseCase) process(ctx context.Context, attachment *do.Attachment) error {
+	info, err := uc.thumbnail(ctx, attachment)
+	if err == nil {
+		attachment.CompleteProcessing(info)
+		usecase.AttachmentsProcessed.WithLabelValues("ready").Inc()
+		return uc.save(ctx, attachment, time.Now())
+	}
+
+	permanent := errors.Is(err, imaging.ErrUndecodable) || errors.Is(err, imaging.ErrTooManyPixels)
+	retryAt := time.Now()
+	if attachment.FailProcessing(permanent, uc.maxAttempts) {
+		retryAt = retryAt.Add(backoff(attachment.ProcessingAttempts()))
+		usecase.AttachmentsProcessed.WithLabelValues("retry").Inc()
+	} else {
+		usecase.AttachmentsProcessed.WithLabelValues("failed").Inc()
+	}
+	return errors.Join(err, uc.save(ctx, attachment, retryAt))
+}
+
+// backoff is the wait after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	wait := retryBackoff
+	for range attempts - 1 {
+		if wait *= 2; wait >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return wait
+}
+
+// thumbnail stores a scaled down copy of the image for each thumbnail size,
+// turned upright as its EXIF orientation says
+func (uc *ProcessAttachmentsUseCase) thumbnail(ctx context.Context, attachment *do.Attachment) (do.ImageInfo, error) {
+	body, err := uc.blobs.Open(ctx, attachment.StorageKey())
+	if err != nil {
+		return do.ImageInfo{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(body, attachment.Size()))
+	if closeErr := body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return do.ImageInfo{}, err
+	}
+
+	img, err := imaging.Decode(data, uc.maxPixels)
+	if err != nil {
+		return do.ImageInfo{}, err
+	}
+	orientation := imaging.Orientation(data)
+
+	info := do.ImageInfo{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	if imaging.Rotated(orientation) {
+		info.Width, info.Height = info.Height, info.Width
+	}
+
+	var smallest *image.NRGBA
+	for _, thumbnail := range do.Thumbnails {
+		scaled := imaging.Orient(imaging.Fit(img, thumbnail.Edge), orientation)
+		if smallest == nil {
+			smallest = scaled
+		}
+
+		var buf bytes.Buffer
+		contentType, err := imaging.Encode(&buf, scaled)
+		if err != nil {
+			return do.ImageInfo{}, err
+		}
+		size := int64(buf.Len())
+		if err := uc.blobs.Put(ctx, attachment.VariantKey(thumbnail.Name), &buf, size, contentType); err != nil {
+			return do.ImageInfo{}, err
+		}
+		info.Variants = append(info.Variants, do.AttachmentVariant{
+			Name:        thumbnail.Name,
+			ContentType: contentType,
+			Width:       scaled.Bounds().Dx(),
+			Height:      scaled.Bounds().Dy(),
+			Size:        size,
+		})
+	}
+
+	// four by three components, or three by four for a portrait
+	x, y := 4, 3
+	if info.Height > info.Width {
+		x, y = 3, 4
+	}
+	info.Blurhash, err = imaging.Blurhash(smallest, x, y)
+	if err != nil {
+		return do.ImageInfo{}, err
+	}
+	return info, nil
+}
+
+// save stores the outcome and, once there is one, tells the participants;
+// an attachment pruned in the meantime has its thumbnails removed again
+func (uc *ProcessAttachmentsUseCase) save(ctx context.Context, attachment *do.Attachment, retryAt time.Time) error {
+	err := uc.attachmentRepo.SaveProcessing(ctx, attachment, retryAt)
+	if errors.Is(err, repository.ErrAttachmentNotFound) {
+		var errs []error
+		for _, variant := range attachment.Image().Variants {
+			errs = append(errs, uc.blobs.Delete(ctx, attachment.VariantKey(variant.Name)))
+		}
+		return errors.Join(errs...)
+	}
+	if err != nil {
+		return err
+	}
+	if attachment.Processing() == do.ProcessingPending {
+		return nil
+	}
+
+	event := realtime.Event{Type: usecase.EventAttachmentProcessed, Data: attachment}
+	uc.publisher.Publish(attachment.OwnerID(), event)
+	if !attachment.IsSent() {
+		return nil
+	}
+	msg, err := uc.messageRepo.FindByID(ctx, attachment.MessageID())
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !msg.IsDeleted() {
+		uc.publisher.Publish(msg.ReceiverID(), event)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package attachment
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/tracing"
+	"time"
+)
+
+// pruneBatch bounds how many rows one query of the pruner handles
+const pruneBatch = 100
+
+// PruneAttachmentsUseCase handles removing files nobody can reach any more
+type PruneAttachmentsUseCase struct {
+	attachmentRepo repository.AttachmentRepository
+	uploadRepo     repository.UploadRepository
+	blobs          BlobStore
+	staging        UploadStaging
+	ttl            time.Duration
+}
+
+// NewPruneAttachmentsUseCase creates a new prune attachments use case
+func NewPruneAttachmentsUseCase(attachmentRepo repository.AttachmentRepository, uploadRepo repository.UploadRepository, blobs BlobStore, staging UploadStaging, cfg config.Attachment) *PruneAttachmentsUseCase {
+	return &PruneAttachmentsUseCase{
+		attachmentRepo: attachmentRepo,
+		uploadRepo:     uploadRepo,
+		blobs:          blobs,
+		staging:        staging,
+		ttl:            cfg.AttachmentUploadTTL,
+	}
+}
+
+// Execute deletes attachments never sent within the upload TTL, whose
+// message was deleted or whose owner was, and uploads that expired
+// unfinished or lost their owner, and returns how many of both it removed.
+// The bytes, thumbnails included, go before the row, so a failure leaves
+// the row behind for the next run.
+func (uc *PruneAttachmentsUseCase) Execute(ctx context.Context) (removed int, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Prune")
+	defer tracing.End(span, &err)
+
+	expiredBefore := time.Now().Add(-uc.ttl)
+	for {
+		garbage, err := uc.attachmentRepo.ListGarbage(ctx, expiredBefore, pruneBatch)
+		if err != nil {
+			return removed, err
+		}
+		for _, attachment := range garbage {
+			if err := uc.deleteBlobs(ctx, attachment); err != nil {
+				return removed, err
+			}
+			if err := uc.attachmentRepo.Delete(ctx, attachment); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(garbage) < pruneBatch {
+			break
+		}
+	}
+
+	for {
+		expired, err := uc.uploadRepo.ListExpired(ctx, expiredBefore, pruneBatch)
+		if err != nil {
+			return removed, err
+		}
+		for _, upload := range expired {
+			if err := uc.staging.Remove(upload.StagingKey()); err != nil {
+				return removed, err
+			}
+			if err := uc.uploadRepo.Delete(ctx, upload.ID()); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(expired) < pruneBatch {
+			break
+		}
+	}
+	return removed, nil
+}
+
+// deleteBlobs removes the file and any thumbnail made of it
+func (uc *PruneAttachmentsUseCase) deleteBlobs(ctx context.Context, attachment *do.Attachment) error {
+	for _, thumbnail := range do.Thumbnails {
+		if err := uc.blobs.Delete(ctx, attachment.VariantKey(thumbnail.Name)); err != nil {
+			return err
+		}
+	}
+	return uc.blobs.Delete(ctx, attachment.StorageKey())
+}
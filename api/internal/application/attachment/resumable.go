@@ -0,0 +1,204 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/tracing"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// findUpload loads an upload of ownerID that has not expired yet; other
+// users' uploads do not exist for them
+func findUpload(ctx context.Context, uploadRepo repository.UploadRepository, uploadID, ownerID uuid.UUID, ttl time.Duration) (*do.Upload, error) {
+	upload, err := uploadRepo.FindByID(ctx, uploadID)
+	if errors.Is(err, repository.ErrUploadNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrUploadNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if upload.OwnerID() != ownerID || time.Since(upload.CreatedAt()) > ttl {
+		return nil, usecase.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// CreateUploadUseCase handles starting resumable uploads
+type CreateUploadUseCase struct {
+	uploadRepo repository.UploadRepository
+	policy     do.AttachmentPolicy
+}
+
+// NewCreateUploadUseCase creates a new create upload use case
+func NewCreateUploadUseCase(uploadRepo repository.UploadRepository, cfg config.Attachment) *CreateUploadUseCase {
+	return &CreateUploadUseCase{
+		uploadRepo: uploadRepo,
+		policy:     policy(cfg),
+	}
+}
+
+// Execute starts an upload of size bytes the owner sends in chunks; the
+// type is only checked once all bytes are there
+func (uc *CreateUploadUseCase) Execute(ctx context.Context, ownerID uuid.UUID, filename string, size int64) (_ *do.Upload, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.CreateUpload")
+	defer tracing.End(span, &err)
+
+	upload, err := do.NewUpload(ownerID, filename, size, uc.policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// AppendUploadUseCase handles receiving chunks of resumable uploads
+type AppendUploadUseCase struct {
+	uploadRepo repository.UploadRepository
+	staging    UploadStaging
+	store      store
+	ttl        time.Duration
+}
+
+// NewAppendUploadUseCase creates a new append upload use case
+func NewAppendUploadUseCase(uploadRepo repository.UploadRepository, attachmentRepo repository.AttachmentRepository, staging UploadStaging, blobs BlobStore, cfg config.Attachment) *AppendUploadUseCase {
+	return &AppendUploadUseCase{
+		uploadRepo: uploadRepo,
+		staging:    staging,
+		store:      newStore(attachmentRepo, blobs, cfg),
+		ttl:        cfg.AttachmentUploadTTL,
+	}
+}
+
+// Execute writes a chunk of length bytes, -1 when unknown, at offset. What
+// arrived of a chunk broken off midway is kept, the returned upload tells
+// where to resume. The chunk completing the upload turns it into an
+// attachment, which is returned as well.
+func (uc *AppendUploadUseCase) Execute(ctx context.Context, uploadID, ownerID uuid.UUID, offset int64, body io.Reader, length int64) (_ *do.Upload, _ *do.Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.AppendUpload")
+	defer tracing.End(span, &err)
+
+	upload, err := findUpload(ctx, uc.uploadRepo, uploadID, ownerID, uc.ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := upload.Accept(offset, length); err != nil {
+		return nil, nil, err
+	}
+
+	from := upload.Received()
+	received, err := uc.staging.Append(upload.StagingKey(), offset, body, upload.Remaining())
+	switch {
+	case errors.Is(err, blob.ErrOffsetMismatch):
+		return nil, nil, fmt.Errorf("%w: %w", do.ErrUploadOffsetMismatch, err)
+	case errors.Is(err, blob.ErrChunkTooLarge):
+		return nil, nil, fmt.Errorf("%w: %w", do.ErrUploadTooLong, err)
+	}
+	appendErr := err
+
+	upload.Advance(received)
+	err = uc.uploadRepo.Advance(ctx, upload, from)
+	if errors.Is(err, repository.ErrUploadConflict) {
+		return nil, nil, fmt.Errorf("%w: %w", do.ErrUploadOffsetMismatch, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if appendErr != nil {
+		return upload, nil, appendErr
+	}
+	if !upload.IsComplete() {
+		return upload, nil, nil
+	}
+
+	attachment, err := uc.finish(ctx, upload)
+	if err != nil {
+		return upload, nil, err
+	}
+	return upload, attachment, nil
+}
+
+// finish stores the staged bytes as an attachment and drops the upload;
+// staging goes first, so whatever fails to clean up keeps its row and is
+// left for the pruner
+func (uc *AppendUploadUseCase) finish(ctx context.Context, upload *do.Upload) (*do.Attachment, error) {
+	f, err := uc.staging.Open(upload.StagingKey())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	attachment, err := uc.store.save(ctx, upload.OwnerID(), upload.Filename(), f, upload.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.staging.Remove(upload.StagingKey()); err == nil {
+		_ = uc.uploadRepo.Delete(ctx, upload.ID())
+	}
+	return attachment, nil
+}
+
+// GetUploadUseCase handles looking up how far a resumable upload got
+type GetUploadUseCase struct {
+	uploadRepo repository.UploadRepository
+	ttl        time.Duration
+}
+
+// NewGetUploadUseCase creates a new get upload use case
+func NewGetUploadUseCase(uploadRepo repository.UploadRepository, cfg config.Attachment) *GetUploadUseCase {
+	return &GetUploadUseCase{
+		uploadRepo: uploadRepo,
+		ttl:        cfg.AttachmentUploadTTL,
+	}
+}
+
+// Execute returns the owner's upload, its received count is the offset to
+// resume from
+func (uc *GetUploadUseCase) Execute(ctx context.Context, uploadID, ownerID uuid.UUID) (_ *do.Upload, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.GetUpload")
+	defer tracing.End(span, &err)
+
+	return findUpload(ctx, uc.uploadRepo, uploadID, ownerID, uc.ttl)
+}
+
+// CancelUploadUseCase handles abandoning resumable uploads
+type CancelUploadUseCase struct {
+	uploadRepo repository.UploadRepository
+	staging    UploadStaging
+	ttl        time.Duration
+}
+
+// NewCancelUploadUseCase creates a new cancel upload use case
+func NewCancelUploadUseCase(uploadRepo repository.UploadRepository, staging UploadStaging, cfg config.Attachment) *CancelUploadUseCase {
+	return &CancelUploadUseCase{
+		uploadRepo: uploadRepo,
+		staging:    staging,
+		ttl:        cfg.AttachmentUploadTTL,
+	}
+}
+
+// Execute drops the owner's upload and the bytes received so far
+func (uc *CancelUploadUseCase) Execute(ctx context.Context, uploadID, ownerID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "attachment.CancelUpload")
+	defer tracing.End(span, &err)
+
+	upload, err := findUpload(ctx, uc.uploadRepo, uploadID, ownerID, uc.ttl)
+	if err != nil {
+		return err
+	}
+	if err := uc.staging.Remove(upload.StagingKey()); err != nil {
+		return err
+	}
+	return uc.uploadRepo.Delete(ctx, upload.ID())
+}
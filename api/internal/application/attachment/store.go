@@ -0,0 +1,136 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/imaging"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+// BlobStore keeps the bytes of attachments, a *blob.Local or *blob.S3
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignURL(ctx context.Context, key string, download blob.Download, expires time.Duration) (string, error)
+}
+
+// UploadStaging keeps the bytes of resumable uploads until they are
+// complete, a *blob.Staging
+type UploadStaging interface {
+	Append(key string, offset int64, body io.Reader, limit int64) (int64, error)
+	Open(key string) (*os.File, error)
+	Remove(key string) error
+}
+
+// policy is the attachment policy the configuration describes
+func policy(cfg config.Attachment) do.AttachmentPolicy {
+	return do.AttachmentPolicy{
+		MaxSize:      cfg.MaxSize(),
+		AllowedTypes: cfg.AttachmentAllowedTypes,
+	}
+}
+
+// exifTypes are the image types that can carry EXIF metadata with a
+// location in it
+var exifTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// store turns the bytes of a finished upload into an attachment, shared by
+// the one shot and the resumable upload
+type store struct {
+	attachmentRepo repository.AttachmentRepository
+	blobs          BlobStore
+	policy         do.AttachmentPolicy
+	quota          int64
+}
+
+func newStore(attachmentRepo repository.AttachmentRepository, blobs BlobStore, cfg config.Attachment) store {
+	return store{
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		policy:         policy(cfg),
+		quota:          cfg.Quota(),
+	}
+}
+
+// save checks body against the policy, the type taken from its content
+// rather than what the client claimed, and stores it. Where a photo was
+// taken is removed before anything is stored. The blob is written before
+// the row so a row never points at missing bytes; the blob of a rejected
+// row is removed again.
+func (s store) save(ctx context.Context, ownerID uuid.UUID, filename string, body io.ReadSeeker, size int64) (*do.Attachment, error) {
+	detected, err := mimetype.DetectReader(body)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(exifTypes, detected.String()) {
+		if body, err = s.scrub(body, size); err != nil {
+			return nil, err
+		}
+	}
+	hash := sha256.New()
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(hash, body); err != nil {
+		return nil, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	attachment, err := do.NewAttachment(ownerID, filename, detected.String(), size, hex.EncodeToString(hash.Sum(nil)), s.policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.blobs.Put(ctx, attachment.StorageKey(), body, attachment.Size(), attachment.ContentType()); err != nil {
+		return nil, err
+	}
+	err = s.attachmentRepo.Create(ctx, attachment, s.quota)
+	if err != nil {
+		if deleteErr := s.blobs.Delete(context.WithoutCancel(ctx), attachment.StorageKey()); deleteErr != nil {
+			err = errors.Join(err, deleteErr)
+		}
+		if errors.Is(err, repository.ErrQuotaExceeded) {
+			return nil, fmt.Errorf("%w: %w", usecase.ErrAttachmentQuotaExceeded, err)
+		}
+		return nil, err
+	}
+	usecase.AttachmentsStored.Inc()
+	return attachment, nil
+}
+
+// scrub returns body with its EXIF location blanked; the size is checked
+// first, the image is read into memory
+func (s store) scrub(body io.ReadSeeker, size int64) (io.ReadSeeker, error) {
+	if err := s.policy.CheckSize(size); err != nil {
+		return nil, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return nil, err
+	}
+	if !imaging.ScrubLocation(data) {
+		return body, nil
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,33 @@
+package attachment
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/tracing"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// UploadAttachmentUseCase handles uploading a file in one request
+type UploadAttachmentUseCase struct {
+	store store
+}
+
+// NewUploadAttachmentUseCase creates a new upload attachment use case
+func NewUploadAttachmentUseCase(attachmentRepo repository.AttachmentRepository, blobs BlobStore, cfg config.Attachment) *UploadAttachmentUseCase {
+	return &UploadAttachmentUseCase{
+		store: newStore(attachmentRepo, blobs, cfg),
+	}
+}
+
+// Execute stores size bytes of body as an unsent attachment of the owner,
+// ready to be sent with a message
+func (uc *UploadAttachmentUseCase) Execute(ctx context.Context, ownerID uuid.UUID, filename string, body io.ReadSeeker, size int64) (_ *do.Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "attachment.Upload")
+	defer tracing.End(span, &err)
+
+	return uc.store.save(ctx, ownerID, filename, body, size)
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// CheckActiveUseCase handles checking a token's user may still use it
+type CheckActiveUseCase struct {
+	userRepo repository.UserRepository
+}
+
+// NewCheckActiveUseCase creates a new check active use case
+func NewCheckActiveUseCase(userRepo repository.UserRepository) *CheckActiveUseCase {
+	return &CheckActiveUseCase{
+		userRepo: userRepo,
+	}
+}
+
+// Execute fails when the user was deleted or suspended after their token
+// was issued, so suspension locks out tokens that have not expired yet
+func (uc *CheckActiveUseCase) Execute(ctx context.Context, userID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.CheckActive")
+	defer tracing.End(span, &err)
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsSuspended() {
+		return do.ErrUserSuspended
+	}
+	return nil
+}
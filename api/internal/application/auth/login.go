@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"errors"
 	usecase "hilo-api/internal/application"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
 )
 
 // LoginUseCase handles user authentication
@@ -20,17 +22,32 @@ func NewLoginUseCase(userRepo repository.UserRepository) *LoginUseCase {
 }
 
 // Execute authenticates a user
-func (uc *LoginUseCase) Execute(ctx context.Context, email, password string) (*do.User, error) {
+func (uc *LoginUseCase) Execute(ctx context.Context, email, password string) (_ *do.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Login")
+	defer tracing.End(span, &err)
+
 	// Find user
 	user, err := uc.userRepo.FindByEmail(ctx, email)
-	if err != nil {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		usecase.LoginsFailed.WithLabelValues(usecase.LoginFailedUnknownUser).Inc()
 		return nil, usecase.ErrInvalidCredentials
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	// Verify password (business rule in domain)
 	if err := user.VerifyPassword(password); err != nil {
+		usecase.LoginsFailed.WithLabelValues(usecase.LoginFailedWrongPassword).Inc()
 		return nil, usecase.ErrInvalidCredentials
 	}
 
+	// Only tell a suspended user after the password matched, so the
+	// suspension does not leak to someone guessing emails
+	if user.IsSuspended() {
+		usecase.LoginsFailed.WithLabelValues(usecase.LoginFailedSuspended).Inc()
+		return nil, do.ErrUserSuspended
+	}
+
 	return user, nil
 }
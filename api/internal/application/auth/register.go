@@ -2,41 +2,64 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	usecase "hilo-api/internal/application"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
 )
 
 // RegisterUseCase handles user registration
 type RegisterUseCase struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	transactor repository.Transactor
 }
 
 // NewRegisterUseCase creates a new register use case
-func NewRegisterUseCase(userRepo repository.UserRepository) *RegisterUseCase {
+func NewRegisterUseCase(userRepo repository.UserRepository, transactor repository.Transactor) *RegisterUseCase {
 	return &RegisterUseCase{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		transactor: transactor,
 	}
 }
 
 // Execute registers a new user
-func (uc *RegisterUseCase) Execute(ctx context.Context, email, password, username string) (*do.User, error) {
-	// Check if user already exists
-	existing, err := uc.userRepo.FindByEmail(ctx, email)
-	if err == nil && existing != nil {
-		return nil, usecase.ErrEmailAlreadyExists
-	}
+func (uc *RegisterUseCase) Execute(ctx context.Context, email, password, username string) (_ *do.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Register")
+	defer tracing.End(span, &err)
 
-	// Create user with business rules
+	// Create user with business rules (hashing stays outside the transaction)
 	user, err := do.NewUser(email, password, username)
 	if err != nil {
 		return nil, err
 	}
 
-	// Persist
-	if err := uc.userRepo.Create(ctx, user); err != nil {
+	// Check-then-insert under SERIALIZABLE so concurrent registrations of the
+	// same email conflict and are retried instead of racing
+	err = uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := uc.userRepo.FindByEmail(ctx, email)
+		switch {
+		case err == nil:
+			return usecase.ErrEmailAlreadyExists
+		case !errors.Is(err, repository.ErrUserNotFound):
+			return err
+		}
+
+		// Persist; the unique constraints still guard against anything the check missed
+		err = uc.userRepo.Create(ctx, user)
+		switch {
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			return fmt.Errorf("%w: %w", usecase.ErrEmailAlreadyExists, err)
+		case errors.Is(err, repository.ErrDuplicateUsername):
+			return fmt.Errorf("%w: %w", usecase.ErrUsernameAlreadyExists, err)
+		}
+		return err
+	}, repository.WithIsolation(repository.TxIsolationSerializable))
+	if err != nil {
 		return nil, err
 	}
+	usecase.Registrations.Inc()
 
 	return user, nil
 }
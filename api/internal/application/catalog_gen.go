@@ -0,0 +1,23 @@
+// Code generated by errcatalog. DO NOT EDIT.
+
+package usecase
+
+import "hilo-api/pkg/errorCatcher"
+
+func init() {
+	errorCatcher.RegisterProblems(
+		errorCatcher.ProblemEntry{Err: ErrAttachmentNotFound, Code: "ATTACHMENT_NOT_FOUND", Title: "Attachment not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrAttachmentQuotaExceeded, Code: "ATTACHMENT_QUOTA_EXCEEDED", Title: "Attachment storage quota exceeded", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrEmailAlreadyExists, Code: "EMAIL_ALREADY_EXISTS", Title: "Email already exists", Status: 409},
+		errorCatcher.ProblemEntry{Err: ErrIdempotencyKeyReused, Code: "IDEMPOTENCY_KEY_REUSED", Title: "Idempotency key was used for a different request", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrInvalidCredentials, Code: "INVALID_CREDENTIALS", Title: "Invalid email or password", Status: 401},
+		errorCatcher.ProblemEntry{Err: ErrMessageEditConflict, Code: "MESSAGE_EDIT_CONFLICT", Title: "Message was changed by another edit", Status: 409},
+		errorCatcher.ProblemEntry{Err: ErrMessageIDConflict, Code: "MESSAGE_ID_CONFLICT", Title: "Message id already taken", Status: 409},
+		errorCatcher.ProblemEntry{Err: ErrMessageNotFound, Code: "MESSAGE_NOT_FOUND", Title: "Message not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrReceiverNotFound, Code: "RECEIVER_NOT_FOUND", Title: "Receiver not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrReplyTargetNotFound, Code: "REPLY_TARGET_NOT_FOUND", Title: "Message replied to not found", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrUploadNotFound, Code: "UPLOAD_NOT_FOUND", Title: "Upload not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrUserNotFound, Code: "USER_NOT_FOUND", Title: "User not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrUsernameAlreadyExists, Code: "USERNAME_ALREADY_EXISTS", Title: "Username already exists", Status: 409},
+	)
+}
@@ -0,0 +1,22 @@
+package usecase_test
+
+import (
+	"testing"
+
+	_ "hilo-api/internal/application"
+	_ "hilo-api/internal/domain/do"
+	_ "hilo-api/internal/domain/repository"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemCatalogTranslated(t *testing.T) {
+	for _, locale := range i18n.Locales() {
+		for _, entry := range errorCatcher.Problems() {
+			_, ok := i18n.Lookup(locale, "problem."+entry.Code)
+			assert.True(t, ok, "%s has no %s translation", entry.Code, locale)
+		}
+	}
+}
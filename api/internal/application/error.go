@@ -4,9 +4,20 @@ import (
 	"errors"
 )
 
+//go:generate go run hilo-api/tools/errcatalog -out catalog_gen.go error.go
+
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrReceiverNotFound   = errors.New("receiver not found")
-	ErrMessageNotFound    = errors.New("message not found")
+	ErrEmailAlreadyExists      = errors.New("email already exists")                             // problem:409
+	ErrUsernameAlreadyExists   = errors.New("username already exists")                          // problem:409
+	ErrInvalidCredentials      = errors.New("invalid email or password")                        // problem:401
+	ErrReceiverNotFound        = errors.New("receiver not found")                               // problem:404
+	ErrMessageNotFound         = errors.New("message not found")                                // problem:404
+	ErrUserNotFound            = errors.New("user not found")                                   // problem:404
+	ErrIdempotencyKeyReused    = errors.New("idempotency key was used for a different request") // problem:422
+	ErrMessageIDConflict       = errors.New("message id already taken")                         // problem:409
+	ErrMessageEditConflict     = errors.New("message was changed by another edit")              // problem:409
+	ErrReplyTargetNotFound     = errors.New("message replied to not found")                     // problem:422
+	ErrAttachmentNotFound      = errors.New("attachment not found")                             // problem:404
+	ErrAttachmentQuotaExceeded = errors.New("attachment storage quota exceeded")                // problem:422
+	ErrUploadNotFound          = errors.New("upload not found")                                 // problem:404
 )
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"hilo-api/pkg/realtime"
+
+	"github.com/google/uuid"
+)
+
+// Event types pushed to connected clients
+const (
+	// EventMessageEdited carries the edited *do.Message
+	EventMessageEdited = "message.edited"
+	// EventMessageDeleted carries the *do.Message deleted for everyone
+	EventMessageDeleted = "message.deleted"
+	// EventMessageHidden carries the *do.Message the user deleted for
+	// themselves, for their other devices
+	EventMessageHidden = "message.hidden"
+	// EventMessageDelivered carries a *do.Message that reached a device of
+	// its receiver, for the sender
+	EventMessageDelivered = "message.delivered"
+	// EventMessageRead carries a *do.Message its receiver read
+	EventMessageRead = "message.read"
+	// EventConversationRead carries the *do.ConversationRead receipt for
+	// the messages of a conversation its reader read at once
+	EventConversationRead = "conversation.read"
+	// EventTypingStarted carries the *do.TypingSignal of a conversation
+	// partner who started typing
+	EventTypingStarted = "typing.started"
+	// EventTypingStopped carries the *do.TypingSignal of a conversation
+	// partner who stopped typing or went quiet
+	EventTypingStopped = "typing.stopped"
+	// EventReactionAdded carries the *do.Reaction a participant added
+	EventReactionAdded = "reaction.added"
+	// EventReactionRemoved carries the *do.Reaction a participant took back
+	EventReactionRemoved = "reaction.removed"
+	// EventAttachmentProcessed carries the *do.Attachment whose thumbnails
+	// are ready or that could not be processed
+	EventAttachmentProcessed = "attachment.processed"
+)
+
+// Publisher pushes events to the clients a user has connected
+type Publisher interface {
+	Publish(userID uuid.UUID, event realtime.Event)
+}
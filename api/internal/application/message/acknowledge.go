@@ -0,0 +1,83 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// AcknowledgeDeliveryUseCase handles the receipts a receiver's device sends
+// for the messages it got
+type AcknowledgeDeliveryUseCase struct {
+	messageRepo repository.MessageRepository
+	publisher   usecase.Publisher
+}
+
+// NewAcknowledgeDeliveryUseCase creates a new acknowledge delivery use case
+func NewAcknowledgeDeliveryUseCase(messageRepo repository.MessageRepository, publisher usecase.Publisher) *AcknowledgeDeliveryUseCase {
+	return &AcknowledgeDeliveryUseCase{
+		messageRepo: messageRepo,
+		publisher:   publisher,
+	}
+}
+
+// Execute marks the messages delivered that receiverID got and tells their
+// senders. It returns the ones that were not delivered before; messages
+// already delivered or read are acknowledged again without effect.
+func (uc *AcknowledgeDeliveryUseCase) Execute(ctx context.Context, receiverID uuid.UUID, messageIDs []uuid.UUID) (_ []*do.Message, err error) {
+	ctx, span := tracing.Start(ctx, "message.AcknowledgeDelivery")
+	defer tracing.End(span, &err)
+
+	// Load messages, each once
+	seen := make(map[uuid.UUID]bool, len(messageIDs))
+	ids := make([]uuid.UUID, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	messages, err := uc.messageRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != len(ids) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, repository.ErrMessageNotFound)
+	}
+
+	// Apply business rule
+	var delivered []*do.Message
+	for _, msg := range messages {
+		wasDelivered := msg.IsDelivered()
+		if err := msg.MarkAsDelivered(receiverID); err != nil {
+			return nil, err
+		}
+		if !wasDelivered {
+			delivered = append(delivered, msg)
+		}
+	}
+	if len(delivered) == 0 {
+		return nil, nil
+	}
+
+	// Persist
+	deliveredIDs := make([]uuid.UUID, len(delivered))
+	for i, msg := range delivered {
+		deliveredIDs[i] = msg.ID()
+	}
+	if err := uc.messageRepo.UpdateDeliveredAt(ctx, deliveredIDs, *delivered[0].DeliveredAt()); err != nil {
+		return nil, err
+	}
+	usecase.MessagesDelivered.Add(float64(len(delivered)))
+
+	for _, msg := range delivered {
+		uc.publisher.Publish(msg.SenderID(), realtime.Event{Type: usecase.EventMessageDelivered, Data: msg})
+	}
+	return delivered, nil
+}
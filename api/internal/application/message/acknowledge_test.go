@@ -0,0 +1,113 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+func (f *fakeMessages) FindByIDs(_ context.Context, ids []uuid.UUID) ([]*do.Message, error) {
+	var found []*do.Message
+	for _, id := range ids {
+		if msg, ok := f.messages[id]; ok {
+			found = append(found, msg)
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeMessages) UpdateDeliveredAt(_ context.Context, ids []uuid.UUID, _ time.Time) error {
+	f.delivered = append(f.delivered, ids...)
+	return nil
+}
+
+func (f *fakeMessages) UpdateReadAt(_ context.Context, id uuid.UUID, _ time.Time) error {
+	f.read = append(f.read, id)
+	return nil
+}
+
+type ReceiptSuite struct {
+	suite.Suite
+	ctx         context.Context
+	messages    *fakeMessages
+	publisher   *fakePublisher
+	acknowledge *AcknowledgeDeliveryUseCase
+	markRead    *MarkAsReadUseCase
+	first       *do.Message
+	second      *do.Message
+}
+
+func (suite *ReceiptSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.publisher = &fakePublisher{}
+	suite.acknowledge = NewAcknowledgeDeliveryUseCase(suite.messages, suite.publisher)
+	suite.markRead = NewMarkAsReadUseCase(suite.messages, suite.publisher)
+
+	sender, receiver := uuid.New(), uuid.New()
+	suite.first, _ = do.NewMessage(sender, receiver, "Lunch?")
+	suite.second, _ = do.NewMessage(sender, receiver, "At noon")
+	suite.messages.messages[suite.first.ID()] = suite.first
+	suite.messages.messages[suite.second.ID()] = suite.second
+}
+
+func (suite *ReceiptSuite) TestAcknowledge() {
+	ids := []uuid.UUID{suite.first.ID(), suite.second.ID(), suite.first.ID()}
+	delivered, err := suite.acknowledge.Execute(suite.ctx, suite.first.ReceiverID(), ids)
+	suite.Require().NoError(err)
+	suite.Len(delivered, 2, "a repeated id counts once")
+	suite.Equal([]uuid.UUID{suite.first.ID(), suite.second.ID()}, suite.messages.delivered)
+	suite.Equal(do.MessageDelivered, suite.first.State())
+
+	suite.Require().Len(suite.publisher.events, 2)
+	suite.Equal(suite.first.SenderID(), suite.publisher.events[0].userID)
+	suite.Equal(usecase.EventMessageDelivered, suite.publisher.events[0].event.Type)
+
+	delivered, err = suite.acknowledge.Execute(suite.ctx, suite.first.ReceiverID(), ids)
+	suite.Require().NoError(err)
+	suite.Empty(delivered, "a second device acknowledges without effect")
+	suite.Len(suite.publisher.events, 2)
+}
+
+func (suite *ReceiptSuite) TestAcknowledgeRead() {
+	suite.Require().NoError(suite.markRead.Execute(suite.ctx, suite.first.ID(), suite.first.ReceiverID()))
+
+	delivered, err := suite.acknowledge.Execute(suite.ctx, suite.first.ReceiverID(), []uuid.UUID{suite.first.ID()})
+	suite.Require().NoError(err)
+	suite.Empty(delivered)
+	suite.Equal(do.MessageRead, suite.first.State(), "never back to delivered")
+}
+
+func (suite *ReceiptSuite) TestAcknowledgeRejected() {
+	_, err := suite.acknowledge.Execute(suite.ctx, suite.first.SenderID(), []uuid.UUID{suite.first.ID()})
+	suite.ErrorIs(err, do.ErrNotReceiver)
+
+	_, err = suite.acknowledge.Execute(suite.ctx, suite.first.ReceiverID(), []uuid.UUID{suite.first.ID(), uuid.New()})
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+	suite.Empty(suite.messages.delivered)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *ReceiptSuite) TestMarkRead() {
+	suite.Require().NoError(suite.markRead.Execute(suite.ctx, suite.first.ID(), suite.first.ReceiverID()))
+	suite.Equal([]uuid.UUID{suite.first.ID()}, suite.messages.read)
+	suite.Equal(do.MessageRead, suite.first.State())
+
+	suite.Require().Len(suite.publisher.events, 2)
+	suite.Equal(suite.first.SenderID(), suite.publisher.events[0].userID)
+	suite.Equal(suite.first.ReceiverID(), suite.publisher.events[1].userID)
+	suite.Equal(usecase.EventMessageRead, suite.publisher.events[0].event.Type)
+
+	suite.Require().NoError(suite.markRead.Execute(suite.ctx, suite.first.ID(), suite.first.ReceiverID()))
+	suite.Len(suite.messages.read, 1, "reading again stores nothing")
+	suite.Len(suite.publisher.events, 2)
+}
+
+func TestReceiptSuite(t *testing.T) {
+	suite.Run(t, new(ReceiptSuite))
+}
@@ -0,0 +1,96 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeleteMode says who a deleted message disappears for
+type DeleteMode string
+
+const (
+	// DeleteForMe hides the message from the requesting user only
+	DeleteForMe DeleteMode = "me"
+	// DeleteForEveryone retracts the message for both participants
+	DeleteForEveryone DeleteMode = "everyone"
+)
+
+// DeleteMessageUseCase handles deleting messages
+type DeleteMessageUseCase struct {
+	messageRepo   repository.MessageRepository
+	publisher     usecase.Publisher
+	retractWindow time.Duration
+}
+
+// NewDeleteMessageUseCase creates a new delete message use case
+func NewDeleteMessageUseCase(messageRepo repository.MessageRepository, publisher usecase.Publisher, cfg config.Message) *DeleteMessageUseCase {
+	return &DeleteMessageUseCase{
+		messageRepo:   messageRepo,
+		publisher:     publisher,
+		retractWindow: cfg.MessageRetractWindow,
+	}
+}
+
+// Execute deletes a message for the user or, when they sent it, for both
+// participants; deleting again is a no-op
+func (uc *DeleteMessageUseCase) Execute(ctx context.Context, messageID, userID uuid.UUID, mode DeleteMode) (err error) {
+	ctx, span := tracing.Start(ctx, "message.Delete")
+	defer tracing.End(span, &err)
+
+	// Load message
+	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !msg.IsParticipant(userID) {
+		return usecase.ErrMessageNotFound
+	}
+
+	if mode == DeleteForEveryone {
+		return uc.retract(ctx, msg, userID)
+	}
+
+	if err := uc.messageRepo.Hide(ctx, msg.ID(), userID, time.Now()); err != nil {
+		return err
+	}
+	usecase.MessagesDeleted.WithLabelValues(string(DeleteForMe)).Inc()
+
+	uc.publisher.Publish(userID, realtime.Event{Type: usecase.EventMessageHidden, Data: msg})
+	return nil
+}
+
+func (uc *DeleteMessageUseCase) retract(ctx context.Context, msg *do.Message, userID uuid.UUID) error {
+	wasDeleted := msg.IsDeleted()
+
+	// Apply business rule
+	if err := msg.Retract(userID, uc.retractWindow); err != nil {
+		return err
+	}
+	if wasDeleted {
+		return nil
+	}
+
+	// Persist
+	if err := uc.messageRepo.Retract(ctx, msg); err != nil {
+		return err
+	}
+	usecase.MessagesDeleted.WithLabelValues(string(DeleteForEveryone)).Inc()
+
+	event := realtime.Event{Type: usecase.EventMessageDeleted, Data: msg}
+	uc.publisher.Publish(msg.ReceiverID(), event)
+	uc.publisher.Publish(msg.SenderID(), event)
+	return nil
+}
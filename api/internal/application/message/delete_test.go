@@ -0,0 +1,73 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeleteMessageSuite struct {
+	suite.Suite
+	messages  *fakeMessages
+	publisher *fakePublisher
+	remove    *DeleteMessageUseCase
+	msg       *do.Message
+}
+
+func (suite *DeleteMessageSuite) SetupTest() {
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.publisher = &fakePublisher{}
+	suite.remove = NewDeleteMessageUseCase(suite.messages, suite.publisher, config.Message{MessageRetractWindow: time.Minute})
+
+	msg, err := do.NewMessage(uuid.New(), uuid.New(), "Oops")
+	suite.Require().NoError(err)
+	suite.messages.messages[msg.ID()] = msg
+	suite.msg = msg
+}
+
+func (suite *DeleteMessageSuite) TestDeleteForMe() {
+	err := suite.remove.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), DeleteForMe)
+	suite.NoError(err)
+	suite.Equal([]uuid.UUID{suite.msg.ID()}, suite.messages.hidden[suite.msg.ReceiverID()])
+	suite.False(suite.msg.IsDeleted())
+
+	suite.Require().Len(suite.publisher.events, 1)
+	suite.Equal(suite.msg.ReceiverID(), suite.publisher.events[0].userID)
+	suite.Equal(usecase.EventMessageHidden, suite.publisher.events[0].event.Type)
+}
+
+func (suite *DeleteMessageSuite) TestDeleteForEveryone() {
+	err := suite.remove.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), DeleteForEveryone)
+	suite.NoError(err)
+	stored := suite.messages.messages[suite.msg.ID()]
+	suite.True(stored.IsDeleted())
+	suite.Empty(stored.Content())
+	suite.Len(suite.publisher.events, 2)
+
+	// a second retraction changes and announces nothing
+	err = suite.remove.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), DeleteForEveryone)
+	suite.NoError(err)
+	suite.Len(suite.publisher.events, 2)
+}
+
+func (suite *DeleteMessageSuite) TestReceiverCannotDeleteForEveryone() {
+	err := suite.remove.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), DeleteForEveryone)
+	suite.ErrorIs(err, do.ErrNotSender)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *DeleteMessageSuite) TestOutsiderSeesNothing() {
+	err := suite.remove.Execute(context.Background(), suite.msg.ID(), uuid.New(), DeleteForMe)
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+	suite.Empty(suite.messages.hidden)
+}
+
+func TestDeleteMessageSuite(t *testing.T) {
+	suite.Run(t, new(DeleteMessageSuite))
+}
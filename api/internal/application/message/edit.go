@@ -0,0 +1,72 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EditMessageUseCase handles editing sent messages
+type EditMessageUseCase struct {
+	messageRepo repository.MessageRepository
+	publisher   usecase.Publisher
+	editWindow  time.Duration
+}
+
+// NewEditMessageUseCase creates a new edit message use case
+func NewEditMessageUseCase(messageRepo repository.MessageRepository, publisher usecase.Publisher, cfg config.Message) *EditMessageUseCase {
+	return &EditMessageUseCase{
+		messageRepo: messageRepo,
+		publisher:   publisher,
+		editWindow:  cfg.MessageEditWindow,
+	}
+}
+
+// Execute replaces the content of a message the editor sent, keeping the
+// previous version, and tells both participants' clients about the edit
+func (uc *EditMessageUseCase) Execute(ctx context.Context, messageID, editorID uuid.UUID, content string) (_ *do.Message, err error) {
+	ctx, span := tracing.Start(ctx, "message.Edit")
+	defer tracing.End(span, &err)
+
+	// Load message
+	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply business rule
+	revision, err := msg.Edit(editorID, content, uc.editWindow)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return msg, nil
+	}
+
+	// Persist
+	err = uc.messageRepo.Edit(ctx, msg, revision)
+	if errors.Is(err, repository.ErrMessageEditConflict) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrMessageEditConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	usecase.MessagesEdited.Inc()
+
+	event := realtime.Event{Type: usecase.EventMessageEdited, Data: msg}
+	uc.publisher.Publish(msg.ReceiverID(), event)
+	uc.publisher.Publish(msg.SenderID(), event)
+	return msg, nil
+}
@@ -0,0 +1,110 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/realtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type published struct {
+	userID uuid.UUID
+	event  realtime.Event
+}
+
+type fakePublisher struct {
+	events []published
+}
+
+func (f *fakePublisher) Publish(userID uuid.UUID, event realtime.Event) {
+	f.events = append(f.events, published{userID: userID, event: event})
+}
+
+type EditMessageSuite struct {
+	suite.Suite
+	messages  *fakeMessages
+	publisher *fakePublisher
+	edit      *EditMessageUseCase
+	revisions *ListRevisionsUseCase
+	msg       *do.Message
+}
+
+func (suite *EditMessageSuite) SetupTest() {
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.publisher = &fakePublisher{}
+	suite.edit = NewEditMessageUseCase(suite.messages, suite.publisher, config.Message{MessageEditWindow: time.Minute})
+	suite.revisions = NewListRevisionsUseCase(suite.messages)
+
+	msg, err := do.NewMessage(uuid.New(), uuid.New(), "Helo")
+	suite.Require().NoError(err)
+	suite.messages.messages[msg.ID()] = msg
+	suite.msg = msg
+}
+
+func (suite *EditMessageSuite) TestEdit() {
+	edited, err := suite.edit.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), "Hello")
+	suite.NoError(err)
+	suite.Equal("Hello", edited.Content())
+	suite.True(edited.IsEdited())
+
+	suite.Require().Len(suite.publisher.events, 2)
+	suite.Equal(suite.msg.ReceiverID(), suite.publisher.events[0].userID)
+	suite.Equal(suite.msg.SenderID(), suite.publisher.events[1].userID)
+	suite.Equal(usecase.EventMessageEdited, suite.publisher.events[0].event.Type)
+
+	msg, revisions, err := suite.revisions.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID())
+	suite.NoError(err)
+	suite.Equal("Hello", msg.Content())
+	suite.Require().Len(revisions, 1)
+	suite.Equal("Helo", revisions[0].Content())
+}
+
+func (suite *EditMessageSuite) TestUnchangedContent() {
+	_, err := suite.edit.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), "Helo")
+	suite.NoError(err)
+	suite.Empty(suite.publisher.events)
+	suite.Empty(suite.messages.revisions)
+}
+
+func (suite *EditMessageSuite) TestNotSender() {
+	_, err := suite.edit.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), "Hello")
+	suite.ErrorIs(err, do.ErrNotSender)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *EditMessageSuite) TestMessageNotFound() {
+	_, err := suite.edit.Execute(context.Background(), uuid.New(), suite.msg.SenderID(), "Hello")
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+}
+
+func (suite *EditMessageSuite) TestConflict() {
+	edit := NewEditMessageUseCase(&conflictingMessages{fakeMessages: suite.messages}, suite.publisher, config.Message{MessageEditWindow: time.Minute})
+	_, err := edit.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), "Hello")
+	suite.ErrorIs(err, usecase.ErrMessageEditConflict)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *EditMessageSuite) TestRevisionsHiddenFromOthers() {
+	_, _, err := suite.revisions.Execute(context.Background(), suite.msg.ID(), uuid.New())
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+}
+
+// conflictingMessages loses every edit to a concurrent one
+type conflictingMessages struct {
+	*fakeMessages
+}
+
+func (f *conflictingMessages) Edit(context.Context, *do.Message, *do.MessageRevision) error {
+	return repository.ErrMessageEditConflict
+}
+
+func TestEditMessageSuite(t *testing.T) {
+	suite.Run(t, new(EditMessageSuite))
+}
@@ -4,23 +4,34 @@ import (
 	"context"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
 
 	"github.com/google/uuid"
 )
 
 // ListConversationUseCase handles listing messages in a conversation
 type ListConversationUseCase struct {
-	messageRepo repository.MessageRepository
+	messageRepo  repository.MessageRepository
+	reactionRepo repository.ReactionRepository
 }
 
 // NewListConversationUseCase creates a new list conversation use case
-func NewListConversationUseCase(messageRepo repository.MessageRepository) *ListConversationUseCase {
+func NewListConversationUseCase(messageRepo repository.MessageRepository, reactionRepo repository.ReactionRepository) *ListConversationUseCase {
 	return &ListConversationUseCase{
-		messageRepo: messageRepo,
+		messageRepo:  messageRepo,
+		reactionRepo: reactionRepo,
 	}
 }
 
-// Execute retrieves messages between two users
-func (uc *ListConversationUseCase) Execute(ctx context.Context, userA, userB uuid.UUID, limit, offset int) ([]*do.Message, error) {
-	return uc.messageRepo.ListConversation(ctx, userA, userB, limit, offset)
+// Execute retrieves messages between two users as userA sees them, with the
+// reactions on each
+func (uc *ListConversationUseCase) Execute(ctx context.Context, userA, userB uuid.UUID, limit, offset int) (_ []*do.Message, err error) {
+	ctx, span := tracing.Start(ctx, "message.ListConversation")
+	defer tracing.End(span, &err)
+
+	messages, err := uc.messageRepo.ListConversation(ctx, userA, userB, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return messages, attachReactions(ctx, uc.reactionRepo, messages, userA)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
 
 	"github.com/google/uuid"
 )
@@ -21,6 +22,9 @@ func NewListConversationsUseCase(messageRepo repository.MessageRepository) *List
 }
 
 // Execute retrieves all conversations for a user
-func (uc *ListConversationsUseCase) Execute(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*do.ConversationPreview, error) {
+func (uc *ListConversationsUseCase) Execute(ctx context.Context, userID uuid.UUID, limit, offset int) (_ []*do.ConversationPreview, err error) {
+	ctx, span := tracing.Start(ctx, "message.ListConversations")
+	defer tracing.End(span, &err)
+
 	return uc.messageRepo.ListUserConversations(ctx, userID, limit, offset)
 }
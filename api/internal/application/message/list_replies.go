@@ -0,0 +1,51 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// ListRepliesUseCase handles listing the replies to a message
+type ListRepliesUseCase struct {
+	messageRepo  repository.MessageRepository
+	reactionRepo repository.ReactionRepository
+}
+
+// NewListRepliesUseCase creates a new list replies use case
+func NewListRepliesUseCase(messageRepo repository.MessageRepository, reactionRepo repository.ReactionRepository) *ListRepliesUseCase {
+	return &ListRepliesUseCase{
+		messageRepo:  messageRepo,
+		reactionRepo: reactionRepo,
+	}
+}
+
+// Execute retrieves the replies to a message, oldest first; to anyone
+// outside the conversation the message does not exist
+func (uc *ListRepliesUseCase) Execute(ctx context.Context, messageID, userID uuid.UUID, limit, offset int) (_ []*do.Message, err error) {
+	ctx, span := tracing.Start(ctx, "message.ListReplies")
+	defer tracing.End(span, &err)
+
+	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !msg.IsParticipant(userID) {
+		return nil, usecase.ErrMessageNotFound
+	}
+
+	replies, err := uc.messageRepo.ListReplies(ctx, messageID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return replies, attachReactions(ctx, uc.reactionRepo, replies, userID)
+}
@@ -0,0 +1,49 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// ListRevisionsUseCase handles listing the edit history of a message
+type ListRevisionsUseCase struct {
+	messageRepo repository.MessageRepository
+}
+
+// NewListRevisionsUseCase creates a new list revisions use case
+func NewListRevisionsUseCase(messageRepo repository.MessageRepository) *ListRevisionsUseCase {
+	return &ListRevisionsUseCase{
+		messageRepo: messageRepo,
+	}
+}
+
+// Execute retrieves the message with the versions its edits replaced,
+// oldest first; to anyone outside the conversation the message does not exist
+func (uc *ListRevisionsUseCase) Execute(ctx context.Context, messageID, userID uuid.UUID) (_ *do.Message, _ []*do.MessageRevision, err error) {
+	ctx, span := tracing.Start(ctx, "message.ListRevisions")
+	defer tracing.End(span, &err)
+
+	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return nil, nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !msg.IsParticipant(userID) {
+		return nil, nil, usecase.ErrMessageNotFound
+	}
+
+	revisions, err := uc.messageRepo.ListRevisions(ctx, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, revisions, nil
+}
@@ -0,0 +1,120 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReadOption interface
+type ReadOption interface {
+	Apply(*readConfig)
+}
+
+type readConfig struct {
+	upToMessageID uuid.UUID
+	upTo          time.Time
+}
+
+// WithUpToMessage method
+// reads the messages up to and including the given one of the conversation
+func WithUpToMessage(id uuid.UUID) ReadOption {
+	return withUpToMessage{id: id}
+}
+
+type withUpToMessage struct {
+	id uuid.UUID
+}
+
+// Apply method
+func (w withUpToMessage) Apply(c *readConfig) {
+	c.upToMessageID = w.id
+}
+
+// WithUpTo method
+// reads the messages created up to t
+func WithUpTo(t time.Time) ReadOption {
+	return withUpTo{t: t}
+}
+
+type withUpTo struct {
+	t time.Time
+}
+
+// Apply method
+func (w withUpTo) Apply(c *readConfig) {
+	c.upTo = w.t
+}
+
+// MarkConversationReadUseCase handles reading a whole conversation at once
+type MarkConversationReadUseCase struct {
+	messageRepo repository.MessageRepository
+	publisher   usecase.Publisher
+}
+
+// NewMarkConversationReadUseCase creates a new mark conversation read use case
+func NewMarkConversationReadUseCase(messageRepo repository.MessageRepository, publisher usecase.Publisher) *MarkConversationReadUseCase {
+	return &MarkConversationReadUseCase{
+		messageRepo: messageRepo,
+		publisher:   publisher,
+	}
+}
+
+// Execute marks the unread messages otherUserID sent to readerID read, all
+// of them unless an option sets where to stop, and sends one receipt for
+// them to both participants' clients. It returns how many it marked.
+func (uc *MarkConversationReadUseCase) Execute(ctx context.Context, readerID, otherUserID uuid.UUID, options ...ReadOption) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "message.MarkConversationRead")
+	defer tracing.End(span, &err)
+
+	var cfg readConfig
+	for _, option := range options {
+		option.Apply(&cfg)
+	}
+
+	now := time.Now()
+	upTo := now
+	if !cfg.upTo.IsZero() && cfg.upTo.Before(now) {
+		upTo = cfg.upTo
+	}
+	if cfg.upToMessageID != uuid.Nil {
+		msg, err := uc.messageRepo.FindByID(ctx, cfg.upToMessageID)
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			return 0, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+		}
+		if err != nil {
+			return 0, err
+		}
+		// a message of another conversation is not one to stop at
+		if !msg.IsParticipant(readerID) || !msg.IsParticipant(otherUserID) {
+			return 0, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, repository.ErrMessageNotFound)
+		}
+		upTo = msg.CreatedAt()
+	}
+
+	count, err := uc.messageRepo.MarkConversationRead(ctx, readerID, otherUserID, upTo, now)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	usecase.MessagesRead.Add(float64(count))
+
+	receipt := &do.ConversationRead{
+		ReaderID:    readerID,
+		OtherUserID: otherUserID,
+		UpTo:        upTo,
+		ReadAt:      now,
+		Count:       count,
+	}
+	event := realtime.Event{Type: usecase.EventConversationRead, Data: receipt}
+	uc.publisher.Publish(otherUserID, event)
+	uc.publisher.Publish(readerID, event)
+	return count, nil
+}
@@ -0,0 +1,96 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+func (f *fakeMessages) MarkConversationRead(_ context.Context, readerID, otherUserID uuid.UUID, upTo, _ time.Time) (int, error) {
+	count := 0
+	for _, msg := range f.messages {
+		if msg.ReceiverID() == readerID && msg.SenderID() == otherUserID && !msg.IsRead() && !msg.CreatedAt().After(upTo) {
+			_ = msg.MarkAsRead(readerID)
+			count++
+		}
+	}
+	return count, nil
+}
+
+type MarkConversationReadSuite struct {
+	suite.Suite
+	ctx       context.Context
+	messages  *fakeMessages
+	publisher *fakePublisher
+	markRead  *MarkConversationReadUseCase
+	alice     uuid.UUID
+	bob       uuid.UUID
+	sent      []*do.Message
+}
+
+func (suite *MarkConversationReadSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.publisher = &fakePublisher{}
+	suite.markRead = NewMarkConversationReadUseCase(suite.messages, suite.publisher)
+	suite.alice, suite.bob = uuid.New(), uuid.New()
+
+	start := time.Now().Add(-time.Hour)
+	suite.sent = nil
+	for i := range 3 {
+		msg := do.ReconstructMessage(uuid.New(), suite.bob, suite.alice, "hi", start.Add(time.Duration(i)*time.Minute), nil, nil, nil, nil, nil)
+		suite.messages.messages[msg.ID()] = msg
+		suite.sent = append(suite.sent, msg)
+	}
+	mine := do.ReconstructMessage(uuid.New(), suite.alice, suite.bob, "hey", start, nil, nil, nil, nil, nil)
+	suite.messages.messages[mine.ID()] = mine
+}
+
+func (suite *MarkConversationReadSuite) TestReadAll() {
+	count, err := suite.markRead.Execute(suite.ctx, suite.alice, suite.bob)
+	suite.Require().NoError(err)
+	suite.Equal(3, count, "alice's own message is not hers to read")
+
+	suite.Require().Len(suite.publisher.events, 2, "one receipt for the lot")
+	suite.Equal(suite.bob, suite.publisher.events[0].userID)
+	suite.Equal(suite.alice, suite.publisher.events[1].userID)
+	suite.Equal(usecase.EventConversationRead, suite.publisher.events[0].event.Type)
+	receipt := suite.publisher.events[0].event.Data.(*do.ConversationRead)
+	suite.Equal(3, receipt.Count)
+	suite.Equal(suite.alice, receipt.ReaderID)
+
+	count, err = suite.markRead.Execute(suite.ctx, suite.alice, suite.bob)
+	suite.Require().NoError(err)
+	suite.Zero(count)
+	suite.Len(suite.publisher.events, 2, "nothing read, nothing to tell")
+}
+
+func (suite *MarkConversationReadSuite) TestReadUpTo() {
+	count, err := suite.markRead.Execute(suite.ctx, suite.alice, suite.bob, WithUpToMessage(suite.sent[1].ID()))
+	suite.Require().NoError(err)
+	suite.Equal(2, count)
+	suite.True(suite.sent[1].IsRead())
+	suite.False(suite.sent[2].IsRead())
+
+	count, err = suite.markRead.Execute(suite.ctx, suite.alice, suite.bob, WithUpTo(suite.sent[2].CreatedAt()))
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *MarkConversationReadSuite) TestUpToMessageOfAnotherConversation() {
+	other := do.ReconstructMessage(uuid.New(), uuid.New(), suite.alice, "hi", time.Now(), nil, nil, nil, nil, nil)
+	suite.messages.messages[other.ID()] = other
+
+	_, err := suite.markRead.Execute(suite.ctx, suite.alice, suite.bob, WithUpToMessage(other.ID()))
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+	suite.False(suite.sent[0].IsRead())
+}
+
+func TestMarkConversationReadSuite(t *testing.T) {
+	suite.Run(t, new(MarkConversationReadSuite))
+}
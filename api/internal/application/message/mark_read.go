@@ -2,7 +2,12 @@ package message
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
 
 	"github.com/google/uuid"
 )
@@ -10,28 +15,49 @@ import (
 // MarkAsReadUseCase handles marking messages as read
 type MarkAsReadUseCase struct {
 	messageRepo repository.MessageRepository
+	publisher   usecase.Publisher
 }
 
 // NewMarkAsReadUseCase creates a new mark as read use case
-func NewMarkAsReadUseCase(messageRepo repository.MessageRepository) *MarkAsReadUseCase {
+func NewMarkAsReadUseCase(messageRepo repository.MessageRepository, publisher usecase.Publisher) *MarkAsReadUseCase {
 	return &MarkAsReadUseCase{
 		messageRepo: messageRepo,
+		publisher:   publisher,
 	}
 }
 
-// Execute marks a message as read
-func (uc *MarkAsReadUseCase) Execute(ctx context.Context, messageID, readerID uuid.UUID) error {
+// Execute marks a message as read and sends the read receipt to both
+// participants' clients, the reader's other devices included
+func (uc *MarkAsReadUseCase) Execute(ctx context.Context, messageID, readerID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "message.MarkAsRead")
+	defer tracing.End(span, &err)
+
 	// Load message
 	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
 	if err != nil {
 		return err
 	}
+	wasRead := msg.IsRead()
 
 	// Apply business rule
 	if err := msg.MarkAsRead(readerID); err != nil {
 		return err
 	}
+	if wasRead {
+		return nil
+	}
 
 	// Persist
-	return uc.messageRepo.UpdateReadAt(ctx, msg.ID(), *msg.ReadAt())
+	if err := uc.messageRepo.UpdateReadAt(ctx, msg.ID(), *msg.ReadAt()); err != nil {
+		return err
+	}
+	usecase.MessagesRead.Inc()
+
+	event := realtime.Event{Type: usecase.EventMessageRead, Data: msg}
+	uc.publisher.Publish(msg.SenderID(), event)
+	uc.publisher.Publish(msg.ReceiverID(), event)
+	return nil
 }
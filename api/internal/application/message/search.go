@@ -0,0 +1,141 @@
+package message
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchOption interface
+type SearchOption interface {
+	Apply(*searchConfig)
+}
+
+type searchConfig struct {
+	filter do.SearchFilter
+	cursor string
+}
+
+// WithSender method
+// only finds messages the given user sent
+func WithSender(id uuid.UUID) SearchOption {
+	return withSender{id: id}
+}
+
+type withSender struct {
+	id uuid.UUID
+}
+
+// Apply method
+func (w withSender) Apply(c *searchConfig) {
+	c.filter.SenderID = w.id
+}
+
+// WithPeriod method
+// only finds messages sent from after up to before; a zero time leaves that
+// end open
+func WithPeriod(after, before time.Time) SearchOption {
+	return withPeriod{after: after, before: before}
+}
+
+type withPeriod struct {
+	after  time.Time
+	before time.Time
+}
+
+// Apply method
+func (w withPeriod) Apply(c *searchConfig) {
+	c.filter.After = w.after
+	c.filter.Before = w.before
+}
+
+// WithAttachmentsOnly method
+// only finds messages that carry attachments
+func WithAttachmentsOnly() SearchOption {
+	return withAttachmentsOnly{}
+}
+
+type withAttachmentsOnly struct{}
+
+// Apply method
+func (withAttachmentsOnly) Apply(c *searchConfig) {
+	c.filter.HasAttachment = true
+}
+
+// WithCursor method
+// continues a search where the page that returned the cursor ended
+func WithCursor(cursor string) SearchOption {
+	return withCursor{cursor: cursor}
+}
+
+type withCursor struct {
+	cursor string
+}
+
+// Apply method
+func (w withCursor) Apply(c *searchConfig) {
+	c.cursor = w.cursor
+}
+
+// SearchMessagesUseCase handles searching the messages of a user
+type SearchMessagesUseCase struct {
+	messageRepo  repository.MessageRepository
+	reactionRepo repository.ReactionRepository
+}
+
+// NewSearchMessagesUseCase creates a new search messages use case
+func NewSearchMessagesUseCase(messageRepo repository.MessageRepository, reactionRepo repository.ReactionRepository) *SearchMessagesUseCase {
+	return &SearchMessagesUseCase{
+		messageRepo:  messageRepo,
+		reactionRepo: reactionRepo,
+	}
+}
+
+// Execute finds up to limit messages of the user's conversations matching
+// text, newest first, each with a snippet of where it matched. next is the
+// cursor for the following page, empty on the last one.
+func (uc *SearchMessagesUseCase) Execute(ctx context.Context, userID uuid.UUID, text string, limit int, options ...SearchOption) (_ []*do.SearchHit, next string, err error) {
+	ctx, span := tracing.Start(ctx, "message.Search")
+	defer tracing.End(span, &err)
+
+	cfg := &searchConfig{}
+	for _, option := range options {
+		option.Apply(cfg)
+	}
+
+	query, err := do.NewSearchQuery(text, cfg.filter)
+	if err != nil {
+		return nil, "", err
+	}
+	var after *do.SearchCursor
+	if cfg.cursor != "" {
+		cursor, err := do.ParseSearchCursor(cfg.cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &cursor
+	}
+
+	// one more than asked tells whether there is a next page
+	hits, err := uc.messageRepo.Search(ctx, userID, query, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+		next = do.CursorAfter(hits[limit-1].Message).String()
+	}
+
+	messages := make([]*do.Message, len(hits))
+	for i, hit := range hits {
+		messages[i] = hit.Message
+	}
+	if err := attachReactions(ctx, uc.reactionRepo, messages, userID); err != nil {
+		return nil, "", err
+	}
+	return hits, next, nil
+}
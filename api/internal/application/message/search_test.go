@@ -0,0 +1,105 @@
+package message
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// Search matches the whole query as a substring, enough to page through
+func (f *fakeMessages) Search(_ context.Context, viewerID uuid.UUID, query *do.SearchQuery, after *do.SearchCursor, limit int) ([]*do.SearchHit, error) {
+	var hits []*do.SearchHit
+	for _, msg := range f.messages {
+		filter := query.Filter()
+		if !msg.IsParticipant(viewerID) || !strings.Contains(msg.Content(), query.Text()) ||
+			(filter.SenderID != uuid.Nil && msg.SenderID() != filter.SenderID) {
+			continue
+		}
+		if after != nil && !msg.CreatedAt().Before(after.CreatedAt) {
+			continue
+		}
+		hits = append(hits, &do.SearchHit{Message: msg, Snippet: do.Highlight(msg.Content(), query.Terms())})
+	}
+	slices.SortFunc(hits, func(a, b *do.SearchHit) int {
+		return b.Message.CreatedAt().Compare(a.Message.CreatedAt())
+	})
+	return hits[:min(limit, len(hits))], nil
+}
+
+type SearchSuite struct {
+	suite.Suite
+	ctx      context.Context
+	messages *fakeMessages
+	search   *SearchMessagesUseCase
+	alice    uuid.UUID
+	bob      uuid.UUID
+	sent     []*do.Message
+}
+
+func (suite *SearchSuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.search = NewSearchMessagesUseCase(suite.messages, &fakeReactions{})
+	suite.alice, suite.bob = uuid.New(), uuid.New()
+
+	start := time.Now().Add(-time.Hour)
+	suite.sent = nil
+	for i, from := range []uuid.UUID{suite.alice, suite.bob, suite.alice} {
+		msg := do.ReconstructMessage(uuid.New(), from, suite.other(from), "lunch plans", start.Add(time.Duration(i)*time.Minute), nil, nil, nil, nil, nil)
+		suite.messages.messages[msg.ID()] = msg
+		suite.sent = append(suite.sent, msg)
+	}
+	stranger := do.ReconstructMessage(uuid.New(), uuid.New(), suite.bob, "lunch plans", start, nil, nil, nil, nil, nil)
+	suite.messages.messages[stranger.ID()] = stranger
+}
+
+func (suite *SearchSuite) other(user uuid.UUID) uuid.UUID {
+	if user == suite.alice {
+		return suite.bob
+	}
+	return suite.alice
+}
+
+func (suite *SearchSuite) TestPages() {
+	first, next, err := suite.search.Execute(suite.ctx, suite.alice, "lunch", 2)
+	suite.Require().NoError(err)
+	suite.Require().Len(first, 2)
+	suite.Equal(suite.sent[2].ID(), first[0].Message.ID(), "newest first")
+	suite.Equal([]do.SnippetPart{{Text: "lunch", Match: true}, {Text: " plans"}}, first[0].Snippet)
+	suite.NotEmpty(next)
+
+	second, next, err := suite.search.Execute(suite.ctx, suite.alice, "lunch", 2, WithCursor(next))
+	suite.Require().NoError(err)
+	suite.Require().Len(second, 1, "the stranger's message is not alice's")
+	suite.Equal(suite.sent[0].ID(), second[0].Message.ID())
+	suite.Empty(next)
+}
+
+func (suite *SearchSuite) TestFilter() {
+	hits, _, err := suite.search.Execute(suite.ctx, suite.alice, "lunch", 10, WithSender(suite.bob))
+	suite.Require().NoError(err)
+	suite.Require().Len(hits, 1)
+	suite.Equal(suite.sent[1].ID(), hits[0].Message.ID())
+}
+
+func (suite *SearchSuite) TestInvalid() {
+	_, _, err := suite.search.Execute(suite.ctx, suite.alice, " ", 10)
+	suite.ErrorIs(err, do.ErrEmptySearchQuery)
+
+	_, _, err = suite.search.Execute(suite.ctx, suite.alice, "lunch", 10, WithCursor("not a cursor"))
+	suite.ErrorIs(err, do.ErrInvalidSearchCursor)
+
+	now := time.Now()
+	_, _, err = suite.search.Execute(suite.ctx, suite.alice, "lunch", 10, WithPeriod(now, now.Add(-time.Hour)))
+	suite.ErrorIs(err, do.ErrInvalidSearchPeriod)
+}
+
+func TestSearchSuite(t *testing.T) {
+	suite.Run(t, new(SearchSuite))
+}
@@ -2,45 +2,267 @@ package message
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	usecase "hilo-api/internal/application"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/tracing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// SendOption interface
+type SendOption interface {
+	Apply(*sendConfig)
+}
+
+type sendConfig struct {
+	key           string
+	messageID     uuid.UUID
+	replyToID     uuid.UUID
+	attachmentIDs []uuid.UUID
+}
+
+// WithIdempotencyKey method
+// a retry with the same key returns the message the first request created
+func WithIdempotencyKey(key string) SendOption {
+	return withIdempotencyKey{key: key}
+}
+
+type withIdempotencyKey struct {
+	key string
+}
+
+// Apply method
+func (w withIdempotencyKey) Apply(c *sendConfig) {
+	c.key = w.key
+}
+
+// WithMessageID method
+// creates the message under a client generated id, which doubles as the
+// idempotency key when none is given
+func WithMessageID(id uuid.UUID) SendOption {
+	return withMessageID{id: id}
+}
+
+type withMessageID struct {
+	id uuid.UUID
+}
+
+// Apply method
+func (w withMessageID) Apply(c *sendConfig) {
+	c.messageID = w.id
+}
+
+// WithReplyTo method
+// makes the message a reply to a message of the same conversation
+func WithReplyTo(id uuid.UUID) SendOption {
+	return withReplyTo{id: id}
+}
+
+type withReplyTo struct {
+	id uuid.UUID
+}
+
+// Apply method
+func (w withReplyTo) Apply(c *sendConfig) {
+	c.replyToID = w.id
+}
+
+// WithAttachments method
+// sends uploaded attachments of the sender with the message, in order
+func WithAttachments(ids ...uuid.UUID) SendOption {
+	return withAttachments{ids: ids}
+}
+
+type withAttachments struct {
+	ids []uuid.UUID
+}
+
+// Apply method
+func (w withAttachments) Apply(c *sendConfig) {
+	c.attachmentIDs = w.ids
+}
+
 // SendMessageUseCase handles sending messages
 type SendMessageUseCase struct {
-	messageRepo repository.MessageRepository
-	userRepo    repository.UserRepository
+	messageRepo     repository.MessageRepository
+	userRepo        repository.UserRepository
+	idempotencyRepo repository.IdempotencyRepository
+	attachmentRepo  repository.AttachmentRepository
+	transactor      repository.Transactor
+	idempotencyTTL  time.Duration
 }
 
 // NewSendMessageUseCase creates a new send message use case
-func NewSendMessageUseCase(messageRepo repository.MessageRepository, userRepo repository.UserRepository) *SendMessageUseCase {
+func NewSendMessageUseCase(
+	messageRepo repository.MessageRepository,
+	userRepo repository.UserRepository,
+	idempotencyRepo repository.IdempotencyRepository,
+	attachmentRepo repository.AttachmentRepository,
+	transactor repository.Transactor,
+	cfg config.Message,
+) *SendMessageUseCase {
 	return &SendMessageUseCase{
-		messageRepo: messageRepo,
-		userRepo:    userRepo,
+		messageRepo:     messageRepo,
+		userRepo:        userRepo,
+		idempotencyRepo: idempotencyRepo,
+		attachmentRepo:  attachmentRepo,
+		transactor:      transactor,
+		idempotencyTTL:  cfg.MessageIdempotencyTTL,
 	}
 }
 
-// Execute sends a message from sender to receiver
-func (uc *SendMessageUseCase) Execute(ctx context.Context, senderID, receiverID uuid.UUID, content string) (*do.Message, error) {
+// Execute sends a message from sender to receiver. With an idempotency key
+// or client message id, replayed reports that the message was created by an
+// earlier request with the same key and nothing new was stored.
+func (uc *SendMessageUseCase) Execute(ctx context.Context, senderID, receiverID uuid.UUID, content string, options ...SendOption) (_ *do.Message, replayed bool, err error) {
+	ctx, span := tracing.Start(ctx, "message.Send")
+	defer tracing.End(span, &err)
+
+	cfg := &sendConfig{}
+	for _, option := range options {
+		option.Apply(cfg)
+	}
+	if cfg.key == "" && cfg.messageID != uuid.Nil {
+		cfg.key = cfg.messageID.String()
+	}
+
+	// A live key short-circuits before any other check, the first request
+	// already passed them
+	if cfg.key != "" {
+		msg, err := uc.replay(ctx, senderID, receiverID, content, cfg)
+		if !errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+			return msg, err == nil, err
+		}
+	}
+
 	// Verify receiver exists
-	_, err := uc.userRepo.FindByID(ctx, receiverID)
+	_, err = uc.userRepo.FindByID(ctx, receiverID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, false, fmt.Errorf("%w: %w", usecase.ErrReceiverNotFound, err)
+	}
 	if err != nil {
-		return nil, usecase.ErrReceiverNotFound
+		return nil, false, err
+	}
+
+	attachments, err := uc.attachmentRepo.FindByIDs(ctx, cfg.attachmentIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(attachments) != len(cfg.attachmentIDs) {
+		return nil, false, do.ErrAttachmentUnavailable
 	}
 
 	// Create message with business rules
-	msg, err := do.NewMessage(senderID, receiverID, content)
+	msg, err := do.NewMessageWithID(cfg.messageID, senderID, receiverID, content, attachments...)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if cfg.replyToID != uuid.Nil {
+		if err := uc.replyTo(ctx, msg, cfg.replyToID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if cfg.key == "" {
+		if err := uc.create(ctx, msg); err != nil {
+			return nil, false, err
+		}
+		usecase.MessagesSent.Inc()
+		return msg, false, nil
+	}
+
+	key, err := do.NewIdempotencyKey(cfg.key, msg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Message and key commit together; a concurrent request with the same
+	// key blocks on the key row, or on the message row when the key is the
+	// client message id, and then finds it taken
+	err = uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.create(ctx, msg); err != nil {
+			return err
+		}
+		return uc.idempotencyRepo.Save(ctx, key, time.Now().Add(-uc.idempotencyTTL))
+	})
+	if errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+		msg, err := uc.replay(ctx, senderID, receiverID, content, cfg)
+		return msg, err == nil, err
+	}
+	// the id is only a conflict when no live key of the sender claims it
+	if errors.Is(err, repository.ErrDuplicateMessageID) && cfg.messageID != uuid.Nil {
+		msg, replayErr := uc.replay(ctx, senderID, receiverID, content, cfg)
+		if !errors.Is(replayErr, repository.ErrIdempotencyKeyNotFound) {
+			return msg, replayErr == nil, replayErr
+		}
+	}
+	if err != nil {
+		return nil, false, err
 	}
+	usecase.MessagesSent.Inc()
 
-	// Persist
-	if err := uc.messageRepo.Create(ctx, msg); err != nil {
+	return msg, false, nil
+}
+
+// create stores the message and claims its attachments in one transaction,
+// joining the caller's if there is one
+func (uc *SendMessageUseCase) create(ctx context.Context, msg *do.Message) error {
+	if len(msg.Attachments()) == 0 {
+		return uc.insert(ctx, msg)
+	}
+	return uc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.insert(ctx, msg); err != nil {
+			return err
+		}
+		err := uc.attachmentRepo.AttachToMessage(ctx, msg)
+		if errors.Is(err, repository.ErrAttachmentUnavailable) {
+			return fmt.Errorf("%w: %w", do.ErrAttachmentUnavailable, err)
+		}
+		return err
+	})
+}
+
+func (uc *SendMessageUseCase) insert(ctx context.Context, msg *do.Message) error {
+	err := uc.messageRepo.Create(ctx, msg)
+	if errors.Is(err, repository.ErrDuplicateMessageID) {
+		return fmt.Errorf("%w: %w", usecase.ErrMessageIDConflict, err)
+	}
+	return err
+}
+
+func (uc *SendMessageUseCase) replyTo(ctx context.Context, msg *do.Message, parentID uuid.UUID) error {
+	parent, err := uc.messageRepo.FindByID(ctx, parentID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrReplyTargetNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	return msg.ReplyTo(parent)
+}
+
+// replay returns the message a live key created, ErrIdempotencyKeyNotFound
+// when there is none or it has expired
+func (uc *SendMessageUseCase) replay(ctx context.Context, senderID, receiverID uuid.UUID, content string, cfg *sendConfig) (*do.Message, error) {
+	existing, err := uc.idempotencyRepo.Find(ctx, senderID, cfg.key)
+	if err != nil {
 		return nil, err
 	}
+	if existing.Expired(time.Now(), uc.idempotencyTTL) {
+		return nil, repository.ErrIdempotencyKeyNotFound
+	}
+	if !existing.Matches(receiverID, cfg.replyToID, content, cfg.attachmentIDs...) {
+		return nil, usecase.ErrIdempotencyKeyReused
+	}
 
-	return msg, nil
+	msg, err := uc.messageRepo.FindByID(ctx, existing.MessageID())
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	return msg, err
 }
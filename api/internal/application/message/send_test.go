@@ -0,0 +1,371 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeUsers struct {
+	repository.UserRepository
+	users map[uuid.UUID]*do.User
+}
+
+func (f *fakeUsers) FindByID(_ context.Context, id uuid.UUID) (*do.User, error) {
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+type fakeMessages struct {
+	repository.MessageRepository
+	messages  map[uuid.UUID]*do.Message
+	revisions []*do.MessageRevision
+	hidden    map[uuid.UUID][]uuid.UUID
+	delivered []uuid.UUID
+	read      []uuid.UUID
+}
+
+func (f *fakeMessages) Create(_ context.Context, msg *do.Message) error {
+	if _, ok := f.messages[msg.ID()]; ok {
+		return repository.ErrDuplicateMessageID
+	}
+	f.messages[msg.ID()] = msg
+	return nil
+}
+
+func (f *fakeMessages) FindByID(_ context.Context, id uuid.UUID) (*do.Message, error) {
+	if msg, ok := f.messages[id]; ok {
+		return msg, nil
+	}
+	return nil, repository.ErrMessageNotFound
+}
+
+func (f *fakeMessages) Edit(_ context.Context, msg *do.Message, revision *do.MessageRevision) error {
+	if _, ok := f.messages[msg.ID()]; !ok {
+		return repository.ErrMessageEditConflict
+	}
+	f.messages[msg.ID()] = msg
+	f.revisions = append(f.revisions, revision)
+	return nil
+}
+
+func (f *fakeMessages) ListRevisions(_ context.Context, messageID uuid.UUID) ([]*do.MessageRevision, error) {
+	var revisions []*do.MessageRevision
+	for _, revision := range f.revisions {
+		if revision.MessageID() == messageID {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
+func (f *fakeMessages) Retract(_ context.Context, msg *do.Message) error {
+	f.messages[msg.ID()] = msg
+	return nil
+}
+
+func (f *fakeMessages) Hide(_ context.Context, messageID, userID uuid.UUID, _ time.Time) error {
+	if f.hidden == nil {
+		f.hidden = map[uuid.UUID][]uuid.UUID{}
+	}
+	f.hidden[userID] = append(f.hidden[userID], messageID)
+	return nil
+}
+
+func (f *fakeMessages) ListReplies(_ context.Context, messageID, _ uuid.UUID, _, _ int) ([]*do.Message, error) {
+	var replies []*do.Message
+	for _, msg := range f.messages {
+		if msg.ReplyToID() == messageID {
+			replies = append(replies, msg)
+		}
+	}
+	return replies, nil
+}
+
+type fakeKeys struct {
+	keys map[string]*do.IdempotencyKey
+	// hideOnce makes the next Find miss, as if another request saved the
+	// key between this request's lookup and its insert
+	hideOnce bool
+}
+
+func (f *fakeKeys) Save(_ context.Context, key *do.IdempotencyKey, expiredBefore time.Time) error {
+	id := key.SenderID().String() + key.Key()
+	if existing, ok := f.keys[id]; ok && !existing.CreatedAt().Before(expiredBefore) {
+		return repository.ErrDuplicateIdempotencyKey
+	}
+	f.keys[id] = key
+	return nil
+}
+
+func (f *fakeKeys) Find(_ context.Context, senderID uuid.UUID, key string) (*do.IdempotencyKey, error) {
+	existing, ok := f.keys[senderID.String()+key]
+	if !ok || f.hideOnce {
+		f.hideOnce = false
+		return nil, repository.ErrIdempotencyKeyNotFound
+	}
+	return existing, nil
+}
+
+func (f *fakeKeys) DeleteExpired(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeAttachments struct {
+	repository.AttachmentRepository
+	attachments map[uuid.UUID]*do.Attachment
+}
+
+func (f *fakeAttachments) FindByIDs(_ context.Context, ids []uuid.UUID) ([]*do.Attachment, error) {
+	var found []*do.Attachment
+	for _, id := range ids {
+		if attachment, ok := f.attachments[id]; ok {
+			// a copy, as every load from the database would be
+			copied := *attachment
+			found = append(found, &copied)
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeAttachments) AttachToMessage(_ context.Context, msg *do.Message) error {
+	for _, attachment := range msg.Attachments() {
+		if f.attachments[attachment.ID()].IsSent() {
+			return repository.ErrAttachmentUnavailable
+		}
+	}
+	for _, attachment := range msg.Attachments() {
+		f.attachments[attachment.ID()] = attachment
+	}
+	return nil
+}
+
+// fakeTransactor undoes the messages fn created when it fails
+type fakeTransactor struct {
+	messages *fakeMessages
+}
+
+func (f fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error, _ ...repository.TxOption) error {
+	before := make(map[uuid.UUID]*do.Message, len(f.messages.messages))
+	for id, msg := range f.messages.messages {
+		before[id] = msg
+	}
+	if err := fn(ctx); err != nil {
+		f.messages.messages = before
+		return err
+	}
+	return nil
+}
+
+type SendMessageSuite struct {
+	suite.Suite
+	sender      uuid.UUID
+	receiver    uuid.UUID
+	messages    *fakeMessages
+	keys        *fakeKeys
+	attachments *fakeAttachments
+	ttl         time.Duration
+}
+
+func (suite *SendMessageSuite) SetupTest() {
+	suite.sender, suite.receiver = uuid.New(), uuid.New()
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.keys = &fakeKeys{keys: map[string]*do.IdempotencyKey{}}
+	suite.attachments = &fakeAttachments{attachments: map[uuid.UUID]*do.Attachment{}}
+	suite.ttl = 24 * time.Hour
+}
+
+func (suite *SendMessageSuite) send(content string, options ...SendOption) (*do.Message, bool, error) {
+	receiver, err := do.NewUser("receiver@example.com", "password123", "receiver")
+	suite.Require().NoError(err)
+	users := &fakeUsers{users: map[uuid.UUID]*do.User{suite.receiver: receiver}}
+	uc := NewSendMessageUseCase(suite.messages, users, suite.keys, suite.attachments, fakeTransactor{messages: suite.messages},
+		config.Message{MessageIdempotencyTTL: suite.ttl})
+	return uc.Execute(context.Background(), suite.sender, suite.receiver, content, options...)
+}
+
+func (suite *SendMessageSuite) TestWithoutKey() {
+	first, replayed, err := suite.send("hello")
+	suite.NoError(err)
+	suite.False(replayed)
+	second, _, err := suite.send("hello")
+	suite.NoError(err)
+	suite.NotEqual(first.ID(), second.ID())
+	suite.Len(suite.messages.messages, 2)
+}
+
+func (suite *SendMessageSuite) TestReplaysIdempotencyKey() {
+	first, replayed, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+	suite.False(replayed)
+
+	second, replayed, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+	suite.True(replayed)
+	suite.Equal(first.ID(), second.ID())
+	suite.Len(suite.messages.messages, 1)
+}
+
+func (suite *SendMessageSuite) TestKeyReusedForDifferentRequest() {
+	_, _, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+
+	_, _, err = suite.send("goodbye", WithIdempotencyKey("retry-1"))
+	suite.ErrorIs(err, usecase.ErrIdempotencyKeyReused)
+}
+
+func (suite *SendMessageSuite) TestClientMessageID() {
+	id := uuid.New()
+	first, _, err := suite.send("hello", WithMessageID(id))
+	suite.NoError(err)
+	suite.Equal(id, first.ID())
+
+	second, replayed, err := suite.send("hello", WithMessageID(id))
+	suite.NoError(err)
+	suite.True(replayed)
+	suite.Equal(id, second.ID())
+}
+
+func (suite *SendMessageSuite) TestExpiredKey() {
+	suite.ttl = time.Nanosecond
+	first, _, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+	time.Sleep(time.Millisecond)
+
+	second, replayed, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+	suite.False(replayed)
+	suite.NotEqual(first.ID(), second.ID())
+
+	// a client message id stays taken after its key expired
+	id := uuid.New()
+	_, _, err = suite.send("hello", WithMessageID(id))
+	suite.NoError(err)
+	time.Sleep(time.Millisecond)
+	_, _, err = suite.send("hello", WithMessageID(id))
+	suite.ErrorIs(err, usecase.ErrMessageIDConflict)
+}
+
+func (suite *SendMessageSuite) TestConcurrentRetryLosesRace() {
+	first, _, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+
+	suite.keys.hideOnce = true
+	second, replayed, err := suite.send("hello", WithIdempotencyKey("retry-1"))
+	suite.NoError(err)
+	suite.True(replayed)
+	suite.Equal(first.ID(), second.ID())
+	suite.Len(suite.messages.messages, 1, "the losing insert is rolled back")
+}
+
+func (suite *SendMessageSuite) TestConcurrentRetryWithClientMessageID() {
+	id := uuid.New()
+	first, _, err := suite.send("hello", WithMessageID(id))
+	suite.NoError(err)
+
+	// the retry misses the key and inserts the same id a second time
+	suite.keys.hideOnce = true
+	second, replayed, err := suite.send("hello", WithMessageID(id))
+	suite.NoError(err)
+	suite.True(replayed)
+	suite.Equal(first.ID(), second.ID())
+	suite.Len(suite.messages.messages, 1)
+
+	// another sender's id clash is not theirs to replay
+	suite.sender = uuid.New()
+	_, _, err = suite.send("hello", WithMessageID(id))
+	suite.ErrorIs(err, usecase.ErrMessageIDConflict)
+}
+
+func (suite *SendMessageSuite) TestReply() {
+	parent, _, err := suite.send("Lunch?")
+	suite.Require().NoError(err)
+
+	reply, _, err := suite.send("Sure", WithReplyTo(parent.ID()), WithIdempotencyKey("reply-1"))
+	suite.NoError(err)
+	suite.Equal(parent.ID(), reply.ReplyToID())
+	suite.Equal("Lunch?", reply.Quote().Content())
+
+	// the same key replying to something else is a different request
+	_, _, err = suite.send("Sure", WithIdempotencyKey("reply-1"))
+	suite.ErrorIs(err, usecase.ErrIdempotencyKeyReused)
+
+	_, _, err = suite.send("Sure", WithReplyTo(uuid.New()))
+	suite.ErrorIs(err, usecase.ErrReplyTargetNotFound)
+
+	replies, err := NewListRepliesUseCase(suite.messages, &fakeReactions{}).Execute(context.Background(), parent.ID(), suite.receiver, 10, 0)
+	suite.NoError(err)
+	suite.Len(replies, 1)
+
+	_, err = NewListRepliesUseCase(suite.messages, &fakeReactions{}).Execute(context.Background(), parent.ID(), uuid.New(), 10, 0)
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+}
+
+func (suite *SendMessageSuite) TestReplyOutsideConversation() {
+	other, err := do.NewMessage(uuid.New(), suite.receiver, "elsewhere")
+	suite.Require().NoError(err)
+	suite.messages.messages[other.ID()] = other
+
+	_, _, err = suite.send("Sure", WithReplyTo(other.ID()))
+	suite.ErrorIs(err, do.ErrReplyOutsideConversation)
+}
+
+func (suite *SendMessageSuite) TestReceiverNotFound() {
+	suite.receiver = uuid.New()
+	receiver := suite.receiver
+	_, _, err := suite.send("hello")
+	suite.NoError(err)
+
+	uc := NewSendMessageUseCase(suite.messages, &fakeUsers{users: map[uuid.UUID]*do.User{}}, suite.keys,
+		suite.attachments, fakeTransactor{messages: suite.messages}, config.Message{MessageIdempotencyTTL: suite.ttl})
+	_, _, err = uc.Execute(context.Background(), suite.sender, receiver, "hello")
+	suite.ErrorIs(err, usecase.ErrReceiverNotFound)
+}
+
+func (suite *SendMessageSuite) upload(ownerID uuid.UUID) uuid.UUID {
+	attachment, err := do.NewAttachment(ownerID, "cat.png", "image/png", 10, "abc",
+		do.AttachmentPolicy{MaxSize: 1 << 20, AllowedTypes: []string{"image/*"}})
+	suite.Require().NoError(err)
+	suite.attachments.attachments[attachment.ID()] = attachment
+	return attachment.ID()
+}
+
+func (suite *SendMessageSuite) TestAttachments() {
+	first, second := suite.upload(suite.sender), suite.upload(suite.sender)
+	msg, _, err := suite.send("", WithAttachments(first, second), WithIdempotencyKey("photos"))
+	suite.Require().NoError(err)
+	suite.Equal([]uuid.UUID{first, second}, msg.AttachmentIDs())
+	suite.Equal(msg.ID(), suite.attachments.attachments[first].MessageID())
+
+	replayed, ok, err := suite.send("", WithAttachments(first, second), WithIdempotencyKey("photos"))
+	suite.NoError(err)
+	suite.True(ok)
+	suite.Equal(msg.ID(), replayed.ID())
+
+	// other attachments under the same key are a different request
+	_, _, err = suite.send("", WithAttachments(second, first), WithIdempotencyKey("photos"))
+	suite.ErrorIs(err, usecase.ErrIdempotencyKeyReused)
+
+	_, _, err = suite.send("again", WithAttachments(first))
+	suite.ErrorIs(err, do.ErrAttachmentUnavailable)
+
+	_, _, err = suite.send("", WithAttachments(uuid.New()))
+	suite.ErrorIs(err, do.ErrAttachmentUnavailable)
+
+	_, _, err = suite.send("", WithAttachments(suite.upload(suite.receiver)))
+	suite.ErrorIs(err, do.ErrAttachmentUnavailable)
+	suite.Len(suite.messages.messages, 1)
+}
+
+func TestSendMessageSuite(t *testing.T) {
+	suite.Run(t, new(SendMessageSuite))
+}
@@ -0,0 +1,99 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// ToggleReactionUseCase handles reacting to messages with emoji
+type ToggleReactionUseCase struct {
+	messageRepo  repository.MessageRepository
+	reactionRepo repository.ReactionRepository
+	publisher    usecase.Publisher
+}
+
+// NewToggleReactionUseCase creates a new toggle reaction use case
+func NewToggleReactionUseCase(messageRepo repository.MessageRepository, reactionRepo repository.ReactionRepository, publisher usecase.Publisher) *ToggleReactionUseCase {
+	return &ToggleReactionUseCase{
+		messageRepo:  messageRepo,
+		reactionRepo: reactionRepo,
+		publisher:    publisher,
+	}
+}
+
+// Execute adds the user's emoji to a message, or removes it when they had
+// already reacted with it, tells both participants' clients and returns the
+// reactions on the message as the user now sees them
+func (uc *ToggleReactionUseCase) Execute(ctx context.Context, messageID, userID uuid.UUID, emoji string) (added bool, _ []*do.ReactionSummary, err error) {
+	ctx, span := tracing.Start(ctx, "message.ToggleReaction")
+	defer tracing.End(span, &err)
+
+	// Load message
+	msg, err := uc.messageRepo.FindByID(ctx, messageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return false, nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	if !msg.IsParticipant(userID) {
+		return false, nil, usecase.ErrMessageNotFound
+	}
+
+	// Apply business rule
+	reaction, err := do.NewReaction(msg, userID, emoji)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Persist
+	added, err = uc.reactionRepo.Toggle(ctx, reaction)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return false, nil, fmt.Errorf("%w: %w", usecase.ErrMessageNotFound, err)
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	event := realtime.Event{Type: usecase.EventReactionRemoved, Data: reaction}
+	if added {
+		event.Type = usecase.EventReactionAdded
+	}
+	uc.publisher.Publish(msg.ReceiverID(), event)
+	uc.publisher.Publish(msg.SenderID(), event)
+
+	summaries, err := uc.reactionRepo.Summaries(ctx, []uuid.UUID{msg.ID()}, userID)
+	if err != nil {
+		return false, nil, err
+	}
+	return added, summaries[msg.ID()], nil
+}
+
+// attachReactions loads the reactions on a page of messages in one query
+// and attaches them as viewerID sees them
+func attachReactions(ctx context.Context, reactionRepo repository.ReactionRepository, messages []*do.Message, viewerID uuid.UUID) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID()
+	}
+	summaries, err := reactionRepo.Summaries(ctx, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		msg.AttachReactions(summaries[msg.ID()])
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package message
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeReactions keeps reactions in the order they were added
+type fakeReactions struct {
+	reactions []*do.Reaction
+}
+
+func (f *fakeReactions) Toggle(_ context.Context, reaction *do.Reaction) (bool, error) {
+	for i, r := range f.reactions {
+		if r.MessageID() == reaction.MessageID() && r.UserID() == reaction.UserID() && r.Emoji() == reaction.Emoji() {
+			f.reactions = append(f.reactions[:i], f.reactions[i+1:]...)
+			return false, nil
+		}
+	}
+	f.reactions = append(f.reactions, reaction)
+	return true, nil
+}
+
+func (f *fakeReactions) Summaries(_ context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]*do.ReactionSummary, error) {
+	summaries := map[uuid.UUID][]*do.ReactionSummary{}
+	for _, id := range messageIDs {
+		byEmoji := map[string]*do.ReactionSummary{}
+		for _, r := range f.reactions {
+			if r.MessageID() != id {
+				continue
+			}
+			summary, ok := byEmoji[r.Emoji()]
+			if !ok {
+				summary = &do.ReactionSummary{Emoji: r.Emoji()}
+				byEmoji[r.Emoji()] = summary
+				summaries[id] = append(summaries[id], summary)
+			}
+			summary.Count++
+			summary.ReactedByMe = summary.ReactedByMe || r.UserID() == viewerID
+		}
+	}
+	return summaries, nil
+}
+
+type ToggleReactionSuite struct {
+	suite.Suite
+	messages  *fakeMessages
+	reactions *fakeReactions
+	publisher *fakePublisher
+	toggle    *ToggleReactionUseCase
+	msg       *do.Message
+}
+
+func (suite *ToggleReactionSuite) SetupTest() {
+	suite.messages = &fakeMessages{messages: map[uuid.UUID]*do.Message{}}
+	suite.reactions = &fakeReactions{}
+	suite.publisher = &fakePublisher{}
+	suite.toggle = NewToggleReactionUseCase(suite.messages, suite.reactions, suite.publisher)
+
+	msg, err := do.NewMessage(uuid.New(), uuid.New(), "Lunch at noon")
+	suite.Require().NoError(err)
+	suite.messages.messages[msg.ID()] = msg
+	suite.msg = msg
+}
+
+func (suite *ToggleReactionSuite) TestToggle() {
+	added, summaries, err := suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), "👍")
+	suite.NoError(err)
+	suite.True(added)
+	suite.Equal([]*do.ReactionSummary{{Emoji: "👍", Count: 1, ReactedByMe: true}}, summaries)
+
+	added, summaries, err = suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), "👍")
+	suite.NoError(err)
+	suite.True(added)
+	suite.Equal([]*do.ReactionSummary{{Emoji: "👍", Count: 2, ReactedByMe: true}}, summaries)
+
+	added, summaries, err = suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), "👍")
+	suite.NoError(err)
+	suite.False(added)
+	suite.Equal([]*do.ReactionSummary{{Emoji: "👍", Count: 1, ReactedByMe: false}}, summaries)
+
+	suite.Require().Len(suite.publisher.events, 6)
+	suite.Equal(suite.msg.ReceiverID(), suite.publisher.events[4].userID)
+	suite.Equal(suite.msg.SenderID(), suite.publisher.events[5].userID)
+	suite.Equal(usecase.EventReactionAdded, suite.publisher.events[0].event.Type)
+	suite.Equal(usecase.EventReactionRemoved, suite.publisher.events[5].event.Type)
+}
+
+func (suite *ToggleReactionSuite) TestInvalidEmoji() {
+	_, _, err := suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), "ok")
+	suite.ErrorIs(err, do.ErrInvalidEmoji)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *ToggleReactionSuite) TestOutsiderSeesNoMessage() {
+	_, _, err := suite.toggle.Execute(context.Background(), suite.msg.ID(), uuid.New(), "👍")
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+
+	_, _, err = suite.toggle.Execute(context.Background(), uuid.New(), suite.msg.ReceiverID(), "👍")
+	suite.ErrorIs(err, usecase.ErrMessageNotFound)
+}
+
+func (suite *ToggleReactionSuite) TestRetractedMessage() {
+	remove := NewDeleteMessageUseCase(suite.messages, suite.publisher, config.Message{MessageRetractWindow: time.Minute})
+	suite.Require().NoError(remove.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), DeleteForEveryone))
+
+	_, _, err := suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.ReceiverID(), "👍")
+	suite.ErrorIs(err, do.ErrMessageDeleted)
+}
+
+func (suite *ToggleReactionSuite) TestListingsCarryReactions() {
+	_, _, err := suite.toggle.Execute(context.Background(), suite.msg.ID(), suite.msg.SenderID(), "🎉")
+	suite.Require().NoError(err)
+
+	messages := []*do.Message{suite.msg}
+	suite.Require().NoError(attachReactions(context.Background(), suite.reactions, messages, suite.msg.ReceiverID()))
+	suite.Equal([]*do.ReactionSummary{{Emoji: "🎉", Count: 1, ReactedByMe: false}}, suite.msg.Reactions())
+}
+
+func TestToggleReactionSuite(t *testing.T) {
+	suite.Run(t, new(ToggleReactionSuite))
+}
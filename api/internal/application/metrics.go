@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"hilo-api/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Login failure reasons, kept apart in metrics only; clients see
+// ErrInvalidCredentials unless the password matched a suspended account
+const (
+	LoginFailedUnknownUser   = "unknown_user"
+	LoginFailedWrongPassword = "wrong_password"
+	LoginFailedSuspended     = "suspended"
+)
+
+var (
+	// MessagesSent counts persisted messages
+	MessagesSent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "messages",
+		Name:      "sent_total",
+		Help:      "Messages sent.",
+	})
+
+	// MessagesDelivered counts messages acknowledged by a receiver's device
+	MessagesDelivered = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "messages",
+		Name:      "delivered_total",
+		Help:      "Messages delivered to a device of the receiver.",
+	})
+
+	// MessagesRead counts messages marked as read
+	MessagesRead = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "messages",
+		Name:      "read_total",
+		Help:      "Messages marked as read.",
+	})
+
+	// MessagesEdited counts stored message edits
+	MessagesEdited = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "messages",
+		Name:      "edited_total",
+		Help:      "Messages edited.",
+	})
+
+	// MessagesDeleted counts message deletions by mode
+	MessagesDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "messages",
+		Name:      "deleted_total",
+		Help:      "Messages deleted, for me or for everyone.",
+	}, []string{"mode"})
+
+	// AttachmentsStored counts uploaded files kept as attachments
+	AttachmentsStored = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "attachments",
+		Name:      "stored_total",
+		Help:      "Uploaded files stored as attachments.",
+	})
+
+	// AttachmentsProcessed counts processing attempts of image attachments
+	// by outcome: ready, retry or failed
+	AttachmentsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "attachments",
+		Name:      "processed_total",
+		Help:      "Image attachment processing attempts by outcome.",
+	}, []string{"outcome"})
+
+	// Registrations counts created accounts
+	Registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "auth",
+		Name:      "registrations_total",
+		Help:      "User registrations.",
+	})
+
+	// LoginsFailed counts rejected logins by reason
+	LoginsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "auth",
+		Name:      "logins_failed_total",
+		Help:      "Rejected logins by reason.",
+	}, []string{"reason"})
+)
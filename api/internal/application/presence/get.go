@@ -0,0 +1,49 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// GetPresenceUseCase handles looking up whether a user is online
+type GetPresenceUseCase struct {
+	userRepo     repository.UserRepository
+	presenceRepo repository.PresenceRepository
+	presence     *realtime.Presence
+}
+
+// NewGetPresenceUseCase creates a new get presence use case
+func NewGetPresenceUseCase(userRepo repository.UserRepository, presenceRepo repository.PresenceRepository, presence *realtime.Presence) *GetPresenceUseCase {
+	return &GetPresenceUseCase{
+		userRepo:     userRepo,
+		presenceRepo: presenceRepo,
+		presence:     presence,
+	}
+}
+
+// Execute retrieves the presence of userID as viewerID sees it
+func (uc *GetPresenceUseCase) Execute(ctx context.Context, viewerID, userID uuid.UUID) (_ *do.Presence, err error) {
+	ctx, span := tracing.Start(ctx, "presence.Get")
+	defer tracing.End(span, &err)
+
+	if _, err := uc.userRepo.FindByID(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+		}
+		return nil, err
+	}
+
+	lastSeen, err := uc.presenceRepo.FindLastSeen(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return lastSeen.PresenceFor(viewerID, uc.presence.Online(userID)), nil
+}
@@ -0,0 +1,36 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// HideLastSeenUseCase handles users hiding their last seen from others
+type HideLastSeenUseCase struct {
+	presenceRepo repository.PresenceRepository
+}
+
+// NewHideLastSeenUseCase creates a new hide last seen use case
+func NewHideLastSeenUseCase(presenceRepo repository.PresenceRepository) *HideLastSeenUseCase {
+	return &HideLastSeenUseCase{
+		presenceRepo: presenceRepo,
+	}
+}
+
+// Execute hides the last seen of userID from other users, or shows it again
+func (uc *HideLastSeenUseCase) Execute(ctx context.Context, userID uuid.UUID, hidden bool) (err error) {
+	ctx, span := tracing.Start(ctx, "presence.HideLastSeen")
+	defer tracing.End(span, &err)
+
+	err = uc.presenceRepo.UpdateLastSeenHidden(ctx, userID, hidden)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("%w: %w", usecase.ErrUserNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package presence
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeUsers struct {
+	repository.UserRepository
+	users map[uuid.UUID]*do.User
+}
+
+func (f *fakeUsers) FindByID(_ context.Context, id uuid.UUID) (*do.User, error) {
+	if user, ok := f.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+type fakeLastSeen struct {
+	seen   map[uuid.UUID]time.Time
+	hidden map[uuid.UUID]bool
+}
+
+func (f *fakeLastSeen) FindLastSeen(_ context.Context, userID uuid.UUID) (*do.LastSeen, error) {
+	var at *time.Time
+	if seen, ok := f.seen[userID]; ok {
+		at = &seen
+	}
+	return do.ReconstructLastSeen(userID, at, f.hidden[userID]), nil
+}
+
+func (f *fakeLastSeen) UpdateLastSeen(_ context.Context, userID uuid.UUID, at time.Time) error {
+	f.seen[userID] = at
+	return nil
+}
+
+func (f *fakeLastSeen) UpdateLastSeenHidden(_ context.Context, userID uuid.UUID, hidden bool) error {
+	f.hidden[userID] = hidden
+	return nil
+}
+
+type published struct {
+	userID uuid.UUID
+	event  realtime.Event
+}
+
+type fakePublisher struct {
+	events []published
+}
+
+func (f *fakePublisher) Publish(userID uuid.UUID, event realtime.Event) {
+	f.events = append(f.events, published{userID: userID, event: event})
+}
+
+type PresenceSuite struct {
+	suite.Suite
+	ctx        context.Context
+	lastSeen   *fakeLastSeen
+	publisher  *fakePublisher
+	presence   *realtime.Presence
+	connect    *ConnectUseCase
+	disconnect *DisconnectUseCase
+	sweep      *SweepUseCase
+	get        *GetPresenceUseCase
+	hide       *HideLastSeenUseCase
+	typing     *TypingUseCase
+	alice      uuid.UUID
+	bob        uuid.UUID
+}
+
+func (suite *PresenceSuite) SetupTest() {
+	suite.ctx = context.Background()
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	suite.alice, suite.bob = alice.ID(), bob.ID()
+	users := &fakeUsers{users: map[uuid.UUID]*do.User{alice.ID(): alice, bob.ID(): bob}}
+
+	suite.lastSeen = &fakeLastSeen{seen: map[uuid.UUID]time.Time{}, hidden: map[uuid.UUID]bool{}}
+	suite.publisher = &fakePublisher{}
+	suite.presence = realtime.NewPresence(time.Minute)
+	typing := realtime.NewTyping(time.Minute, time.Hour)
+
+	suite.connect = NewConnectUseCase(suite.presence)
+	suite.disconnect = NewDisconnectUseCase(suite.lastSeen, typing, suite.publisher)
+	suite.sweep = NewSweepUseCase(suite.lastSeen, suite.presence, typing, suite.publisher)
+	suite.get = NewGetPresenceUseCase(users, suite.lastSeen, suite.presence)
+	suite.hide = NewHideLastSeenUseCase(suite.lastSeen)
+	suite.typing = NewTypingUseCase(users, typing, suite.publisher)
+}
+
+func (suite *PresenceSuite) TestLastSeenOnLastDisconnect() {
+	phone := suite.connect.Execute(suite.ctx, suite.alice)
+	laptop := suite.connect.Execute(suite.ctx, suite.alice)
+
+	presence, err := suite.get.Execute(suite.ctx, suite.bob, suite.alice)
+	suite.Require().NoError(err)
+	suite.Equal(&do.Presence{UserID: suite.alice, Online: true}, presence)
+
+	suite.Require().NoError(suite.disconnect.Execute(suite.ctx, phone))
+	suite.Empty(suite.lastSeen.seen, "still online on the laptop")
+	suite.Require().NoError(suite.disconnect.Execute(suite.ctx, laptop))
+	suite.Contains(suite.lastSeen.seen, suite.alice)
+
+	presence, err = suite.get.Execute(suite.ctx, suite.bob, suite.alice)
+	suite.Require().NoError(err)
+	suite.False(presence.Online)
+	suite.NotNil(presence.LastSeenAt)
+}
+
+func (suite *PresenceSuite) TestHideLastSeen() {
+	suite.lastSeen.seen[suite.alice] = time.Now()
+	suite.Require().NoError(suite.hide.Execute(suite.ctx, suite.alice, true))
+
+	presence, err := suite.get.Execute(suite.ctx, suite.bob, suite.alice)
+	suite.Require().NoError(err)
+	suite.Nil(presence.LastSeenAt)
+
+	presence, err = suite.get.Execute(suite.ctx, suite.alice, suite.alice)
+	suite.Require().NoError(err)
+	suite.NotNil(presence.LastSeenAt, "hidden from others only")
+
+	_, err = suite.get.Execute(suite.ctx, suite.alice, uuid.New())
+	suite.ErrorIs(err, usecase.ErrUserNotFound)
+}
+
+func (suite *PresenceSuite) TestTyping() {
+	suite.Require().NoError(suite.typing.Execute(suite.ctx, suite.alice, suite.bob, true))
+	suite.Require().NoError(suite.typing.Execute(suite.ctx, suite.alice, suite.bob, true))
+	suite.Require().Len(suite.publisher.events, 1, "repeated starts are throttled")
+	suite.Equal(suite.bob, suite.publisher.events[0].userID)
+	suite.Equal(usecase.EventTypingStarted, suite.publisher.events[0].event.Type)
+	suite.Equal(&do.TypingSignal{UserID: suite.alice, Typing: true, ExpiresIn: time.Hour}, suite.publisher.events[0].event.Data)
+
+	suite.Require().NoError(suite.typing.Execute(suite.ctx, suite.alice, suite.bob, false))
+	suite.Require().NoError(suite.typing.Execute(suite.ctx, suite.alice, suite.bob, false))
+	suite.Require().Len(suite.publisher.events, 2, "only a stop after a start is news")
+	suite.Equal(usecase.EventTypingStopped, suite.publisher.events[1].event.Type)
+}
+
+func (suite *PresenceSuite) TestTypingRejected() {
+	suite.ErrorIs(suite.typing.Execute(suite.ctx, suite.alice, suite.alice, true), do.ErrTypingToSelf)
+	suite.ErrorIs(suite.typing.Execute(suite.ctx, suite.alice, uuid.New(), true), usecase.ErrReceiverNotFound)
+	suite.Empty(suite.publisher.events)
+}
+
+func (suite *PresenceSuite) TestGoingOfflineStopsTyping() {
+	session := suite.connect.Execute(suite.ctx, suite.alice)
+	suite.Require().NoError(suite.typing.Execute(suite.ctx, suite.alice, suite.bob, true))
+
+	suite.Require().NoError(suite.disconnect.Execute(suite.ctx, session))
+	suite.Require().Len(suite.publisher.events, 2)
+	suite.Equal(usecase.EventTypingStopped, suite.publisher.events[1].event.Type)
+	suite.Equal(suite.bob, suite.publisher.events[1].userID)
+}
+
+func (suite *PresenceSuite) TestSweepNothingDue() {
+	suite.connect.Execute(suite.ctx, suite.alice)
+	offline, err := suite.sweep.Execute(suite.ctx)
+	suite.Require().NoError(err)
+	suite.Zero(offline, "the session just joined")
+	suite.True(suite.presence.Online(suite.alice))
+}
+
+func TestPresenceSuite(t *testing.T) {
+	suite.Run(t, new(PresenceSuite))
+}
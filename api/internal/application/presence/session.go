@@ -0,0 +1,71 @@
+package presence
+
+import (
+	"context"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConnectUseCase handles a device of a user coming online
+type ConnectUseCase struct {
+	presence *realtime.Presence
+}
+
+// NewConnectUseCase creates a new connect use case
+func NewConnectUseCase(presence *realtime.Presence) *ConnectUseCase {
+	return &ConnectUseCase{
+		presence: presence,
+	}
+}
+
+// Execute opens a session for the device, which has to beat to stay online
+// and is handed to DisconnectUseCase when the device goes away
+func (uc *ConnectUseCase) Execute(ctx context.Context, userID uuid.UUID) *realtime.Session {
+	_, span := tracing.Start(ctx, "presence.Connect")
+	defer tracing.End(span, nil)
+
+	session, _ := uc.presence.Join(userID)
+	return session
+}
+
+// DisconnectUseCase handles a device of a user going away
+type DisconnectUseCase struct {
+	presenceRepo repository.PresenceRepository
+	typing       *realtime.Typing
+	publisher    usecase.Publisher
+}
+
+// NewDisconnectUseCase creates a new disconnect use case
+func NewDisconnectUseCase(presenceRepo repository.PresenceRepository, typing *realtime.Typing, publisher usecase.Publisher) *DisconnectUseCase {
+	return &DisconnectUseCase{
+		presenceRepo: presenceRepo,
+		typing:       typing,
+		publisher:    publisher,
+	}
+}
+
+// Execute closes the session; when it was the user's last one they are
+// offline, so their last seen is stored and their typing ends
+func (uc *DisconnectUseCase) Execute(ctx context.Context, session *realtime.Session) (err error) {
+	ctx, span := tracing.Start(ctx, "presence.Disconnect")
+	defer tracing.End(span, &err)
+
+	if !session.Leave() {
+		return nil
+	}
+	return wentOffline(ctx, uc.presenceRepo, uc.typing, uc.publisher, session.UserID(), time.Now())
+}
+
+// wentOffline stores when userID was last seen and tells whoever they were
+// typing to that they stopped
+func wentOffline(ctx context.Context, presenceRepo repository.PresenceRepository, typing *realtime.Typing, publisher usecase.Publisher, userID uuid.UUID, at time.Time) error {
+	for _, pair := range typing.StopFrom(userID) {
+		relayTyping(publisher, pair, false, 0)
+	}
+	return presenceRepo.UpdateLastSeen(ctx, userID, at)
+}
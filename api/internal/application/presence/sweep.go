@@ -0,0 +1,50 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+)
+
+// SweepUseCase handles what ends by itself: sessions that stopped beating
+// and typing nobody stopped
+type SweepUseCase struct {
+	presenceRepo repository.PresenceRepository
+	presence     *realtime.Presence
+	typing       *realtime.Typing
+	publisher    usecase.Publisher
+}
+
+// NewSweepUseCase creates a new sweep use case
+func NewSweepUseCase(presenceRepo repository.PresenceRepository, presence *realtime.Presence, typing *realtime.Typing, publisher usecase.Publisher) *SweepUseCase {
+	return &SweepUseCase{
+		presenceRepo: presenceRepo,
+		presence:     presence,
+		typing:       typing,
+		publisher:    publisher,
+	}
+}
+
+// Execute expires typing and sessions, treating the users left without a
+// session like ones whose last device disconnected; it returns how many
+// went offline
+func (uc *SweepUseCase) Execute(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "presence.Sweep")
+	defer tracing.End(span, &err)
+
+	for _, pair := range uc.typing.Expire() {
+		relayTyping(uc.publisher, pair, false, 0)
+	}
+
+	offline := uc.presence.Expire()
+	now := time.Now()
+	var errs []error
+	for _, userID := range offline {
+		errs = append(errs, wentOffline(ctx, uc.presenceRepo, uc.typing, uc.publisher, userID, now))
+	}
+	return len(offline), errors.Join(errs...)
+}
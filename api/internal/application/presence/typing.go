@@ -0,0 +1,77 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TypingUseCase handles the typing signals a client sends while its user
+// writes to someone
+type TypingUseCase struct {
+	userRepo  repository.UserRepository
+	typing    *realtime.Typing
+	publisher usecase.Publisher
+}
+
+// NewTypingUseCase creates a new typing use case
+func NewTypingUseCase(userRepo repository.UserRepository, typing *realtime.Typing, publisher usecase.Publisher) *TypingUseCase {
+	return &TypingUseCase{
+		userRepo:  userRepo,
+		typing:    typing,
+		publisher: publisher,
+	}
+}
+
+// Execute records that userID started or stopped typing to peerID and
+// passes it on to the peer's clients, a start at most once per throttle
+func (uc *TypingUseCase) Execute(ctx context.Context, userID, peerID uuid.UUID, typing bool) (err error) {
+	ctx, span := tracing.Start(ctx, "presence.Typing")
+	defer tracing.End(span, &err)
+
+	// Apply business rule
+	if _, err := do.NewTypingSignal(userID, peerID, typing, uc.typing.Timeout()); err != nil {
+		return err
+	}
+
+	pair := realtime.TypingPair{From: userID, To: peerID}
+	if !typing {
+		if uc.typing.Stop(pair) {
+			relayTyping(uc.publisher, pair, false, 0)
+		}
+		return nil
+	}
+	if !uc.typing.Start(pair) {
+		return nil
+	}
+
+	// only starts that are passed on look the peer up
+	if _, err := uc.userRepo.FindByID(ctx, peerID); err != nil {
+		uc.typing.Stop(pair)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("%w: %w", usecase.ErrReceiverNotFound, err)
+		}
+		return err
+	}
+	relayTyping(uc.publisher, pair, true, uc.typing.Timeout())
+	return nil
+}
+
+// relayTyping tells pair.To's clients that pair.From started or stopped
+// typing
+func relayTyping(publisher usecase.Publisher, pair realtime.TypingPair, typing bool, expiresIn time.Duration) {
+	signal := &do.TypingSignal{UserID: pair.From, Typing: typing, ExpiresIn: expiresIn}
+	eventType := usecase.EventTypingStopped
+	if typing {
+		eventType = usecase.EventTypingStarted
+	}
+	publisher.Publish(pair.To, realtime.Event{Type: eventType, Data: signal})
+}
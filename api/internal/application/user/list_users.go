@@ -4,6 +4,7 @@ import (
 	"context"
 	"hilo-api/internal/domain/do"
 	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/tracing"
 )
 
 // ListUsersUseCase handles listing all users
@@ -19,6 +20,9 @@ func NewListUsersUseCase(userRepo repository.UserRepository) *ListUsersUseCase {
 }
 
 // Execute retrieves all users with pagination
-func (uc *ListUsersUseCase) Execute(ctx context.Context, limit, offset int) ([]*do.User, error) {
+func (uc *ListUsersUseCase) Execute(ctx context.Context, limit, offset int) (_ []*do.User, err error) {
+	ctx, span := tracing.Start(ctx, "user.ListUsers")
+	defer tracing.End(span, &err)
+
 	return uc.userRepo.FindAll(ctx, limit, offset)
 }
@@ -0,0 +1,304 @@
+package do
+
+import (
+	"errors"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptyAttachment          = errors.New("attachment is empty")                                   // problem:422
+	ErrAttachmentTooLarge       = errors.New("attachment is larger than allowed")                     // problem:413
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")                        // problem:415
+	ErrTooManyAttachments       = errors.New("message has too many attachments")                      // problem:422
+	ErrAttachmentUnavailable    = errors.New("attachment was not uploaded by sender or already sent") // problem:422
+	ErrUploadOffsetMismatch     = errors.New("upload offset does not match the bytes received")       // problem:409
+	ErrUploadTooLong            = errors.New("chunk runs past the declared upload size")              // problem:422
+)
+
+const (
+	// MaxAttachmentsPerMessage bounds the files one message carries
+	MaxAttachmentsPerMessage = 10
+	// maxFilenameLength is in bytes, what most file systems accept
+	maxFilenameLength = 255
+)
+
+// ProcessingState is how far thumbnailing of an image attachment got
+type ProcessingState string
+
+const (
+	// ProcessingNone is for files that are not thumbnailed
+	ProcessingNone    ProcessingState = ""
+	ProcessingPending ProcessingState = "pending"
+	ProcessingReady   ProcessingState = "ready"
+	ProcessingFailed  ProcessingState = "failed"
+)
+
+// Thumbnail is a fixed size every processed image is scaled down to
+type Thumbnail struct {
+	Name string
+	// Edge is the longest side in pixels
+	Edge int
+}
+
+// Thumbnails are the variants made of each image, smallest first
+var Thumbnails = []Thumbnail{
+	{Name: "small", Edge: 160},
+	{Name: "medium", Edge: 480},
+}
+
+// processableTypes are the image types thumbnails are made of
+var processableTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ImageInfo is what processing found out about an image attachment
+type ImageInfo struct {
+	// Width and Height are as the image is displayed, after EXIF rotation
+	Width    int
+	Height   int
+	Blurhash string
+	Variants []AttachmentVariant
+}
+
+// AttachmentVariant is a scaled down copy of an image attachment
+type AttachmentVariant struct {
+	Name        string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+}
+
+// Attachment is a file a user uploaded; it belongs to no message until the
+// owner sends one with it
+type Attachment struct {
+	id          uuid.UUID
+	ownerID     uuid.UUID
+	messageID   uuid.UUID
+	filename    string
+	contentType string
+	size        int64
+	checksum    string
+	createdAt   time.Time
+	processing  ProcessingState
+	attempts    int
+	image       ImageInfo
+}
+
+// AttachmentPolicy is what an uploaded file has to satisfy
+type AttachmentPolicy struct {
+	MaxSize int64
+	// AllowedTypes are media types, type/* allows a whole family
+	AllowedTypes []string
+}
+
+// CheckSize rejects empty files and files above MaxSize
+func (p AttachmentPolicy) CheckSize(size int64) error {
+	if size <= 0 {
+		return ErrEmptyAttachment
+	}
+	if size > p.MaxSize {
+		return ErrAttachmentTooLarge
+	}
+	return nil
+}
+
+// CheckType rejects media types AllowedTypes does not list; parameters such
+// as charset are ignored
+func (p AttachmentPolicy) CheckType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ErrAttachmentTypeNotAllowed
+	}
+	family, _, _ := strings.Cut(mediaType, "/")
+	for _, allowed := range p.AllowedTypes {
+		if allowed == mediaType || allowed == family+"/*" {
+			return nil
+		}
+	}
+	return ErrAttachmentTypeNotAllowed
+}
+
+// NewAttachment creates an attachment for a file whose type was sniffed
+// from its content, never taken from the client
+func NewAttachment(ownerID uuid.UUID, filename, contentType string, size int64, checksum string, policy AttachmentPolicy) (*Attachment, error) {
+	if err := policy.CheckSize(size); err != nil {
+		return nil, err
+	}
+	if err := policy.CheckType(contentType); err != nil {
+		return nil, err
+	}
+
+	processing := ProcessingNone
+	if mediaType, _, _ := mime.ParseMediaType(contentType); slices.Contains(processableTypes, mediaType) {
+		processing = ProcessingPending
+	}
+
+	return &Attachment{
+		id:          uuid.New(),
+		ownerID:     ownerID,
+		filename:    SanitizeFilename(filename),
+		contentType: contentType,
+		size:        size,
+		checksum:    checksum,
+		createdAt:   time.Now(),
+		processing:  processing,
+	}, nil
+}
+
+// ReconstructAttachment rebuilds attachment from database (no validation)
+func ReconstructAttachment(id, ownerID, messageID uuid.UUID, filename, contentType string, size int64, checksum string, createdAt time.Time) *Attachment {
+	return &Attachment{
+		id:          id,
+		ownerID:     ownerID,
+		messageID:   messageID,
+		filename:    filename,
+		contentType: contentType,
+		size:        size,
+		checksum:    checksum,
+		createdAt:   createdAt,
+	}
+}
+
+// RestoreProcessing sets the processing outcome read from the database
+func (a *Attachment) RestoreProcessing(state ProcessingState, attempts int, image ImageInfo) {
+	a.processing = state
+	a.attempts = attempts
+	a.image = image
+}
+
+// CompleteProcessing records the dimensions and thumbnails of the image
+func (a *Attachment) CompleteProcessing(image ImageInfo) {
+	a.processing = ProcessingReady
+	a.image = image
+}
+
+// FailProcessing records a failed attempt and reports whether another one
+// is due; a permanent failure, such as a file that is no image, or running
+// out of attempts gives up
+func (a *Attachment) FailProcessing(permanent bool, maxAttempts int) (retry bool) {
+	if permanent || a.attempts >= maxAttempts {
+		a.processing = ProcessingFailed
+		return false
+	}
+	return true
+}
+
+// SanitizeFilename keeps the base name a client sent without control
+// characters, cut to a length file systems accept
+func SanitizeFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+
+	for len(filename) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	if filename == "" || filename == "." || filename == "/" {
+		return "file"
+	}
+	return filename
+}
+
+// StorageKey is where the blob store keeps the file
+func (a *Attachment) StorageKey() string {
+	return "attachments/" + a.ownerID.String() + "/" + a.id.String()
+}
+
+// VariantKey is where the blob store keeps the named thumbnail
+func (a *Attachment) VariantKey(name string) string {
+	return "thumbnails/" + a.ownerID.String() + "/" + a.id.String() + "/" + name
+}
+
+// Getters
+func (a *Attachment) ID() uuid.UUID               { return a.id }
+func (a *Attachment) OwnerID() uuid.UUID          { return a.ownerID }
+func (a *Attachment) MessageID() uuid.UUID        { return a.messageID }
+func (a *Attachment) IsSent() bool                { return a.messageID != uuid.Nil }
+func (a *Attachment) Filename() string            { return a.filename }
+func (a *Attachment) ContentType() string         { return a.contentType }
+func (a *Attachment) Size() int64                 { return a.size }
+func (a *Attachment) Checksum() string            { return a.checksum }
+func (a *Attachment) CreatedAt() time.Time        { return a.createdAt }
+func (a *Attachment) Processing() ProcessingState { return a.processing }
+func (a *Attachment) ProcessingAttempts() int     { return a.attempts }
+func (a *Attachment) Image() ImageInfo            { return a.image }
+
+// Upload is a file arriving in chunks; it becomes an attachment once all
+// of its declared size was received
+type Upload struct {
+	id        uuid.UUID
+	ownerID   uuid.UUID
+	filename  string
+	size      int64
+	received  int64
+	createdAt time.Time
+}
+
+// NewUpload starts an upload of size bytes; only its size can be checked
+// before the content arrives
+func NewUpload(ownerID uuid.UUID, filename string, size int64, policy AttachmentPolicy) (*Upload, error) {
+	if err := policy.CheckSize(size); err != nil {
+		return nil, err
+	}
+
+	return &Upload{
+		id:        uuid.New(),
+		ownerID:   ownerID,
+		filename:  SanitizeFilename(filename),
+		size:      size,
+		createdAt: time.Now(),
+	}, nil
+}
+
+// ReconstructUpload rebuilds upload from database (no validation)
+func ReconstructUpload(id, ownerID uuid.UUID, filename string, size, received int64, createdAt time.Time) *Upload {
+	return &Upload{
+		id:        id,
+		ownerID:   ownerID,
+		filename:  filename,
+		size:      size,
+		received:  received,
+		createdAt: createdAt,
+	}
+}
+
+// Accept checks that a chunk of length bytes at offset continues the
+// upload; length is -1 when unknown
+func (u *Upload) Accept(offset, length int64) error {
+	if offset != u.received {
+		return ErrUploadOffsetMismatch
+	}
+	if length > u.Remaining() {
+		return ErrUploadTooLong
+	}
+	return nil
+}
+
+// Advance records that received bytes of the upload have arrived
+func (u *Upload) Advance(received int64) {
+	u.received = received
+}
+
+// Getters
+func (u *Upload) ID() uuid.UUID        { return u.id }
+func (u *Upload) OwnerID() uuid.UUID   { return u.ownerID }
+func (u *Upload) Filename() string     { return u.filename }
+func (u *Upload) Size() int64          { return u.size }
+func (u *Upload) Received() int64      { return u.received }
+func (u *Upload) Remaining() int64     { return u.size - u.received }
+func (u *Upload) IsComplete() bool     { return u.received >= u.size }
+func (u *Upload) CreatedAt() time.Time { return u.createdAt }
+func (u *Upload) StagingKey() string   { return u.id.String() }
@@ -0,0 +1,178 @@
+package do
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = AttachmentPolicy{MaxSize: 1 << 20, AllowedTypes: []string{"image/*", "application/pdf", "text/plain"}}
+
+func TestNewAttachment(t *testing.T) {
+	ownerID := uuid.New()
+
+	t.Run("create valid attachment", func(t *testing.T) {
+		attachment, err := NewAttachment(ownerID, "cat.png", "image/png", 1024, "abc", testPolicy)
+
+		require.NoError(t, err)
+		assert.Equal(t, ownerID, attachment.OwnerID())
+		assert.Equal(t, "cat.png", attachment.Filename())
+		assert.False(t, attachment.IsSent())
+		assert.Equal(t, "attachments/"+ownerID.String()+"/"+attachment.ID().String(), attachment.StorageKey())
+	})
+
+	t.Run("type parameters are ignored", func(t *testing.T) {
+		_, err := NewAttachment(ownerID, "notes.txt", "text/plain; charset=utf-8", 10, "abc", testPolicy)
+		assert.NoError(t, err)
+	})
+
+	t.Run("type not allowed", func(t *testing.T) {
+		_, err := NewAttachment(ownerID, "run.exe", "application/x-msdownload", 10, "abc", testPolicy)
+		assert.Equal(t, ErrAttachmentTypeNotAllowed, err)
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		_, err := NewAttachment(ownerID, "empty.png", "image/png", 0, "abc", testPolicy)
+		assert.Equal(t, ErrEmptyAttachment, err)
+
+		_, err = NewAttachment(ownerID, "huge.png", "image/png", 2<<20, "abc", testPolicy)
+		assert.Equal(t, ErrAttachmentTooLarge, err)
+	})
+}
+
+func TestAttachmentProcessing(t *testing.T) {
+	ownerID := uuid.New()
+
+	t.Run("only decodable images are processed", func(t *testing.T) {
+		photo, err := NewAttachment(ownerID, "cat.jpg", "image/jpeg", 10, "abc", testPolicy)
+		require.NoError(t, err)
+		assert.Equal(t, ProcessingPending, photo.Processing())
+
+		drawing, err := NewAttachment(ownerID, "cat.svg", "image/svg+xml", 10, "abc", testPolicy)
+		require.NoError(t, err)
+		assert.Equal(t, ProcessingNone, drawing.Processing())
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		photo, _ := NewAttachment(ownerID, "cat.png", "image/png", 10, "abc", testPolicy)
+		info := ImageInfo{Width: 800, Height: 600, Blurhash: "LEHV6nWB2yk8", Variants: []AttachmentVariant{{Name: "small", Width: 160, Height: 120}}}
+
+		photo.CompleteProcessing(info)
+		assert.Equal(t, ProcessingReady, photo.Processing())
+		assert.Equal(t, info, photo.Image())
+		assert.Equal(t, "thumbnails/"+ownerID.String()+"/"+photo.ID().String()+"/small", photo.VariantKey("small"))
+	})
+
+	t.Run("retries until attempts run out", func(t *testing.T) {
+		photo, _ := NewAttachment(ownerID, "cat.png", "image/png", 10, "abc", testPolicy)
+
+		photo.RestoreProcessing(ProcessingPending, 2, ImageInfo{})
+		assert.True(t, photo.FailProcessing(false, 3))
+		assert.Equal(t, ProcessingPending, photo.Processing())
+
+		photo.RestoreProcessing(ProcessingPending, 3, ImageInfo{})
+		assert.False(t, photo.FailProcessing(false, 3))
+		assert.Equal(t, ProcessingFailed, photo.Processing())
+	})
+
+	t.Run("permanent failure gives up at once", func(t *testing.T) {
+		photo, _ := NewAttachment(ownerID, "cat.png", "image/png", 10, "abc", testPolicy)
+		photo.RestoreProcessing(ProcessingPending, 1, ImageInfo{})
+
+		assert.False(t, photo.FailProcessing(true, 3))
+		assert.Equal(t, ProcessingFailed, photo.Processing())
+	})
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	assert.Equal(t, "passwd", SanitizeFilename("../../etc/passwd"))
+	assert.Equal(t, "report.pdf", SanitizeFilename(`C:\Users\me\report.pdf`))
+	assert.Equal(t, "ab.txt", SanitizeFilename("a\x00b\n.txt"))
+	assert.Equal(t, "照片.jpg", SanitizeFilename("照片.jpg"))
+	assert.Equal(t, "file", SanitizeFilename(""))
+	assert.Equal(t, "file", SanitizeFilename("/"))
+
+	long := SanitizeFilename(strings.Repeat("照", 100))
+	assert.LessOrEqual(t, len(long), 255)
+	assert.True(t, strings.HasPrefix(long, "照照"))
+}
+
+func TestMessageWithAttachments(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+	upload := func(ownerID uuid.UUID) *Attachment {
+		attachment, err := NewAttachment(ownerID, "cat.png", "image/png", 10, "abc", testPolicy)
+		require.NoError(t, err)
+		return attachment
+	}
+
+	t.Run("attachments make content optional", func(t *testing.T) {
+		first, second := upload(senderID), upload(senderID)
+		msg, err := NewMessageWithID(uuid.Nil, senderID, receiverID, "", first, second)
+
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID(), second.ID()}, msg.AttachmentIDs())
+		assert.Equal(t, msg.ID(), first.MessageID())
+		assert.True(t, second.IsSent())
+
+		_, err = msg.Edit(senderID, "", time.Minute)
+		assert.NoError(t, err, "a caption can be cleared")
+
+		require.NoError(t, msg.Retract(senderID, time.Minute))
+		assert.Empty(t, msg.Attachments())
+	})
+
+	t.Run("only unsent uploads of the sender", func(t *testing.T) {
+		_, err := NewMessageWithID(uuid.Nil, senderID, receiverID, "", upload(receiverID))
+		assert.Equal(t, ErrAttachmentUnavailable, err)
+
+		attachment := upload(senderID)
+		_, err = NewMessageWithID(uuid.Nil, senderID, receiverID, "", attachment, attachment)
+		assert.Equal(t, ErrAttachmentUnavailable, err)
+		assert.False(t, attachment.IsSent(), "a rejected message leaves its attachments unsent")
+
+		_, err = NewMessageWithID(uuid.Nil, senderID, receiverID, "", attachment)
+		require.NoError(t, err)
+		_, err = NewMessageWithID(uuid.Nil, senderID, receiverID, "", attachment)
+		assert.Equal(t, ErrAttachmentUnavailable, err)
+	})
+
+	t.Run("too many attachments", func(t *testing.T) {
+		attachments := make([]*Attachment, MaxAttachmentsPerMessage+1)
+		for i := range attachments {
+			attachments[i] = upload(senderID)
+		}
+		_, err := NewMessageWithID(uuid.Nil, senderID, receiverID, "", attachments...)
+		assert.Equal(t, ErrTooManyAttachments, err)
+	})
+
+	t.Run("attachments change the request hash", func(t *testing.T) {
+		attachment := upload(senderID)
+		assert.Equal(t, RequestHash(receiverID, uuid.Nil, "hi"), RequestHash(receiverID, uuid.Nil, "hi"))
+		assert.NotEqual(t, RequestHash(receiverID, uuid.Nil, "hi"), RequestHash(receiverID, uuid.Nil, "hi", attachment.ID()))
+	})
+}
+
+func TestUpload(t *testing.T) {
+	upload, err := NewUpload(uuid.New(), "video.mp4", 100, testPolicy)
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrUploadOffsetMismatch, upload.Accept(10, 10))
+	assert.Equal(t, ErrUploadTooLong, upload.Accept(0, 101))
+	assert.NoError(t, upload.Accept(0, -1))
+
+	upload.Advance(60)
+	assert.Equal(t, int64(40), upload.Remaining())
+	assert.False(t, upload.IsComplete())
+	assert.NoError(t, upload.Accept(60, 40))
+
+	upload.Advance(100)
+	assert.True(t, upload.IsComplete())
+
+	_, err = NewUpload(uuid.New(), "huge.mp4", 2<<20, testPolicy)
+	assert.Equal(t, ErrAttachmentTooLarge, err)
+}
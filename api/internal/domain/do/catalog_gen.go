@@ -0,0 +1,37 @@
+// Code generated by errcatalog. DO NOT EDIT.
+
+package do
+
+import "hilo-api/pkg/errorCatcher"
+
+func init() {
+	errorCatcher.RegisterProblems(
+		errorCatcher.ProblemEntry{Err: ErrAttachmentTooLarge, Code: "ATTACHMENT_TOO_LARGE", Title: "Attachment is larger than allowed", Status: 413},
+		errorCatcher.ProblemEntry{Err: ErrAttachmentTypeNotAllowed, Code: "ATTACHMENT_TYPE_NOT_ALLOWED", Title: "Attachment type is not allowed", Status: 415},
+		errorCatcher.ProblemEntry{Err: ErrAttachmentUnavailable, Code: "ATTACHMENT_UNAVAILABLE", Title: "Attachment was not uploaded by sender or already sent", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrCannotSendToSelf, Code: "CANNOT_SEND_TO_SELF", Title: "Cannot send message to yourself", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrEditWindowClosed, Code: "EDIT_WINDOW_CLOSED", Title: "Message can no longer be edited", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrEmptyAttachment, Code: "EMPTY_ATTACHMENT", Title: "Attachment is empty", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrEmptyContent, Code: "EMPTY_CONTENT", Title: "Message content cannot be empty", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrEmptySearchQuery, Code: "EMPTY_SEARCH_QUERY", Title: "Search query is empty", Status: 400},
+		errorCatcher.ProblemEntry{Err: ErrEmptyUsername, Code: "EMPTY_USERNAME", Title: "Username cannot be empty", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrInvalidCredentials, Code: "INVALID_CREDENTIALS", Title: "Invalid email or password", Status: 401},
+		errorCatcher.ProblemEntry{Err: ErrInvalidEmail, Code: "INVALID_EMAIL", Title: "Invalid email format", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrInvalidEmoji, Code: "INVALID_EMOJI", Title: "Reaction must be a single emoji", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrInvalidIdempotencyKey, Code: "INVALID_IDEMPOTENCY_KEY", Title: "Idempotency key must be 1 to 255 printable characters", Status: 400},
+		errorCatcher.ProblemEntry{Err: ErrInvalidSearchCursor, Code: "INVALID_SEARCH_CURSOR", Title: "Search cursor is not one this server gave", Status: 400},
+		errorCatcher.ProblemEntry{Err: ErrInvalidSearchPeriod, Code: "INVALID_SEARCH_PERIOD", Title: "Search period must end after it starts", Status: 400},
+		errorCatcher.ProblemEntry{Err: ErrMessageDeleted, Code: "MESSAGE_DELETED", Title: "Message was deleted", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrNotReceiver, Code: "NOT_RECEIVER", Title: "Only receiver can mark message as delivered or read", Status: 403},
+		errorCatcher.ProblemEntry{Err: ErrNotSender, Code: "NOT_SENDER", Title: "Only sender can edit or delete message", Status: 403},
+		errorCatcher.ProblemEntry{Err: ErrReplyOutsideConversation, Code: "REPLY_OUTSIDE_CONVERSATION", Title: "Can only reply to a message in the same conversation", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrRetractWindowClosed, Code: "RETRACT_WINDOW_CLOSED", Title: "Message can no longer be deleted for everyone", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrSearchQueryTooLong, Code: "SEARCH_QUERY_TOO_LONG", Title: "Search query is longer than allowed", Status: 400},
+		errorCatcher.ProblemEntry{Err: ErrTooManyAttachments, Code: "TOO_MANY_ATTACHMENTS", Title: "Message has too many attachments", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrTypingToSelf, Code: "TYPING_TO_SELF", Title: "Cannot send typing signals to yourself", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrUploadOffsetMismatch, Code: "UPLOAD_OFFSET_MISMATCH", Title: "Upload offset does not match the bytes received", Status: 409},
+		errorCatcher.ProblemEntry{Err: ErrUploadTooLong, Code: "UPLOAD_TOO_LONG", Title: "Chunk runs past the declared upload size", Status: 422},
+		errorCatcher.ProblemEntry{Err: ErrUserSuspended, Code: "USER_SUSPENDED", Title: "User account is suspended", Status: 403},
+		errorCatcher.ProblemEntry{Err: ErrWeakPassword, Code: "WEAK_PASSWORD", Title: "Password must be at least 8 characters", Status: 422},
+	)
+}
@@ -0,0 +1,102 @@
+package do
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key a client may send
+const MaxIdempotencyKeyLength = 255
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 255 printable characters") // problem:400
+)
+
+// IdempotencyKey remembers which message a sender's request created, so a
+// retry of the same request returns that message instead of a duplicate
+type IdempotencyKey struct {
+	senderID    uuid.UUID
+	key         string
+	requestHash string
+	messageID   uuid.UUID
+	createdAt   time.Time
+}
+
+// NewIdempotencyKey records that key created msg
+func NewIdempotencyKey(key string, msg *Message) (*IdempotencyKey, error) {
+	if err := ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+	return &IdempotencyKey{
+		senderID:    msg.SenderID(),
+		key:         key,
+		requestHash: RequestHash(msg.ReceiverID(), msg.ReplyToID(), msg.Content(), msg.AttachmentIDs()...),
+		messageID:   msg.ID(),
+		createdAt:   time.Now(),
+	}, nil
+}
+
+// ReconstructIdempotencyKey rebuilds an idempotency key from database (no validation)
+func ReconstructIdempotencyKey(senderID uuid.UUID, key, requestHash string, messageID uuid.UUID, createdAt time.Time) *IdempotencyKey {
+	return &IdempotencyKey{
+		senderID:    senderID,
+		key:         key,
+		requestHash: requestHash,
+		messageID:   messageID,
+		createdAt:   createdAt,
+	}
+}
+
+// ValidateIdempotencyKey checks the length and that key is printable ASCII
+func ValidateIdempotencyKey(key string) error {
+	if len(key) == 0 || len(key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
+
+// RequestHash fingerprints what a send asked for, a key reused for a
+// different request must not replay the first one. replyToID is uuid.Nil
+// for a message that replies to nothing; such a message without
+// attachments hashes as it did before replies and attachments existed.
+func RequestHash(receiverID, replyToID uuid.UUID, content string, attachmentIDs ...uuid.UUID) string {
+	request := receiverID.String() + "\x00" + content
+	if replyToID != uuid.Nil {
+		request += "\x00" + replyToID.String()
+	}
+	if len(attachmentIDs) > 0 {
+		request += "\x00attachments"
+		for _, id := range attachmentIDs {
+			request += "\x00" + id.String()
+		}
+	}
+	sum := sha256.Sum256([]byte(request))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether a send of content to receiverID, replying to
+// replyToID with attachmentIDs, is the request the key was first used for
+func (k *IdempotencyKey) Matches(receiverID, replyToID uuid.UUID, content string, attachmentIDs ...uuid.UUID) bool {
+	return k.requestHash == RequestHash(receiverID, replyToID, content, attachmentIDs...)
+}
+
+// Expired reports whether the key is older than ttl at now
+func (k *IdempotencyKey) Expired(now time.Time, ttl time.Duration) bool {
+	return !now.Before(k.createdAt.Add(ttl))
+}
+
+// Getters
+func (k *IdempotencyKey) SenderID() uuid.UUID  { return k.senderID }
+func (k *IdempotencyKey) Key() string          { return k.key }
+func (k *IdempotencyKey) RequestHash() string  { return k.requestHash }
+func (k *IdempotencyKey) MessageID() uuid.UUID { return k.messageID }
+func (k *IdempotencyKey) CreatedAt() time.Time { return k.createdAt }
@@ -0,0 +1,59 @@
+package do
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMessageWithID(t *testing.T) {
+	id := uuid.New()
+	msg, err := NewMessageWithID(id, uuid.New(), uuid.New(), "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, id, msg.ID())
+
+	msg, err = NewMessageWithID(uuid.Nil, uuid.New(), uuid.New(), "Hello")
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, msg.ID())
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	assert.NoError(t, ValidateIdempotencyKey("8e03978e-40d5-43e8-bc93-6894a57f9324"))
+	assert.NoError(t, ValidateIdempotencyKey(strings.Repeat("k", MaxIdempotencyKeyLength)))
+
+	for _, key := range []string{"", strings.Repeat("k", MaxIdempotencyKeyLength+1), "line\nbreak", "ключ"} {
+		assert.ErrorIs(t, ValidateIdempotencyKey(key), ErrInvalidIdempotencyKey, key)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	receiverID := uuid.New()
+	msg, err := NewMessage(uuid.New(), receiverID, "Hello")
+	require.NoError(t, err)
+
+	key, err := NewIdempotencyKey("retry-1", msg)
+	require.NoError(t, err)
+	assert.Equal(t, msg.SenderID(), key.SenderID())
+	assert.Equal(t, msg.ID(), key.MessageID())
+
+	t.Run("matches only the same request", func(t *testing.T) {
+		assert.True(t, key.Matches(receiverID, uuid.Nil, "Hello"))
+		assert.False(t, key.Matches(receiverID, uuid.Nil, "Hello!"))
+		assert.False(t, key.Matches(uuid.New(), uuid.Nil, "Hello"))
+		assert.False(t, key.Matches(receiverID, uuid.New(), "Hello"))
+	})
+
+	t.Run("expires after ttl", func(t *testing.T) {
+		assert.False(t, key.Expired(key.CreatedAt().Add(time.Hour), 24*time.Hour))
+		assert.True(t, key.Expired(key.CreatedAt().Add(24*time.Hour), 24*time.Hour))
+	})
+
+	t.Run("rejects invalid key", func(t *testing.T) {
+		_, err := NewIdempotencyKey("", msg)
+		assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+	})
+}
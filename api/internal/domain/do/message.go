@@ -8,19 +8,62 @@ import (
 )
 
 var (
-	ErrCannotSendToSelf = errors.New("cannot send message to yourself")
-	ErrEmptyContent     = errors.New("message content cannot be empty")
-	ErrNotReceiver      = errors.New("only receiver can mark message as read")
+	ErrCannotSendToSelf         = errors.New("cannot send message to yourself")                      // problem:422
+	ErrEmptyContent             = errors.New("message content cannot be empty")                      // problem:422
+	ErrNotReceiver              = errors.New("only receiver can mark message as delivered or read")  // problem:403
+	ErrNotSender                = errors.New("only sender can edit or delete message")               // problem:403
+	ErrEditWindowClosed         = errors.New("message can no longer be edited")                      // problem:422
+	ErrMessageDeleted           = errors.New("message was deleted")                                  // problem:422
+	ErrRetractWindowClosed      = errors.New("message can no longer be deleted for everyone")        // problem:422
+	ErrReplyOutsideConversation = errors.New("can only reply to a message in the same conversation") // problem:422
+)
+
+// QuotePreviewLength is how many characters of the replied-to message a
+// quote carries
+const QuotePreviewLength = 100
+
+// MessageState is how far a message got on its way to the receiver; it
+// only ever moves forward
+type MessageState string
+
+// Message states
+const (
+	MessageSent      MessageState = "sent"
+	MessageDelivered MessageState = "delivered"
+	MessageRead      MessageState = "read"
 )
 
 // Message represents a chat message between two users
 type Message struct {
-	id         uuid.UUID
-	senderID   uuid.UUID
-	receiverID uuid.UUID
+	id          uuid.UUID
+	senderID    uuid.UUID
+	receiverID  uuid.UUID
+	content     string
+	createdAt   time.Time
+	deliveredAt *time.Time
+	readAt      *time.Time
+	editedAt    *time.Time
+	deletedAt   *time.Time
+	quote       *MessageQuote
+	attachments []*Attachment
+	reactions   []*ReactionSummary
+}
+
+// MessageQuote is the compact preview of the message a reply answers
+type MessageQuote struct {
+	id        uuid.UUID
+	senderID  uuid.UUID
+	content   string
+	truncated bool
+	deleted   bool
+}
+
+// MessageRevision is a version of a message's content that an edit replaced
+type MessageRevision struct {
+	messageID  uuid.UUID
 	content    string
 	createdAt  time.Time
-	readAt     *time.Time
+	replacedAt time.Time
 }
 
 // ConversationPreview represents the latest message in a conversation
@@ -30,38 +73,131 @@ type ConversationPreview struct {
 	UnreadCount int
 }
 
+// ConversationRead is the single receipt for every message of OtherUserID
+// the reader read at once, all those created up to UpTo
+type ConversationRead struct {
+	ReaderID    uuid.UUID
+	OtherUserID uuid.UUID
+	UpTo        time.Time
+	ReadAt      time.Time
+	Count       int
+}
+
+// Statistics is a system-wide snapshot of users, messages and conversations.
+// The Since fields only count activity at or after Since.
+type Statistics struct {
+	Users               int
+	SuspendedUsers      int
+	Messages            int
+	UnreadMessages      int
+	Conversations       int
+	Since               time.Time
+	MessagesSince       int
+	ActiveConversations int
+}
+
 // NewMessage creates a new message with business rules enforced
 func NewMessage(senderID, receiverID uuid.UUID, content string) (*Message, error) {
+	return NewMessageWithID(uuid.New(), senderID, receiverID, content)
+}
+
+// NewMessageWithID creates a message under an id the client generated, so a
+// retried send can be recognised; uuid.Nil falls back to a fresh id. The
+// attachments have to be unsent uploads of the sender, with them the
+// content is an optional caption.
+func NewMessageWithID(id, senderID, receiverID uuid.UUID, content string, attachments ...*Attachment) (*Message, error) {
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
 	if senderID == receiverID {
 		return nil, ErrCannotSendToSelf
 	}
 
-	if content == "" {
+	if content == "" && len(attachments) == 0 {
 		return nil, ErrEmptyContent
 	}
 
+	if len(attachments) > MaxAttachmentsPerMessage {
+		return nil, ErrTooManyAttachments
+	}
+
+	seen := make(map[uuid.UUID]bool, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.ownerID != senderID || attachment.IsSent() || seen[attachment.id] {
+			return nil, ErrAttachmentUnavailable
+		}
+		seen[attachment.id] = true
+	}
+	for _, attachment := range attachments {
+		attachment.messageID = id
+	}
+
 	return &Message{
-		id:         uuid.New(),
-		senderID:   senderID,
-		receiverID: receiverID,
-		content:    content,
-		createdAt:  time.Now(),
+		id:          id,
+		senderID:    senderID,
+		receiverID:  receiverID,
+		content:     content,
+		createdAt:   time.Now(),
+		attachments: attachments,
 	}, nil
 }
 
 // ReconstructMessage rebuilds message from database (no validation)
-func ReconstructMessage(id, senderID, receiverID uuid.UUID, content string, createdAt time.Time, readAt *time.Time) *Message {
+func ReconstructMessage(id, senderID, receiverID uuid.UUID, content string, createdAt time.Time, deliveredAt, readAt, editedAt, deletedAt *time.Time, quote *MessageQuote) *Message {
 	return &Message{
-		id:         id,
-		senderID:   senderID,
-		receiverID: receiverID,
+		id:          id,
+		senderID:    senderID,
+		receiverID:  receiverID,
+		content:     content,
+		createdAt:   createdAt,
+		deliveredAt: deliveredAt,
+		readAt:      readAt,
+		editedAt:    editedAt,
+		deletedAt:   deletedAt,
+		quote:       quote,
+	}
+}
+
+// NewMessageQuote builds the preview of a replied-to message, cutting its
+// content to QuotePreviewLength characters
+func NewMessageQuote(id, senderID uuid.UUID, content string, deleted bool) *MessageQuote {
+	quote := &MessageQuote{id: id, senderID: senderID, content: content, deleted: deleted}
+	if runes := []rune(content); len(runes) > QuotePreviewLength {
+		quote.content = string(runes[:QuotePreviewLength])
+		quote.truncated = true
+	}
+	return quote
+}
+
+// ReconstructMessageRevision rebuilds revision from database (no validation)
+func ReconstructMessageRevision(messageID uuid.UUID, content string, createdAt, replacedAt time.Time) *MessageRevision {
+	return &MessageRevision{
+		messageID:  messageID,
 		content:    content,
 		createdAt:  createdAt,
-		readAt:     readAt,
+		replacedAt: replacedAt,
 	}
 }
 
-// MarkAsRead marks the message as read by the receiver
+// MarkAsDelivered records that the message reached a device of the
+// receiver; a message already delivered or read stays as it is
+func (m *Message) MarkAsDelivered(receiverID uuid.UUID) error {
+	if receiverID != m.receiverID {
+		return ErrNotReceiver
+	}
+
+	if m.deliveredAt != nil {
+		return nil // already delivered, idempotent
+	}
+
+	now := time.Now()
+	m.deliveredAt = &now
+	return nil
+}
+
+// MarkAsRead marks the message as read by the receiver, delivering it on
+// the way if no device acknowledged it yet
 func (m *Message) MarkAsRead(readerID uuid.UUID) error {
 	if readerID != m.receiverID {
 		return ErrNotReceiver
@@ -72,15 +208,168 @@ func (m *Message) MarkAsRead(readerID uuid.UUID) error {
 	}
 
 	now := time.Now()
+	if m.deliveredAt == nil {
+		m.deliveredAt = &now
+	}
 	m.readAt = &now
 	return nil
 }
 
+// Edit replaces the content and returns the version it replaced. Only the
+// sender may edit, and only within window of sending; an edit that leaves
+// the content as it was changes nothing and returns no revision.
+func (m *Message) Edit(editorID uuid.UUID, content string, window time.Duration) (*MessageRevision, error) {
+	if editorID != m.senderID {
+		return nil, ErrNotSender
+	}
+
+	if m.deletedAt != nil {
+		return nil, ErrMessageDeleted
+	}
+
+	if content == "" && len(m.attachments) == 0 {
+		return nil, ErrEmptyContent
+	}
+
+	now := time.Now()
+	if now.Sub(m.createdAt) > window {
+		return nil, ErrEditWindowClosed
+	}
+
+	if content == m.content {
+		return nil, nil
+	}
+
+	revision := &MessageRevision{
+		messageID:  m.id,
+		content:    m.content,
+		createdAt:  m.createdAt,
+		replacedAt: now,
+	}
+	if m.editedAt != nil {
+		revision.createdAt = *m.editedAt
+	}
+
+	m.content = content
+	m.editedAt = &now
+	return revision, nil
+}
+
+// Retract deletes the message for both participants: the content and
+// attachments are dropped and the message stays behind as a placeholder.
+// Only the sender may retract, and only within window of sending;
+// retracting twice is a no-op.
+func (m *Message) Retract(userID uuid.UUID, window time.Duration) error {
+	if userID != m.senderID {
+		return ErrNotSender
+	}
+
+	if m.deletedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if now.Sub(m.createdAt) > window {
+		return ErrRetractWindowClosed
+	}
+
+	m.content = ""
+	m.attachments = nil
+	m.deletedAt = &now
+	return nil
+}
+
+// ReplyTo makes the message a reply to parent, which has to be a message
+// of the same conversation that was not deleted for everyone
+func (m *Message) ReplyTo(parent *Message) error {
+	if !parent.IsParticipant(m.senderID) || !parent.IsParticipant(m.receiverID) {
+		return ErrReplyOutsideConversation
+	}
+
+	if parent.deletedAt != nil {
+		return ErrMessageDeleted
+	}
+
+	m.quote = NewMessageQuote(parent.id, parent.senderID, parent.content, false)
+	return nil
+}
+
+// IsParticipant reports whether userID sent or received the message
+func (m *Message) IsParticipant(userID uuid.UUID) bool {
+	return userID == m.senderID || userID == m.receiverID
+}
+
+// State tells whether the message was read, delivered or only sent
+func (m *Message) State() MessageState {
+	switch {
+	case m.readAt != nil:
+		return MessageRead
+	case m.deliveredAt != nil:
+		return MessageDelivered
+	default:
+		return MessageSent
+	}
+}
+
 // Getters
-func (m *Message) ID() uuid.UUID         { return m.id }
-func (m *Message) SenderID() uuid.UUID   { return m.senderID }
-func (m *Message) ReceiverID() uuid.UUID { return m.receiverID }
-func (m *Message) Content() string       { return m.content }
-func (m *Message) CreatedAt() time.Time  { return m.createdAt }
-func (m *Message) ReadAt() *time.Time    { return m.readAt }
-func (m *Message) IsRead() bool          { return m.readAt != nil }
+func (m *Message) ID() uuid.UUID           { return m.id }
+func (m *Message) SenderID() uuid.UUID     { return m.senderID }
+func (m *Message) ReceiverID() uuid.UUID   { return m.receiverID }
+func (m *Message) Content() string         { return m.content }
+func (m *Message) CreatedAt() time.Time    { return m.createdAt }
+func (m *Message) DeliveredAt() *time.Time { return m.deliveredAt }
+func (m *Message) IsDelivered() bool       { return m.deliveredAt != nil }
+func (m *Message) ReadAt() *time.Time      { return m.readAt }
+func (m *Message) IsRead() bool            { return m.readAt != nil }
+func (m *Message) EditedAt() *time.Time    { return m.editedAt }
+func (m *Message) IsEdited() bool          { return m.editedAt != nil }
+func (m *Message) DeletedAt() *time.Time   { return m.deletedAt }
+func (m *Message) IsDeleted() bool         { return m.deletedAt != nil }
+func (m *Message) Quote() *MessageQuote    { return m.quote }
+func (m *Message) IsReply() bool           { return m.quote != nil }
+
+// Attachments are the files sent with the message, in the order sent
+func (m *Message) Attachments() []*Attachment { return m.attachments }
+
+// AttachmentIDs lists the ids of Attachments in order
+func (m *Message) AttachmentIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, len(m.attachments))
+	for i, attachment := range m.attachments {
+		ids[i] = attachment.id
+	}
+	return ids
+}
+
+// RestoreAttachments sets the attachments loaded from database (no validation)
+func (m *Message) RestoreAttachments(attachments []*Attachment) {
+	m.attachments = attachments
+}
+
+// Reactions are the emoji on the message for the viewer it was loaded for,
+// nil unless AttachReactions was called
+func (m *Message) Reactions() []*ReactionSummary { return m.reactions }
+
+// AttachReactions sets the reactions as the viewer the message was loaded
+// for sees them
+func (m *Message) AttachReactions(reactions []*ReactionSummary) {
+	m.reactions = reactions
+}
+
+// ReplyToID is uuid.Nil unless the message is a reply
+func (m *Message) ReplyToID() uuid.UUID {
+	if m.quote == nil {
+		return uuid.Nil
+	}
+	return m.quote.id
+}
+
+func (q *MessageQuote) ID() uuid.UUID       { return q.id }
+func (q *MessageQuote) SenderID() uuid.UUID { return q.senderID }
+func (q *MessageQuote) Content() string     { return q.content }
+func (q *MessageQuote) IsTruncated() bool   { return q.truncated }
+func (q *MessageQuote) IsDeleted() bool     { return q.deleted }
+
+func (r *MessageRevision) MessageID() uuid.UUID  { return r.messageID }
+func (r *MessageRevision) Content() string       { return r.content }
+func (r *MessageRevision) CreatedAt() time.Time  { return r.createdAt }
+func (r *MessageRevision) ReplacedAt() time.Time { return r.replacedAt }
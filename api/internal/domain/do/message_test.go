@@ -1,8 +1,10 @@
 package do
 
 import (
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -102,7 +104,7 @@ func TestReconstructMessage(t *testing.T) {
 	readAt := time.Now()
 
 	t.Run("reconstruct unread message", func(t *testing.T) {
-		msg := ReconstructMessage(id, senderID, receiverID, "Content", createdAt, nil)
+		msg := ReconstructMessage(id, senderID, receiverID, "Content", createdAt, nil, nil, nil, nil, nil)
 
 		assert.Equal(t, id, msg.ID())
 		assert.Equal(t, senderID, msg.SenderID())
@@ -111,13 +113,219 @@ func TestReconstructMessage(t *testing.T) {
 		assert.Equal(t, createdAt, msg.CreatedAt())
 		assert.False(t, msg.IsRead())
 		assert.Nil(t, msg.ReadAt())
+		assert.Equal(t, MessageSent, msg.State())
 	})
 
 	t.Run("reconstruct read message", func(t *testing.T) {
-		msg := ReconstructMessage(id, senderID, receiverID, "Content", createdAt, &readAt)
+		msg := ReconstructMessage(id, senderID, receiverID, "Content", createdAt, &readAt, &readAt, nil, nil, nil)
 
 		assert.True(t, msg.IsRead())
 		assert.NotNil(t, msg.ReadAt())
 		assert.Equal(t, readAt, *msg.ReadAt())
+		assert.Equal(t, MessageRead, msg.State())
 	})
 }
+
+func TestMessage_MarkAsDelivered(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+
+	t.Run("receiver acknowledges delivery", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Test")
+		assert.Equal(t, MessageSent, msg.State())
+
+		require.NoError(t, msg.MarkAsDelivered(receiverID))
+		assert.Equal(t, MessageDelivered, msg.State())
+		assert.WithinDuration(t, time.Now(), *msg.DeliveredAt(), time.Second)
+		assert.False(t, msg.IsRead())
+	})
+
+	t.Run("sender cannot acknowledge delivery", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Test")
+
+		assert.Equal(t, ErrNotReceiver, msg.MarkAsDelivered(senderID))
+		assert.Equal(t, MessageSent, msg.State())
+	})
+
+	t.Run("delivering twice keeps the first time", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Test")
+		require.NoError(t, msg.MarkAsDelivered(receiverID))
+		first := *msg.DeliveredAt()
+
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, msg.MarkAsDelivered(receiverID))
+		assert.Equal(t, first, *msg.DeliveredAt())
+	})
+
+	t.Run("reading delivers a message nobody acknowledged", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Test")
+		require.NoError(t, msg.MarkAsRead(receiverID))
+
+		assert.Equal(t, MessageRead, msg.State())
+		assert.Equal(t, msg.ReadAt(), msg.DeliveredAt())
+	})
+
+	t.Run("a read message never goes back to delivered", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Test")
+		require.NoError(t, msg.MarkAsRead(receiverID))
+
+		require.NoError(t, msg.MarkAsDelivered(receiverID))
+		assert.Equal(t, MessageRead, msg.State())
+	})
+}
+
+func TestMessage_Edit(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+
+	t.Run("sender edits within window", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Helo")
+
+		revision, err := msg.Edit(senderID, "Hello", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", msg.Content())
+		assert.True(t, msg.IsEdited())
+		assert.Equal(t, "Helo", revision.Content())
+		assert.Equal(t, msg.CreatedAt(), revision.CreatedAt())
+		assert.Equal(t, *msg.EditedAt(), revision.ReplacedAt())
+
+		second, err := msg.Edit(senderID, "Hello!", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", second.Content())
+		assert.Equal(t, revision.ReplacedAt(), second.CreatedAt())
+	})
+
+	t.Run("unchanged content is no revision", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+		revision, err := msg.Edit(senderID, "Hello", time.Minute)
+
+		require.NoError(t, err)
+		assert.Nil(t, revision)
+		assert.False(t, msg.IsEdited())
+	})
+
+	t.Run("receiver cannot edit", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+		_, err := msg.Edit(receiverID, "Hijacked", time.Minute)
+
+		assert.Equal(t, ErrNotSender, err)
+		assert.Equal(t, "Hello", msg.Content())
+	})
+
+	t.Run("content cannot be emptied", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+		_, err := msg.Edit(senderID, "", time.Minute)
+
+		assert.Equal(t, ErrEmptyContent, err)
+	})
+
+	t.Run("window closed", func(t *testing.T) {
+		msg := ReconstructMessage(uuid.New(), senderID, receiverID, "Hello", time.Now().Add(-time.Hour), nil, nil, nil, nil, nil)
+
+		_, err := msg.Edit(senderID, "Too late", 15*time.Minute)
+
+		assert.Equal(t, ErrEditWindowClosed, err)
+		assert.False(t, msg.IsEdited())
+	})
+}
+
+func TestMessage_Retract(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+
+	t.Run("sender retracts within window", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Oops")
+
+		require.NoError(t, msg.Retract(senderID, time.Minute))
+
+		assert.True(t, msg.IsDeleted())
+		assert.Empty(t, msg.Content())
+		deletedAt := *msg.DeletedAt()
+
+		require.NoError(t, msg.Retract(senderID, time.Minute))
+		assert.Equal(t, deletedAt, *msg.DeletedAt(), "retracting twice changes nothing")
+	})
+
+	t.Run("receiver cannot retract", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+		assert.Equal(t, ErrNotSender, msg.Retract(receiverID, time.Minute))
+		assert.False(t, msg.IsDeleted())
+	})
+
+	t.Run("window closed", func(t *testing.T) {
+		msg := ReconstructMessage(uuid.New(), senderID, receiverID, "Hello", time.Now().Add(-time.Hour), nil, nil, nil, nil, nil)
+
+		assert.Equal(t, ErrRetractWindowClosed, msg.Retract(senderID, 15*time.Minute))
+		assert.Equal(t, "Hello", msg.Content())
+	})
+
+	t.Run("retracted message cannot be edited", func(t *testing.T) {
+		msg, _ := NewMessage(senderID, receiverID, "Hello")
+		require.NoError(t, msg.Retract(senderID, time.Minute))
+
+		_, err := msg.Edit(senderID, "Back", time.Minute)
+
+		assert.Equal(t, ErrMessageDeleted, err)
+	})
+}
+
+func TestMessage_ReplyTo(t *testing.T) {
+	alice := uuid.New()
+	bob := uuid.New()
+	parent, _ := NewMessage(bob, alice, "Lunch?")
+
+	t.Run("reply in the same conversation", func(t *testing.T) {
+		reply, _ := NewMessage(alice, bob, "Sure")
+
+		require.NoError(t, reply.ReplyTo(parent))
+
+		assert.True(t, reply.IsReply())
+		assert.Equal(t, parent.ID(), reply.ReplyToID())
+		assert.Equal(t, bob, reply.Quote().SenderID())
+		assert.Equal(t, "Lunch?", reply.Quote().Content())
+	})
+
+	t.Run("reply to another conversation", func(t *testing.T) {
+		reply, _ := NewMessage(alice, uuid.New(), "Sure")
+
+		assert.Equal(t, ErrReplyOutsideConversation, reply.ReplyTo(parent))
+		assert.False(t, reply.IsReply())
+		assert.Equal(t, uuid.Nil, reply.ReplyToID())
+	})
+
+	t.Run("reply to a retracted message", func(t *testing.T) {
+		retracted, _ := NewMessage(bob, alice, "Oops")
+		require.NoError(t, retracted.Retract(bob, time.Minute))
+		reply, _ := NewMessage(alice, bob, "What?")
+
+		assert.Equal(t, ErrMessageDeleted, reply.ReplyTo(retracted))
+	})
+}
+
+func TestNewMessageQuote(t *testing.T) {
+	long := strings.Repeat("字", QuotePreviewLength+1)
+
+	quote := NewMessageQuote(uuid.New(), uuid.New(), long, false)
+	assert.Equal(t, QuotePreviewLength, utf8.RuneCountInString(quote.Content()))
+	assert.True(t, quote.IsTruncated())
+
+	quote = NewMessageQuote(uuid.New(), uuid.New(), "short", true)
+	assert.Equal(t, "short", quote.Content())
+	assert.False(t, quote.IsTruncated())
+	assert.True(t, quote.IsDeleted())
+}
+
+func TestMessage_IsParticipant(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+	msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+	assert.True(t, msg.IsParticipant(senderID))
+	assert.True(t, msg.IsParticipant(receiverID))
+	assert.False(t, msg.IsParticipant(uuid.New()))
+}
@@ -0,0 +1,70 @@
+package do
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTypingToSelf = errors.New("cannot send typing signals to yourself") // problem:422
+)
+
+// LastSeen is when a user was last online, kept once their last session
+// ends, and whether they hide it from other users
+type LastSeen struct {
+	userID uuid.UUID
+	at     *time.Time
+	hidden bool
+}
+
+// Presence is whether a user is online and when they were last seen, as
+// one viewer sees it; LastSeenAt is nil while online, when hidden or when
+// never known
+type Presence struct {
+	UserID     uuid.UUID
+	Online     bool
+	LastSeenAt *time.Time
+}
+
+// TypingSignal tells a user that their conversation partner started or
+// stopped typing; a start lasts ExpiresIn unless repeated
+type TypingSignal struct {
+	UserID    uuid.UUID
+	Typing    bool
+	ExpiresIn time.Duration
+}
+
+// NewTypingSignal checks that the typist is not signalling themselves
+func NewTypingSignal(from, to uuid.UUID, typing bool, expiresIn time.Duration) (*TypingSignal, error) {
+	if from == to {
+		return nil, ErrTypingToSelf
+	}
+	signal := &TypingSignal{UserID: from, Typing: typing}
+	if typing {
+		signal.ExpiresIn = expiresIn
+	}
+	return signal, nil
+}
+
+// ReconstructLastSeen rebuilds last seen from database (no validation); a
+// user never seen has neither at nor a row
+func ReconstructLastSeen(userID uuid.UUID, at *time.Time, hidden bool) *LastSeen {
+	return &LastSeen{userID: userID, at: at, hidden: hidden}
+}
+
+// PresenceFor is the presence viewerID sees; a user always sees their own
+// last seen, others only unless it is hidden
+func (l *LastSeen) PresenceFor(viewerID uuid.UUID, online bool) *Presence {
+	presence := &Presence{UserID: l.userID, Online: online}
+	if !online && (!l.hidden || viewerID == l.userID) {
+		presence.LastSeenAt = l.at
+	}
+	return presence
+}
+
+// Getters
+func (l *LastSeen) UserID() uuid.UUID { return l.userID }
+func (l *LastSeen) At() *time.Time    { return l.at }
+func (l *LastSeen) IsHidden() bool    { return l.hidden }
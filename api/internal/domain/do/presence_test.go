@@ -0,0 +1,50 @@
+package do
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastSeen_PresenceFor(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	at := time.Now().Add(-time.Hour)
+
+	t.Run("others see last seen", func(t *testing.T) {
+		presence := ReconstructLastSeen(alice, &at, false).PresenceFor(bob, false)
+		assert.Equal(t, &Presence{UserID: alice, LastSeenAt: &at}, presence)
+	})
+
+	t.Run("hidden from others", func(t *testing.T) {
+		presence := ReconstructLastSeen(alice, &at, true).PresenceFor(bob, false)
+		assert.Equal(t, &Presence{UserID: alice}, presence)
+	})
+
+	t.Run("online shows no last seen", func(t *testing.T) {
+		presence := ReconstructLastSeen(alice, &at, false).PresenceFor(bob, true)
+		assert.Equal(t, &Presence{UserID: alice, Online: true}, presence)
+	})
+
+	t.Run("never hidden from oneself", func(t *testing.T) {
+		presence := ReconstructLastSeen(alice, &at, true).PresenceFor(alice, false)
+		assert.Equal(t, &at, presence.LastSeenAt)
+	})
+}
+
+func TestNewTypingSignal(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+
+	signal, err := NewTypingSignal(alice, bob, true, 6*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, &TypingSignal{UserID: alice, Typing: true, ExpiresIn: 6 * time.Second}, signal)
+
+	signal, err = NewTypingSignal(alice, bob, false, 6*time.Second)
+	require.NoError(t, err)
+	assert.Zero(t, signal.ExpiresIn, "a stop does not expire")
+
+	_, err = NewTypingSignal(alice, alice, true, time.Second)
+	assert.Equal(t, ErrTypingToSelf, err)
+}
@@ -0,0 +1,51 @@
+package do
+
+import (
+	"errors"
+	"hilo-api/pkg/emoji"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidEmoji = errors.New("reaction must be a single emoji") // problem:422
+
+// Reaction is one user's emoji on a message
+type Reaction struct {
+	messageID uuid.UUID
+	userID    uuid.UUID
+	emoji     string
+	createdAt time.Time
+}
+
+// ReactionSummary aggregates one emoji on a message as a viewer sees it
+type ReactionSummary struct {
+	Emoji       string
+	Count       int
+	ReactedByMe bool
+}
+
+// NewReaction creates a reaction by userID to msg, which must not have been
+// deleted for everyone; the caller checks userID is a participant
+func NewReaction(msg *Message, userID uuid.UUID, value string) (*Reaction, error) {
+	if msg.IsDeleted() {
+		return nil, ErrMessageDeleted
+	}
+
+	if !emoji.Valid(value) {
+		return nil, ErrInvalidEmoji
+	}
+
+	return &Reaction{
+		messageID: msg.ID(),
+		userID:    userID,
+		emoji:     value,
+		createdAt: time.Now(),
+	}, nil
+}
+
+// Getters
+func (r *Reaction) MessageID() uuid.UUID { return r.messageID }
+func (r *Reaction) UserID() uuid.UUID    { return r.userID }
+func (r *Reaction) Emoji() string        { return r.emoji }
+func (r *Reaction) CreatedAt() time.Time { return r.createdAt }
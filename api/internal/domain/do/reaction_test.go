@@ -0,0 +1,40 @@
+package do
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReaction(t *testing.T) {
+	senderID := uuid.New()
+	receiverID := uuid.New()
+	msg, _ := NewMessage(senderID, receiverID, "Hello")
+
+	t.Run("participant reacts", func(t *testing.T) {
+		reaction, err := NewReaction(msg, receiverID, "👍")
+
+		require.NoError(t, err)
+		assert.Equal(t, msg.ID(), reaction.MessageID())
+		assert.Equal(t, receiverID, reaction.UserID())
+		assert.Equal(t, "👍", reaction.Emoji())
+	})
+
+	t.Run("not an emoji", func(t *testing.T) {
+		_, err := NewReaction(msg, receiverID, "ok")
+
+		assert.Equal(t, ErrInvalidEmoji, err)
+	})
+
+	t.Run("retracted message", func(t *testing.T) {
+		retracted, _ := NewMessage(senderID, receiverID, "Oops")
+		require.NoError(t, retracted.Retract(senderID, time.Minute))
+
+		_, err := NewReaction(retracted, receiverID, "👍")
+
+		assert.Equal(t, ErrMessageDeleted, err)
+	})
+}
@@ -0,0 +1,244 @@
+package do
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptySearchQuery    = errors.New("search query is empty")                     // problem:400
+	ErrSearchQueryTooLong  = errors.New("search query is longer than allowed")       // problem:400
+	ErrInvalidSearchPeriod = errors.New("search period must end after it starts")    // problem:400
+	ErrInvalidSearchCursor = errors.New("search cursor is not one this server gave") // problem:400
+)
+
+const (
+	// MaxSearchQueryLength is in characters
+	MaxSearchQueryLength = 200
+	// HighlightStart and HighlightStop enclose a match in a marked snippet
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+	// snippetRadius is how many characters a snippet keeps around a match
+	snippetRadius = 40
+)
+
+// SearchFilter narrows a message search; zero fields do not filter
+type SearchFilter struct {
+	SenderID uuid.UUID
+	// After is inclusive, Before exclusive
+	After         time.Time
+	Before        time.Time
+	HasAttachment bool
+}
+
+// SearchQuery is what a user looks for in their messages. Words are matched
+// by stem and "quoted phrases" in order; text in a script written without
+// spaces, such as Chinese, is matched as substrings instead.
+type SearchQuery struct {
+	text      string
+	terms     []string
+	substring bool
+	filter    SearchFilter
+}
+
+// NewSearchQuery validates the text and filter of a search
+func NewSearchQuery(text string, filter SearchFilter) (*SearchQuery, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(text) > MaxSearchQueryLength {
+		return nil, ErrSearchQueryTooLong
+	}
+	if !filter.After.IsZero() && !filter.Before.IsZero() && !filter.After.Before(filter.Before) {
+		return nil, ErrInvalidSearchPeriod
+	}
+
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	return &SearchQuery{
+		text:      text,
+		terms:     terms,
+		substring: strings.IndexFunc(text, unspaced) >= 0,
+		filter:    filter,
+	}, nil
+}
+
+// unspaced reports whether r belongs to a script written without spaces
+// between words, which a word based index cannot split
+func unspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
+
+// searchTerms splits text into words and quoted phrases
+func searchTerms(text string) []string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		// odd parts were between quotes
+		if i%2 == 1 {
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// Getters
+func (q *SearchQuery) Text() string         { return q.text }
+func (q *SearchQuery) Terms() []string      { return q.terms }
+func (q *SearchQuery) Substring() bool      { return q.substring }
+func (q *SearchQuery) Filter() SearchFilter { return q.filter }
+
+// SearchCursor is where a page of search results ended, results being
+// ordered newest first
+type SearchCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CursorAfter is the cursor that continues after msg
+func CursorAfter(msg *Message) SearchCursor {
+	return SearchCursor{CreatedAt: msg.CreatedAt(), ID: msg.ID()}
+}
+
+// String encodes the cursor for a client to hand back
+func (c SearchCursor) String() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseSearchCursor decodes a cursor String produced
+func ParseSearchCursor(s string) (SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return SearchCursor{}, ErrInvalidSearchCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return SearchCursor{}, ErrInvalidSearchCursor
+	}
+	at, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return SearchCursor{}, ErrInvalidSearchCursor
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return SearchCursor{}, ErrInvalidSearchCursor
+	}
+	return SearchCursor{CreatedAt: time.UnixMicro(at), ID: parsed}, nil
+}
+
+// SnippetPart is a piece of a search snippet, Match when it is what was
+// searched for
+type SnippetPart struct {
+	Text  string
+	Match bool
+}
+
+// SearchHit is a message that matched a search with the part of it that did
+type SearchHit struct {
+	Message *Message
+	Snippet []SnippetPart
+}
+
+// ParseHighlight splits a snippet whose matches are enclosed in
+// HighlightStart and HighlightStop
+func ParseHighlight(marked string) []SnippetPart {
+	var parts []SnippetPart
+	for marked != "" {
+		before, rest, found := strings.Cut(marked, HighlightStart)
+		if before != "" {
+			parts = append(parts, SnippetPart{Text: before})
+		}
+		if !found {
+			break
+		}
+		match, after, _ := strings.Cut(rest, HighlightStop)
+		if match != "" {
+			parts = append(parts, SnippetPart{Text: match, Match: true})
+		}
+		marked = after
+	}
+	return parts
+}
+
+// Highlight cuts a snippet around the first occurrence of any term in
+// content, marking every occurrence inside it; matching ignores case
+func Highlight(content string, terms []string) []SnippetPart {
+	runes := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	// lower casing changed the length, matches cannot be mapped back
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	matched := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		needle := []rune(strings.ToLower(term))
+		if len(needle) == 0 {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) != string(needle) {
+				continue
+			}
+			for j := range needle {
+				matched[i+j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if first > snippetRadius {
+		start = first - snippetRadius
+	}
+	if first >= 0 && first+2*snippetRadius < end {
+		end = max(first, start) + 2*snippetRadius
+	}
+
+	var parts []SnippetPart
+	if start > 0 {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && matched[j] == matched[i] {
+			j++
+		}
+		parts = append(parts, SnippetPart{Text: string(runes[i:j]), Match: matched[i]})
+		i = j
+	}
+	if end < len(runes) {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+	return mergeSnippet(parts)
+}
+
+// mergeSnippet joins neighbouring parts that are both matches or both not
+func mergeSnippet(parts []SnippetPart) []SnippetPart {
+	var merged []SnippetPart
+	for _, part := range parts {
+		if n := len(merged); n > 0 && merged[n-1].Match == part.Match {
+			merged[n-1].Text += part.Text
+			continue
+		}
+		merged = append(merged, part)
+	}
+	return merged
+}
@@ -0,0 +1,86 @@
+package do
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSearchQuery(t *testing.T) {
+	t.Run("words and phrases", func(t *testing.T) {
+		query, err := NewSearchQuery(`  dinner "see you  tomorrow" -late `, SearchFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, `dinner "see you  tomorrow" -late`, query.Text())
+		assert.Equal(t, []string{"dinner", "see you tomorrow", "-late"}, query.Terms())
+		assert.False(t, query.Substring())
+	})
+
+	t.Run("unspaced scripts match substrings", func(t *testing.T) {
+		query, err := NewSearchQuery("明天 dinner", SearchFilter{})
+		require.NoError(t, err)
+		assert.True(t, query.Substring())
+
+		query, err = NewSearchQuery("ラーメン", SearchFilter{})
+		require.NoError(t, err)
+		assert.True(t, query.Substring())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewSearchQuery("   ", SearchFilter{})
+		assert.Equal(t, ErrEmptySearchQuery, err)
+
+		_, err = NewSearchQuery(`""`, SearchFilter{})
+		assert.Equal(t, ErrEmptySearchQuery, err)
+
+		_, err = NewSearchQuery(strings.Repeat("字", MaxSearchQueryLength+1), SearchFilter{})
+		assert.Equal(t, ErrSearchQueryTooLong, err)
+
+		now := time.Now()
+		_, err = NewSearchQuery("dinner", SearchFilter{After: now, Before: now})
+		assert.Equal(t, ErrInvalidSearchPeriod, err)
+	})
+}
+
+func TestSearchCursor(t *testing.T) {
+	cursor := SearchCursor{CreatedAt: time.Now().Truncate(time.Microsecond), ID: uuid.New()}
+
+	parsed, err := ParseSearchCursor(cursor.String())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	assert.Equal(t, cursor.ID, parsed.ID)
+
+	for _, invalid := range []string{"", "!!", "bm9jb2xvbg", "MTIzOm5vdC1hLXV1aWQ"} {
+		_, err := ParseSearchCursor(invalid)
+		assert.Equal(t, ErrInvalidSearchCursor, err, invalid)
+	}
+}
+
+func TestParseHighlight(t *testing.T) {
+	assert.Equal(t, []SnippetPart{
+		{Text: "see you "},
+		{Text: "tomorrow", Match: true},
+		{Text: " at "},
+		{Text: "dinner", Match: true},
+	}, ParseHighlight("see you \x02tomorrow\x03 at \x02dinner\x03"))
+	assert.Nil(t, ParseHighlight(""))
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, []SnippetPart{
+		{Text: "我們"},
+		{Text: "明天", Match: true},
+		{Text: "一起吃"},
+		{Text: "Dinner", Match: true},
+	}, Highlight("我們明天一起吃Dinner", []string{"明天", "dinner"}))
+
+	long := strings.Repeat("a", 100) + "明天" + strings.Repeat("b", 100)
+	parts := Highlight(long, []string{"明天"})
+	require.Len(t, parts, 3)
+	assert.Equal(t, "…"+strings.Repeat("a", snippetRadius), parts[0].Text)
+	assert.Equal(t, SnippetPart{Text: "明天", Match: true}, parts[1])
+	assert.Equal(t, strings.Repeat("b", 2*snippetRadius-2)+"…", parts[2].Text)
+}
@@ -8,10 +8,14 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+//go:generate go run hilo-api/tools/errcatalog -out catalog_gen.go user.go message.go idempotency.go reaction.go attachment.go search.go presence.go
+
 var (
-	ErrInvalidEmail       = errors.New("invalid email format")
-	ErrWeakPassword       = errors.New("password must be at least 8 characters")
-	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidEmail       = errors.New("invalid email format")                   // problem:422
+	ErrWeakPassword       = errors.New("password must be at least 8 characters") // problem:422
+	ErrInvalidCredentials = errors.New("invalid email or password")              // problem:401
+	ErrEmptyUsername      = errors.New("username cannot be empty")               // problem:422
+	ErrUserSuspended      = errors.New("user account is suspended")              // problem:403
 )
 
 const (
@@ -26,6 +30,7 @@ type User struct {
 	passwordHash string
 	username     string
 	createdAt    time.Time
+	suspendedAt  *time.Time
 }
 
 // NewUser creates a new user with hashed password
@@ -39,10 +44,10 @@ func NewUser(email, password, username string) (*User, error) {
 	}
 
 	if username == "" {
-		return nil, errors.New("username cannot be empty")
+		return nil, ErrEmptyUsername
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
+	hash, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -50,21 +55,33 @@ func NewUser(email, password, username string) (*User, error) {
 	return &User{
 		id:           uuid.New(),
 		email:        email,
-		passwordHash: string(hash),
+		passwordHash: hash,
 		username:     username,
 		createdAt:    time.Now(),
 	}, nil
 }
 
 // ReconstructUser rebuilds user from database (no validation)
-func ReconstructUser(id uuid.UUID, email, passwordHash, username string, createdAt time.Time) *User {
+func ReconstructUser(id uuid.UUID, email, passwordHash, username string, createdAt time.Time, suspendedAt *time.Time) *User {
 	return &User{
 		id:           id,
 		email:        email,
 		passwordHash: passwordHash,
 		username:     username,
 		createdAt:    createdAt,
+		suspendedAt:  suspendedAt,
+	}
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrWeakPassword
 	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
 
 // VerifyPassword checks if the provided password matches
@@ -76,9 +93,35 @@ func (u *User) VerifyPassword(password string) error {
 	return nil
 }
 
+// ChangePassword replaces the password, enforcing the same rules as NewUser
+func (u *User) ChangePassword(password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	u.passwordHash = hash
+	return nil
+}
+
+// Suspend blocks the user from logging in and from using tokens already issued
+func (u *User) Suspend() {
+	if u.suspendedAt != nil {
+		return // already suspended, idempotent
+	}
+	now := time.Now()
+	u.suspendedAt = &now
+}
+
+// Reinstate lifts a suspension
+func (u *User) Reinstate() {
+	u.suspendedAt = nil
+}
+
 // Getters
-func (u *User) ID() uuid.UUID        { return u.id }
-func (u *User) Email() string        { return u.email }
-func (u *User) PasswordHash() string { return u.passwordHash }
-func (u *User) Username() string     { return u.username }
-func (u *User) CreatedAt() time.Time { return u.createdAt }
+func (u *User) ID() uuid.UUID           { return u.id }
+func (u *User) Email() string           { return u.email }
+func (u *User) PasswordHash() string    { return u.passwordHash }
+func (u *User) Username() string        { return u.username }
+func (u *User) CreatedAt() time.Time    { return u.createdAt }
+func (u *User) SuspendedAt() *time.Time { return u.suspendedAt }
+func (u *User) IsSuspended() bool       { return u.suspendedAt != nil }
@@ -85,14 +85,60 @@ func TestReconstructUser(t *testing.T) {
 	createdAt := time.Now().Add(-24 * time.Hour)
 
 	t.Run("reconstruct user from database", func(t *testing.T) {
-		user := ReconstructUser(id, email, passwordHash, username, createdAt)
+		user := ReconstructUser(id, email, passwordHash, username, createdAt, nil)
 
 		assert.Equal(t, id, user.ID())
 		assert.Equal(t, email, user.Email())
 		assert.Equal(t, passwordHash, user.PasswordHash())
 		assert.Equal(t, username, user.Username())
 		assert.Equal(t, createdAt, user.CreatedAt())
+		assert.False(t, user.IsSuspended())
 	})
+
+	t.Run("reconstruct suspended user", func(t *testing.T) {
+		suspendedAt := time.Now().Add(-time.Hour)
+		user := ReconstructUser(id, email, passwordHash, username, createdAt, &suspendedAt)
+
+		assert.True(t, user.IsSuspended())
+		assert.Equal(t, &suspendedAt, user.SuspendedAt())
+	})
+}
+
+func TestUser_ChangePassword(t *testing.T) {
+	user, err := NewUser("test@example.com", "old_password", "testuser")
+	require.NoError(t, err)
+	oldHash := user.PasswordHash()
+
+	t.Run("too short password is rejected", func(t *testing.T) {
+		assert.Equal(t, ErrWeakPassword, user.ChangePassword("short"))
+		assert.Equal(t, oldHash, user.PasswordHash())
+	})
+
+	t.Run("new password replaces the old one", func(t *testing.T) {
+		require.NoError(t, user.ChangePassword("new_password"))
+		assert.NotEqual(t, oldHash, user.PasswordHash())
+		assert.NoError(t, user.VerifyPassword("new_password"))
+		assert.Equal(t, ErrInvalidCredentials, user.VerifyPassword("old_password"))
+	})
+}
+
+func TestUser_Suspend(t *testing.T) {
+	user, err := NewUser("test@example.com", "password123", "testuser")
+	require.NoError(t, err)
+	assert.False(t, user.IsSuspended())
+
+	user.Suspend()
+	require.True(t, user.IsSuspended())
+	suspendedAt := *user.SuspendedAt()
+	assert.WithinDuration(t, time.Now(), suspendedAt, time.Second)
+
+	// suspending again keeps the original time
+	user.Suspend()
+	assert.Equal(t, suspendedAt, *user.SuspendedAt())
+
+	user.Reinstate()
+	assert.False(t, user.IsSuspended())
+	assert.Nil(t, user.SuspendedAt())
 }
 
 func TestPasswordHashing(t *testing.T) {
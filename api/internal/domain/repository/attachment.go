@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentRepository defines persistence of uploaded files and the storage
+// each user has used for them
+type AttachmentRepository interface {
+	// Create saves an unsent attachment and charges its size to the owner,
+	// failing with ErrQuotaExceeded if that would go past quota bytes
+	Create(ctx context.Context, attachment *do.Attachment, quota int64) error
+
+	// FindByID retrieves an attachment by ID
+	FindByID(ctx context.Context, id uuid.UUID) (*do.Attachment, error)
+
+	// FindByIDs retrieves attachments in the order of ids, leaving out the
+	// ones that do not exist
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*do.Attachment, error)
+
+	// AttachToMessage links the attachments of a new message to it, failing
+	// with ErrAttachmentUnavailable if one of them was sent in the meantime
+	AttachToMessage(ctx context.Context, msg *do.Message) error
+
+	// ListGarbage retrieves attachments nobody can reach any more: never
+	// sent and created before unsentBefore, sent with a message since
+	// deleted, or left behind by a deleted owner
+	ListGarbage(ctx context.Context, unsentBefore time.Time, limit int) ([]*do.Attachment, error)
+
+	// Delete removes an attachment and gives its size back to the owner, if
+	// they still exist
+	Delete(ctx context.Context, attachment *do.Attachment) error
+
+	// ClaimProcessing retrieves up to limit attachments whose processing is
+	// due and counts an attempt for each; they are not due again before
+	// lease is over, so concurrent workers never claim the same one
+	ClaimProcessing(ctx context.Context, limit int, lease time.Duration) ([]*do.Attachment, error)
+
+	// SaveProcessing stores the processing outcome of an attachment; one
+	// still pending is due again at retryAt
+	SaveProcessing(ctx context.Context, attachment *do.Attachment, retryAt time.Time) error
+}
+
+// UploadRepository defines persistence of resumable uploads in progress
+type UploadRepository interface {
+	// Create saves a new upload
+	Create(ctx context.Context, upload *do.Upload) error
+
+	// FindByID retrieves an upload by ID
+	FindByID(ctx context.Context, id uuid.UUID) (*do.Upload, error)
+
+	// Advance stores the bytes received so far, failing with
+	// ErrUploadConflict unless from was the stored count
+	Advance(ctx context.Context, upload *do.Upload, from int64) error
+
+	// Delete removes an upload; removing it twice is not an error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListExpired retrieves uploads created before expiredBefore and those
+	// of deleted owners
+	ListExpired(ctx context.Context, expiredBefore time.Time, limit int) ([]*do.Upload, error)
+}
@@ -0,0 +1,14 @@
+// Code generated by errcatalog. DO NOT EDIT.
+
+package repository
+
+import "hilo-api/pkg/errorCatcher"
+
+func init() {
+	errorCatcher.RegisterProblems(
+		errorCatcher.ProblemEntry{Err: ErrAttachmentNotFound, Code: "ATTACHMENT_NOT_FOUND", Title: "Attachment not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrMessageNotFound, Code: "MESSAGE_NOT_FOUND", Title: "Message not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrUploadNotFound, Code: "UPLOAD_NOT_FOUND", Title: "Upload not found", Status: 404},
+		errorCatcher.ProblemEntry{Err: ErrUserNotFound, Code: "USER_NOT_FOUND", Title: "User not found", Status: 404},
+	)
+}
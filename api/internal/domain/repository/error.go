@@ -0,0 +1,28 @@
+package repository
+
+import "errors"
+
+//go:generate go run hilo-api/tools/errcatalog -out catalog_gen.go error.go
+
+var (
+	ErrUserNotFound            = errors.New("user not found")    // problem:404
+	ErrMessageNotFound         = errors.New("message not found") // problem:404
+	ErrDuplicateEmail          = errors.New("email already taken")
+	ErrDuplicateUsername       = errors.New("username already taken")
+	ErrDuplicateMessageID      = errors.New("message id already taken")
+	ErrMessageEditConflict     = errors.New("message changed by another edit")
+	ErrIdempotencyKeyNotFound  = errors.New("idempotency key not found")
+	ErrDuplicateIdempotencyKey = errors.New("idempotency key already used")
+	ErrAttachmentNotFound      = errors.New("attachment not found") // problem:404
+	ErrAttachmentUnavailable   = errors.New("attachment missing or already sent")
+	ErrQuotaExceeded           = errors.New("attachment quota exceeded")
+	ErrUploadNotFound          = errors.New("upload not found") // problem:404
+	ErrUploadConflict          = errors.New("upload advanced by another request")
+	ErrUserRepository          = errors.New("[User Repository Failed]")
+	ErrMessageRepository       = errors.New("[Message Repository Failed]")
+	ErrIdempotencyRepository   = errors.New("[Idempotency Repository Failed]")
+	ErrReactionRepository      = errors.New("[Reaction Repository Failed]")
+	ErrAttachmentRepository    = errors.New("[Attachment Repository Failed]")
+	ErrUploadRepository        = errors.New("[Upload Repository Failed]")
+	ErrPresenceRepository      = errors.New("[Presence Repository Failed]")
+)
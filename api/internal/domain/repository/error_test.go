@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"hilo-api/internal/domain/repository"
+	"hilo-api/pkg/database/postgres"
+	"hilo-api/pkg/errorCatcher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrappedRepositoryErrorOutranksCategory(t *testing.T) {
+	err := postgres.WrapError(sql.ErrNoRows, repository.ErrUserNotFound)
+
+	entry, ok := errorCatcher.LookupProblem(err)
+	assert.True(t, ok)
+	assert.Equal(t, "USER_NOT_FOUND", entry.Code)
+	assert.Equal(t, 404, entry.Status)
+	assert.ErrorIs(t, err, errorCatcher.ErrDatabaseRowNotFound)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRepository defines persistence of idempotency keys, unique per sender
+type IdempotencyRepository interface {
+	// Save stores key, taking over a key of the same sender created before
+	// expiredBefore; a live key fails with ErrDuplicateIdempotencyKey
+	Save(ctx context.Context, key *do.IdempotencyKey, expiredBefore time.Time) error
+
+	// Find retrieves the key a sender used
+	Find(ctx context.Context, senderID uuid.UUID, key string) (*do.IdempotencyKey, error)
+
+	// DeleteExpired removes keys created before expiredBefore
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
@@ -16,13 +16,55 @@ type MessageRepository interface {
 	// FindByID retrieves message by ID
 	FindByID(ctx context.Context, id uuid.UUID) (*do.Message, error)
 
-	// UpdateReadAt marks message as read
+	// FindByIDs retrieves messages in the order of ids, leaving out the ones
+	// that do not exist
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*do.Message, error)
+
+	// UpdateDeliveredAt marks the messages delivered that were not yet
+	UpdateDeliveredAt(ctx context.Context, ids []uuid.UUID, deliveredAt time.Time) error
+
+	// UpdateReadAt marks message as read, and delivered if it was not yet
 	UpdateReadAt(ctx context.Context, id uuid.UUID, readAt time.Time) error
 
-	// ListConversation retrieves messages between two users
+	// MarkConversationRead marks every unread message otherUserID sent to
+	// readerID up to upTo read, and delivered if it was not yet, returning
+	// how many it marked
+	MarkConversationRead(ctx context.Context, readerID, otherUserID uuid.UUID, upTo, readAt time.Time) (int, error)
+
+	// Edit stores the edited message along with the revision it replaced,
+	// failing with ErrMessageEditConflict if another edit got there first
+	Edit(ctx context.Context, msg *do.Message, revision *do.MessageRevision) error
+
+	// ListRevisions retrieves the replaced versions of a message, oldest first
+	ListRevisions(ctx context.Context, messageID uuid.UUID) ([]*do.MessageRevision, error)
+
+	// Retract stores a message deleted for everyone and drops its revisions
+	// and reactions
+	Retract(ctx context.Context, msg *do.Message) error
+
+	// Hide deletes a message for userID only
+	Hide(ctx context.Context, messageID, userID uuid.UUID, hiddenAt time.Time) error
+
+	// ListConversation retrieves messages between two users as userA sees
+	// them, leaving out the ones userA deleted for themselves
 	ListConversation(ctx context.Context, userA, userB uuid.UUID, limit, offset int) ([]*do.Message, error)
 
+	// ListReplies retrieves the replies to a message, oldest first, leaving
+	// out the ones viewerID deleted for themselves
+	ListReplies(ctx context.Context, messageID, viewerID uuid.UUID, limit, offset int) ([]*do.Message, error)
+
+	// Search retrieves up to limit messages of viewerID's conversations that
+	// match query, newest first, continuing after the cursor when there is
+	// one; deleted messages and those viewerID deleted for themselves are
+	// left out
+	Search(ctx context.Context, viewerID uuid.UUID, query *do.SearchQuery, after *do.SearchCursor, limit int) ([]*do.SearchHit, error)
+
 	// ListUserConversations retrieves all conversations for a user
-	// Returns the latest message from each conversation
+	// Returns the latest message from each conversation the user has not
+	// deleted for themselves; deleted messages never count as unread
 	ListUserConversations(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*do.ConversationPreview, error)
+
+	// Statistics aggregates users, messages and conversations,
+	// counting recent activity from since on
+	Statistics(ctx context.Context, since time.Time) (*do.Statistics, error)
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PresenceRepository defines persistence of when users were last online
+type PresenceRepository interface {
+	// FindLastSeen retrieves the last seen of a user, empty for a user who
+	// was never seen
+	FindLastSeen(ctx context.Context, userID uuid.UUID) (*do.LastSeen, error)
+
+	// UpdateLastSeen stores when a user was last online
+	UpdateLastSeen(ctx context.Context, userID uuid.UUID, at time.Time) error
+
+	// UpdateLastSeenHidden stores whether a user hides their last seen,
+	// failing with ErrUserNotFound for an unknown user
+	UpdateLastSeenHidden(ctx context.Context, userID uuid.UUID, hidden bool) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+
+	"github.com/google/uuid"
+)
+
+// ReactionRepository defines persistence of emoji reactions on messages
+type ReactionRepository interface {
+	// Toggle adds the reaction, or removes it if the user already reacted to
+	// the message with that emoji, and reports whether it was added
+	Toggle(ctx context.Context, reaction *do.Reaction) (bool, error)
+
+	// Summaries aggregates the reactions on each of messageIDs as viewerID
+	// sees them, in the order each emoji was first used; messages without
+	// reactions are left out
+	Summaries(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]*do.ReactionSummary, error)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import "context"
+
+// TxIsolation is how much of concurrent transactions a transaction may see
+type TxIsolation int
+
+const (
+	// TxIsolationDefault leaves the isolation level to the database
+	TxIsolationDefault TxIsolation = iota
+	TxIsolationReadCommitted
+	TxIsolationRepeatableRead
+	TxIsolationSerializable
+)
+
+// TxOptions are what a unit of work asks of its transaction
+type TxOptions struct {
+	Isolation TxIsolation
+	ReadOnly  bool
+}
+
+// TxOption interface
+type TxOption interface {
+	Apply(*TxOptions)
+}
+
+// WithIsolation method
+func WithIsolation(level TxIsolation) TxOption {
+	return withIsolation{level: level}
+}
+
+type withIsolation struct {
+	level TxIsolation
+}
+
+// Apply method
+func (w withIsolation) Apply(o *TxOptions) {
+	o.Isolation = w.level
+}
+
+// WithReadOnly method
+func WithReadOnly() TxOption {
+	return withReadOnly{}
+}
+
+type withReadOnly struct{}
+
+// Apply method
+func (withReadOnly) Apply(o *TxOptions) {
+	o.ReadOnly = true
+}
+
+// Transactor runs a unit of work atomically across repositories
+type Transactor interface {
+	// WithinTx executes fn in a transaction; repositories called with the
+	// ctx passed to fn take part in it
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error, options ...TxOption) error
+}
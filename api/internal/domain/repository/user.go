@@ -12,6 +12,12 @@ type UserRepository interface {
 	// Create saves a new user
 	Create(ctx context.Context, user *do.User) error
 
+	// Update saves the password and suspension of an existing user
+	Update(ctx context.Context, user *do.User) error
+
+	// Delete removes a user together with their messages
+	Delete(ctx context.Context, id uuid.UUID) error
+
 	// FindByID retrieves user by ID
 	FindByID(ctx context.Context, id uuid.UUID) (*do.User, error)
 
@@ -0,0 +1,330 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type AttachmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentRepository(db *sqlx.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// conn joins the transaction carried by ctx, if any
+func (r *AttachmentRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
+// Create charges the owner's usage row and inserts the attachment in one
+// statement; the usage row is only touched while it stays within quota, and
+// concurrent uploads of the same user queue up on it
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *do.Attachment, quota int64) error {
+	query := `
+		WITH charged AS (
+			INSERT INTO attachment_usage AS u (user_id, bytes)
+			SELECT $2, $6::bigint WHERE $6::bigint <= $9::bigint
+			ON CONFLICT (user_id) DO UPDATE
+			SET bytes = u.bytes + EXCLUDED.bytes
+			WHERE u.bytes + EXCLUDED.bytes <= $9::bigint
+			RETURNING 1
+		)
+		INSERT INTO attachments (id, owner_id, message_id, sent_at, filename, content_type, size, checksum, created_at, processing_state)
+		SELECT $1, $2, $3, CASE WHEN $3::uuid IS NULL THEN NULL ELSE $8::timestamptz END, $4, $5, $6::bigint, $7, $8, $10
+		WHERE EXISTS (SELECT 1 FROM charged)
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		attachment.ID(),
+		attachment.OwnerID(),
+		uuid.NullUUID{UUID: attachment.MessageID(), Valid: attachment.IsSent()},
+		attachment.Filename(),
+		attachment.ContentType(),
+		attachment.Size(),
+		attachment.Checksum(),
+		attachment.CreatedAt(),
+		quota,
+		attachment.Processing(),
+	)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return affectedOne(result, repository.ErrQuotaExceeded, repository.ErrAttachmentRepository)
+}
+
+// attachmentColumns are the columns scanAttachment reads, in order
+const attachmentColumns = `a.id, a.owner_id, a.message_id, a.filename, a.content_type, a.size, a.checksum, a.created_at,
+	a.processing_state, a.processing_attempts, a.width, a.height, a.blurhash, a.variants`
+
+// variantJSON is how a thumbnail is kept in the variants column
+type variantJSON struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+func scanAttachment(row rowScanner) (*do.Attachment, error) {
+	var (
+		id          uuid.UUID
+		ownerID     uuid.NullUUID
+		messageID   uuid.NullUUID
+		filename    string
+		contentType string
+		size        int64
+		checksum    string
+		createdAt   time.Time
+		processing  string
+		attempts    int
+		image       do.ImageInfo
+		variants    []byte
+	)
+	if err := row.Scan(&id, &ownerID, &messageID, &filename, &contentType, &size, &checksum, &createdAt,
+		&processing, &attempts, &image.Width, &image.Height, &image.Blurhash, &variants); err != nil {
+		return nil, err
+	}
+
+	var stored []variantJSON
+	if err := json.Unmarshal(variants, &stored); err != nil {
+		return nil, err
+	}
+	for _, v := range stored {
+		image.Variants = append(image.Variants, do.AttachmentVariant(v))
+	}
+
+	attachment := do.ReconstructAttachment(id, ownerID.UUID, messageID.UUID, filename, contentType, size, checksum, createdAt)
+	attachment.RestoreProcessing(do.ProcessingState(processing), attempts, image)
+	return attachment, nil
+}
+
+// collectAttachments scans and closes rows of attachmentColumns
+func collectAttachments(rows *sql.Rows, subject error) ([]*do.Attachment, error) {
+	defer rows.Close()
+
+	var attachments []*do.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, pgdb.WrapError(err, subject)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, pgdb.WrapError(rows.Err(), subject)
+}
+
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	array := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		array[i] = id.String()
+	}
+	return array
+}
+
+func (r *AttachmentRepository) FindByID(ctx context.Context, id uuid.UUID) (*do.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments a WHERE a.id = $1`
+
+	attachment, err := scanAttachment(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pgdb.WrapError(err, repository.ErrAttachmentNotFound)
+		}
+		return nil, pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return attachment, nil
+}
+
+func (r *AttachmentRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*do.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS wanted(id, n)
+		JOIN attachments a ON a.id = wanted.id
+		ORDER BY wanted.n
+	`
+	rows, err := r.conn(ctx).QueryContext(ctx, query, uuidArray(ids))
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return collectAttachments(rows, repository.ErrAttachmentRepository)
+}
+
+// AttachToMessage claims the attachments for the message in the order they
+// were given; one already claimed by another message, even one deleted
+// since, is left alone, which shows in the row count
+func (r *AttachmentRepository) AttachToMessage(ctx context.Context, msg *do.Message) error {
+	ids := msg.AttachmentIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE attachments a
+		SET message_id = $1, position = claimed.n - 1, sent_at = NOW()
+		FROM unnest($3::uuid[]) WITH ORDINALITY AS claimed(id, n)
+		WHERE a.id = claimed.id AND a.owner_id = $2 AND a.sent_at IS NULL
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query, msg.ID(), msg.SenderID(), uuidArray(ids))
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	if n != int64(len(ids)) {
+		return repository.ErrAttachmentUnavailable
+	}
+	return nil
+}
+
+// ListGarbage also picks up the rows the foreign keys orphaned: those of a
+// deleted owner, and those whose message was deleted outright, which keep
+// sent_at
+func (r *AttachmentRepository) ListGarbage(ctx context.Context, unsentBefore time.Time, limit int) ([]*do.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		LEFT JOIN messages m ON m.id = a.message_id
+		WHERE (a.sent_at IS NULL AND a.created_at < $1)
+		   OR (a.sent_at IS NOT NULL AND a.message_id IS NULL)
+		   OR a.owner_id IS NULL
+		   OR m.deleted_at IS NOT NULL
+		ORDER BY a.created_at, a.id
+		LIMIT $2
+	`
+	rows, err := r.conn(ctx).QueryContext(ctx, query, unsentBefore, limit)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return collectAttachments(rows, repository.ErrAttachmentRepository)
+}
+
+// Delete only gives the size back when it removed the row, so deleting twice
+// does not refund twice; a deleted owner has nothing to refund
+func (r *AttachmentRepository) Delete(ctx context.Context, attachment *do.Attachment) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM attachments
+			WHERE id = $1
+			RETURNING owner_id, size
+		)
+		UPDATE attachment_usage u
+		SET bytes = GREATEST(u.bytes - removed.size, 0)
+		FROM removed
+		WHERE u.user_id = removed.owner_id
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, attachment.ID())
+	return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+}
+
+// ClaimProcessing pushes the next attempt of the claimed rows out by lease
+// in the same statement that picks them; rows another worker is claiming
+// are skipped rather than waited for
+func (r *AttachmentRepository) ClaimProcessing(ctx context.Context, limit int, lease time.Duration) ([]*do.Attachment, error) {
+	query := `
+		UPDATE attachments a
+		SET processing_attempts = a.processing_attempts + 1,
+		    processing_next_at = NOW() + make_interval(secs => $2)
+		FROM (
+			SELECT id FROM attachments
+			WHERE processing_state = 'pending' AND processing_next_at <= NOW()
+			ORDER BY processing_next_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE a.id = due.id
+		RETURNING ` + attachmentColumns
+	rows, err := r.conn(ctx).QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return collectAttachments(rows, repository.ErrAttachmentRepository)
+}
+
+func (r *AttachmentRepository) SaveProcessing(ctx context.Context, attachment *do.Attachment, retryAt time.Time) error {
+	image := attachment.Image()
+	variants := make([]variantJSON, 0, len(image.Variants))
+	for _, v := range image.Variants {
+		variants = append(variants, variantJSON(v))
+	}
+	encoded, err := json.Marshal(variants)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+
+	query := `
+		UPDATE attachments
+		SET processing_state = $2, processing_next_at = $3,
+		    width = $4, height = $5, blurhash = $6, variants = $7
+		WHERE id = $1
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		attachment.ID(),
+		attachment.Processing(),
+		retryAt,
+		image.Width,
+		image.Height,
+		image.Blurhash,
+		encoded,
+	)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrAttachmentRepository)
+	}
+	return affectedOne(result, repository.ErrAttachmentNotFound, repository.ErrAttachmentRepository)
+}
+
+// loadAttachments fills in the attachments of messages with one query;
+// messages deleted for everyone keep none
+func loadAttachments(ctx context.Context, conn pgdb.Executor, messages ...*do.Message) error {
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, msg := range messages {
+		if !msg.IsDeleted() {
+			ids = append(ids, msg.ID())
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE a.message_id = ANY($1::uuid[])
+		ORDER BY a.message_id, a.position
+	`
+	rows, err := conn.QueryContext(ctx, query, uuidArray(ids))
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	attachments, err := collectAttachments(rows, repository.ErrMessageRepository)
+	if err != nil {
+		return err
+	}
+
+	byMessage := map[uuid.UUID][]*do.Attachment{}
+	for _, attachment := range attachments {
+		byMessage[attachment.MessageID()] = append(byMessage[attachment.MessageID()], attachment)
+	}
+	for _, msg := range messages {
+		if list, ok := byMessage[msg.ID()]; ok {
+			msg.RestoreAttachments(list)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package postgres_test
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/infrastructure/postgres"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	attachmentRepo := postgres.NewAttachmentRepository(tdb.DB)
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	policy := do.AttachmentPolicy{MaxSize: 1 << 20, AllowedTypes: []string{"image/*"}}
+	upload := func(t *testing.T, size int64) *do.Attachment {
+		attachment, err := do.NewAttachment(alice.ID(), "cat.png", "image/png", size, "abc", policy)
+		require.NoError(t, err)
+		return attachment
+	}
+
+	first, second := upload(t, 400), upload(t, 500)
+
+	t.Run("quota", func(t *testing.T) {
+		require.NoError(t, attachmentRepo.Create(ctx, first, 1000))
+		require.NoError(t, attachmentRepo.Create(ctx, second, 1000))
+		assert.ErrorIs(t, attachmentRepo.Create(ctx, upload(t, 200), 1000), repository.ErrQuotaExceeded)
+		assert.ErrorIs(t, attachmentRepo.Create(ctx, upload(t, 2000), 1000), repository.ErrQuotaExceeded)
+	})
+
+	t.Run("find by ids keeps order", func(t *testing.T) {
+		found, err := attachmentRepo.FindByIDs(ctx, []uuid.UUID{second.ID(), uuid.New(), first.ID()})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, second.ID(), found[0].ID())
+		assert.Equal(t, first.ID(), found[1].ID())
+	})
+
+	t.Run("send with message", func(t *testing.T) {
+		loaded, err := attachmentRepo.FindByIDs(ctx, []uuid.UUID{second.ID(), first.ID()})
+		require.NoError(t, err)
+		msg, err := do.NewMessageWithID(uuid.Nil, alice.ID(), bob.ID(), "", loaded...)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		require.NoError(t, attachmentRepo.AttachToMessage(ctx, msg))
+
+		found, err := messageRepo.FindByID(ctx, msg.ID())
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{second.ID(), first.ID()}, found.AttachmentIDs())
+
+		page, err := messageRepo.ListConversation(ctx, bob.ID(), alice.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Len(t, page[0].Attachments(), 2)
+
+		again, err := do.NewMessageWithID(uuid.Nil, alice.ID(), bob.ID(), "", do.ReconstructAttachment(
+			first.ID(), alice.ID(), uuid.Nil, "cat.png", "image/png", 400, "abc", time.Now()))
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, again))
+		assert.ErrorIs(t, attachmentRepo.AttachToMessage(ctx, again), repository.ErrAttachmentUnavailable)
+
+		require.NoError(t, msg.Retract(alice.ID(), time.Hour))
+		require.NoError(t, messageRepo.Retract(ctx, msg))
+		found, err = messageRepo.FindByID(ctx, msg.ID())
+		require.NoError(t, err)
+		assert.Empty(t, found.Attachments())
+	})
+
+	t.Run("processing", func(t *testing.T) {
+		// first and second are pending from their upload
+		claimed, err := attachmentRepo.ClaimProcessing(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, 1, claimed[0].ProcessingAttempts())
+
+		// leased, so not claimed twice
+		again, err := attachmentRepo.ClaimProcessing(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, again)
+
+		info := do.ImageInfo{Width: 800, Height: 600, Blurhash: "LEHV6nWB2yk8", Variants: []do.AttachmentVariant{
+			{Name: "small", ContentType: "image/jpeg", Width: 160, Height: 120, Size: 3000},
+		}}
+		claimed[0].CompleteProcessing(info)
+		require.NoError(t, attachmentRepo.SaveProcessing(ctx, claimed[0], time.Now()))
+		// a retry due now is claimed again
+		require.True(t, claimed[1].FailProcessing(false, 5))
+		require.NoError(t, attachmentRepo.SaveProcessing(ctx, claimed[1], time.Now().Add(-time.Second)))
+
+		found, err := attachmentRepo.FindByID(ctx, claimed[0].ID())
+		require.NoError(t, err)
+		assert.Equal(t, do.ProcessingReady, found.Processing())
+		assert.Equal(t, info, found.Image())
+
+		retried, err := attachmentRepo.ClaimProcessing(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, retried, 1)
+		assert.Equal(t, claimed[1].ID(), retried[0].ID())
+		assert.Equal(t, 2, retried[0].ProcessingAttempts())
+
+		gone := do.ReconstructAttachment(uuid.New(), alice.ID(), uuid.Nil, "cat.png", "image/png", 1, "abc", time.Now())
+		assert.ErrorIs(t, attachmentRepo.SaveProcessing(ctx, gone, time.Now()), repository.ErrAttachmentNotFound)
+	})
+
+	t.Run("garbage and delete", func(t *testing.T) {
+		unsent := upload(t, 100)
+		require.NoError(t, attachmentRepo.Create(ctx, unsent, 1000))
+
+		garbage, err := attachmentRepo.ListGarbage(ctx, time.Now().Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Len(t, garbage, 3, "the retracted message's two and the unsent one")
+
+		for _, attachment := range garbage {
+			require.NoError(t, attachmentRepo.Delete(ctx, attachment))
+			require.NoError(t, attachmentRepo.Delete(ctx, attachment))
+		}
+		require.NoError(t, attachmentRepo.Create(ctx, upload(t, 1000), 1000), "the quota is free again")
+	})
+}
+
+func TestAttachmentRepository_DeleteOwner(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	attachmentRepo := postgres.NewAttachmentRepository(tdb.DB)
+	uploadRepo := postgres.NewUploadRepository(tdb.DB)
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	policy := do.AttachmentPolicy{MaxSize: 1 << 20, AllowedTypes: []string{"image/*"}}
+	upload := func(t *testing.T, owner *do.User, size int64) *do.Attachment {
+		attachment, err := do.NewAttachment(owner.ID(), "cat.png", "image/png", size, "abc", policy)
+		require.NoError(t, err)
+		require.NoError(t, attachmentRepo.Create(ctx, attachment, 1000))
+		return attachment
+	}
+	send := func(t *testing.T, from, to *do.User, attachment *do.Attachment) {
+		msg, err := do.NewMessageWithID(uuid.Nil, from.ID(), to.ID(), "", attachment)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		require.NoError(t, attachmentRepo.AttachToMessage(ctx, msg))
+	}
+
+	aliceUnsent := upload(t, alice, 300)
+	aliceSent := upload(t, alice, 200)
+	send(t, alice, bob, aliceSent)
+	bobSent := upload(t, bob, 400)
+	send(t, bob, alice, bobSent)
+	bobUnsent := upload(t, bob, 100)
+
+	staged, err := do.NewUpload(alice.ID(), "video.mp4", 100, do.AttachmentPolicy{MaxSize: 1 << 20})
+	require.NoError(t, err)
+	require.NoError(t, uploadRepo.Create(ctx, staged))
+
+	require.NoError(t, userRepo.Delete(ctx, alice.ID()))
+
+	// recent unsent attachments and uploads are not due yet
+	due := time.Now().Add(-time.Hour)
+	garbage, err := attachmentRepo.ListGarbage(ctx, due, 10)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(garbage))
+	for i, attachment := range garbage {
+		ids[i] = attachment.ID()
+	}
+	assert.ElementsMatch(t, []uuid.UUID{aliceUnsent.ID(), aliceSent.ID(), bobSent.ID()}, ids)
+
+	expired, err := uploadRepo.ListExpired(ctx, due, 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, staged.ID(), expired[0].ID())
+
+	// bob is still charged for the attachment of the deleted conversation
+	assert.ErrorIs(t, attachmentRepo.Create(ctx, do.ReconstructAttachment(
+		uuid.New(), bob.ID(), uuid.Nil, "cat.png", "image/png", 600, "abc", time.Now()), 1000), repository.ErrQuotaExceeded)
+
+	for _, attachment := range garbage {
+		require.NoError(t, attachmentRepo.Delete(ctx, attachment))
+	}
+
+	garbage, err = attachmentRepo.ListGarbage(ctx, due, 10)
+	require.NoError(t, err)
+	assert.Empty(t, garbage)
+
+	_, err = attachmentRepo.FindByID(ctx, bobUnsent.ID())
+	require.NoError(t, err)
+	upload(t, bob, 900) // the quota minus bobUnsent is free again
+}
+
+func TestUploadRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	uploadRepo := postgres.NewUploadRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	require.NoError(t, userRepo.Create(ctx, alice))
+
+	upload, err := do.NewUpload(alice.ID(), "video.mp4", 100, do.AttachmentPolicy{MaxSize: 1 << 20})
+	require.NoError(t, err)
+	require.NoError(t, uploadRepo.Create(ctx, upload))
+
+	upload.Advance(40)
+	require.NoError(t, uploadRepo.Advance(ctx, upload, 0))
+	assert.ErrorIs(t, uploadRepo.Advance(ctx, upload, 0), repository.ErrUploadConflict)
+
+	found, err := uploadRepo.FindByID(ctx, upload.ID())
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), found.Received())
+
+	expired, err := uploadRepo.ListExpired(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, expired, 1)
+
+	require.NoError(t, uploadRepo.Delete(ctx, upload.ID()))
+	require.NoError(t, uploadRepo.Delete(ctx, upload.ID()))
+	_, err = uploadRepo.FindByID(ctx, upload.ID())
+	assert.ErrorIs(t, err, repository.ErrUploadNotFound)
+}
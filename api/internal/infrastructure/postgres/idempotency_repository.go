@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// conn joins the transaction carried by ctx, if any
+func (r *IdempotencyRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
+// Save inserts the key; on conflict the existing row is only replaced when
+// it has expired, otherwise no row is touched and the key counts as taken.
+// A concurrent insert of the same key waits for the first to commit.
+func (r *IdempotencyRepository) Save(ctx context.Context, key *do.IdempotencyKey, expiredBefore time.Time) error {
+	query := `
+		INSERT INTO message_idempotency_keys AS k (sender_id, key, request_hash, message_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (sender_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    message_id = EXCLUDED.message_id,
+		    created_at = EXCLUDED.created_at
+		WHERE k.created_at < $6
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		key.SenderID(),
+		key.Key(),
+		key.RequestHash(),
+		key.MessageID(),
+		key.CreatedAt(),
+		expiredBefore,
+	)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrIdempotencyRepository)
+	}
+	return affectedOne(result, repository.ErrDuplicateIdempotencyKey, repository.ErrIdempotencyRepository)
+}
+
+func (r *IdempotencyRepository) Find(ctx context.Context, senderID uuid.UUID, key string) (*do.IdempotencyKey, error) {
+	query := `
+		SELECT sender_id, key, request_hash, message_id, created_at
+		FROM message_idempotency_keys
+		WHERE sender_id = $1 AND key = $2
+	`
+
+	var (
+		sender      uuid.UUID
+		k           string
+		requestHash string
+		messageID   uuid.UUID
+		createdAt   time.Time
+	)
+	err := r.conn(ctx).QueryRowContext(ctx, query, senderID, key).Scan(
+		&sender, &k, &requestHash, &messageID, &createdAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pgdb.WrapError(err, repository.ErrIdempotencyKeyNotFound)
+		}
+		return nil, pgdb.WrapError(err, repository.ErrIdempotencyRepository)
+	}
+	return do.ReconstructIdempotencyKey(sender, k, requestHash, messageID, createdAt), nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	query := `DELETE FROM message_idempotency_keys WHERE created_at < $1`
+	result, err := r.conn(ctx).ExecContext(ctx, query, expiredBefore)
+	if err != nil {
+		return 0, pgdb.WrapError(err, repository.ErrIdempotencyRepository)
+	}
+	n, err := result.RowsAffected()
+	return n, pgdb.WrapError(err, repository.ErrIdempotencyRepository)
+}
@@ -0,0 +1,75 @@
+package postgres_test
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/infrastructure/postgres"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	keyRepo := postgres.NewIdempotencyRepository(tdb.DB)
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	sender, _ := do.NewUser("sender@example.com", "password123", "sender")
+	receiver, _ := do.NewUser("receiver@example.com", "password123", "receiver")
+	require.NoError(t, userRepo.Create(ctx, sender))
+	require.NoError(t, userRepo.Create(ctx, receiver))
+
+	newKey := func(t *testing.T, content string) *do.IdempotencyKey {
+		msg, err := do.NewMessage(sender.ID(), receiver.ID(), content)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		key, err := do.NewIdempotencyKey("retry-1", msg)
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("save and find", func(t *testing.T) {
+		key := newKey(t, "Hello")
+		require.NoError(t, keyRepo.Save(ctx, key, time.Now().Add(-time.Hour)))
+
+		found, err := keyRepo.Find(ctx, sender.ID(), "retry-1")
+		require.NoError(t, err)
+		assert.Equal(t, key.MessageID(), found.MessageID())
+		assert.True(t, found.Matches(receiver.ID(), uuid.Nil, "Hello"))
+	})
+
+	t.Run("live key is taken", func(t *testing.T) {
+		err := keyRepo.Save(ctx, newKey(t, "Again"), time.Now().Add(-time.Hour))
+		assert.ErrorIs(t, err, repository.ErrDuplicateIdempotencyKey)
+	})
+
+	t.Run("expired key is replaced", func(t *testing.T) {
+		key := newKey(t, "Later")
+		require.NoError(t, keyRepo.Save(ctx, key, time.Now().Add(time.Minute)))
+
+		found, err := keyRepo.Find(ctx, sender.ID(), "retry-1")
+		require.NoError(t, err)
+		assert.Equal(t, key.MessageID(), found.MessageID())
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		deleted, err := keyRepo.DeleteExpired(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		_, err = keyRepo.Find(ctx, sender.ID(), "retry-1")
+		assert.ErrorIs(t, err, repository.ErrIdempotencyKeyNotFound)
+	})
+}
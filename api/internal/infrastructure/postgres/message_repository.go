@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,203 +23,559 @@ func NewMessageRepository(db *sqlx.DB) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
+// conn joins the transaction carried by ctx, if any
+func (r *MessageRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
 func (r *MessageRepository) Create(ctx context.Context, msg *do.Message) error {
 	query := `
-		INSERT INTO messages (id, sender_id, receiver_id, content, created_at, read_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO messages (id, sender_id, receiver_id, content, created_at, delivered_at, read_at, reply_to_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		msg.ID(),
 		msg.SenderID(),
 		msg.ReceiverID(),
 		msg.Content(),
 		msg.CreatedAt(),
+		msg.DeliveredAt(),
 		msg.ReadAt(),
+		uuid.NullUUID{UUID: msg.ReplyToID(), Valid: msg.IsReply()},
 	)
-	return err
+	if pgdb.ConstraintName(err) == "messages_pkey" {
+		return pgdb.WrapError(err, repository.ErrDuplicateMessageID)
+	}
+	return pgdb.WrapError(err, repository.ErrMessageRepository)
 }
 
-func (r *MessageRepository) FindByID(ctx context.Context, id uuid.UUID) (*do.Message, error) {
-	query := `
-		SELECT id, sender_id, receiver_id, content, created_at, read_at
-		FROM messages
-		WHERE id = $1
-	`
+// messageColumns are the columns scanMessage reads, in order: message m
+// and the preview of the message q it replies to, joined by quoteJoin. One
+// character past the preview length tells whether the quote was cut; a
+// quote the viewer deleted for themselves reads as deleted and empty.
+var messageColumns = `
+	m.id, m.sender_id, m.receiver_id, m.content, m.created_at, m.delivered_at, m.read_at, m.edited_at, m.deleted_at,
+	q.id, q.sender_id,
+	CASE WHEN qh.message_id IS NULL THEN LEFT(q.content, ` + strconv.Itoa(do.QuotePreviewLength+1) + `) ELSE '' END,
+	COALESCE(q.deleted_at, qh.hidden_at)`
+
+// quoteJoin joins the message m replies to as q, and as qh whether viewer,
+// a query parameter or NULL::uuid for none, deleted it for themselves
+func quoteJoin(viewer string) string {
+	return `
+		LEFT JOIN messages q ON q.id = m.reply_to_id
+		LEFT JOIN message_hidden qh ON qh.message_id = q.id AND qh.user_id = ` + viewer
+}
 
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanMessage reads messageColumns followed by extra
+func scanMessage(row rowScanner, extra ...any) (*do.Message, error) {
 	var (
-		msgID      uuid.UUID
-		senderID   uuid.UUID
-		receiverID uuid.UUID
-		content    string
-		createdAt  time.Time
-		readAt     sql.NullTime
+		id             uuid.UUID
+		senderID       uuid.UUID
+		receiverID     uuid.UUID
+		content        string
+		createdAt      time.Time
+		deliveredAt    sql.NullTime
+		readAt         sql.NullTime
+		editedAt       sql.NullTime
+		deletedAt      sql.NullTime
+		quoteID        uuid.NullUUID
+		quoteSenderID  uuid.NullUUID
+		quoteContent   sql.NullString
+		quoteDeletedAt sql.NullTime
 	)
+	dest := append([]any{
+		&id, &senderID, &receiverID, &content, &createdAt, &deliveredAt, &readAt, &editedAt, &deletedAt,
+		&quoteID, &quoteSenderID, &quoteContent, &quoteDeletedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&msgID, &senderID, &receiverID, &content, &createdAt, &readAt,
-	)
+	var quote *do.MessageQuote
+	if quoteID.Valid {
+		quote = do.NewMessageQuote(quoteID.UUID, quoteSenderID.UUID, quoteContent.String, quoteDeletedAt.Valid)
+	}
+	return do.ReconstructMessage(id, senderID, receiverID, content, createdAt,
+		nullTime(deliveredAt), nullTime(readAt), nullTime(editedAt), nullTime(deletedAt), quote), nil
+}
+
+func (r *MessageRepository) FindByID(ctx context.Context, id uuid.UUID) (*do.Message, error) {
+	query := `SELECT ` + messageColumns + ` FROM messages m ` + quoteJoin("NULL::uuid") + ` WHERE m.id = $1`
 
+	msg, err := scanMessage(r.conn(ctx).QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("message not found")
+			return nil, pgdb.WrapError(err, repository.ErrMessageNotFound)
 		}
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	if err := loadAttachments(ctx, r.conn(ctx), msg); err != nil {
 		return nil, err
 	}
+	return msg, nil
+}
 
-	var readAtPtr *time.Time
-	if readAt.Valid {
-		readAtPtr = &readAt.Time
+func (r *MessageRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*do.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	return do.ReconstructMessage(msgID, senderID, receiverID, content, createdAt, readAtPtr), nil
+	query := `
+		SELECT ` + messageColumns + `
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS wanted(id, n)
+		JOIN messages m ON m.id = wanted.id
+		` + quoteJoin("NULL::uuid") + `
+		ORDER BY wanted.n
+	`
+	rows, err := r.conn(ctx).QueryContext(ctx, query, uuidArray(ids))
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	return r.collectMessages(ctx, rows)
+}
+
+// UpdateDeliveredAt leaves messages delivered before alone, so the first
+// device to acknowledge a message sets the time
+func (r *MessageRepository) UpdateDeliveredAt(ctx context.Context, ids []uuid.UUID, deliveredAt time.Time) error {
+	query := `
+		UPDATE messages
+		SET delivered_at = $1
+		WHERE id = ANY($2::uuid[]) AND delivered_at IS NULL
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, deliveredAt, uuidArray(ids))
+	return pgdb.WrapError(err, repository.ErrMessageRepository)
 }
 
 func (r *MessageRepository) UpdateReadAt(ctx context.Context, id uuid.UUID, readAt time.Time) error {
 	query := `
 		UPDATE messages
-		SET read_at = $1
+		SET read_at = $1, delivered_at = COALESCE(delivered_at, $1)
 		WHERE id = $2
 	`
-	_, err := r.db.ExecContext(ctx, query, readAt, id)
-	return err
+	_, err := r.conn(ctx).ExecContext(ctx, query, readAt, id)
+	return pgdb.WrapError(err, repository.ErrMessageRepository)
 }
 
-func (r *MessageRepository) ListConversation(ctx context.Context, userA, userB uuid.UUID, limit, offset int) ([]*do.Message, error) {
+func (r *MessageRepository) MarkConversationRead(ctx context.Context, readerID, otherUserID uuid.UUID, upTo, readAt time.Time) (int, error) {
 	query := `
-		SELECT id, sender_id, receiver_id, content, created_at, read_at
-		FROM messages
-		WHERE (sender_id = $1 AND receiver_id = $2)
-		   OR (sender_id = $2 AND receiver_id = $1)
-		ORDER BY created_at DESC
-		LIMIT $3 OFFSET $4
+		UPDATE messages
+		SET read_at = $1, delivered_at = COALESCE(delivered_at, $1)
+		WHERE receiver_id = $2 AND sender_id = $3
+		  AND read_at IS NULL AND created_at <= $4
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query, readAt, readerID, otherUserID, upTo)
+	if err != nil {
+		return 0, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	count, err := result.RowsAffected()
+	return int(count), pgdb.WrapError(err, repository.ErrMessageRepository)
+}
+
+// Edit only applies while the stored content is still the version the
+// revision replaced, so of two concurrent edits the second one conflicts
+func (r *MessageRepository) Edit(ctx context.Context, msg *do.Message, revision *do.MessageRevision) error {
+	query := `
+		WITH edited AS (
+			UPDATE messages
+			SET content = $2, edited_at = $3
+			WHERE id = $1 AND content = $4 AND deleted_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO message_revisions (message_id, content, created_at, replaced_at)
+		SELECT id, $4, $5, $3 FROM edited
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		msg.ID(),
+		msg.Content(),
+		msg.EditedAt(),
+		revision.Content(),
+		revision.CreatedAt(),
+	)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	return affectedOne(result, repository.ErrMessageEditConflict, repository.ErrMessageRepository)
+}
+
+// Retract empties the content, drops the revisions that still hold it and
+// the reactions to it, leaving the message as a placeholder; an already
+// retracted message is kept as it was
+func (r *MessageRepository) Retract(ctx context.Context, msg *do.Message) error {
+	query := `
+		WITH retracted AS (
+			UPDATE messages
+			SET content = '', deleted_at = $2
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id
+		),
+		revisions AS (
+			DELETE FROM message_revisions
+			WHERE message_id IN (SELECT id FROM retracted)
+		)
+		DELETE FROM message_reactions
+		WHERE message_id IN (SELECT id FROM retracted)
 	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, msg.ID(), msg.DeletedAt())
+	return pgdb.WrapError(err, repository.ErrMessageRepository)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userA, userB, limit, offset)
+func (r *MessageRepository) Hide(ctx context.Context, messageID, userID uuid.UUID, hiddenAt time.Time) error {
+	query := `
+		INSERT INTO message_hidden (user_id, message_id, hidden_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, message_id) DO NOTHING
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, userID, messageID, hiddenAt)
+	return pgdb.WrapError(err, repository.ErrMessageRepository)
+}
+
+func (r *MessageRepository) ListRevisions(ctx context.Context, messageID uuid.UUID) ([]*do.MessageRevision, error) {
+	query := `
+		SELECT content, created_at, replaced_at
+		FROM message_revisions
+		WHERE message_id = $1
+		ORDER BY replaced_at, id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, messageID)
 	if err != nil {
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
 	}
 	defer rows.Close()
 
-	var messages []*do.Message
+	var revisions []*do.MessageRevision
 	for rows.Next() {
 		var (
-			id         uuid.UUID
-			senderID   uuid.UUID
-			receiverID uuid.UUID
 			content    string
 			createdAt  time.Time
-			readAt     sql.NullTime
+			replacedAt time.Time
 		)
+		if err := rows.Scan(&content, &createdAt, &replacedAt); err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+		}
+		revisions = append(revisions, do.ReconstructMessageRevision(messageID, content, createdAt, replacedAt))
+	}
+
+	return revisions, pgdb.WrapError(rows.Err(), repository.ErrMessageRepository)
+}
+
+func (r *MessageRepository) ListConversation(ctx context.Context, userA, userB uuid.UUID, limit, offset int) ([]*do.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		` + quoteJoin("$1") + `
+		WHERE ((m.sender_id = $1 AND m.receiver_id = $2)
+		    OR (m.sender_id = $2 AND m.receiver_id = $1))
+		  AND NOT EXISTS (
+			SELECT 1 FROM message_hidden h
+			WHERE h.user_id = $1 AND h.message_id = m.id
+		  )
+		ORDER BY m.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
 
-		if err := rows.Scan(&id, &senderID, &receiverID, &content, &createdAt, &readAt); err != nil {
-			return nil, err
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userA, userB, limit, offset)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	return r.collectMessages(ctx, rows)
+}
+
+func (r *MessageRepository) ListReplies(ctx context.Context, messageID, viewerID uuid.UUID, limit, offset int) ([]*do.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		` + quoteJoin("$2") + `
+		WHERE m.reply_to_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM message_hidden h
+			WHERE h.user_id = $2 AND h.message_id = m.id
+		  )
+		ORDER BY m.created_at, m.id
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, messageID, viewerID, limit, offset)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	return r.collectMessages(ctx, rows)
+}
+
+// collectMessages scans and closes rows of messageColumns, then loads the
+// attachments of the page
+func (r *MessageRepository) collectMessages(ctx context.Context, rows *sql.Rows) ([]*do.Message, error) {
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadAttachments(ctx, r.conn(ctx), messages...); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]*do.Message, error) {
+	defer rows.Close()
+
+	var messages []*do.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
 		}
+		messages = append(messages, msg)
+	}
+
+	return messages, pgdb.WrapError(rows.Err(), repository.ErrMessageRepository)
+}
+
+// headlineOptions make ts_headline mark matches the way do.ParseHighlight
+// reads them, keeping a fragment or two around them
+var headlineOptions = `StartSel="` + do.HighlightStart + `", StopSel="` + do.HighlightStop +
+	`", MaxWords=24, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "`
+
+// Search matches words against the search_vector index; a query in a script
+// without spaces matches every term as a substring instead, which the
+// trigram index serves, and its snippet is cut here rather than by
+// ts_headline, which would not find the terms either
+func (r *MessageRepository) Search(ctx context.Context, viewerID uuid.UUID, query *do.SearchQuery, after *do.SearchCursor, limit int) ([]*do.SearchHit, error) {
+	match := `m.search_vector @@ websearch_to_tsquery('english', $2)`
+	snippet := `ts_headline('english', translate(m.content, E'\x02\x03', ''), websearch_to_tsquery('english', $2), $10)`
+	var (
+		terms any = query.Text()
+		extra []any
+	)
+	if query.Substring() {
+		// one ILIKE per term: the trigram index serves ANDed ILIKEs, but
+		// not ILIKE ALL over an array. The first term takes $2, the rest
+		// follow the shared parameters.
+		snippet = `NULL::text`
+		conditions := make([]string, len(query.Terms()))
+		for i, term := range query.Terms() {
+			pattern := "%" + likeEscaper.Replace(term) + "%"
+			if i == 0 {
+				terms = pattern
+				conditions[i] = `m.content ILIKE $2`
+				continue
+			}
+			extra = append(extra, pattern)
+			conditions[i] = `m.content ILIKE $` + strconv.Itoa(9+len(extra))
+		}
+		match = "(" + strings.Join(conditions, " AND ") + ")"
+	}
+
+	statement := `
+		SELECT ` + messageColumns + `, ` + snippet + `
+		FROM messages m
+		` + quoteJoin("$1") + `
+		WHERE (m.sender_id = $1 OR m.receiver_id = $1)
+		  AND m.deleted_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM message_hidden h
+			WHERE h.user_id = $1 AND h.message_id = m.id
+		  )
+		  AND ` + match + `
+		  AND ($3::uuid IS NULL OR m.sender_id = $3)
+		  AND ($4::timestamptz IS NULL OR m.created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR m.created_at < $5)
+		  AND (NOT $6::boolean OR EXISTS (SELECT 1 FROM attachments a WHERE a.message_id = m.id))
+		  AND ($7::timestamptz IS NULL OR (m.created_at, m.id) < ($7::timestamptz, $8::uuid))
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $9
+	`
+	filter := query.Filter()
+	var cursor do.SearchCursor
+	if after != nil {
+		cursor = *after
+	}
+	args := []any{
+		viewerID,
+		terms,
+		uuid.NullUUID{UUID: filter.SenderID, Valid: filter.SenderID != uuid.Nil},
+		sqlTime(filter.After),
+		sqlTime(filter.Before),
+		filter.HasAttachment,
+		sqlTime(cursor.CreatedAt),
+		uuid.NullUUID{UUID: cursor.ID, Valid: after != nil},
+		limit,
+	}
+	if query.Substring() {
+		args = append(args, extra...)
+	} else {
+		args = append(args, headlineOptions)
+	}
 
-		var readAtPtr *time.Time
-		if readAt.Valid {
-			readAtPtr = &readAt.Time
+	rows, err := r.conn(ctx).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	defer rows.Close()
+
+	var (
+		hits     []*do.SearchHit
+		messages []*do.Message
+	)
+	for rows.Next() {
+		var marked sql.NullString
+		msg, err := scanMessage(rows, &marked)
+		if err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
 		}
+		hit := &do.SearchHit{Message: msg, Snippet: do.ParseHighlight(marked.String)}
+		if query.Substring() {
+			hit.Snippet = do.Highlight(msg.Content(), query.Terms())
+		}
+		hits = append(hits, hit)
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
 
-		messages = append(messages, do.ReconstructMessage(id, senderID, receiverID, content, createdAt, readAtPtr))
+	if err := loadAttachments(ctx, r.conn(ctx), messages...); err != nil {
+		return nil, err
 	}
+	return hits, nil
+}
 
-	return messages, rows.Err()
+// likeEscaper makes user text match literally in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlTime is NULL for the zero time
+func sqlTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
 
 func (r *MessageRepository) ListUserConversations(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*do.ConversationPreview, error) {
 	// Get latest message from each conversation using ROW_NUMBER
 	query := `
-		WITH conversation_messages AS (
-			-- Identify other user and rank messages by time
-			SELECT 
-				id, 
-				sender_id, 
-				receiver_id, 
-				content, 
-				created_at, 
-				read_at,
-				CASE 
-					WHEN sender_id = $1 THEN receiver_id 
-					ELSE sender_id 
-				END as other_user_id,
+		WITH visible_messages AS (
+			-- Messages the user has not deleted for themselves, with the other user
+			SELECT
+				id, receiver_id, created_at, read_at, deleted_at,
+				CASE
+					WHEN sender_id = $1 THEN receiver_id
+					ELSE sender_id
+				END as other_user_id
+			FROM messages m
+			WHERE (sender_id = $1 OR receiver_id = $1)
+			  AND NOT EXISTS (
+				SELECT 1 FROM message_hidden h
+				WHERE h.user_id = $1 AND h.message_id = m.id
+			  )
+		),
+		conversation_messages AS (
+			-- Rank messages by time; a message deleted for everyone still
+			-- counts, its placeholder is the preview
+			SELECT
+				id,
+				other_user_id,
 				ROW_NUMBER() OVER (
-					PARTITION BY CASE 
-						WHEN sender_id = $1 THEN receiver_id 
-						ELSE sender_id 
-					END
+					PARTITION BY other_user_id
 					ORDER BY created_at DESC, id DESC
 				) as rn
-			FROM messages
-			WHERE sender_id = $1 OR receiver_id = $1
-		),
-		latest_messages AS (
-			-- Get only the latest message (rn = 1) from each conversation
-			SELECT id, sender_id, receiver_id, content, created_at, read_at, other_user_id
-			FROM conversation_messages
-			WHERE rn = 1
+			FROM visible_messages
 		),
 		unread_counts AS (
-			-- Count unread messages from each user
-			SELECT 
-				sender_id as other_user_id,
+			-- Count unread messages from each user, deleted ones left out
+			SELECT
+				other_user_id,
 				COUNT(*) as unread_count
-			FROM messages
-			WHERE receiver_id = $1 AND read_at IS NULL
-			GROUP BY sender_id
+			FROM visible_messages
+			WHERE receiver_id = $1 AND read_at IS NULL AND deleted_at IS NULL
+			GROUP BY other_user_id
 		)
-		SELECT 
-			lm.id, lm.sender_id, lm.receiver_id, lm.content, lm.created_at, lm.read_at,
-			u.id, u.email, u.password, u.username, u.created_at,
+		SELECT
+			` + messageColumns + `,
+			u.id, u.email, u.password, u.username, u.created_at, u.suspended_at,
 			COALESCE(uc.unread_count, 0) as unread_count
-		FROM latest_messages lm
-		JOIN users u ON u.id = lm.other_user_id
-		LEFT JOIN unread_counts uc ON uc.other_user_id = lm.other_user_id
-		ORDER BY lm.created_at DESC
+		FROM conversation_messages cm
+		JOIN messages m ON m.id = cm.id
+		` + quoteJoin("$1") + `
+		JOIN users u ON u.id = cm.other_user_id
+		LEFT JOIN unread_counts uc ON uc.other_user_id = cm.other_user_id
+		-- Get only the latest message (rn = 1) from each conversation
+		WHERE cm.rn = 1
+		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
 	}
 	defer rows.Close()
 
 	var previews []*do.ConversationPreview
 	for rows.Next() {
 		var (
-			msgID         uuid.UUID
-			senderID      uuid.UUID
-			receiverID    uuid.UUID
-			content       string
-			msgCreatedAt  time.Time
-			readAt        sql.NullTime
 			userID        uuid.UUID
 			email         string
 			password      string
 			username      string
 			userCreatedAt time.Time
+			suspendedAt   sql.NullTime
 			unreadCount   int
 		)
 
-		if err := rows.Scan(
-			&msgID, &senderID, &receiverID, &content, &msgCreatedAt, &readAt,
-			&userID, &email, &password, &username, &userCreatedAt,
+		msg, err := scanMessage(rows,
+			&userID, &email, &password, &username, &userCreatedAt, &suspendedAt,
 			&unreadCount,
-		); err != nil {
-			return nil, err
-		}
-
-		var readAtPtr *time.Time
-		if readAt.Valid {
-			readAtPtr = &readAt.Time
+		)
+		if err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
 		}
 
 		previews = append(previews, &do.ConversationPreview{
-			OtherUser:   do.ReconstructUser(userID, email, password, username, userCreatedAt),
-			LastMessage: do.ReconstructMessage(msgID, senderID, receiverID, content, msgCreatedAt, readAtPtr),
+			OtherUser:   do.ReconstructUser(userID, email, password, username, userCreatedAt, nullTime(suspendedAt)),
+			LastMessage: msg,
 			UnreadCount: unreadCount,
 		})
 	}
 
-	return previews, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+
+	messages := make([]*do.Message, len(previews))
+	for i, preview := range previews {
+		messages[i] = preview.LastMessage
+	}
+	if err := loadAttachments(ctx, r.conn(ctx), messages...); err != nil {
+		return nil, err
+	}
+	return previews, nil
+}
+
+// Statistics aggregates users, messages and conversations across the whole system
+func (r *MessageRepository) Statistics(ctx context.Context, since time.Time) (*do.Statistics, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE suspended_at IS NOT NULL),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE read_at IS NULL),
+			COUNT(*) FILTER (WHERE created_at >= $1),
+			COUNT(DISTINCT (LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id))),
+			COUNT(DISTINCT (LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id))) FILTER (WHERE created_at >= $1)
+		FROM messages
+	`
+
+	stats := &do.Statistics{Since: since}
+	err := r.conn(ctx).QueryRowContext(ctx, query, since).Scan(
+		&stats.Users, &stats.SuspendedUsers,
+		&stats.Messages, &stats.UnreadMessages, &stats.MessagesSince,
+		&stats.Conversations, &stats.ActiveConversations,
+	)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrMessageRepository)
+	}
+	return stats, nil
 }
@@ -3,10 +3,14 @@ package postgres_test
 import (
 	"context"
 	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
 	"hilo-api/internal/infrastructure/postgres"
+	"hilo-api/pkg/errorCatcher"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -88,6 +92,8 @@ func TestMessageRepository_FindByID(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, found)
 		assert.Contains(t, err.Error(), "not found")
+		assert.ErrorIs(t, err, repository.ErrMessageNotFound)
+		assert.ErrorIs(t, err, errorCatcher.ErrDatabaseRowNotFound)
 	})
 }
 
@@ -125,6 +131,31 @@ func TestMessageRepository_UpdateReadAt(t *testing.T) {
 		assert.True(t, found.IsRead())
 		assert.NotNil(t, found.ReadAt())
 		assert.WithinDuration(t, readAt, *found.ReadAt(), time.Second)
+		assert.Equal(t, do.MessageRead, found.State(), "reading delivers too")
+		assert.Equal(t, found.ReadAt(), found.DeliveredAt())
+	})
+
+	t.Run("update delivered_at once", func(t *testing.T) {
+		sender, _ := do.NewUser("courier@example.com", "password123", "courier")
+		receiver, _ := do.NewUser("recipient@example.com", "password123", "recipient")
+		require.NoError(t, userRepo.Create(ctx, sender))
+		require.NoError(t, userRepo.Create(ctx, receiver))
+
+		first, _ := do.NewMessage(sender.ID(), receiver.ID(), "first")
+		second, _ := do.NewMessage(sender.ID(), receiver.ID(), "second")
+		require.NoError(t, messageRepo.Create(ctx, first))
+		require.NoError(t, messageRepo.Create(ctx, second))
+
+		deliveredAt := time.Now().Add(-time.Minute)
+		require.NoError(t, messageRepo.UpdateDeliveredAt(ctx, []uuid.UUID{first.ID()}, deliveredAt))
+		require.NoError(t, messageRepo.UpdateDeliveredAt(ctx, []uuid.UUID{first.ID(), second.ID()}, time.Now()))
+
+		found, err := messageRepo.FindByIDs(ctx, []uuid.UUID{second.ID(), uuid.New(), first.ID()})
+		require.NoError(t, err)
+		require.Len(t, found, 2, "unknown ids are left out")
+		assert.Equal(t, second.ID(), found[0].ID())
+		assert.Equal(t, do.MessageDelivered, found[0].State())
+		assert.WithinDuration(t, deliveredAt, *found[1].DeliveredAt(), time.Second, "the first acknowledgement wins")
 	})
 }
 
@@ -282,3 +313,402 @@ func TestMessageRepository_ListUserConversations(t *testing.T) {
 		assert.Empty(t, previews)
 	})
 }
+
+func TestMessageRepository_MarkConversationRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	carol, _ := do.NewUser("carol@example.com", "password123", "carol")
+	for _, user := range []*do.User{alice, bob, carol} {
+		require.NoError(t, userRepo.Create(ctx, user))
+	}
+
+	start := time.Now().Add(-time.Hour)
+	var fromBob []*do.Message
+	for i := range 3 {
+		msg := do.ReconstructMessage(uuid.New(), bob.ID(), alice.ID(), "hi", start.Add(time.Duration(i)*time.Minute), nil, nil, nil, nil, nil)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		fromBob = append(fromBob, msg)
+	}
+	fromCarol, _ := do.NewMessage(carol.ID(), alice.ID(), "hi")
+	toBob, _ := do.NewMessage(alice.ID(), bob.ID(), "hi")
+	require.NoError(t, messageRepo.Create(ctx, fromCarol))
+	require.NoError(t, messageRepo.Create(ctx, toBob))
+
+	readAt := time.Now()
+	count, err := messageRepo.MarkConversationRead(ctx, alice.ID(), bob.ID(), fromBob[1].CreatedAt(), readAt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	found, err := messageRepo.FindByIDs(ctx, []uuid.UUID{fromBob[0].ID(), fromBob[2].ID(), fromCarol.ID(), toBob.ID()})
+	require.NoError(t, err)
+	assert.Equal(t, do.MessageRead, found[0].State())
+	assert.WithinDuration(t, readAt, *found[0].DeliveredAt(), time.Second, "reading delivers too")
+	assert.Equal(t, do.MessageSent, found[1].State(), "created after the point read up to")
+	assert.Equal(t, do.MessageSent, found[2].State(), "another conversation")
+	assert.Equal(t, do.MessageSent, found[3].State(), "alice sent it")
+
+	count, err = messageRepo.MarkConversationRead(ctx, alice.ID(), bob.ID(), time.Now(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "messages read before are left alone")
+}
+
+func TestMessageRepository_Statistics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	carol, _ := do.NewUser("carol@example.com", "password123", "carol")
+	for _, user := range []*do.User{alice, bob, carol} {
+		require.NoError(t, userRepo.Create(ctx, user))
+	}
+	carol.Suspend()
+	require.NoError(t, userRepo.Update(ctx, carol))
+
+	old := do.ReconstructMessage(uuid.New(), alice.ID(), carol.ID(), "old", time.Now().Add(-48*time.Hour), nil, nil, nil, nil, nil)
+	require.NoError(t, messageRepo.Create(ctx, old))
+	for _, pair := range [][2]*do.User{{alice, bob}, {bob, alice}} {
+		msg, _ := do.NewMessage(pair[0].ID(), pair[1].ID(), "hi")
+		require.NoError(t, messageRepo.Create(ctx, msg))
+	}
+	read, _ := do.NewMessage(alice.ID(), bob.ID(), "read me")
+	require.NoError(t, read.MarkAsRead(bob.ID()))
+	require.NoError(t, messageRepo.Create(ctx, read))
+
+	since := time.Now().Add(-24 * time.Hour)
+	stats, err := messageRepo.Statistics(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, &do.Statistics{
+		Users:               3,
+		SuspendedUsers:      1,
+		Messages:            4,
+		UnreadMessages:      3,
+		Conversations:       2,
+		Since:               since,
+		MessagesSince:       3,
+		ActiveConversations: 1,
+	}, stats)
+}
+
+func TestMessageRepository_Edit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	sender, _ := do.NewUser("sender@example.com", "password123", "sender")
+	receiver, _ := do.NewUser("receiver@example.com", "password123", "receiver")
+	require.NoError(t, userRepo.Create(ctx, sender))
+	require.NoError(t, userRepo.Create(ctx, receiver))
+
+	msg, _ := do.NewMessage(sender.ID(), receiver.ID(), "Helo")
+	require.NoError(t, messageRepo.Create(ctx, msg))
+
+	t.Run("edit keeps the revision", func(t *testing.T) {
+		stored, err := messageRepo.FindByID(ctx, msg.ID())
+		require.NoError(t, err)
+		revision, err := stored.Edit(sender.ID(), "Hello", time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Edit(ctx, stored, revision))
+
+		found, err := messageRepo.FindByID(ctx, msg.ID())
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", found.Content())
+		assert.True(t, found.IsEdited())
+
+		revisions, err := messageRepo.ListRevisions(ctx, msg.ID())
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, "Helo", revisions[0].Content())
+	})
+
+	t.Run("stale edit conflicts", func(t *testing.T) {
+		// loaded before the edit above, so still holding the first version
+		revision, err := msg.Edit(sender.ID(), "Hi", time.Hour)
+		require.NoError(t, err)
+
+		err = messageRepo.Edit(ctx, msg, revision)
+		assert.ErrorIs(t, err, repository.ErrMessageEditConflict)
+
+		revisions, err := messageRepo.ListRevisions(ctx, msg.ID())
+		require.NoError(t, err)
+		assert.Len(t, revisions, 1)
+	})
+}
+
+func TestMessageRepository_Delete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	send := func(t *testing.T, from, to *do.User, content string) *do.Message {
+		msg, err := do.NewMessage(from.ID(), to.ID(), content)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		time.Sleep(time.Millisecond) // keep created_at ordered
+		return msg
+	}
+	first := send(t, alice, bob, "first")
+	second := send(t, alice, bob, "second")
+	third := send(t, alice, bob, "third")
+
+	t.Run("hidden for one side only", func(t *testing.T) {
+		require.NoError(t, messageRepo.Hide(ctx, third.ID(), bob.ID(), time.Now()))
+		require.NoError(t, messageRepo.Hide(ctx, third.ID(), bob.ID(), time.Now()), "hiding twice is fine")
+
+		forBob, err := messageRepo.ListConversation(ctx, bob.ID(), alice.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, forBob, 2)
+		assert.Equal(t, second.ID(), forBob[0].ID())
+
+		forAlice, err := messageRepo.ListConversation(ctx, alice.ID(), bob.ID(), 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, forAlice, 3)
+
+		previews, err := messageRepo.ListUserConversations(ctx, bob.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, previews, 1)
+		assert.Equal(t, second.ID(), previews[0].LastMessage.ID())
+		assert.Equal(t, 2, previews[0].UnreadCount)
+	})
+
+	t.Run("retracted stays as placeholder", func(t *testing.T) {
+		require.NoError(t, second.Retract(alice.ID(), time.Hour))
+		require.NoError(t, messageRepo.Retract(ctx, second))
+
+		found, err := messageRepo.FindByID(ctx, second.ID())
+		require.NoError(t, err)
+		assert.True(t, found.IsDeleted())
+		assert.Empty(t, found.Content())
+
+		previews, err := messageRepo.ListUserConversations(ctx, bob.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, previews, 1)
+		assert.Equal(t, second.ID(), previews[0].LastMessage.ID())
+		assert.True(t, previews[0].LastMessage.IsDeleted())
+		assert.Equal(t, 1, previews[0].UnreadCount, "only first is left unread")
+	})
+
+	t.Run("conversation disappears when all of it is hidden", func(t *testing.T) {
+		require.NoError(t, messageRepo.Hide(ctx, first.ID(), bob.ID(), time.Now()))
+		require.NoError(t, messageRepo.Hide(ctx, second.ID(), bob.ID(), time.Now()))
+
+		previews, err := messageRepo.ListUserConversations(ctx, bob.ID(), 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, previews)
+
+		previews, err = messageRepo.ListUserConversations(ctx, alice.ID(), 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, previews, 1)
+	})
+}
+
+func TestMessageRepository_Replies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	parent, _ := do.NewMessage(bob.ID(), alice.ID(), strings.Repeat("a", do.QuotePreviewLength+20))
+	require.NoError(t, messageRepo.Create(ctx, parent))
+	reply, _ := do.NewMessage(alice.ID(), bob.ID(), "Sure")
+	require.NoError(t, reply.ReplyTo(parent))
+	require.NoError(t, messageRepo.Create(ctx, reply))
+
+	t.Run("quote is loaded with the message", func(t *testing.T) {
+		found, err := messageRepo.FindByID(ctx, reply.ID())
+		require.NoError(t, err)
+		require.True(t, found.IsReply())
+		assert.Equal(t, parent.ID(), found.ReplyToID())
+		assert.Equal(t, bob.ID(), found.Quote().SenderID())
+		assert.Len(t, found.Quote().Content(), do.QuotePreviewLength)
+		assert.True(t, found.Quote().IsTruncated())
+
+		page, err := messageRepo.ListConversation(ctx, alice.ID(), bob.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.True(t, page[0].IsReply())
+		assert.False(t, page[1].IsReply())
+	})
+
+	t.Run("list replies", func(t *testing.T) {
+		replies, err := messageRepo.ListReplies(ctx, parent.ID(), bob.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, replies, 1)
+		assert.Equal(t, reply.ID(), replies[0].ID())
+
+		require.NoError(t, messageRepo.Hide(ctx, reply.ID(), bob.ID(), time.Now()))
+		replies, err = messageRepo.ListReplies(ctx, parent.ID(), bob.ID(), 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, replies)
+	})
+
+	t.Run("quote the viewer deleted for themselves", func(t *testing.T) {
+		require.NoError(t, messageRepo.Hide(ctx, parent.ID(), alice.ID(), time.Now()))
+
+		page, err := messageRepo.ListConversation(ctx, alice.ID(), bob.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, reply.ID(), page[0].ID())
+		assert.True(t, page[0].Quote().IsDeleted())
+		assert.Empty(t, page[0].Quote().Content())
+
+		replies, err := messageRepo.ListReplies(ctx, parent.ID(), alice.ID(), 10, 0)
+		require.NoError(t, err)
+		require.Len(t, replies, 1)
+		assert.True(t, replies[0].Quote().IsDeleted())
+
+		// only alice deleted it, the quote is whole for everyone else
+		found, err := messageRepo.FindByID(ctx, reply.ID())
+		require.NoError(t, err)
+		assert.False(t, found.Quote().IsDeleted())
+		assert.NotEmpty(t, found.Quote().Content())
+	})
+
+	t.Run("quote of a retracted message", func(t *testing.T) {
+		require.NoError(t, parent.Retract(bob.ID(), time.Hour))
+		require.NoError(t, messageRepo.Retract(ctx, parent))
+
+		found, err := messageRepo.FindByID(ctx, reply.ID())
+		require.NoError(t, err)
+		assert.True(t, found.Quote().IsDeleted())
+		assert.Empty(t, found.Quote().Content())
+	})
+}
+
+func TestMessageRepository_Search(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	carol, _ := do.NewUser("carol@example.com", "password123", "carol")
+	for _, user := range []*do.User{alice, bob, carol} {
+		require.NoError(t, userRepo.Create(ctx, user))
+	}
+
+	send := func(from, to *do.User, content string) *do.Message {
+		msg, err := do.NewMessage(from.ID(), to.ID(), content)
+		require.NoError(t, err)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		time.Sleep(time.Millisecond)
+		return msg
+	}
+	dinner := send(alice, bob, "Are we still having dinner tomorrow?")
+	dinners := send(bob, alice, "Dinners at the new place are great, see you tomorrow")
+	chinese := send(bob, alice, "我們明天一起吃晚餐好嗎")
+	send(carol, bob, "dinner with carol")
+	hidden := send(bob, alice, "secret dinner plans")
+	require.NoError(t, messageRepo.Hide(ctx, hidden.ID(), alice.ID(), time.Now()))
+
+	search := func(text string, filter do.SearchFilter, after *do.SearchCursor, limit int) []*do.SearchHit {
+		query, err := do.NewSearchQuery(text, filter)
+		require.NoError(t, err)
+		hits, err := messageRepo.Search(ctx, alice.ID(), query, after, limit)
+		require.NoError(t, err)
+		return hits
+	}
+	ids := func(hits []*do.SearchHit) []uuid.UUID {
+		var ids []uuid.UUID
+		for _, hit := range hits {
+			ids = append(ids, hit.Message.ID())
+		}
+		return ids
+	}
+
+	t.Run("stemmed words in own conversations only", func(t *testing.T) {
+		hits := search("dinner", do.SearchFilter{}, nil, 10)
+		assert.Equal(t, []uuid.UUID{dinners.ID(), dinner.ID()}, ids(hits))
+		assert.Contains(t, hits[1].Snippet, do.SnippetPart{Text: "dinner", Match: true})
+	})
+
+	t.Run("phrase", func(t *testing.T) {
+		assert.Equal(t, []uuid.UUID{dinners.ID()}, ids(search(`"see you tomorrow"`, do.SearchFilter{}, nil, 10)))
+		assert.Empty(t, search(`"tomorrow see"`, do.SearchFilter{}, nil, 10))
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		assert.Equal(t, []uuid.UUID{dinner.ID()}, ids(search("dinner", do.SearchFilter{SenderID: alice.ID()}, nil, 10)))
+		assert.Equal(t, []uuid.UUID{dinner.ID()}, ids(search("dinner", do.SearchFilter{Before: dinners.CreatedAt()}, nil, 10)))
+		assert.Equal(t, []uuid.UUID{dinners.ID()}, ids(search("dinner", do.SearchFilter{After: dinners.CreatedAt()}, nil, 10)))
+		assert.Empty(t, search("dinner", do.SearchFilter{HasAttachment: true}, nil, 10))
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		first := search("dinner", do.SearchFilter{}, nil, 1)
+		require.Len(t, first, 1)
+		cursor := do.CursorAfter(first[0].Message)
+		assert.Equal(t, []uuid.UUID{dinner.ID()}, ids(search("dinner", do.SearchFilter{}, &cursor, 1)))
+	})
+
+	t.Run("substrings for chinese", func(t *testing.T) {
+		hits := search("明天 晚餐", do.SearchFilter{}, nil, 10)
+		require.Equal(t, []uuid.UUID{chinese.ID()}, ids(hits))
+		assert.Contains(t, hits[0].Snippet, do.SnippetPart{Text: "明天", Match: true})
+		assert.Empty(t, search("明天 100%", do.SearchFilter{}, nil, 10))
+		assert.Equal(t, []uuid.UUID{chinese.ID()}, ids(search("我們 明天 晚餐", do.SearchFilter{}, nil, 10)))
+		assert.Empty(t, search("我們 明天 早餐", do.SearchFilter{}, nil, 10))
+	})
+
+	t.Run("retracted messages are not found", func(t *testing.T) {
+		require.NoError(t, dinner.Retract(alice.ID(), time.Hour))
+		require.NoError(t, messageRepo.Retract(ctx, dinner))
+		assert.Equal(t, []uuid.UUID{dinners.ID()}, ids(search("dinner", do.SearchFilter{}, nil, 10)))
+	})
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PresenceRepository struct {
+	db *sqlx.DB
+}
+
+func NewPresenceRepository(db *sqlx.DB) *PresenceRepository {
+	return &PresenceRepository{db: db}
+}
+
+// conn joins the transaction carried by ctx, if any
+func (r *PresenceRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
+func (r *PresenceRepository) FindLastSeen(ctx context.Context, userID uuid.UUID) (*do.LastSeen, error) {
+	query := `SELECT last_seen_at, hide_last_seen FROM user_presence WHERE user_id = $1`
+
+	var (
+		at     sql.NullTime
+		hidden bool
+	)
+	err := r.conn(ctx).QueryRowContext(ctx, query, userID).Scan(&at, &hidden)
+	if errors.Is(err, sql.ErrNoRows) {
+		return do.ReconstructLastSeen(userID, nil, false), nil
+	}
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrPresenceRepository)
+	}
+	return do.ReconstructLastSeen(userID, nullTime(at), hidden), nil
+}
+
+// UpdateLastSeen never moves last seen back, a node catching up on a
+// session that expired late loses to the later time
+func (r *PresenceRepository) UpdateLastSeen(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	query := `
+		INSERT INTO user_presence (user_id, last_seen_at)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET last_seen_at = GREATEST(user_presence.last_seen_at, EXCLUDED.last_seen_at)
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, userID, at)
+	if pgdb.ConstraintName(err) == "user_presence_user_id_fkey" {
+		return pgdb.WrapError(err, repository.ErrUserNotFound)
+	}
+	return pgdb.WrapError(err, repository.ErrPresenceRepository)
+}
+
+func (r *PresenceRepository) UpdateLastSeenHidden(ctx context.Context, userID uuid.UUID, hidden bool) error {
+	query := `
+		INSERT INTO user_presence (user_id, hide_last_seen)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET hide_last_seen = EXCLUDED.hide_last_seen
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query, userID, hidden)
+	if pgdb.ConstraintName(err) == "user_presence_user_id_fkey" {
+		return pgdb.WrapError(err, repository.ErrUserNotFound)
+	}
+	return pgdb.WrapError(err, repository.ErrPresenceRepository)
+}
@@ -0,0 +1,62 @@
+package postgres_test
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/infrastructure/postgres"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresenceRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	presenceRepo := postgres.NewPresenceRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	require.NoError(t, userRepo.Create(ctx, alice))
+
+	t.Run("never seen", func(t *testing.T) {
+		lastSeen, err := presenceRepo.FindLastSeen(ctx, alice.ID())
+		require.NoError(t, err)
+		assert.Nil(t, lastSeen.At())
+		assert.False(t, lastSeen.IsHidden())
+	})
+
+	t.Run("last seen only moves forward", func(t *testing.T) {
+		seen := time.Now()
+		require.NoError(t, presenceRepo.UpdateLastSeen(ctx, alice.ID(), seen))
+		require.NoError(t, presenceRepo.UpdateLastSeen(ctx, alice.ID(), seen.Add(-time.Hour)))
+
+		lastSeen, err := presenceRepo.FindLastSeen(ctx, alice.ID())
+		require.NoError(t, err)
+		require.NotNil(t, lastSeen.At())
+		assert.WithinDuration(t, seen, *lastSeen.At(), time.Millisecond)
+	})
+
+	t.Run("hiding keeps the time", func(t *testing.T) {
+		require.NoError(t, presenceRepo.UpdateLastSeenHidden(ctx, alice.ID(), true))
+
+		lastSeen, err := presenceRepo.FindLastSeen(ctx, alice.ID())
+		require.NoError(t, err)
+		assert.True(t, lastSeen.IsHidden())
+		assert.NotNil(t, lastSeen.At())
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		err := presenceRepo.UpdateLastSeenHidden(ctx, uuid.New(), true)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+}
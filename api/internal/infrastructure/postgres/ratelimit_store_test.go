@@ -0,0 +1,54 @@
+package postgres_test
+
+import (
+	"context"
+	"hilo-api/pkg/database/postgres"
+	"hilo-api/pkg/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore_Take(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	store := postgres.NewRateLimitStore(tdb.DB)
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Period: time.Hour}
+
+	t.Run("spends the burst then denies", func(t *testing.T) {
+		for remaining := 1; remaining >= 0; remaining-- {
+			result, err := store.Take(ctx, "ip:192.0.2.1", policy)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := store.Take(ctx, "ip:192.0.2.1", policy)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Positive(t, result.RetryAfter)
+	})
+
+	t.Run("keys are separate", func(t *testing.T) {
+		result, err := store.Take(ctx, "ip:192.0.2.2", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("prune drops idle buckets", func(t *testing.T) {
+		_, err := tdb.Exec("UPDATE rate_limits SET updated_at = now() - interval '2 hours'")
+		require.NoError(t, err)
+
+		pruned, err := store.Prune(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), pruned)
+	})
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReactionRepository struct {
+	db *sqlx.DB
+}
+
+func NewReactionRepository(db *sqlx.DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// conn joins the transaction carried by ctx, if any
+func (r *ReactionRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
+// Toggle deletes the reaction and only inserts it when there was nothing to
+// delete, so a single statement decides which way the toggle goes
+func (r *ReactionRepository) Toggle(ctx context.Context, reaction *do.Reaction) (bool, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM message_reactions
+			WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+			RETURNING 1
+		)
+		INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+		SELECT $1, $2, $3, $4::timestamptz
+		WHERE NOT EXISTS (SELECT 1 FROM removed)
+		ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		reaction.MessageID(),
+		reaction.UserID(),
+		reaction.Emoji(),
+		reaction.CreatedAt(),
+	)
+	if err != nil {
+		if pgdb.ConstraintName(err) == "message_reactions_message_id_fkey" {
+			return false, pgdb.WrapError(err, repository.ErrMessageNotFound)
+		}
+		return false, pgdb.WrapError(err, repository.ErrReactionRepository)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, pgdb.WrapError(err, repository.ErrReactionRepository)
+	}
+	return added == 1, nil
+}
+
+func (r *ReactionRepository) Summaries(ctx context.Context, messageIDs []uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID][]*do.ReactionSummary, error) {
+	summaries := map[uuid.UUID][]*do.ReactionSummary{}
+	if len(messageIDs) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT message_id, emoji, COUNT(*), BOOL_OR(user_id = $2), MIN(created_at) AS first_at
+		FROM message_reactions
+		WHERE message_id = ANY($1::uuid[])
+		GROUP BY message_id, emoji
+		ORDER BY message_id, first_at, emoji
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, uuidArray(messageIDs), viewerID)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrReactionRepository)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			messageID uuid.UUID
+			firstAt   time.Time
+			summary   do.ReactionSummary
+		)
+		if err := rows.Scan(&messageID, &summary.Emoji, &summary.Count, &summary.ReactedByMe, &firstAt); err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrReactionRepository)
+		}
+		summaries[messageID] = append(summaries[messageID], &summary)
+	}
+
+	return summaries, pgdb.WrapError(rows.Err(), repository.ErrReactionRepository)
+}
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/infrastructure/postgres"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	reactionRepo := postgres.NewReactionRepository(tdb.DB)
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	alice, _ := do.NewUser("alice@example.com", "password123", "alice")
+	bob, _ := do.NewUser("bob@example.com", "password123", "bob")
+	require.NoError(t, userRepo.Create(ctx, alice))
+	require.NoError(t, userRepo.Create(ctx, bob))
+
+	msg, _ := do.NewMessage(alice.ID(), bob.ID(), "Lunch at noon")
+	other, _ := do.NewMessage(bob.ID(), alice.ID(), "Sure")
+	require.NoError(t, messageRepo.Create(ctx, msg))
+	require.NoError(t, messageRepo.Create(ctx, other))
+
+	toggle := func(t *testing.T, userID uuid.UUID, emoji string) bool {
+		reaction, err := do.NewReaction(msg, userID, emoji)
+		require.NoError(t, err)
+		added, err := reactionRepo.Toggle(ctx, reaction)
+		require.NoError(t, err)
+		return added
+	}
+
+	t.Run("toggle adds then removes", func(t *testing.T) {
+		assert.True(t, toggle(t, bob.ID(), "👍"))
+		assert.True(t, toggle(t, alice.ID(), "👍"))
+		assert.True(t, toggle(t, bob.ID(), "🎉"))
+		assert.False(t, toggle(t, alice.ID(), "👍"))
+		assert.True(t, toggle(t, alice.ID(), "👍"))
+	})
+
+	t.Run("summaries per viewer", func(t *testing.T) {
+		summaries, err := reactionRepo.Summaries(ctx, []uuid.UUID{msg.ID(), other.ID()}, alice.ID())
+		require.NoError(t, err)
+		assert.Equal(t, []*do.ReactionSummary{
+			{Emoji: "👍", Count: 2, ReactedByMe: true},
+			{Emoji: "🎉", Count: 1, ReactedByMe: false},
+		}, summaries[msg.ID()])
+		assert.NotContains(t, summaries, other.ID())
+	})
+
+	t.Run("retract drops reactions", func(t *testing.T) {
+		require.NoError(t, msg.Retract(alice.ID(), time.Hour))
+		require.NoError(t, messageRepo.Retract(ctx, msg))
+
+		summaries, err := reactionRepo.Summaries(ctx, []uuid.UUID{msg.ID()}, alice.ID())
+		require.NoError(t, err)
+		assert.Empty(t, summaries)
+	})
+}
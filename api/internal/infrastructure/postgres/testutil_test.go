@@ -3,8 +3,10 @@ package postgres_test
 import (
 	"context"
 	"fmt"
+	"hilo-api/deployments/migrations"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"testing"
 	"time"
 
@@ -15,8 +17,6 @@ import (
 	"go.uber.org/zap"
 )
 
-const migrationDir = "deployments/migrations/postgresql"
-
 var (
 	testDB *sqlx.DB
 )
@@ -77,35 +77,19 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-// runMigrations applies database migrations
+// runMigrations applies the embedded up migrations in version order
 func runMigrations(db *sqlx.DB, dbName string) error {
-	// Read SQL files directly instead of using golang-migrate
+	// Execute the SQL files directly instead of using golang-migrate
 	// This is more reliable on Windows
-
-	wd, err := os.Getwd()
+	list, err := migrations.List()
 	if err != nil {
 		return err
 	}
 
-	// Construct absolute migration path
-	migrationPath := filepath.Join(wd, "..", "..", "..", migrationDir)
-	absPath, err := filepath.Abs(migrationPath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
-
-	zap.L().Info("running migrations", zap.String("path", absPath))
-
-	// Execute migration files in order
-	migrationFiles := []string{
-		"20251104031423_extension.up.sql",
-		"20251104031532_database.up.sql",
-	}
-
-	for _, filename := range migrationFiles {
-		filePath := filepath.Join(absPath, filename)
+	for _, migration := range list {
+		filename := fmt.Sprintf("%d_%s.up.sql", migration.Version, migration.Name)
 
-		sqlBytes, err := os.ReadFile(filePath)
+		sqlBytes, err := fs.ReadFile(migrations.FS, path.Join(migrations.Dir, filename))
 		if err != nil {
 			return fmt.Errorf("failed to read migration file %s: %w", filename, err)
 		}
@@ -137,7 +121,7 @@ func NewTestDB(t *testing.T) *TestDB {
 func (tdb *TestDB) Cleanup() {
 	tdb.t.Helper()
 
-	_, err := tdb.Exec("TRUNCATE users, messages CASCADE")
+	_, err := tdb.Exec("TRUNCATE users, messages, message_idempotency_keys, rate_limits, attachments, attachment_usage, uploads CASCADE")
 	if err != nil {
 		tdb.t.Fatalf("failed to cleanup database: %v", err)
 	}
@@ -0,0 +1,100 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/infrastructure/postgres"
+	"hilo-api/pkg/config"
+	pgdb "hilo-api/pkg/database/postgres"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxManager_WithinTx(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	txManager := pgdb.NewTxManager(tdb.DB, config.Postgres{PostgresTxTimeout: 5 * time.Second})
+	userRepo := postgres.NewUserRepository(tdb.DB)
+	messageRepo := postgres.NewMessageRepository(tdb.DB)
+	ctx := context.Background()
+
+	t.Run("commit persists writes from every repository", func(t *testing.T) {
+		sender, _ := do.NewUser("commit-sender@example.com", "password123", "commitSender")
+		receiver, _ := do.NewUser("commit-receiver@example.com", "password123", "commitReceiver")
+		msg, _ := do.NewMessage(sender.ID(), receiver.ID(), "inside transaction")
+
+		err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			if err := userRepo.Create(ctx, sender); err != nil {
+				return err
+			}
+			if err := userRepo.Create(ctx, receiver); err != nil {
+				return err
+			}
+			return messageRepo.Create(ctx, msg)
+		})
+		require.NoError(t, err)
+
+		found, err := messageRepo.FindByID(ctx, msg.ID())
+		require.NoError(t, err)
+		assert.Equal(t, msg.Content(), found.Content())
+	})
+
+	t.Run("error rolls back every repository", func(t *testing.T) {
+		user, _ := do.NewUser("rollback@example.com", "password123", "rollback")
+		errAbort := errors.New("abort")
+
+		err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, userRepo.Create(ctx, user))
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+
+		found, err := userRepo.FindByID(ctx, user.ID())
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("nested call joins the outer transaction", func(t *testing.T) {
+		user, _ := do.NewUser("nested@example.com", "password123", "nested")
+		errAbort := errors.New("abort outer")
+
+		err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			inner := txManager.WithinTx(ctx, func(ctx context.Context) error {
+				return userRepo.Create(ctx, user)
+			})
+			require.NoError(t, inner)
+			return errAbort
+		}, pgdb.WithIsolationLevel(sql.LevelSerializable))
+		assert.ErrorIs(t, err, errAbort)
+
+		_, err = userRepo.FindByID(ctx, user.ID())
+		assert.Error(t, err)
+	})
+	t.Run("domain options reach the transaction", func(t *testing.T) {
+		transactor := postgres.NewTransactor(txManager)
+
+		err := transactor.WithinTx(ctx, func(ctx context.Context) error {
+			tx, ok := pgdb.TxFromContext(ctx)
+			require.True(t, ok)
+
+			var isolation, readOnly string
+			require.NoError(t, tx.QueryRowContext(ctx, "SHOW transaction_isolation").Scan(&isolation))
+			require.NoError(t, tx.QueryRowContext(ctx, "SHOW transaction_read_only").Scan(&readOnly))
+			assert.Equal(t, "serializable", isolation)
+			assert.Equal(t, "on", readOnly)
+			return nil
+		}, repository.WithIsolation(repository.TxIsolationSerializable), repository.WithReadOnly())
+		require.NoError(t, err)
+	})
+}
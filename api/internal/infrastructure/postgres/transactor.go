@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+)
+
+// Transactor runs repository units of work through a TxManager
+type Transactor struct {
+	manager *pgdb.TxManager
+}
+
+func NewTransactor(manager *pgdb.TxManager) *Transactor {
+	return &Transactor{manager: manager}
+}
+
+var isolationLevels = map[repository.TxIsolation]sql.IsolationLevel{
+	repository.TxIsolationDefault:        sql.LevelDefault,
+	repository.TxIsolationReadCommitted:  sql.LevelReadCommitted,
+	repository.TxIsolationRepeatableRead: sql.LevelRepeatableRead,
+	repository.TxIsolationSerializable:   sql.LevelSerializable,
+}
+
+// WithinTx translates the domain options into TxManager ones
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error, options ...repository.TxOption) error {
+	opts := repository.TxOptions{}
+	for _, option := range options {
+		option.Apply(&opts)
+	}
+
+	txOptions := []pgdb.TxOption{pgdb.WithIsolationLevel(isolationLevels[opts.Isolation])}
+	if opts.ReadOnly {
+		txOptions = append(txOptions, pgdb.WithReadOnly())
+	}
+	return t.manager.WithinTx(ctx, fn, txOptions...)
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type UploadRepository struct {
+	db *sqlx.DB
+}
+
+func NewUploadRepository(db *sqlx.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// conn joins the transaction carried by ctx, if any
+func (r *UploadRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
+func (r *UploadRepository) Create(ctx context.Context, upload *do.Upload) error {
+	query := `
+		INSERT INTO uploads (id, owner_id, filename, size, received, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.conn(ctx).ExecContext(ctx, query,
+		upload.ID(),
+		upload.OwnerID(),
+		upload.Filename(),
+		upload.Size(),
+		upload.Received(),
+		upload.CreatedAt(),
+	)
+	return pgdb.WrapError(err, repository.ErrUploadRepository)
+}
+
+// uploadColumns are the columns scanUpload reads, in order
+const uploadColumns = `id, owner_id, filename, size, received, created_at`
+
+func scanUpload(row rowScanner) (*do.Upload, error) {
+	var (
+		id        uuid.UUID
+		ownerID   uuid.NullUUID
+		filename  string
+		size      int64
+		received  int64
+		createdAt time.Time
+	)
+	if err := row.Scan(&id, &ownerID, &filename, &size, &received, &createdAt); err != nil {
+		return nil, err
+	}
+	return do.ReconstructUpload(id, ownerID.UUID, filename, size, received, createdAt), nil
+}
+
+func (r *UploadRepository) FindByID(ctx context.Context, id uuid.UUID) (*do.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1`
+
+	upload, err := scanUpload(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pgdb.WrapError(err, repository.ErrUploadNotFound)
+		}
+		return nil, pgdb.WrapError(err, repository.ErrUploadRepository)
+	}
+	return upload, nil
+}
+
+// Advance compares and sets the received count, so of two chunks sent for
+// the same offset only the first is counted
+func (r *UploadRepository) Advance(ctx context.Context, upload *do.Upload, from int64) error {
+	query := `
+		UPDATE uploads
+		SET received = $2
+		WHERE id = $1 AND received = $3
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query, upload.ID(), upload.Received(), from)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrUploadRepository)
+	}
+	return affectedOne(result, repository.ErrUploadConflict, repository.ErrUploadRepository)
+}
+
+func (r *UploadRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM uploads WHERE id = $1`
+	_, err := r.conn(ctx).ExecContext(ctx, query, id)
+	return pgdb.WrapError(err, repository.ErrUploadRepository)
+}
+
+func (r *UploadRepository) ListExpired(ctx context.Context, expiredBefore time.Time, limit int) ([]*do.Upload, error) {
+	query := `
+		SELECT ` + uploadColumns + `
+		FROM uploads
+		WHERE created_at < $1 OR owner_id IS NULL
+		ORDER BY created_at, id
+		LIMIT $2
+	`
+	rows, err := r.conn(ctx).QueryContext(ctx, query, expiredBefore, limit)
+	if err != nil {
+		return nil, pgdb.WrapError(err, repository.ErrUploadRepository)
+	}
+	defer rows.Close()
+
+	var uploads []*do.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrUploadRepository)
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, pgdb.WrapError(rows.Err(), repository.ErrUploadRepository)
+}
@@ -5,11 +5,20 @@ import (
 	"database/sql"
 	"errors"
 	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	pgdb "hilo-api/pkg/database/postgres"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// userConstraintErrors maps unique constraints on users to the repository error they signal
+var userConstraintErrors = map[string]error{
+	"users_email_key":    repository.ErrDuplicateEmail,
+	"users_username_key": repository.ErrDuplicateUsername,
+}
+
 type UserRepository struct {
 	db *sqlx.DB
 }
@@ -18,143 +27,202 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// conn joins the transaction carried by ctx, if any
+func (r *UserRepository) conn(ctx context.Context) pgdb.Executor {
+	return pgdb.Conn(ctx, r.db)
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *do.User) error {
 	query := `
 		INSERT INTO users (id, email, password, username, created_at)
 		VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		user.ID(),
 		user.Email(),
 		user.PasswordHash(),
 		user.Username(),
 		user.CreatedAt(),
 	)
-	return err
+	if subject, ok := userConstraintErrors[pgdb.ConstraintName(err)]; ok {
+		return pgdb.WrapError(err, subject)
+	}
+	return pgdb.WrapError(err, repository.ErrUserRepository)
+}
+
+// Update saves the mutable fields of user: password and suspension
+func (r *UserRepository) Update(ctx context.Context, user *do.User) error {
+	query := `
+		UPDATE users
+		SET password = $2, suspended_at = $3
+		WHERE id = $1
+	`
+	result, err := r.conn(ctx).ExecContext(ctx, query,
+		user.ID(),
+		user.PasswordHash(),
+		user.SuspendedAt(),
+	)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrUserRepository)
+	}
+	return affectedOne(result, repository.ErrUserNotFound, repository.ErrUserRepository)
+}
+
+// Delete removes the user; their messages go with them through ON DELETE CASCADE
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM users WHERE id = $1`
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return pgdb.WrapError(err, repository.ErrUserRepository)
+	}
+	return affectedOne(result, repository.ErrUserNotFound, repository.ErrUserRepository)
 }
 
 func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*do.User, error) {
 	query := `
-		SELECT id, email, password, username, created_at
+		SELECT id, email, password, username, created_at, suspended_at
 		FROM users
 		WHERE id = $1
 	`
 
 	var (
-		uid       uuid.UUID
-		email     string
-		password  string
-		username  string
-		createdAt sql.NullTime
+		uid         uuid.UUID
+		email       string
+		password    string
+		username    string
+		createdAt   sql.NullTime
+		suspendedAt sql.NullTime
 	)
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&uid, &email, &password, &username, &createdAt,
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(
+		&uid, &email, &password, &username, &createdAt, &suspendedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return nil, pgdb.WrapError(err, repository.ErrUserNotFound)
 		}
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 	}
 
-	return do.ReconstructUser(uid, email, password, username, createdAt.Time), nil
+	return do.ReconstructUser(uid, email, password, username, createdAt.Time, nullTime(suspendedAt)), nil
 }
 
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*do.User, error) {
 	query := `
-		SELECT id, email, password, username, created_at
+		SELECT id, email, password, username, created_at, suspended_at
 		FROM users
 		WHERE email = $1
 	`
 
 	var (
-		id        uuid.UUID
-		userEmail string
-		password  string
-		username  string
-		createdAt sql.NullTime
+		id          uuid.UUID
+		userEmail   string
+		password    string
+		username    string
+		createdAt   sql.NullTime
+		suspendedAt sql.NullTime
 	)
 
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&id, &userEmail, &password, &username, &createdAt,
+	err := r.conn(ctx).QueryRowContext(ctx, query, email).Scan(
+		&id, &userEmail, &password, &username, &createdAt, &suspendedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return nil, pgdb.WrapError(err, repository.ErrUserNotFound)
 		}
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 	}
 
-	return do.ReconstructUser(id, userEmail, password, username, createdAt.Time), nil
+	return do.ReconstructUser(id, userEmail, password, username, createdAt.Time, nullTime(suspendedAt)), nil
 }
 
 func (r *UserRepository) FindAll(ctx context.Context, limit, offset int) ([]*do.User, error) {
 	query := `
-		SELECT id, email, password, username, created_at
+		SELECT id, email, password, username, created_at, suspended_at
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 	}
 	defer rows.Close()
 
 	var users []*do.User
 	for rows.Next() {
 		var (
-			id        uuid.UUID
-			email     string
-			password  string
-			username  string
-			createdAt sql.NullTime
+			id          uuid.UUID
+			email       string
+			password    string
+			username    string
+			createdAt   sql.NullTime
+			suspendedAt sql.NullTime
 		)
 
-		if err := rows.Scan(&id, &email, &password, &username, &createdAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&id, &email, &password, &username, &createdAt, &suspendedAt); err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 		}
 
-		users = append(users, do.ReconstructUser(id, email, password, username, createdAt.Time))
+		users = append(users, do.ReconstructUser(id, email, password, username, createdAt.Time, nullTime(suspendedAt)))
 	}
 
-	return users, rows.Err()
+	return users, pgdb.WrapError(rows.Err(), repository.ErrUserRepository)
 }
 
 func (r *UserRepository) Search(ctx context.Context, queryString string, limit int) ([]*do.User, error) {
 	query := `
-		SELECT id, email, password, username, created_at
+		SELECT id, email, password, username, created_at, suspended_at
 		FROM users
 		WHERE username ILIKE $1
 		LIMIT $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, "%"+queryString+"%", limit)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, "%"+queryString+"%", limit)
 	if err != nil {
-		return nil, err
+		return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 	}
 	defer rows.Close()
 
 	var users []*do.User
 	for rows.Next() {
 		var (
-			id        uuid.UUID
-			email     string
-			password  string
-			username  string
-			createdAt sql.NullTime
+			id          uuid.UUID
+			email       string
+			password    string
+			username    string
+			createdAt   sql.NullTime
+			suspendedAt sql.NullTime
 		)
 
-		if err := rows.Scan(&id, &email, &password, &username, &createdAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&id, &email, &password, &username, &createdAt, &suspendedAt); err != nil {
+			return nil, pgdb.WrapError(err, repository.ErrUserRepository)
 		}
 
-		users = append(users, do.ReconstructUser(id, email, password, username, createdAt.Time))
+		users = append(users, do.ReconstructUser(id, email, password, username, createdAt.Time, nullTime(suspendedAt)))
 	}
 
-	return users, rows.Err()
+	return users, pgdb.WrapError(rows.Err(), repository.ErrUserRepository)
+}
+
+// affectedOne reports notFound when a statement addressed by primary key touched no row
+func affectedOne(result sql.Result, notFound, subject error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return pgdb.WrapError(err, subject)
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
 }
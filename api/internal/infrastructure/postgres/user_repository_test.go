@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
 	"hilo-api/internal/infrastructure/postgres"
+	"hilo-api/pkg/errorCatcher"
 	"testing"
 
 	"github.com/google/uuid"
@@ -47,6 +49,81 @@ func TestUserRepository_Create(t *testing.T) {
 
 		err2 := repo.Create(ctx, user2)
 		assert.Error(t, err2) // Should violate unique constraint
+		assert.ErrorIs(t, err2, repository.ErrDuplicateEmail)
+		assert.ErrorIs(t, err2, errorCatcher.ErrDatabaseExecuteUniqueViolation)
+	})
+
+	t.Run("cannot create duplicate username", func(t *testing.T) {
+		user1, _ := do.NewUser("first@example.com", "password123", "sameName")
+		user2, _ := do.NewUser("second@example.com", "password456", "sameName")
+
+		require.NoError(t, repo.Create(ctx, user1))
+
+		err := repo.Create(ctx, user2)
+		assert.ErrorIs(t, err, repository.ErrDuplicateUsername)
+		assert.ErrorIs(t, err, errorCatcher.ErrDatabaseExecuteUniqueViolation)
+	})
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	repo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	t.Run("suspend and change password", func(t *testing.T) {
+		user, _ := do.NewUser("update@example.com", "password123", "updateuser")
+		require.NoError(t, repo.Create(ctx, user))
+
+		user.Suspend()
+		require.NoError(t, user.ChangePassword("new_password"))
+		require.NoError(t, repo.Update(ctx, user))
+
+		found, err := repo.FindByID(ctx, user.ID())
+		require.NoError(t, err)
+		assert.True(t, found.IsSuspended())
+		assert.NoError(t, found.VerifyPassword("new_password"))
+
+		found.Reinstate()
+		require.NoError(t, repo.Update(ctx, found))
+		found, err = repo.FindByID(ctx, user.ID())
+		require.NoError(t, err)
+		assert.False(t, found.IsSuspended())
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		user, _ := do.NewUser("missing@example.com", "password123", "missing")
+		assert.ErrorIs(t, repo.Update(ctx, user), repository.ErrUserNotFound)
+	})
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	repo := postgres.NewUserRepository(tdb.DB)
+	ctx := context.Background()
+
+	t.Run("delete existing user", func(t *testing.T) {
+		user, _ := do.NewUser("delete@example.com", "password123", "deleteuser")
+		require.NoError(t, repo.Create(ctx, user))
+
+		require.NoError(t, repo.Delete(ctx, user.ID()))
+		_, err := repo.FindByID(ctx, user.ID())
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		assert.ErrorIs(t, repo.Delete(ctx, uuid.New()), repository.ErrUserNotFound)
 	})
 }
 
@@ -80,6 +157,8 @@ func TestUserRepository_FindByID(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, found)
 		assert.Contains(t, err.Error(), "not found")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		assert.ErrorIs(t, err, errorCatcher.ErrDatabaseRowNotFound)
 	})
 }
 
@@ -0,0 +1,50 @@
+package restful
+
+import (
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/restful"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewAdmin method
+func NewAdmin(logger *zap.Logger, level zap.AtomicLevel) *Admin {
+	return &Admin{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Admin serves runtime operations; its routes need a token whose
+// permissions cover /api/v1/admin
+type Admin struct {
+	logger *zap.Logger
+	level  zap.AtomicLevel
+}
+
+// LogLevel method
+func (a *Admin) LogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: a.level.String()})
+}
+
+// SetLogLevel method
+// lasts until the next restart or a reload that changes LOG_LEVEL
+func (a *Admin) SetLogLevel(c *gin.Context) {
+	var req dto.LogLevelRequest
+	restful.MustBindJSON(c, &req)
+
+	level, _ := zapcore.ParseLevel(req.Level)
+	previous := a.level.Level()
+	a.level.SetLevel(level)
+	// logged at warn so the change is visible at any level but error
+	a.logger.Warn("log level changed",
+		zap.String("system", "Admin"),
+		zap.Stringer("from", previous),
+		zap.Stringer("to", level),
+		zap.Any(GinContextUserIDKey, c.Value(GinContextUserIDKey)),
+	)
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: level.String()})
+}
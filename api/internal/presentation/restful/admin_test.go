@@ -0,0 +1,77 @@
+package restful
+
+import (
+	"encoding/json"
+	"hilo-api/internal/presentation/restful/dto"
+	authDefinition "hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/restful"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type AdminSuite struct {
+	suite.Suite
+	level  zap.AtomicLevel
+	router *gin.Engine
+}
+
+func (suite *AdminSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	admin := NewAdmin(zap.NewNop(), suite.level)
+
+	suite.router = gin.New()
+	suite.router.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "admin"))
+	// stands in for the JWT guard accepting a token
+	suite.router.Use(func(c *gin.Context) {
+		if c.GetHeader(authDefinition.AuthorizationKey) != "" {
+			c.Set(authDefinition.AuthorizationKey, "claim")
+		}
+	})
+	AddRoutes(suite.router, restful.CommonHandler{
+		Error404:   restful.Error404Set,
+		QuickReply: restful.QuickReplySet,
+		PromHTTP:   restful.NewPromHTTPSet,
+		PromGuard:  func(c *gin.Context) {},
+	}, HandlerSet{Admin: admin, RateLimits: RateLimits{Default: func(c *gin.Context) {}}})
+}
+
+var authorized = map[string]string{authDefinition.AuthorizationKey: "Bearer token"}
+
+func (suite *AdminSuite) TestLogLevel() {
+	body, err := Get("/api/v1/admin/log-level", authorized, suite.router)
+	suite.NoError(err)
+	var res dto.LogLevelResponse
+	suite.NoError(json.Unmarshal(body, &res))
+	suite.Equal("info", res.Level)
+}
+
+func (suite *AdminSuite) TestSetLogLevel() {
+	body, err := PutJSON("/api/v1/admin/log-level", map[string]interface{}{"level": "debug"}, authorized, suite.router)
+	suite.NoError(err)
+	var res dto.LogLevelResponse
+	suite.NoError(json.Unmarshal(body, &res))
+	suite.Equal("debug", res.Level)
+	suite.Equal(zapcore.DebugLevel, suite.level.Level())
+}
+
+func (suite *AdminSuite) TestSetLogLevelInvalid() {
+	_, err := PutJSON("/api/v1/admin/log-level", map[string]interface{}{"level": "loud"}, authorized, suite.router)
+	suite.EqualError(err, "request error by code: 400")
+	suite.Equal(zapcore.InfoLevel, suite.level.Level())
+}
+
+func (suite *AdminSuite) TestRequiresAuthorization() {
+	_, err := PutJSON("/api/v1/admin/log-level", map[string]interface{}{"level": "debug"}, nil, suite.router)
+	suite.EqualError(err, "request error by code: 401")
+	suite.Equal(zapcore.InfoLevel, suite.level.Level())
+}
+
+func TestAdminSuite(t *testing.T) {
+	suite.Run(t, new(AdminSuite))
+}
@@ -0,0 +1,165 @@
+package restful
+
+import (
+	"errors"
+	"hilo-api/internal/application/attachment"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/restful"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is what a multipart body may add around the file
+const multipartOverhead = 1 << 20
+
+// NewAttachment method
+func NewAttachment(cfg config.Attachment, upload *attachment.UploadAttachmentUseCase, get *attachment.GetAttachmentUseCase, create *attachment.CreateUploadUseCase, appendChunk *attachment.AppendUploadUseCase, progress *attachment.GetUploadUseCase, cancel *attachment.CancelUploadUseCase) *Attachment {
+	return &Attachment{
+		upload:      upload,
+		get:         get,
+		create:      create,
+		appendChunk: appendChunk,
+		progress:    progress,
+		cancel:      cancel,
+		maxBody:     cfg.MaxSize() + multipartOverhead,
+		uploadTTL:   cfg.AttachmentUploadTTL,
+	}
+}
+
+// Attachment serves the files the signed in user uploads and receives
+type Attachment struct {
+	upload      *attachment.UploadAttachmentUseCase
+	get         *attachment.GetAttachmentUseCase
+	create      *attachment.CreateUploadUseCase
+	appendChunk *attachment.AppendUploadUseCase
+	progress    *attachment.GetUploadUseCase
+	cancel      *attachment.CancelUploadUseCase
+	maxBody     int64
+	uploadTTL   time.Duration
+}
+
+// Upload method
+// a multipart form with the file in the "file" field; the attachment can be
+// sent with a message until the upload TTL runs out
+func (a *Attachment) Upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, a.maxBody)
+	var req dto.UploadAttachmentRequest
+	err := c.ShouldBind(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		panic(do.ErrAttachmentTooLarge)
+	}
+	errorCatcher.PanicIfErr(err, errorCatcher.ErrGinBindingAndValidate, restful.ErrBindRequest)
+
+	file, err := req.File.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	stored, err := a.upload.Execute(c.Request.Context(), MustUserID(c), req.File.Filename, file, req.File.Size)
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.AttachmentResponse
+	res.FromDomain(stored)
+	c.JSON(http.StatusCreated, res)
+}
+
+// Get method
+// the attachment with short lived download URLs for it and its thumbnails
+func (a *Attachment) Get(c *gin.Context) {
+	var uri dto.AttachmentURI
+	restful.MustBindUri(c, &uri)
+
+	found, links, err := a.get.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c))
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.AttachmentResponse
+	res.FromDomain(found)
+	res.SetURLs(links.URL, links.Variants, links.ExpiresAt)
+	c.JSON(http.StatusOK, res)
+}
+
+// CreateUpload method
+// starts a resumable upload, the chunks go to the URL in Location
+func (a *Attachment) CreateUpload(c *gin.Context) {
+	var req dto.CreateUploadRequest
+	restful.MustBindJSON(c, &req)
+
+	upload, err := a.create.Execute(c.Request.Context(), MustUserID(c), req.Filename, req.Size)
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.UploadResponse
+	res.FromDomain(upload, a.uploadTTL)
+	c.Header("Location", c.Request.URL.Path+"/"+res.ID)
+	c.Header(definition.UploadOffsetHeader, "0")
+	c.JSON(http.StatusCreated, res)
+}
+
+// AppendUpload method
+// the raw body is the chunk starting at Upload-Offset; after an interrupted
+// chunk GetUpload tells where to resume
+func (a *Attachment) AppendUpload(c *gin.Context) {
+	var uri dto.UploadURI
+	restful.MustBindUri(c, &uri)
+	var header dto.AppendUploadHeader
+	restful.MustBindHeader(c, &header)
+
+	upload, stored, err := a.appendChunk.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c),
+		*header.Offset, c.Request.Body, c.Request.ContentLength)
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.UploadResponse
+	res.FromDomain(upload, a.uploadTTL)
+	c.Header(definition.UploadOffsetHeader, strconv.FormatInt(upload.Received(), 10))
+	if stored == nil {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	res.Attachment = &dto.AttachmentResponse{}
+	res.Attachment.FromDomain(stored)
+	c.JSON(http.StatusCreated, res)
+}
+
+// GetUpload method
+func (a *Attachment) GetUpload(c *gin.Context) {
+	var uri dto.UploadURI
+	restful.MustBindUri(c, &uri)
+
+	upload, err := a.progress.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c))
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.UploadResponse
+	res.FromDomain(upload, a.uploadTTL)
+	c.Header(definition.UploadOffsetHeader, strconv.FormatInt(upload.Received(), 10))
+	c.JSON(http.StatusOK, res)
+}
+
+// CancelUpload method
+func (a *Attachment) CancelUpload(c *gin.Context) {
+	var uri dto.UploadURI
+	restful.MustBindUri(c, &uri)
+
+	if err := a.cancel.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c)); err != nil {
+		panic(err)
+	}
+	c.Status(http.StatusNoContent)
+}
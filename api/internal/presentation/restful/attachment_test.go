@@ -0,0 +1,167 @@
+package restful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"hilo-api/internal/application/attachment"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/domain/repository"
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/errorCatcher"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type fakeAttachments struct {
+	repository.AttachmentRepository
+	created []*do.Attachment
+}
+
+func (f *fakeAttachments) Create(_ context.Context, attachment *do.Attachment, _ int64) error {
+	f.created = append(f.created, attachment)
+	return nil
+}
+
+func (f *fakeAttachments) FindByID(_ context.Context, id uuid.UUID) (*do.Attachment, error) {
+	for _, attachment := range f.created {
+		if attachment.ID() == id {
+			return attachment, nil
+		}
+	}
+	return nil, repository.ErrAttachmentNotFound
+}
+
+type AttachmentSuite struct {
+	suite.Suite
+	user        uuid.UUID
+	local       *blob.Local
+	attachments *fakeAttachments
+	router      *gin.Engine
+}
+
+func (suite *AttachmentSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.user = uuid.New()
+	cfg := config.Attachment{
+		AttachmentMaxSizeMB:    1,
+		AttachmentQuotaMB:      10,
+		AttachmentAllowedTypes: []string{"text/plain"},
+		AttachmentURLExpiry:    time.Minute,
+	}
+
+	var err error
+	suite.local, err = blob.NewLocal(suite.T().TempDir(), []byte("secret"), blob.WithBaseURL("/api/v1/blobs"))
+	suite.Require().NoError(err)
+	suite.attachments = &fakeAttachments{}
+	handler := NewAttachment(cfg, attachment.NewUploadAttachmentUseCase(suite.attachments, suite.local, cfg),
+		attachment.NewGetAttachmentUseCase(suite.attachments, nil, suite.local, cfg), nil, nil, nil, nil)
+
+	suite.router = gin.New()
+	suite.router.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "attachment"))
+	// stands in for the JWT guard
+	suite.router.Use(func(c *gin.Context) { c.Set(GinContextUserIDKey, suite.user.String()) })
+	suite.router.POST("/api/v1/attachments", handler.Upload)
+	suite.router.GET("/api/v1/attachments/:id", handler.Get)
+	suite.router.GET("/api/v1/blobs/*key", NewBlobs(suite.local).Serve)
+}
+
+func (suite *AttachmentSuite) upload(content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "notes.txt")
+	suite.Require().NoError(err)
+	_, err = part.Write(content)
+	suite.Require().NoError(err)
+	suite.Require().NoError(form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AttachmentSuite) TestUpload() {
+	w := suite.upload([]byte("hello"))
+	suite.Equal(http.StatusCreated, w.Code, w.Body.String())
+	suite.Contains(w.Body.String(), `"content_type":"text/plain; charset=utf-8"`)
+	suite.Len(suite.attachments.created, 1)
+}
+
+func (suite *AttachmentSuite) TestUploadTooLarge() {
+	w := suite.upload(bytes.Repeat([]byte("a"), 3<<20))
+	suite.Equal(http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	suite.Empty(suite.attachments.created)
+}
+
+func (suite *AttachmentSuite) TestGetWithThumbnails() {
+	photo := do.ReconstructAttachment(uuid.New(), suite.user, uuid.Nil, "cat.jpg", "image/jpeg", 100, "abc", time.Now())
+	photo.CompleteProcessing(do.ImageInfo{Width: 800, Height: 600, Blurhash: "LEHV6nWB2yk8", Variants: []do.AttachmentVariant{
+		{Name: "small", ContentType: "image/jpeg", Width: 160, Height: 120, Size: 10},
+	}})
+	suite.attachments.created = append(suite.attachments.created, photo)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+photo.ID().String(), nil))
+	suite.Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var res dto.AttachmentResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &res))
+	suite.Equal("ready", res.Processing)
+	suite.Equal(800, res.Width)
+	suite.Equal("LEHV6nWB2yk8", res.Blurhash)
+	suite.Require().Len(res.Variants, 1)
+	suite.Equal(160, res.Variants[0].Width)
+	suite.Contains(res.Variants[0].URL, "/api/v1/blobs/"+photo.VariantKey("small"))
+}
+
+func (suite *AttachmentSuite) TestServeSignedURL() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.local.Put(ctx, "attachments/a/b", strings.NewReader("<html>hi</html>"), 15, "text/html"))
+	url, err := suite.local.SignURL(ctx, "attachments/a/b", blob.Download{ContentType: "text/html", Filename: "page.html"}, time.Minute)
+	suite.Require().NoError(err)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("<html>hi</html>", w.Body.String())
+	suite.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+	suite.Equal(`attachment; filename=page.html`, w.Header().Get("Content-Disposition"))
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Range", "bytes=0-5")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Equal(http.StatusPartialContent, w.Code)
+	suite.Equal("<html>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.Replace(url, "page.html", "page.svg", 1), nil))
+	suite.Equal(http.StatusForbidden, w.Code, "a tampered URL is rejected")
+}
+
+func (suite *AttachmentSuite) TestServeWithoutLocalBackend() {
+	router := gin.New()
+	router.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "attachment"))
+	router.GET("/api/v1/blobs/*key", NewBlobs(nil).Serve)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/blobs/attachments/a/b", nil))
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestAttachmentSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentSuite))
+}
@@ -0,0 +1,65 @@
+package restful
+
+import (
+	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/pkg/blob"
+	"hilo-api/pkg/errorCatcher"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrBlobURL = errors.New("[Blob URL Rejected]")
+)
+
+// NewBlobs method
+// local is nil when attachments live in S3, which serves them itself
+func NewBlobs(local *blob.Local) *Blobs {
+	return &Blobs{local: local}
+}
+
+// Blobs serves attachments of the local backend through the URLs it signed
+type Blobs struct {
+	local *blob.Local
+}
+
+// Serve method
+// reached without a token, the signature in the query is the credential;
+// range requests are supported so media can be streamed
+func (b *Blobs) Serve(c *gin.Context) {
+	if b.local == nil {
+		panic(usecase.ErrAttachmentNotFound)
+	}
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	download, err := b.local.Verify(key, c.Request.URL.Query())
+	if errors.Is(err, blob.ErrBadURL) || errors.Is(err, blob.ErrInvalidKey) {
+		errorCatcher.PanicIfErr(err, errorCatcher.ErrPermissionDeny, ErrBlobURL)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	body, err := b.local.Open(c.Request.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		panic(fmt.Errorf("%w: %w", usecase.ErrAttachmentNotFound, err))
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer body.Close()
+
+	// the key names an immutable file, the URL expiry keeps caches honest
+	c.Header("Content-Type", download.ContentType)
+	c.Header("Content-Disposition", download.Disposition())
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	c.Header("Cache-Control", "private, max-age=300")
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, body.(io.ReadSeeker))
+}
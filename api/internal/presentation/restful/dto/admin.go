@@ -0,0 +1,11 @@
+package dto
+
+// LogLevelRequest changes the level of the running logger
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal"`
+}
+
+// LogLevelResponse represents the level of the running logger
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
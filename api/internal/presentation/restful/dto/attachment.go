@@ -0,0 +1,135 @@
+package dto
+
+import (
+	"hilo-api/internal/domain/do"
+	"mime/multipart"
+	"time"
+)
+
+// UploadAttachmentRequest represents a multipart upload of one file
+type UploadAttachmentRequest struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+// AttachmentURI represents the attachment id path parameter
+type AttachmentURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// AttachmentResponse represents an uploaded file; URL is only set where a
+// download link was asked for and stops working at URLExpiresAt. Images
+// report their processing, pending until Width, Height, Blurhash and the
+// thumbnail Variants are known.
+type AttachmentResponse struct {
+	ID           string             `json:"id"`
+	Filename     string             `json:"filename"`
+	ContentType  string             `json:"content_type"`
+	Size         int64              `json:"size"`
+	Checksum     string             `json:"sha256"`
+	CreatedAt    time.Time          `json:"created_at"`
+	Processing   string             `json:"processing,omitempty"`
+	Width        int                `json:"width,omitempty"`
+	Height       int                `json:"height,omitempty"`
+	Blurhash     string             `json:"blurhash,omitempty"`
+	Variants     []*VariantResponse `json:"variants,omitempty"`
+	URL          string             `json:"url,omitempty"`
+	URLExpiresAt *time.Time         `json:"url_expires_at,omitempty"`
+}
+
+// VariantResponse represents a thumbnail of an image attachment; URL is set
+// along with the attachment's
+type VariantResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url,omitempty"`
+}
+
+// FromDomain converts domain attachment to DTO
+func (a *AttachmentResponse) FromDomain(attachment *do.Attachment) {
+	a.ID = attachment.ID().String()
+	a.Filename = attachment.Filename()
+	a.ContentType = attachment.ContentType()
+	a.Size = attachment.Size()
+	a.Checksum = attachment.Checksum()
+	a.CreatedAt = attachment.CreatedAt()
+	a.Processing = string(attachment.Processing())
+
+	image := attachment.Image()
+	a.Width = image.Width
+	a.Height = image.Height
+	a.Blurhash = image.Blurhash
+	a.Variants = nil
+	for _, variant := range image.Variants {
+		a.Variants = append(a.Variants, &VariantResponse{
+			Name:        variant.Name,
+			ContentType: variant.ContentType,
+			Width:       variant.Width,
+			Height:      variant.Height,
+			Size:        variant.Size,
+		})
+	}
+}
+
+// SetURLs sets the download link of the attachment and of its thumbnails,
+// which variantURLs maps by name
+func (a *AttachmentResponse) SetURLs(url string, variantURLs map[string]string, expiresAt time.Time) {
+	a.URL = url
+	a.URLExpiresAt = &expiresAt
+	for _, variant := range a.Variants {
+		variant.URL = variantURLs[variant.Name]
+	}
+}
+
+// AttachmentsFromDomain converts domain attachments to DTOs, nil when there
+// are none
+func AttachmentsFromDomain(attachments []*do.Attachment) []*AttachmentResponse {
+	if len(attachments) == 0 {
+		return nil
+	}
+	res := make([]*AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		res[i] = &AttachmentResponse{}
+		res[i].FromDomain(attachment)
+	}
+	return res
+}
+
+// CreateUploadRequest represents the start of a resumable upload of size bytes
+type CreateUploadRequest struct {
+	Filename string `json:"filename" binding:"required,max=255"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+}
+
+// UploadURI represents the upload id path parameter
+type UploadURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// AppendUploadHeader represents the offset a chunk of a resumable upload
+// starts at, the bytes received so far
+type AppendUploadHeader struct {
+	Offset *int64 `header:"Upload-Offset" binding:"required,min=0"`
+}
+
+// UploadResponse represents a resumable upload; Received is the offset to
+// send the next chunk at. The chunk completing it carries the Attachment.
+type UploadResponse struct {
+	ID         string              `json:"id"`
+	Filename   string              `json:"filename"`
+	Size       int64               `json:"size"`
+	Received   int64               `json:"received"`
+	ExpiresAt  time.Time           `json:"expires_at"`
+	Attachment *AttachmentResponse `json:"attachment,omitempty"`
+}
+
+// FromDomain converts domain upload to DTO, it expires ttl after it started
+func (u *UploadResponse) FromDomain(upload *do.Upload, ttl time.Duration) {
+	u.ID = upload.ID().String()
+	u.Filename = upload.Filename()
+	u.Size = upload.Size()
+	u.Received = upload.Received()
+	u.ExpiresAt = upload.CreatedAt().Add(ttl)
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"hilo-api/internal/domain/do"
+	"hilo-api/pkg/realtime"
+)
+
+// EventData converts the domain data of a realtime event to its DTO
+func EventData(event realtime.Event) any {
+	switch data := event.Data.(type) {
+	case *do.Message:
+		res := &MessageResponse{}
+		res.FromDomain(data)
+		return res
+	case *do.ConversationRead:
+		res := &ConversationReadResponse{}
+		res.FromDomain(data)
+		return res
+	case *do.TypingSignal:
+		res := &TypingEventResponse{}
+		res.FromDomain(data)
+		return res
+	case *do.Reaction:
+		res := &ReactionEventResponse{}
+		res.FromDomain(data)
+		return res
+	case *do.Attachment:
+		res := &AttachmentResponse{}
+		res.FromDomain(data)
+		return res
+	default:
+		return data
+	}
+}
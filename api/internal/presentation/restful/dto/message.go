@@ -5,10 +5,15 @@ import (
 	"time"
 )
 
-// SendMessageRequest represents send message request
+// SendMessageRequest represents send message request; ID is an optional
+// client generated message id that makes retries safe, like Idempotency-Key.
+// With uploaded attachments the content is an optional caption.
 type SendMessageRequest struct {
-	ReceiverID string `json:"receiver_id" binding:"required,uuid"`
-	Content    string `json:"content" binding:"required,max=5000"`
+	ID            string   `json:"id" binding:"omitempty,uuid"`
+	ReceiverID    string   `json:"receiver_id" binding:"required,uuid"`
+	Content       string   `json:"content" binding:"required_without=AttachmentIDs,max=5000"`
+	ReplyToID     string   `json:"reply_to_id" binding:"omitempty,uuid"`
+	AttachmentIDs []string `json:"attachment_ids" binding:"omitempty,max=10,dive,uuid"`
 }
 
 // MarkAsReadRequest represents mark as read request
@@ -16,14 +21,107 @@ type MarkAsReadRequest struct {
 	MessageID string `json:"message_id" binding:"required,uuid"`
 }
 
-// MessageResponse represents a single message
+// ConversationURI represents the other user of a conversation path parameter
+type ConversationURI struct {
+	UserID string `uri:"user_id" binding:"required,uuid"`
+}
+
+// MarkConversationReadRequest represents reading a conversation up to a
+// message or a time; an empty object reads all of it
+type MarkConversationReadRequest struct {
+	UpToMessageID string     `json:"up_to_message_id" binding:"omitempty,uuid,excluded_with=UpTo"`
+	UpTo          *time.Time `json:"up_to"`
+}
+
+// MarkConversationReadResponse represents how many messages were read
+type MarkConversationReadResponse struct {
+	Read int `json:"read"`
+}
+
+// ConversationReadResponse represents the receipt for the messages of a
+// conversation read at once, those of other_user_id created up to up_to
+type ConversationReadResponse struct {
+	ReaderID    string    `json:"reader_id"`
+	OtherUserID string    `json:"other_user_id"`
+	UpTo        time.Time `json:"up_to"`
+	ReadAt      time.Time `json:"read_at"`
+	Count       int       `json:"count"`
+}
+
+// FromDomain converts domain conversation read to DTO
+func (r *ConversationReadResponse) FromDomain(receipt *do.ConversationRead) {
+	r.ReaderID = receipt.ReaderID.String()
+	r.OtherUserID = receipt.OtherUserID.String()
+	r.UpTo = receipt.UpTo
+	r.ReadAt = receipt.ReadAt
+	r.Count = receipt.Count
+}
+
+// AcknowledgeDeliveryRequest represents the messages a device of the
+// receiver got, typically everything a sync fetched
+type AcknowledgeDeliveryRequest struct {
+	MessageIDs []string `json:"message_ids" binding:"required,min=1,max=100,dive,uuid"`
+}
+
+// AcknowledgeDeliveryResponse represents the messages that were not
+// delivered before the acknowledgement
+type AcknowledgeDeliveryResponse struct {
+	Delivered []string `json:"delivered"`
+}
+
+// MessageURI represents the message id path parameter
+type MessageURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// EditMessageRequest represents edit message request; the caption of a
+// message with attachments may be edited to empty
+type EditMessageRequest struct {
+	Content string `json:"content" binding:"max=5000"`
+}
+
+// DeleteMessageRequest represents delete message request; For is me (the
+// default) or everyone
+type DeleteMessageRequest struct {
+	For string `form:"for" binding:"omitempty,oneof=me everyone"`
+}
+
+// MessageResponse represents a single message; a message deleted for
+// everyone keeps its place with empty content and Deleted set. State is
+// sent, delivered or read.
 type MessageResponse struct {
-	ID         string     `json:"id"`
-	SenderID   string     `json:"sender_id"`
-	ReceiverID string     `json:"receiver_id"`
-	Content    string     `json:"content"`
-	CreatedAt  time.Time  `json:"created_at"`
-	ReadAt     *time.Time `json:"read_at,omitempty"`
+	ID          string                `json:"id"`
+	SenderID    string                `json:"sender_id"`
+	ReceiverID  string                `json:"receiver_id"`
+	Content     string                `json:"content"`
+	CreatedAt   time.Time             `json:"created_at"`
+	State       string                `json:"state"`
+	DeliveredAt *time.Time            `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time            `json:"read_at,omitempty"`
+	EditedAt    *time.Time            `json:"edited_at,omitempty"`
+	Deleted     bool                  `json:"deleted,omitempty"`
+	DeletedAt   *time.Time            `json:"deleted_at,omitempty"`
+	ReplyTo     *QuoteResponse        `json:"reply_to,omitempty"`
+	Attachments []*AttachmentResponse `json:"attachments,omitempty"`
+	Reactions   []*ReactionResponse   `json:"reactions,omitempty"`
+}
+
+// QuoteResponse represents the preview of the message a reply answers
+type QuoteResponse struct {
+	ID        string `json:"id"`
+	SenderID  string `json:"sender_id"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+// FromDomain converts domain message quote to DTO
+func (q *QuoteResponse) FromDomain(quote *do.MessageQuote) {
+	q.ID = quote.ID().String()
+	q.SenderID = quote.SenderID().String()
+	q.Content = quote.Content()
+	q.Truncated = quote.IsTruncated()
+	q.Deleted = quote.IsDeleted()
 }
 
 // FromDomain converts domain message to DTO
@@ -33,7 +131,39 @@ func (m *MessageResponse) FromDomain(msg *do.Message) {
 	m.ReceiverID = msg.ReceiverID().String()
 	m.Content = msg.Content()
 	m.CreatedAt = msg.CreatedAt()
+	m.State = string(msg.State())
+	m.DeliveredAt = msg.DeliveredAt()
 	m.ReadAt = msg.ReadAt()
+	m.EditedAt = msg.EditedAt()
+	m.Deleted = msg.IsDeleted()
+	m.DeletedAt = msg.DeletedAt()
+	if quote := msg.Quote(); quote != nil {
+		m.ReplyTo = &QuoteResponse{}
+		m.ReplyTo.FromDomain(quote)
+	}
+	m.Attachments = AttachmentsFromDomain(msg.Attachments())
+	m.Reactions = ReactionsFromDomain(msg.Reactions())
+}
+
+// MessageRevisionResponse represents a version of a message an edit replaced
+type MessageRevisionResponse struct {
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// FromDomain converts domain message revision to DTO
+func (r *MessageRevisionResponse) FromDomain(revision *do.MessageRevision) {
+	r.Content = revision.Content()
+	r.CreatedAt = revision.CreatedAt()
+	r.ReplacedAt = revision.ReplacedAt()
+}
+
+// ListRevisionsResponse represents the current message with its edit
+// history, oldest revision first
+type ListRevisionsResponse struct {
+	Message   *MessageResponse           `json:"message"`
+	Revisions []*MessageRevisionResponse `json:"revisions"`
 }
 
 // ListMessagesRequest represents list messages request
@@ -43,6 +173,12 @@ type ListMessagesRequest struct {
 	Offset      int    `form:"offset" binding:"omitempty,min=0"`
 }
 
+// ListRepliesRequest represents list replies request
+type ListRepliesRequest struct {
+	Limit  int `form:"limit" binding:"required,min=1,max=100"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
 // ListMessagesResponse represents list messages response
 type ListMessagesResponse struct {
 	Messages []*MessageResponse `json:"messages"`
@@ -72,3 +208,45 @@ type ListConversationsResponse struct {
 	Conversations []*ConversationPreviewResponse `json:"conversations"`
 	Total         int                            `json:"total"`
 }
+
+// SearchMessagesRequest represents search messages request; Query takes
+// words and "quoted phrases", Cursor is the next_cursor of the previous page
+type SearchMessagesRequest struct {
+	Query         string    `form:"q" binding:"required,max=200"`
+	From          string    `form:"from" binding:"omitempty,uuid"`
+	After         time.Time `form:"after" time_format:"2006-01-02T15:04:05Z07:00"`
+	Before        time.Time `form:"before" time_format:"2006-01-02T15:04:05Z07:00"`
+	HasAttachment bool      `form:"has_attachment"`
+	Limit         int       `form:"limit" binding:"required,min=1,max=100"`
+	Cursor        string    `form:"cursor" binding:"max=100"`
+}
+
+// SnippetPartResponse represents a piece of a search snippet; Match marks
+// the pieces the query matched, for clients to highlight
+type SnippetPartResponse struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// SearchResultResponse represents a message a search found
+type SearchResultResponse struct {
+	Message *MessageResponse       `json:"message"`
+	Snippet []*SnippetPartResponse `json:"snippet"`
+}
+
+// FromDomain converts domain search hit to DTO
+func (r *SearchResultResponse) FromDomain(hit *do.SearchHit) {
+	r.Message = &MessageResponse{}
+	r.Message.FromDomain(hit.Message)
+	r.Snippet = make([]*SnippetPartResponse, len(hit.Snippet))
+	for i, part := range hit.Snippet {
+		r.Snippet[i] = &SnippetPartResponse{Text: part.Text, Match: part.Match}
+	}
+}
+
+// SearchMessagesResponse represents search messages response, newest first;
+// NextCursor is empty on the last page
+type SearchMessagesResponse struct {
+	Results    []*SearchResultResponse `json:"results"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"hilo-api/internal/domain/do"
+	"time"
+)
+
+// UserURI represents the user id path parameter
+type UserURI struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// PresenceResponse represents whether a user is online; LastSeenAt is left
+// out while they are online, never seen or hide it
+type PresenceResponse struct {
+	UserID     string     `json:"user_id"`
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// FromDomain converts domain presence to DTO
+func (p *PresenceResponse) FromDomain(presence *do.Presence) {
+	p.UserID = presence.UserID.String()
+	p.Online = presence.Online
+	p.LastSeenAt = presence.LastSeenAt
+}
+
+// PresenceSettingsRequest represents the presence settings of the signed in
+// user
+type PresenceSettingsRequest struct {
+	HideLastSeen *bool `json:"hide_last_seen" binding:"required"`
+}
+
+// TypingRequest represents a typing signal for the conversation with
+// user_id; clients repeat typing true every few seconds while the user
+// types and send false when they stop
+type TypingRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Typing *bool  `json:"typing" binding:"required"`
+}
+
+// TypingEventResponse represents a conversation partner typing; a start
+// ends by itself after expires_in_ms unless repeated
+type TypingEventResponse struct {
+	UserID      string `json:"user_id"`
+	Typing      bool   `json:"typing"`
+	ExpiresInMs int64  `json:"expires_in_ms,omitempty"`
+}
+
+// FromDomain converts domain typing signal to DTO
+func (t *TypingEventResponse) FromDomain(signal *do.TypingSignal) {
+	t.UserID = signal.UserID.String()
+	t.Typing = signal.Typing
+	t.ExpiresInMs = signal.ExpiresIn.Milliseconds()
+}
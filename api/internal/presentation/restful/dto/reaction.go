@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"hilo-api/internal/domain/do"
+	"time"
+)
+
+// ReactionRequest represents toggle reaction request
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required,max=64"`
+}
+
+// ReactionResponse represents one emoji on a message as the caller sees it
+type ReactionResponse struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+// FromDomain converts domain reaction summary to DTO
+func (r *ReactionResponse) FromDomain(summary *do.ReactionSummary) {
+	r.Emoji = summary.Emoji
+	r.Count = summary.Count
+	r.ReactedByMe = summary.ReactedByMe
+}
+
+// ReactionsFromDomain converts domain reaction summaries to DTOs, nil when
+// there are none
+func ReactionsFromDomain(summaries []*do.ReactionSummary) []*ReactionResponse {
+	if len(summaries) == 0 {
+		return nil
+	}
+	res := make([]*ReactionResponse, 0, len(summaries))
+	for _, summary := range summaries {
+		r := &ReactionResponse{}
+		r.FromDomain(summary)
+		res = append(res, r)
+	}
+	return res
+}
+
+// ToggleReactionResponse represents toggle reaction response; Added is
+// false when the call took the caller's reaction back
+type ToggleReactionResponse struct {
+	Added     bool                `json:"added"`
+	Reactions []*ReactionResponse `json:"reactions"`
+}
+
+// ReactionEventResponse represents a reaction added or removed, pushed to
+// both participants
+type ReactionEventResponse struct {
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FromDomain converts domain reaction to DTO
+func (r *ReactionEventResponse) FromDomain(reaction *do.Reaction) {
+	r.MessageID = reaction.MessageID().String()
+	r.UserID = reaction.UserID().String()
+	r.Emoji = reaction.Emoji()
+	r.CreatedAt = reaction.CreatedAt()
+}
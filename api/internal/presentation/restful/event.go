@@ -0,0 +1,83 @@
+package restful
+
+import (
+	"context"
+	"hilo-api/internal/application/presence"
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/metrics"
+	"hilo-api/pkg/realtime"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat keeps idle streams open through proxies that cut silent
+// connections
+const streamHeartbeat = 25 * time.Second
+
+// NewEvents method
+// streams beat at least twice per presence timeout, so a device whose
+// stream still writes never counts as offline
+func NewEvents(hub *realtime.Hub, connect *presence.ConnectUseCase, disconnect *presence.DisconnectUseCase, cfg config.Presence) *Events {
+	return &Events{
+		hub:        hub,
+		connect:    connect,
+		disconnect: disconnect,
+		heartbeat:  min(streamHeartbeat, cfg.PresenceTimeout/2),
+	}
+}
+
+// Events streams what happens to the signed in user's conversations
+type Events struct {
+	hub        *realtime.Hub
+	connect    *presence.ConnectUseCase
+	disconnect *presence.DisconnectUseCase
+	heartbeat  time.Duration
+}
+
+// Stream method
+// a server-sent event stream, one event per realtime event with its type
+// as the event name; it ends when the client goes away, the server shuts
+// down or the client falls too far behind, and clients reconnect then.
+// The user counts as online while the stream is open.
+func (e *Events) Stream(c *gin.Context) {
+	userID := MustUserID(c)
+	sub := e.hub.Subscribe(userID)
+	defer sub.Close()
+	metrics.RealtimeStreamsOpen.Inc()
+	defer metrics.RealtimeStreamsOpen.Dec()
+
+	session := e.connect.Execute(c.Request.Context(), userID)
+	defer func() {
+		// the stream is over and nobody is left to tell, the span has it
+		_ = e.disconnect.Execute(context.WithoutCancel(c.Request.Context()), session)
+	}()
+
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, dto.EventData(event))
+			session.Beat()
+			return true
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return false
+			}
+			session.Beat()
+			return true
+		}
+	})
+}
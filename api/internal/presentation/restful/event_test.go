@@ -0,0 +1,136 @@
+package restful
+
+import (
+	"bufio"
+	"context"
+	"hilo-api/internal/application/presence"
+	"hilo-api/internal/domain/do"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/metrics"
+	"hilo-api/pkg/realtime"
+	"hilo-api/pkg/restful"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// lastSeenStub drops last seen updates; the stream only needs somewhere to
+// put them
+type lastSeenStub struct{}
+
+func (lastSeenStub) FindLastSeen(_ context.Context, userID uuid.UUID) (*do.LastSeen, error) {
+	return do.ReconstructLastSeen(userID, nil, false), nil
+}
+
+func (lastSeenStub) UpdateLastSeen(context.Context, uuid.UUID, time.Time) error {
+	return nil
+}
+
+func (lastSeenStub) UpdateLastSeenHidden(context.Context, uuid.UUID, bool) error {
+	return nil
+}
+
+type EventsSuite struct {
+	suite.Suite
+	hub    *realtime.Hub
+	userID uuid.UUID
+	server *httptest.Server
+}
+
+func (suite *EventsSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	suite.hub = realtime.NewHub()
+	suite.userID = uuid.New()
+	connect := presence.NewConnectUseCase(realtime.NewPresence(time.Minute))
+	typing := realtime.NewTyping(time.Second, 2*time.Second)
+	disconnect := presence.NewDisconnectUseCase(lastSeenStub{}, typing, suite.hub)
+	events := NewEvents(suite.hub, connect, disconnect, config.Presence{PresenceTimeout: time.Minute})
+	events.heartbeat = 20 * time.Millisecond
+
+	router := gin.New()
+	router.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "events"))
+	// stands in for the JWT guard accepting a token
+	router.Use(func(c *gin.Context) {
+		c.Set(GinContextUserIDKey, suite.userID.String())
+	})
+	AddRoutes(router, restful.CommonHandler{
+		Error404:   restful.Error404Set,
+		QuickReply: restful.QuickReplySet,
+		PromHTTP:   restful.NewPromHTTPSet,
+		PromGuard:  func(c *gin.Context) {},
+	}, HandlerSet{Events: events, RateLimits: RateLimits{Default: func(c *gin.Context) {}}})
+	suite.server = httptest.NewServer(router)
+}
+
+func (suite *EventsSuite) TearDownTest() {
+	suite.hub.Close()
+	suite.server.Close()
+}
+
+// connect opens the stream and waits until the hub knows about it
+func (suite *EventsSuite) connect() (*http.Response, *bufio.Scanner) {
+	res, err := http.Get(suite.server.URL + "/api/v1/events")
+	suite.Require().NoError(err)
+	suite.Equal("text/event-stream", res.Header.Get("Content-Type"))
+	suite.Eventually(func() bool { return suite.hub.Connected(suite.userID) == 1 }, time.Second, time.Millisecond)
+	return res, bufio.NewScanner(res.Body)
+}
+
+func (suite *EventsSuite) TestStreamsEvents() {
+	res, lines := suite.connect()
+	defer res.Body.Close()
+
+	msg, err := do.NewMessage(uuid.New(), suite.userID, "Hello")
+	suite.Require().NoError(err)
+	suite.hub.Publish(suite.userID, realtime.Event{Type: "message.edited", Data: msg})
+
+	var event, data string
+	for lines.Scan() {
+		line := lines.Text()
+		if v, ok := strings.CutPrefix(line, "event:"); ok {
+			event = v
+		}
+		if v, ok := strings.CutPrefix(line, "data:"); ok {
+			data = v
+			break
+		}
+	}
+	suite.Equal("message.edited", event)
+	suite.Contains(data, `"content":"Hello"`)
+	suite.Contains(data, msg.ID().String())
+}
+
+func (suite *EventsSuite) TestHeartbeat() {
+	res, lines := suite.connect()
+	defer res.Body.Close()
+
+	suite.True(lines.Scan())
+	suite.Equal(": heartbeat", lines.Text())
+}
+
+func (suite *EventsSuite) TestEndsWhenHubCloses() {
+	res, lines := suite.connect()
+	defer res.Body.Close()
+
+	suite.Equal(1.0, testutil.ToFloat64(metrics.RealtimeStreamsOpen))
+
+	suite.hub.Close()
+	for lines.Scan() {
+	}
+	suite.NoError(lines.Err())
+	suite.Zero(suite.hub.Connected(suite.userID))
+	suite.Eventually(func() bool { return testutil.ToFloat64(metrics.RealtimeStreamsOpen) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
@@ -0,0 +1,32 @@
+package restful
+
+import (
+	"context"
+
+	"hilo-api/deployments/migrations"
+	"hilo-api/internal/domain/definition"
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/database/postgres"
+	"hilo-api/pkg/health"
+	"hilo-api/pkg/jwt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NewHealth method
+// readiness covers the database pool, the schema version the binary was
+// built against and the JWT key ring
+func NewHealth(db *sqlx.DB, es256JWT definition.ES256JWT, opt config.Server) (*health.Health, error) {
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	return health.NewHealth(
+		health.WithTimeout(opt.ReadinessTimeout),
+		health.WithCheck("postgres", postgres.PingCheck(db)),
+		health.WithCheck("migrations", postgres.SchemaCheck(db, schemaVersion)),
+		health.WithCheck("keyring", func(ctx context.Context) error {
+			return jwt.Probe(es256JWT)
+		}),
+	), nil
+}
@@ -1,5 +1,26 @@
 package restful
 
+import (
+	"errors"
+	authDefinition "hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
 const (
-	GinContextUserIDKey = "user_id"
+	GinContextUserIDKey = authDefinition.ContextUserIDKey
 )
+
+var (
+	ErrMissingUserID = errors.New("[Missing User ID]")
+)
+
+// MustUserID returns the user the JWT guard authenticated, panicking with an
+// authentication error when the route was reached without one
+func MustUserID(c *gin.Context) uuid.UUID {
+	id, err := uuid.Parse(c.GetString(GinContextUserIDKey))
+	errorCatcher.PanicIfErr(err, errorCatcher.ErrAuthenticate, ErrMissingUserID)
+	return id
+}
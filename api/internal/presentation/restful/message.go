@@ -0,0 +1,292 @@
+package restful
+
+import (
+	"hilo-api/internal/application/message"
+	"hilo-api/internal/domain/do"
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/restful"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NewMessage method
+func NewMessage(send *message.SendMessageUseCase, edit *message.EditMessageUseCase, revisions *message.ListRevisionsUseCase, remove *message.DeleteMessageUseCase, replies *message.ListRepliesUseCase, list *message.ListConversationUseCase, react *message.ToggleReactionUseCase, search *message.SearchMessagesUseCase, acknowledge *message.AcknowledgeDeliveryUseCase, markRead *message.MarkAsReadUseCase, readConversation *message.MarkConversationReadUseCase) *Message {
+	return &Message{
+		send:             send,
+		edit:             edit,
+		revisions:        revisions,
+		remove:           remove,
+		replies:          replies,
+		list:             list,
+		react:            react,
+		search:           search,
+		acknowledge:      acknowledge,
+		markRead:         markRead,
+		readConversation: readConversation,
+	}
+}
+
+// Message serves the messages of the signed in user
+type Message struct {
+	send             *message.SendMessageUseCase
+	edit             *message.EditMessageUseCase
+	revisions        *message.ListRevisionsUseCase
+	remove           *message.DeleteMessageUseCase
+	replies          *message.ListRepliesUseCase
+	list             *message.ListConversationUseCase
+	react            *message.ToggleReactionUseCase
+	search           *message.SearchMessagesUseCase
+	acknowledge      *message.AcknowledgeDeliveryUseCase
+	markRead         *message.MarkAsReadUseCase
+	readConversation *message.MarkConversationReadUseCase
+}
+
+// Send method
+// a retry carrying the same Idempotency-Key header or message id gets the
+// original message and status back, marked with Idempotent-Replayed
+func (m *Message) Send(c *gin.Context) {
+	var req dto.SendMessageRequest
+	restful.MustBindJSON(c, &req)
+	senderID := MustUserID(c)
+
+	var options []message.SendOption
+	if key := c.GetHeader(definition.IdempotencyKeyHeader); key != "" {
+		options = append(options, message.WithIdempotencyKey(key))
+	}
+	if req.ID != "" {
+		options = append(options, message.WithMessageID(uuid.MustParse(req.ID)))
+	}
+	if req.ReplyToID != "" {
+		options = append(options, message.WithReplyTo(uuid.MustParse(req.ReplyToID)))
+	}
+	if len(req.AttachmentIDs) > 0 {
+		ids := make([]uuid.UUID, len(req.AttachmentIDs))
+		for i, id := range req.AttachmentIDs {
+			ids[i] = uuid.MustParse(id)
+		}
+		options = append(options, message.WithAttachments(ids...))
+	}
+
+	msg, replayed, err := m.send.Execute(c.Request.Context(), senderID, uuid.MustParse(req.ReceiverID), req.Content, options...)
+	if err != nil {
+		panic(err)
+	}
+	if replayed {
+		c.Header(definition.IdempotentReplayedHeader, "true")
+	}
+
+	var res dto.MessageResponse
+	res.FromDomain(msg)
+	c.JSON(http.StatusCreated, res)
+}
+
+// Edit method
+func (m *Message) Edit(c *gin.Context) {
+	var uri dto.MessageURI
+	restful.MustBindUri(c, &uri)
+	var req dto.EditMessageRequest
+	restful.MustBindJSON(c, &req)
+
+	msg, err := m.edit.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c), req.Content)
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.MessageResponse
+	res.FromDomain(msg)
+	c.JSON(http.StatusOK, res)
+}
+
+// Revisions method
+func (m *Message) Revisions(c *gin.Context) {
+	var uri dto.MessageURI
+	restful.MustBindUri(c, &uri)
+
+	msg, revisions, err := m.revisions.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c))
+	if err != nil {
+		panic(err)
+	}
+
+	res := dto.ListRevisionsResponse{
+		Message:   &dto.MessageResponse{},
+		Revisions: make([]*dto.MessageRevisionResponse, 0, len(revisions)),
+	}
+	res.Message.FromDomain(msg)
+	for _, revision := range revisions {
+		r := &dto.MessageRevisionResponse{}
+		r.FromDomain(revision)
+		res.Revisions = append(res.Revisions, r)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Delete method
+// ?for=everyone retracts a message the caller sent for both sides,
+// otherwise it disappears for the caller only
+func (m *Message) Delete(c *gin.Context) {
+	var uri dto.MessageURI
+	restful.MustBindUri(c, &uri)
+	var req dto.DeleteMessageRequest
+	restful.MustBindQuery(c, &req)
+
+	mode := message.DeleteForMe
+	if req.For != "" {
+		mode = message.DeleteMode(req.For)
+	}
+	if err := m.remove.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c), mode); err != nil {
+		panic(err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Replies method
+func (m *Message) Replies(c *gin.Context) {
+	var uri dto.MessageURI
+	restful.MustBindUri(c, &uri)
+	var req dto.ListRepliesRequest
+	restful.MustBindQuery(c, &req)
+
+	replies, err := m.replies.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c), req.Limit, req.Offset)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, listMessagesResponse(replies))
+}
+
+// List method
+// the conversation with user_id, newest first
+func (m *Message) List(c *gin.Context) {
+	var req dto.ListMessagesRequest
+	restful.MustBindQuery(c, &req)
+
+	messages, err := m.list.Execute(c.Request.Context(), MustUserID(c), uuid.MustParse(req.OtherUserID), req.Limit, req.Offset)
+	if err != nil {
+		panic(err)
+	}
+	c.JSON(http.StatusOK, listMessagesResponse(messages))
+}
+
+// React method
+// reacting again with the same emoji takes the reaction back
+func (m *Message) React(c *gin.Context) {
+	var uri dto.MessageURI
+	restful.MustBindUri(c, &uri)
+	var req dto.ReactionRequest
+	restful.MustBindJSON(c, &req)
+
+	added, reactions, err := m.react.Execute(c.Request.Context(), uuid.MustParse(uri.ID), MustUserID(c), req.Emoji)
+	if err != nil {
+		panic(err)
+	}
+
+	res := dto.ToggleReactionResponse{Added: added, Reactions: dto.ReactionsFromDomain(reactions)}
+	if res.Reactions == nil {
+		res.Reactions = []*dto.ReactionResponse{}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Search method
+// messages of the caller's conversations matching q, newest first
+func (m *Message) Search(c *gin.Context) {
+	var req dto.SearchMessagesRequest
+	restful.MustBindQuery(c, &req)
+
+	var options []message.SearchOption
+	if req.From != "" {
+		options = append(options, message.WithSender(uuid.MustParse(req.From)))
+	}
+	if !req.After.IsZero() || !req.Before.IsZero() {
+		options = append(options, message.WithPeriod(req.After, req.Before))
+	}
+	if req.HasAttachment {
+		options = append(options, message.WithAttachmentsOnly())
+	}
+	if req.Cursor != "" {
+		options = append(options, message.WithCursor(req.Cursor))
+	}
+
+	hits, next, err := m.search.Execute(c.Request.Context(), MustUserID(c), req.Query, req.Limit, options...)
+	if err != nil {
+		panic(err)
+	}
+
+	res := dto.SearchMessagesResponse{Results: make([]*dto.SearchResultResponse, len(hits)), NextCursor: next}
+	for i, hit := range hits {
+		res.Results[i] = &dto.SearchResultResponse{}
+		res.Results[i].FromDomain(hit)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AcknowledgeDelivery method
+// a device of the caller got the messages; their senders see them delivered
+func (m *Message) AcknowledgeDelivery(c *gin.Context) {
+	var req dto.AcknowledgeDeliveryRequest
+	restful.MustBindJSON(c, &req)
+
+	ids := make([]uuid.UUID, len(req.MessageIDs))
+	for i, id := range req.MessageIDs {
+		ids[i] = uuid.MustParse(id)
+	}
+	delivered, err := m.acknowledge.Execute(c.Request.Context(), MustUserID(c), ids)
+	if err != nil {
+		panic(err)
+	}
+
+	res := dto.AcknowledgeDeliveryResponse{Delivered: make([]string, len(delivered))}
+	for i, msg := range delivered {
+		res.Delivered[i] = msg.ID().String()
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// MarkAsRead method
+func (m *Message) MarkAsRead(c *gin.Context) {
+	var req dto.MarkAsReadRequest
+	restful.MustBindJSON(c, &req)
+
+	if err := m.markRead.Execute(c.Request.Context(), uuid.MustParse(req.MessageID), MustUserID(c)); err != nil {
+		panic(err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarkConversationRead method
+// reads what user_id sent the caller, up to a message or time when given,
+// with a single receipt instead of one per message
+func (m *Message) MarkConversationRead(c *gin.Context) {
+	var uri dto.ConversationURI
+	restful.MustBindUri(c, &uri)
+	var req dto.MarkConversationReadRequest
+	restful.MustBindJSON(c, &req)
+
+	var options []message.ReadOption
+	if req.UpToMessageID != "" {
+		options = append(options, message.WithUpToMessage(uuid.MustParse(req.UpToMessageID)))
+	}
+	if req.UpTo != nil {
+		options = append(options, message.WithUpTo(*req.UpTo))
+	}
+
+	count, err := m.readConversation.Execute(c.Request.Context(), MustUserID(c), uuid.MustParse(uri.UserID), options...)
+	if err != nil {
+		panic(err)
+	}
+	c.JSON(http.StatusOK, dto.MarkConversationReadResponse{Read: count})
+}
+
+func listMessagesResponse(messages []*do.Message) dto.ListMessagesResponse {
+	res := dto.ListMessagesResponse{Messages: make([]*dto.MessageResponse, 0, len(messages))}
+	for _, msg := range messages {
+		r := &dto.MessageResponse{}
+		r.FromDomain(msg)
+		res.Messages = append(res.Messages, r)
+	}
+	res.Total = len(res.Messages)
+	return res
+}
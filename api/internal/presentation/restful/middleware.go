@@ -2,6 +2,9 @@ package restful
 
 import (
 	"errors"
+	"fmt"
+	usecase "hilo-api/internal/application"
+	"hilo-api/internal/application/auth"
 	"hilo-api/internal/domain/claim"
 	"hilo-api/internal/domain/definition"
 	authDefinition "hilo-api/pkg/definition"
@@ -11,18 +14,21 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // NewAPIGuardValidator method
-func NewAPIGuardValidator(jwt definition.ES256JWT) *APIGuardValidator {
+func NewAPIGuardValidator(jwt definition.ES256JWT, checkActive *auth.CheckActiveUseCase) *APIGuardValidator {
 	return &APIGuardValidator{
-		jwt: jwt,
+		jwt:         jwt,
+		checkActive: checkActive,
 	}
 }
 
 // APIGuardValidator method
 type APIGuardValidator struct {
-	jwt definition.ES256JWT
+	jwt         definition.ES256JWT
+	checkActive *auth.CheckActiveUseCase
 }
 
 // Verify method
@@ -39,6 +45,9 @@ func (b *APIGuardValidator) Verify(c *gin.Context, token string) error {
 	c.Set(GinContextUserIDKey, userClaim.UserID)
 	for _, permission := range userClaim.Permissions {
 		if strings.HasPrefix(c.FullPath(), permission) || strings.HasPrefix(c.Request.RequestURI, permission) {
+			if err := b.verifyActive(c, userClaim.UserID); err != nil {
+				return err
+			}
 			c.Set(authDefinition.AuthorizationKey, userClaim)
 			return nil
 		}
@@ -49,3 +58,25 @@ func (b *APIGuardValidator) Verify(c *gin.Context, token string) error {
 		errors.New("no permission allowed to resource"),
 	)
 }
+
+// verifyActive rejects tokens of users deleted or suspended since they were
+// issued; tokens without a user are not tied to an account
+func (b *APIGuardValidator) verifyActive(c *gin.Context, userID string) error {
+	if userID == "" {
+		return nil
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errorCatcher.ConcatError(errorCatcher.ErrAuthenticate, restful.ErrValidatorVerify, err)
+	}
+
+	err = b.checkActive.Execute(c.Request.Context(), id)
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		return errorCatcher.ConcatError(errorCatcher.ErrAuthenticate, restful.ErrValidatorVerify, err)
+	}
+	if err != nil {
+		// a suspension surfaces as itself, so the client can tell it apart
+		return fmt.Errorf("%w: %w", err, restful.ErrValidatorVerify)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package restful
+
+import (
+	"hilo-api/internal/application/presence"
+	"hilo-api/internal/presentation/restful/dto"
+	"hilo-api/pkg/restful"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NewPresence method
+func NewPresence(get *presence.GetPresenceUseCase, hide *presence.HideLastSeenUseCase, typing *presence.TypingUseCase) *Presence {
+	return &Presence{
+		get:    get,
+		hide:   hide,
+		typing: typing,
+	}
+}
+
+// Presence serves who is online and typing
+type Presence struct {
+	get    *presence.GetPresenceUseCase
+	hide   *presence.HideLastSeenUseCase
+	typing *presence.TypingUseCase
+}
+
+// Get method
+func (p *Presence) Get(c *gin.Context) {
+	var uri dto.UserURI
+	restful.MustBindUri(c, &uri)
+
+	found, err := p.get.Execute(c.Request.Context(), MustUserID(c), uuid.MustParse(uri.ID))
+	if err != nil {
+		panic(err)
+	}
+
+	var res dto.PresenceResponse
+	res.FromDomain(found)
+	c.JSON(http.StatusOK, res)
+}
+
+// Settings method
+func (p *Presence) Settings(c *gin.Context) {
+	var req dto.PresenceSettingsRequest
+	restful.MustBindJSON(c, &req)
+
+	if err := p.hide.Execute(c.Request.Context(), MustUserID(c), *req.HideLastSeen); err != nil {
+		panic(err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Typing method
+// passed on to the other user's clients, not stored
+func (p *Presence) Typing(c *gin.Context) {
+	var req dto.TypingRequest
+	restful.MustBindJSON(c, &req)
+
+	if err := p.typing.Execute(c.Request.Context(), MustUserID(c), uuid.MustParse(req.UserID), *req.Typing); err != nil {
+		panic(err)
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package restful
+
+import (
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/ratelimit"
+	"hilo-api/pkg/restful"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimits are the middleware each route group is limited by
+type RateLimits struct {
+	// Default covers everything under /api/v1, per user once signed in
+	Default gin.HandlerFunc
+	// Messages covers sending messages, per user
+	Messages gin.HandlerFunc
+}
+
+// NewRateLimits method
+func NewRateLimits(cfg config.RateLimit, limiter *restful.RateLimiter) (RateLimits, error) {
+	defaultPolicy, err := ratelimit.ParsePolicy("default", cfg.RateLimitDefault)
+	if err != nil {
+		return RateLimits{}, err
+	}
+	messagesPolicy, err := ratelimit.ParsePolicy("messages", cfg.RateLimitMessages)
+	if err != nil {
+		return RateLimits{}, err
+	}
+	return RateLimits{
+		Default:  limiter.Limit(defaultPolicy, restful.ByUserOrClientIP),
+		Messages: limiter.Limit(messagesPolicy, restful.ByUserOrClientIP),
+	}, nil
+}
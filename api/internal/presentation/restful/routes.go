@@ -1,6 +1,7 @@
 package restful
 
 import (
+	"hilo-api/pkg/health"
 	"hilo-api/pkg/restful"
 
 	"github.com/gin-gonic/gin"
@@ -8,12 +9,53 @@ import (
 
 // HandlerSet struct
 type HandlerSet struct {
+	Health     *health.Health
+	Admin      *Admin
+	Message    *Message
+	Attachment *Attachment
+	Blobs      *Blobs
+	Events     *Events
+	Presence   *Presence
+	RateLimits RateLimits
 }
 
 // AddRoutes func
 func AddRoutes(route *gin.Engine, commonHandler restful.CommonHandler, handlers HandlerSet) {
 	route.GET("/ping", commonHandler.QuickReply)
-	route.GET("/metrics", commonHandler.PromHTTP)
+	route.GET("/healthz", handlers.Health.Liveness)
+	route.GET("/readyz", handlers.Health.Readiness)
+	route.GET(commonHandler.MetricsPath, commonHandler.PromGuard, commonHandler.PromHTTP)
+
+	api := route.Group("/api/v1", handlers.RateLimits.Default)
+	messages := api.Group("/messages")
+	messages.POST("", handlers.RateLimits.Messages, handlers.Message.Send)
+	messages.GET("", handlers.Message.List)
+	messages.GET("/search", handlers.Message.Search)
+	messages.POST("/delivered", handlers.Message.AcknowledgeDelivery)
+	messages.POST("/read", handlers.Message.MarkAsRead)
+	messages.PATCH("/:id", handlers.RateLimits.Messages, handlers.Message.Edit)
+	messages.DELETE("/:id", handlers.Message.Delete)
+	messages.GET("/:id/revisions", handlers.Message.Revisions)
+	messages.GET("/:id/replies", handlers.Message.Replies)
+	messages.POST("/:id/reactions", handlers.RateLimits.Messages, handlers.Message.React)
+	api.POST("/conversations/:user_id/read", handlers.Message.MarkConversationRead)
+	attachments := api.Group("/attachments")
+	attachments.POST("", handlers.RateLimits.Messages, handlers.Attachment.Upload)
+	attachments.GET("/:id", handlers.Attachment.Get)
+	uploads := api.Group("/uploads")
+	uploads.POST("", handlers.RateLimits.Messages, handlers.Attachment.CreateUpload)
+	uploads.GET("/:id", handlers.Attachment.GetUpload)
+	uploads.PATCH("/:id", handlers.Attachment.AppendUpload)
+	uploads.DELETE("/:id", handlers.Attachment.CancelUpload)
+	api.GET("/blobs/*key", handlers.Blobs.Serve)
+	api.GET("/events", handlers.Events.Stream)
+	api.GET("/users/:id/presence", handlers.Presence.Get)
+	api.PUT("/presence", handlers.Presence.Settings)
+	api.POST("/typing", handlers.Presence.Typing)
+
+	admin := api.Group("/admin", restful.RequireAuthorization)
+	admin.GET("/log-level", handlers.Admin.LogLevel)
+	admin.PUT("/log-level", handlers.Admin.SetLogLevel)
 
 	route.NoRoute(commonHandler.Error404)
 }
@@ -0,0 +1,50 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+	ErrBadURL     = errors.New("blob url is invalid or expired")
+)
+
+// Download describes how a signed URL serves a blob: the type it is sent
+// with and the name a browser saves it under
+type Download struct {
+	ContentType string
+	Filename    string
+}
+
+// Disposition is the Content-Disposition of the download; media a browser
+// can show safely is inline, everything else is saved as a file
+func (d Download) Disposition() string {
+	disposition := "attachment"
+	for _, prefix := range []string{"image/", "video/", "audio/"} {
+		if strings.HasPrefix(d.ContentType, prefix) && d.ContentType != "image/svg+xml" {
+			disposition = "inline"
+		}
+	}
+	if d.Filename == "" {
+		return disposition
+	}
+	return mime.FormatMediaType(disposition, map[string]string{"filename": d.Filename})
+}
+
+// validKey keeps keys to relative slash separated paths without dot
+// segments, so no store can be tricked into leaving its root
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
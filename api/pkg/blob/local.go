@@ -0,0 +1,183 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// LocalOption interface
+type LocalOption interface {
+	Apply(*Local)
+}
+
+// WithBaseURL method
+// sets where the route serving ServeFile is mounted, signed URLs start with it
+func WithBaseURL(base string) LocalOption {
+	return withBaseURL{base: base}
+}
+
+type withBaseURL struct {
+	base string
+}
+
+// Apply method
+func (w withBaseURL) Apply(l *Local) {
+	l.baseURL = w.base
+}
+
+// WithLocalClock method
+func WithLocalClock(now func() time.Time) LocalOption {
+	return withLocalClock{now: now}
+}
+
+type withLocalClock struct {
+	now func() time.Time
+}
+
+// Apply method
+func (w withLocalClock) Apply(l *Local) {
+	l.now = w.now
+}
+
+// Local keeps blobs as files under a directory. It has no server of its
+// own: signed URLs point at the API, which checks them with Verify and
+// streams the file.
+type Local struct {
+	dir     string
+	secret  []byte
+	baseURL string
+	now     func() time.Time
+}
+
+// NewLocal method
+// secret signs the download URLs, every node serving them needs the same one
+func NewLocal(dir string, secret []byte, options ...LocalOption) (*Local, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("local blob store needs a signing secret")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("local blob store: %w", err)
+	}
+
+	l := &Local{dir: dir, secret: secret, baseURL: "/blobs", now: time.Now}
+	for _, option := range options {
+		option.Apply(l)
+	}
+	return l, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first, a reader never sees half a blob
+func (l *Local) Put(_ context.Context, key string, body io.Reader, size int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if err == nil && written != size {
+		err = fmt.Errorf("blob %q: wrote %d of %d bytes", key, written, size)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open returns an *os.File, so callers can seek and serve ranges
+func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Delete method
+// deleting a missing blob is not an error
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignURL returns a URL below the base URL that Verify accepts until expiry
+func (l *Local) SignURL(_ context.Context, key string, download Download, expires time.Duration) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(l.now().Add(expires).Unix(), 10))
+	query.Set("type", download.ContentType)
+	query.Set("filename", download.Filename)
+	query.Set("signature", l.signature(key, query))
+	return l.baseURL + "/" + key + "?" + query.Encode(), nil
+}
+
+// Verify checks a URL SignURL issued and returns what to serve it as
+func (l *Local) Verify(key string, query url.Values) (Download, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || l.now().Unix() > expires {
+		return Download{}, ErrBadURL
+	}
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return Download{}, ErrBadURL
+	}
+	want, _ := hex.DecodeString(l.signature(key, query))
+	if !hmac.Equal(signature, want) {
+		return Download{}, ErrBadURL
+	}
+	return Download{ContentType: query.Get("type"), Filename: query.Get("filename")}, nil
+}
+
+func (l *Local) signature(key string, query url.Values) string {
+	mac := hmac.New(sha256.New, l.secret)
+	for _, part := range []string{key, query.Get("expires"), query.Get("type"), query.Get("filename")} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
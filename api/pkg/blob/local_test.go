@@ -0,0 +1,96 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalSuite struct {
+	suite.Suite
+	store *Local
+	now   time.Time
+}
+
+func (suite *LocalSuite) SetupTest() {
+	suite.now = time.Unix(1_700_000_000, 0)
+	store, err := NewLocal(suite.T().TempDir(), []byte("secret"),
+		WithBaseURL("https://chat.example/api/v1/blobs"),
+		WithLocalClock(func() time.Time { return suite.now }),
+	)
+	suite.Require().NoError(err)
+	suite.store = store
+}
+
+func (suite *LocalSuite) TestPutOpenDelete() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.store.Put(ctx, "a/b/c", strings.NewReader("hello"), 5, "text/plain"))
+
+	f, err := suite.store.Open(ctx, "a/b/c")
+	suite.Require().NoError(err)
+	body, _ := io.ReadAll(f)
+	suite.NoError(f.Close())
+	suite.Equal("hello", string(body))
+	_, seekable := f.(*os.File)
+	suite.True(seekable)
+
+	suite.NoError(suite.store.Delete(ctx, "a/b/c"))
+	suite.NoError(suite.store.Delete(ctx, "a/b/c"))
+	_, err = suite.store.Open(ctx, "a/b/c")
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *LocalSuite) TestShortBodyIsNotStored() {
+	ctx := context.Background()
+	suite.Error(suite.store.Put(ctx, "short", strings.NewReader("hel"), 5, ""))
+	_, err := suite.store.Open(ctx, "short")
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *LocalSuite) TestInvalidKeys() {
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", `a\b`} {
+		suite.ErrorIs(suite.store.Put(context.Background(), key, strings.NewReader(""), 0, ""), ErrInvalidKey, key)
+	}
+}
+
+func (suite *LocalSuite) TestSignedURL() {
+	download := Download{ContentType: "image/png", Filename: "cat.png"}
+	raw, err := suite.store.SignURL(context.Background(), "a/b", download, time.Minute)
+	suite.Require().NoError(err)
+	suite.True(strings.HasPrefix(raw, "https://chat.example/api/v1/blobs/a/b?"))
+
+	u, err := url.Parse(raw)
+	suite.Require().NoError(err)
+	got, err := suite.store.Verify("a/b", u.Query())
+	suite.NoError(err)
+	suite.Equal(download, got)
+
+	_, err = suite.store.Verify("a/c", u.Query())
+	suite.ErrorIs(err, ErrBadURL, "signature is bound to the key")
+
+	tampered := u.Query()
+	tampered.Set("type", "text/html")
+	_, err = suite.store.Verify("a/b", tampered)
+	suite.ErrorIs(err, ErrBadURL, "and to the type")
+
+	suite.now = suite.now.Add(2 * time.Minute)
+	_, err = suite.store.Verify("a/b", u.Query())
+	suite.ErrorIs(err, ErrBadURL, "and expires")
+}
+
+func (suite *LocalSuite) TestDisposition() {
+	suite.Equal(`inline; filename=cat.png`, Download{ContentType: "image/png", Filename: "cat.png"}.Disposition())
+	suite.Equal(`attachment; filename=page.svg`, Download{ContentType: "image/svg+xml", Filename: "page.svg"}.Disposition())
+	suite.Equal(`attachment; filename*=utf-8''%E5%A0%B1%E5%91%8A.pdf`, Download{ContentType: "application/pdf", Filename: "報告.pdf"}.Disposition())
+	suite.Equal("attachment", Download{}.Disposition())
+}
+
+func TestLocalSuite(t *testing.T) {
+	suite.Run(t, new(LocalSuite))
+}
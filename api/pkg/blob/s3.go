@@ -0,0 +1,217 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Option interface
+type S3Option interface {
+	Apply(*S3)
+}
+
+// WithHTTPClient method
+func WithHTTPClient(client *http.Client) S3Option {
+	return withHTTPClient{client: client}
+}
+
+type withHTTPClient struct {
+	client *http.Client
+}
+
+// Apply method
+func (w withHTTPClient) Apply(s *S3) {
+	s.client = w.client
+}
+
+// WithVirtualHostedStyle method
+// addresses the bucket as a subdomain of the endpoint, as AWS prefers;
+// MinIO and most self-hosted services want the default path style
+func WithVirtualHostedStyle() S3Option {
+	return withVirtualHostedStyle{}
+}
+
+type withVirtualHostedStyle struct{}
+
+// Apply method
+func (withVirtualHostedStyle) Apply(s *S3) {
+	s.pathStyle = false
+}
+
+// WithS3Clock method
+func WithS3Clock(now func() time.Time) S3Option {
+	return withS3Clock{now: now}
+}
+
+type withS3Clock struct {
+	now func() time.Time
+}
+
+// Apply method
+func (w withS3Clock) Apply(s *S3) {
+	s.now = w.now
+}
+
+// S3 keeps blobs in a bucket of an S3 compatible service, talking to its
+// REST API directly; downloads go straight to the service through
+// presigned URLs
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	signer    signer
+	pathStyle bool
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 method
+func NewS3(endpoint, region, bucket, accessKey, secretKey string, options ...S3Option) (*S3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("s3 blob store: endpoint %q is not an http(s) URL", endpoint)
+	}
+	if bucket == "" {
+		return nil, errors.New("s3 blob store: bucket is required")
+	}
+
+	s := &S3{
+		endpoint: u,
+		bucket:   bucket,
+		signer: signer{
+			accessKey: accessKey,
+			secretKey: secretKey,
+			region:    region,
+			service:   "s3",
+		},
+		pathStyle: true,
+		client:    http.DefaultClient,
+		now:       time.Now,
+	}
+	for _, option := range options {
+		option.Apply(s)
+	}
+	return s, nil
+}
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	u := *s.endpoint
+	base := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		u.Path = base + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = base + "/" + key
+	}
+	return &u, nil
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		// the body streams through once, it cannot be hashed up front
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+	s.signer.signHeader(req, payloadHash, s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %q: %w", method, key, err)
+	}
+	return res, nil
+}
+
+// Put method
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	res, err := s.do(ctx, http.MethodPut, key, body, size, header)
+	if err != nil {
+		return err
+	}
+	defer drain(res)
+	return s.check(res, key)
+}
+
+// Open method
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(res, key); err != nil {
+		drain(res)
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Delete method
+// S3 answers a delete of a missing object with success as well
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer drain(res)
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s.check(res, key)
+}
+
+// SignURL presigns a GET that makes the service answer with the download's
+// type and disposition
+func (s *S3) SignURL(_ context.Context, key string, download Download, expires time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if download.ContentType != "" {
+		query.Set("response-content-type", download.ContentType)
+	}
+	query.Set("response-content-disposition", download.Disposition())
+	u.RawQuery = query.Encode()
+	return s.signer.presign(http.MethodGet, u, expires, s.now()).String(), nil
+}
+
+func (s *S3) check(res *http.Response, key string) error {
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %q", ErrNotFound, key)
+	case res.StatusCode >= 300:
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("s3 %s %q: %s: %s", res.Request.Method, key, res.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// drain lets the connection be reused
+func drain(res *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+	res.Body.Close()
+}
@@ -0,0 +1,180 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeS3 is a stand-in for MinIO: it keeps objects in memory and rejects
+// requests whose signature does not check out
+type fakeS3 struct {
+	signer  signer
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	body        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = fakeObject{body: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		contentType := object.contentType
+		if override := r.URL.Query().Get("response-content-type"); override != "" {
+			contentType = override
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", r.URL.Query().Get("response-content-disposition"))
+		_, _ = w.Write(object.body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized signs the request again the way the client should have
+func (f *fakeS3) authorized(r *http.Request) bool {
+	if signature := r.URL.Query().Get("X-Amz-Signature"); signature != "" {
+		date, err := time.Parse(sigDateFormat, r.URL.Query().Get("X-Amz-Date"))
+		if err != nil {
+			return false
+		}
+		seconds, err := strconv.Atoi(r.URL.Query().Get("X-Amz-Expires"))
+		expires := time.Duration(seconds) * time.Second
+		if err != nil || time.Now().After(date.Add(expires)) {
+			return false
+		}
+		unsigned := *r.URL
+		unsigned.Host = r.Host
+		query := unsigned.Query()
+		for key := range query {
+			if strings.HasPrefix(key, "X-Amz-") {
+				query.Del(key)
+			}
+		}
+		unsigned.RawQuery = query.Encode()
+		resigned := f.signer.presign(r.Method, &unsigned, expires, date)
+		return resigned.Query().Get("X-Amz-Signature") == signature
+	}
+
+	date, err := time.Parse(sigDateFormat, r.Header.Get(headerDate))
+	if err != nil {
+		return false
+	}
+	resigned, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for _, name := range []string{"Content-Type"} {
+		if value := r.Header.Get(name); value != "" {
+			resigned.Header.Set(name, value)
+		}
+	}
+	f.signer.signHeader(resigned, r.Header.Get(headerContentHash), date)
+	return resigned.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+type S3Suite struct {
+	suite.Suite
+	fake   *fakeS3
+	server *httptest.Server
+	store  *S3
+}
+
+func (suite *S3Suite) SetupTest() {
+	suite.fake = &fakeS3{
+		signer:  signer{accessKey: "minio", secretKey: "minio-secret", region: "us-east-1", service: "s3"},
+		objects: map[string]fakeObject{},
+	}
+	suite.server = httptest.NewServer(suite.fake)
+	store, err := NewS3(suite.server.URL, "us-east-1", "chat", "minio", "minio-secret")
+	suite.Require().NoError(err)
+	suite.store = store
+}
+
+func (suite *S3Suite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *S3Suite) TestPutOpenDelete() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.store.Put(ctx, "attachments/a b", strings.NewReader("hello"), 5, "text/plain"))
+	suite.Contains(suite.fake.objects, "/chat/attachments/a b")
+
+	body, err := suite.store.Open(ctx, "attachments/a b")
+	suite.Require().NoError(err)
+	content, _ := io.ReadAll(body)
+	suite.NoError(body.Close())
+	suite.Equal("hello", string(content))
+
+	suite.NoError(suite.store.Delete(ctx, "attachments/a b"))
+	_, err = suite.store.Open(ctx, "attachments/a b")
+	suite.ErrorIs(err, ErrNotFound)
+}
+
+func (suite *S3Suite) TestWrongCredentials() {
+	store, err := NewS3(suite.server.URL, "us-east-1", "chat", "minio", "wrong")
+	suite.Require().NoError(err)
+	err = store.Put(context.Background(), "x", strings.NewReader("x"), 1, "")
+	suite.ErrorContains(err, "403")
+}
+
+func (suite *S3Suite) TestSignedURL() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.store.Put(ctx, "attachments/cat", strings.NewReader("meow"), 4, "application/octet-stream"))
+
+	raw, err := suite.store.SignURL(ctx, "attachments/cat", Download{ContentType: "image/png", Filename: "cat.png"}, time.Minute)
+	suite.Require().NoError(err)
+
+	res, err := http.Get(raw)
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.Equal("image/png", res.Header.Get("Content-Type"))
+	suite.Equal("inline; filename=cat.png", res.Header.Get("Content-Disposition"))
+
+	u, _ := url.Parse(raw)
+	query := u.Query()
+	query.Set("response-content-type", "text/html")
+	u.RawQuery = query.Encode()
+	res, err = http.Get(u.String())
+	suite.Require().NoError(err)
+	res.Body.Close()
+	suite.Equal(http.StatusForbidden, res.StatusCode)
+}
+
+func (suite *S3Suite) TestVirtualHostedStyle() {
+	store, err := NewS3("https://s3.example.com", "eu-west-1", "chat", "k", "s", WithVirtualHostedStyle())
+	suite.Require().NoError(err)
+	raw, err := store.SignURL(context.Background(), "a/b", Download{}, time.Minute)
+	suite.Require().NoError(err)
+	suite.True(strings.HasPrefix(raw, "https://chat.s3.example.com/a/b?"), raw)
+}
+
+func TestS3Suite(t *testing.T) {
+	suite.Run(t, new(S3Suite))
+}
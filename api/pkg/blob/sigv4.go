@@ -0,0 +1,186 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4, as S3 and the services copying its API expect it
+const (
+	sigAlgorithm      = "AWS4-HMAC-SHA256"
+	sigDateFormat     = "20060102T150405Z"
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	headerContentHash = "X-Amz-Content-Sha256"
+	headerDate        = "X-Amz-Date"
+)
+
+type signer struct {
+	accessKey string
+	secretKey string
+	region    string
+	service   string
+}
+
+func (s signer) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+// signHeader adds the Authorization header to req, signing every header it
+// already carries; payloadHash is the hex SHA-256 of the body or
+// UNSIGNED-PAYLOAD
+func (s signer) signHeader(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set(headerContentHash, payloadHash)
+	req.Header.Set(headerDate, now.Format(sigDateFormat))
+
+	headers, signed := canonicalHeaders(req)
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signed,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", sigAlgorithm+
+		" Credential="+s.accessKey+"/"+s.scope(now)+
+		",SignedHeaders="+signed+
+		",Signature="+s.signature(canonical, now))
+}
+
+// presign returns u carrying its signature in the query, valid for expires
+// and only signing the host header
+func (s signer) presign(method string, u *url.URL, expires time.Duration, now time.Time) *url.URL {
+	now = now.UTC()
+	signed := *u
+	query := signed.Query()
+	query.Set("X-Amz-Algorithm", sigAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(sigDateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		method,
+		canonicalPath(&signed),
+		canonicalQuery(query),
+		"host:" + signed.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(canonical, now))
+	signed.RawQuery = canonicalQuery(query)
+	return &signed
+}
+
+func (s signer) signature(canonical string, now time.Time) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := sigAlgorithm + "\n" + now.Format(sigDateFormat) + "\n" + s.scope(now) + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalHeaders lists host and the request's headers lowercased and
+// sorted, with the matching signed header list
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		values["host"] = req.Host
+	}
+	for name, vals := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" || name == "user-agent" {
+			continue
+		}
+		trimmed := make([]string, len(vals))
+		for i, v := range vals {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalPath encodes every path segment once, the way S3 expects
+func canonicalPath(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts by encoded key, then value, and encodes both
+func canonicalQuery(query url.Values) string {
+	encoded := make(map[string][]string, len(query))
+	keys := make([]string, 0, len(query))
+	for key, vals := range query {
+		values := make([]string, len(vals))
+		for i, v := range vals {
+			values[i] = uriEncode(v)
+		}
+		sort.Strings(values)
+		encoded[uriEncode(key)] = values
+		keys = append(keys, uriEncode(key))
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, v := range encoded[key] {
+			pairs = append(pairs, key+"="+v)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters
+func uriEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
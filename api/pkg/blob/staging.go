@@ -0,0 +1,127 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	ErrOffsetMismatch = errors.New("chunk does not continue the upload")
+	ErrChunkTooLarge  = errors.New("chunk runs past the end of the upload")
+)
+
+// Staging keeps the bytes of unfinished uploads as files on local disk,
+// so chunks of one upload have to reach the node that holds the rest
+type Staging struct {
+	dir   string
+	locks [64]sync.Mutex
+}
+
+// NewStaging method
+func NewStaging(dir string) (*Staging, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("upload staging: %w", err)
+	}
+	return &Staging{dir: dir}, nil
+}
+
+func (s *Staging) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// lock serialises chunks of the same upload
+func (s *Staging) lock(key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &s.locks[h.Sum32()%uint32(len(s.locks))]
+}
+
+// Append writes body at offset and returns the size of the upload after
+// it, also when reading body failed partway. Bytes past offset that were
+// never acknowledged are dropped first; a chunk longer than limit is
+// rejected whole.
+func (s *Staging) Append(key string, offset int64, body io.Reader, limit int64) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	mu := s.lock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() < offset {
+		return info.Size(), fmt.Errorf("%w: have %d bytes, chunk starts at %d", ErrOffsetMismatch, info.Size(), offset)
+	}
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	written, err := io.CopyN(f, body, limit+1)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err == nil && written > limit {
+		if err := f.Truncate(offset); err != nil {
+			return 0, err
+		}
+		return offset, ErrChunkTooLarge
+	}
+
+	// a chunk broken off midway keeps what arrived, the client resumes
+	// from there
+	if syncErr := f.Sync(); err == nil {
+		err = syncErr
+	}
+	return offset + written, err
+}
+
+// Open method
+func (s *Staging) Open(key string) (*os.File, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Remove method
+// removing an upload that has no bytes yet is not an error
+func (s *Staging) Remove(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
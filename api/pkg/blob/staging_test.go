@@ -0,0 +1,49 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaging(t *testing.T) {
+	staging, err := NewStaging(t.TempDir())
+	require.NoError(t, err)
+
+	size, err := staging.Append("upload", 0, strings.NewReader("hello "), 11)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+
+	_, err = staging.Append("upload", 8, strings.NewReader("rld"), 5)
+	assert.ErrorIs(t, err, ErrOffsetMismatch)
+
+	_, err = staging.Append("upload", 6, strings.NewReader("world!"), 5)
+	assert.ErrorIs(t, err, ErrChunkTooLarge)
+
+	// a chunk that broke off keeps what arrived
+	broken := io.MultiReader(strings.NewReader("wor"), iotest.ErrReader(errors.New("connection reset")))
+	size, err = staging.Append("upload", 6, broken, 5)
+	assert.Error(t, err)
+	assert.Equal(t, int64(9), size)
+
+	// resending from an earlier offset replaces the unacknowledged bytes
+	size, err = staging.Append("upload", 6, strings.NewReader("world"), 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), size)
+
+	f, err := staging.Open("upload")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(f)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "hello world", string(body))
+
+	require.NoError(t, staging.Remove("upload"))
+	require.NoError(t, staging.Remove("upload"))
+	_, err = staging.Open("upload")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
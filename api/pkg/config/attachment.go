@@ -0,0 +1,41 @@
+package config
+
+import "time"
+
+// Attachment type
+// the local backend keeps files under ATTACHMENT_LOCAL_DIR and serves them
+// itself through signed URLs below ATTACHMENT_PUBLIC_URL; s3 stores them in
+// a bucket of any S3 compatible service, which serves them directly.
+// Resumable uploads are staged under ATTACHMENT_UPLOAD_DIR on the node that
+// received them until complete. Images get thumbnails from a background
+// worker that looks for new ones every ATTACHMENT_PROCESS_INTERVAL.
+type Attachment struct {
+	AttachmentBackend         string        `split_words:"true" default:"local"`
+	AttachmentLocalDir        string        `split_words:"true" default:"./data/attachments"`
+	AttachmentUploadDir       string        `split_words:"true" default:"./data/uploads"`
+	AttachmentPublicURL       string        `split_words:"true" default:"/api/v1/blobs"`
+	AttachmentURLSecret       string        `split_words:"true" default:"" secret:"true"`
+	AttachmentURLExpiry       time.Duration `split_words:"true" default:"15m"`
+	AttachmentS3Endpoint      string        `split_words:"true" default:""`
+	AttachmentS3Region        string        `split_words:"true" default:"us-east-1"`
+	AttachmentS3Bucket        string        `split_words:"true" default:""`
+	AttachmentS3AccessKey     string        `split_words:"true" default:""`
+	AttachmentS3SecretKey     string        `split_words:"true" default:"" secret:"true"`
+	AttachmentS3PathStyle     bool          `split_words:"true" default:"true"`
+	AttachmentMaxSizeMB       int64         `split_words:"true" default:"25"`
+	AttachmentQuotaMB         int64         `split_words:"true" default:"1024"`
+	AttachmentAllowedTypes    []string      `split_words:"true" default:"image/*,video/*,audio/*,application/pdf,text/plain"`
+	AttachmentUploadTTL       time.Duration `split_words:"true" default:"24h"`
+	AttachmentProcessInterval time.Duration `split_words:"true" default:"5s"`
+	AttachmentProcessAttempts int           `split_words:"true" default:"5"`
+	AttachmentMaxMegapixels   int           `split_words:"true" default:"50"`
+}
+
+// MaxSize is AttachmentMaxSizeMB in bytes
+func (c Attachment) MaxSize() int64 { return c.AttachmentMaxSizeMB << 20 }
+
+// Quota is AttachmentQuotaMB in bytes
+func (c Attachment) Quota() int64 { return c.AttachmentQuotaMB << 20 }
+
+// MaxPixels is AttachmentMaxMegapixels in pixels
+func (c Attachment) MaxPixels() int { return c.AttachmentMaxMegapixels * 1_000_000 }
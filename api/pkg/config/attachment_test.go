@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AttachmentSuite struct {
+	suite.Suite
+	AttachmentBackend      string
+	AttachmentMaxSizeMB    int64
+	AttachmentAllowedTypes []string
+	AttachmentURLExpiry    time.Duration
+}
+
+func (suite *AttachmentSuite) SetupSuite() {
+	os.Clearenv()
+	suite.AttachmentBackend = "s3"
+	suite.AttachmentMaxSizeMB = 100
+	suite.AttachmentAllowedTypes = []string{"image/png", "image/jpeg"}
+	suite.AttachmentURLExpiry = time.Hour
+	suite.NoError(os.Setenv("ATTACHMENT_BACKEND", suite.AttachmentBackend))
+	suite.NoError(os.Setenv("ATTACHMENT_MAX_SIZE_MB", "100"))
+	suite.NoError(os.Setenv("ATTACHMENT_ALLOWED_TYPES", "image/png,image/jpeg"))
+	suite.NoError(os.Setenv("ATTACHMENT_URL_EXPIRY", suite.AttachmentURLExpiry.String()))
+}
+
+func (suite *AttachmentSuite) TestDefaultOption() {
+	attachment := &Attachment{}
+	suite.NoError(LoadFromEnv(attachment))
+	suite.Equal(suite.AttachmentBackend, attachment.AttachmentBackend)
+	suite.Equal(suite.AttachmentMaxSizeMB, attachment.AttachmentMaxSizeMB)
+	suite.Equal(suite.AttachmentAllowedTypes, attachment.AttachmentAllowedTypes)
+	suite.Equal(suite.AttachmentURLExpiry, attachment.AttachmentURLExpiry)
+	suite.Equal(int64(100<<20), attachment.MaxSize())
+	suite.Equal(int64(1024<<20), attachment.Quota())
+}
+
+func TestAttachmentSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentSuite))
+}
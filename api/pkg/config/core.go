@@ -3,7 +3,7 @@ package config
 // Core type
 type Core struct {
 	LoggerMode    string `split_words:"true" default:"customized"`
-	LogLevel      string `split_words:"true" default:"INFO"`
+	LogLevel      string `split_words:"true" default:"INFO" reload:"true"`
 	IsReleaseMode bool   `split_words:"true" default:"false"`
 	SystemName    string `split_words:"true" default:"system"`
 }
@@ -1,7 +1,12 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import "os"
 
+// LoadFromEnv fills cfg from its default tags and the process environment
 func LoadFromEnv(cfg interface{}) error {
-	return envconfig.Process("", cfg)
+	source, err := EnvSource(os.Environ())
+	if err != nil {
+		return err
+	}
+	return Load(cfg, source)
 }
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileKey lists the config files to layer under the environment,
+// comma separated, later files winning
+const ConfigFileKey = "CONFIG_FILE"
+
+var ErrConfigFormat = errors.New("unsupported config file format, use .yaml, .yml or .toml")
+
+// FileSource reads a YAML or TOML file into a layer. Keys are the environment
+// variable names in any case, either at the top level or grouped one level
+// deep by section:
+//
+//	server:
+//	  port: 8080
+//	  allow_origins: [https://example.com]
+//	postgres:
+//	  postgres_password_file: /run/secrets/postgres
+func FileSource(path string) (Source, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigLoad, err)
+	}
+
+	document := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &document)
+	case ".toml":
+		err = toml.Unmarshal(raw, &document)
+	default:
+		return nil, fmt.Errorf("%w: %s: %w", ErrConfigLoad, path, ErrConfigFormat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrConfigLoad, path, err)
+	}
+
+	source := Source{}
+	for key, value := range document {
+		if section, ok := value.(map[string]interface{}); ok {
+			for key, value := range section {
+				if err := source.put(key, value); err != nil {
+					return nil, fmt.Errorf("%w: %s: %w", ErrConfigLoad, path, err)
+				}
+			}
+			continue
+		}
+		if err := source.put(key, value); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrConfigLoad, path, err)
+		}
+	}
+	return source, nil
+}
+
+// put stores a decoded value the way it would be written in the environment
+func (s Source) put(key string, value interface{}) error {
+	key = strings.ToUpper(key)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return fmt.Errorf("%s: sections cannot be nested", key)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		s[key] = strings.Join(items, ",")
+	case nil:
+		s[key] = ""
+	default:
+		s[key] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// FileSources reads the files named by CONFIG_FILE in env, in order
+func FileSources(env Source) ([]Source, error) {
+	var sources []Source
+	for _, path := range configFiles(env) {
+		source, err := FileSource(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// configFiles lists the paths named by CONFIG_FILE in env
+func configFiles(env Source) []string {
+	var paths []string
+	for _, path := range strings.Split(env[ConfigFileKey], ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// unknownKeys returns the keys of source no field reads, sorted
+func unknownKeys(source Source, known map[string]bool) []string {
+	var unknown []string
+	for key := range source {
+		if !known[strings.TrimSuffix(key, secretFileSuffix)] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -3,5 +3,5 @@ package config
 // JWT type
 type JWT struct {
 	PrivateKeyPath string `split_words:"true" default:"./es256_private.pem"`
-	PrivateKey     string `split_words:"true" default:""`
+	PrivateKey     string `split_words:"true" default:"" secret:"true"`
 }
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretFileSuffix marks a key whose value is read from the file it names,
+// the way Docker and Kubernetes mount secrets
+const secretFileSuffix = "_FILE"
+
+var (
+	ErrConfigLoad     = errors.New("config load failed")
+	ErrConflictingKey = errors.New("set both directly and through a _FILE")
+
+	// the same word splitting envconfig applies to split_words fields
+	gatherRegexp  = regexp.MustCompile("([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)")
+	acronymRegexp = regexp.MustCompile("([A-Z]+)([A-Z][^A-Z]+)")
+)
+
+// Source is one layer of raw values keyed by environment variable name
+type Source map[string]string
+
+// EnvSource builds a layer from KEY=VALUE pairs such as os.Environ
+func EnvSource(environ []string) (Source, error) {
+	source := Source{}
+	for _, pair := range environ {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok {
+			source[key] = value
+		}
+	}
+	return source, nil
+}
+
+// lookup returns the value of key, reading the file named by KEY_FILE when
+// the key itself is absent
+func (s Source) lookup(key string) (string, bool, error) {
+	value, set := s[key]
+	path, indirect := s[key+secretFileSuffix]
+	if !indirect || path == "" {
+		return value, set, nil
+	}
+	if set {
+		return "", false, fmt.Errorf("%s: %w", key, ErrConflictingKey)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", key, secretFileSuffix, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// field is an addressable config field and the key it is read from
+type field struct {
+	key    string
+	value  reflect.Value
+	def    string
+	hasDef bool
+	secret bool
+	reload bool
+}
+
+func fieldsOf(cfg interface{}) ([]field, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a pointer to a struct", ErrConfigLoad, cfg)
+	}
+	v = v.Elem()
+
+	fields := make([]field, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		def, hasDef := structField.Tag.Lookup("default")
+		fields = append(fields, field{
+			key:    keyOf(structField),
+			value:  v.Field(i),
+			def:    def,
+			hasDef: hasDef && def != "",
+			secret: structField.Tag.Get("secret") == "true",
+			reload: structField.Tag.Get("reload") == "true",
+		})
+	}
+	return fields, nil
+}
+
+// keyOf names the environment variable of a field exactly as envconfig does
+func keyOf(structField reflect.StructField) string {
+	if key := structField.Tag.Get("envconfig"); key != "" {
+		return strings.ToUpper(key)
+	}
+	key := structField.Name
+	if split, _ := strconv.ParseBool(structField.Tag.Get("split_words")); split {
+		var words []string
+		for _, match := range gatherRegexp.FindAllStringSubmatch(structField.Name, -1) {
+			if m := acronymRegexp.FindStringSubmatch(match[0]); len(m) == 3 {
+				words = append(words, m[1], m[2])
+			} else {
+				words = append(words, match[0])
+			}
+		}
+		key = strings.Join(words, "_")
+	}
+	return strings.ToUpper(key)
+}
+
+// Load fills cfg from its default tags overlaid by sources, later sources winning
+func Load(cfg interface{}, sources ...Source) error {
+	fields, err := fieldsOf(cfg)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, f := range fields {
+		value, ok := f.def, f.hasDef
+		for _, source := range sources {
+			v, set, err := source.lookup(f.key)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if set {
+				value, ok = v, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", f.key, value, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrConfigLoad, errors.Join(errs...))
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice of %s", v.Type().Elem())
+		}
+		values := []string{}
+		if strings.TrimSpace(raw) != "" {
+			values = strings.Split(raw, ",")
+		}
+		v.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyOfMatchesEnvconfig(t *testing.T) {
+	for name, want := range map[string]string{
+		"JWTGuard":             "JWT_GUARD",
+		"MaxMultipartMemoryMB": "MAX_MULTIPART_MEMORY_MB",
+		"PostgresSSLMode":      "POSTGRES_SSL_MODE",
+		"PostgresURL":          "POSTGRES_URL",
+		"PrivateKeyPath":       "PRIVATE_KEY_PATH",
+	} {
+		assert.Equal(t, want, keyOf(reflect.StructField{Name: name, Tag: `split_words:"true"`}), name)
+	}
+	assert.Equal(t, "PORT", keyOf(reflect.StructField{Name: "Port"}))
+	assert.Equal(t, "HTTP_PORT", keyOf(reflect.StructField{Name: "Port", Tag: `envconfig:"http_port"`}))
+}
+
+func TestLoadLayers(t *testing.T) {
+	server := Server{}
+	require.NoError(t, Load(&server,
+		Source{"PORT": "8080", "SERVER_TIMEOUT": "10s"},
+		Source{"PORT": "9090", "ALLOW_ORIGINS": ""},
+	))
+	assert.Equal(t, "9090", server.Port)
+	assert.Equal(t, 10*time.Second, server.ServerTimeout)
+	assert.Equal(t, []string{}, server.AllowOrigins)
+	assert.Equal(t, 30*time.Second, server.ShutdownTimeout, "defaults fill the rest")
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cret\n"), 0o600))
+
+	postgres := Postgres{}
+	require.NoError(t, Load(&postgres, Source{"POSTGRES_PASSWORD_FILE": secret}))
+	assert.Equal(t, "s3cret", postgres.PostgresPassword)
+
+	// a higher layer setting the key directly still wins
+	require.NoError(t, Load(&postgres, Source{"POSTGRES_PASSWORD_FILE": secret}, Source{"POSTGRES_PASSWORD": "direct"}))
+	assert.Equal(t, "direct", postgres.PostgresPassword)
+
+	err := Load(&postgres, Source{"POSTGRES_PASSWORD_FILE": secret, "POSTGRES_PASSWORD": "direct"})
+	assert.ErrorIs(t, err, ErrConflictingKey)
+
+	err = Load(&postgres, Source{"POSTGRES_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// only known keys are indirected
+	require.NoError(t, Load(&postgres, Source{"UNRELATED_FILE": "/does/not/exist"}))
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	err := Load(&Server{}, Source{"PORT": "8080", "SERVER_TIMEOUT": "soon", "JWT_GUARD": "maybe"})
+	require.ErrorIs(t, err, ErrConfigLoad)
+	assert.ErrorContains(t, err, `SERVER_TIMEOUT="soon"`)
+	assert.ErrorContains(t, err, `JWT_GUARD="maybe"`)
+
+	assert.ErrorIs(t, Load(Server{}), ErrConfigLoad)
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("port: 8080\nmetrics:\n  metrics_allowed_networks: [10.0.0.0/8]\n"), 0o600))
+	source, err := FileSource(yamlFile)
+	require.NoError(t, err)
+	assert.Equal(t, Source{"PORT": "8080", "METRICS_ALLOWED_NETWORKS": "10.0.0.0/8"}, source)
+
+	tomlFile := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(tomlFile, []byte("[tracing]\ntracing_sample_ratio = 0.5\n"), 0o600))
+	source, err = FileSource(tomlFile)
+	require.NoError(t, err)
+	assert.Equal(t, Source{"TRACING_SAMPLE_RATIO": "0.5"}, source)
+
+	jsonFile := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte("{}"), 0o600))
+	_, err = FileSource(jsonFile)
+	assert.ErrorIs(t, err, ErrConfigFormat)
+
+	nested := filepath.Join(dir, "nested.yaml")
+	require.NoError(t, os.WriteFile(nested, []byte("server:\n  cors:\n    allow_origins: []\n"), 0o600))
+	_, err = FileSource(nested)
+	assert.ErrorIs(t, err, ErrConfigLoad)
+}
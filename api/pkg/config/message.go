@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// Message type
+type Message struct {
+	// MessageIdempotencyTTL is how long a retried send replays the message
+	// its Idempotency-Key or client message id first created
+	MessageIdempotencyTTL time.Duration `split_words:"true" default:"24h"`
+	// MessageEditWindow is how long after sending the sender may edit a
+	// message; 0 turns editing off
+	MessageEditWindow time.Duration `split_words:"true" default:"15m"`
+	// MessageRetractWindow is how long after sending the sender may delete
+	// a message for everyone; 0 leaves only deleting for oneself
+	MessageRetractWindow time.Duration `split_words:"true" default:"1h"`
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MessageSuite struct {
+	suite.Suite
+	MessageIdempotencyTTL time.Duration
+	MessageEditWindow     time.Duration
+	MessageRetractWindow  time.Duration
+}
+
+func (suite *MessageSuite) SetupSuite() {
+	os.Clearenv()
+	suite.MessageIdempotencyTTL = 2 * time.Hour
+	suite.MessageEditWindow = 0
+	suite.MessageRetractWindow = 48 * time.Hour
+	suite.NoError(os.Setenv("MESSAGE_IDEMPOTENCY_TTL", suite.MessageIdempotencyTTL.String()))
+	suite.NoError(os.Setenv("MESSAGE_EDIT_WINDOW", suite.MessageEditWindow.String()))
+	suite.NoError(os.Setenv("MESSAGE_RETRACT_WINDOW", suite.MessageRetractWindow.String()))
+}
+
+func (suite *MessageSuite) TestDefaultOption() {
+	message := &Message{}
+	suite.NoError(LoadFromEnv(message))
+	suite.Equal(suite.MessageIdempotencyTTL, message.MessageIdempotencyTTL)
+	suite.Equal(suite.MessageEditWindow, message.MessageEditWindow)
+	suite.Equal(suite.MessageRetractWindow, message.MessageRetractWindow)
+}
+
+func TestMessageSuite(t *testing.T) {
+	suite.Run(t, new(MessageSuite))
+}
@@ -0,0 +1,11 @@
+package config
+
+// Metrics type
+// guards /metrics independently of the user JWT guard; when both are set a
+// scraper must come from an allowed network and present the credentials
+type Metrics struct {
+	MetricsPath              string   `split_words:"true" default:"/metrics"`
+	MetricsBasicAuthUsername string   `split_words:"true" default:""`
+	MetricsBasicAuthPassword string   `split_words:"true" default:"" secret:"true"`
+	MetricsAllowedNetworks   []string `split_words:"true" default:"127.0.0.1/32,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16"`
+}
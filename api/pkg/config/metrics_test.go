@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+	MetricsPath              string
+	MetricsBasicAuthUsername string
+	MetricsBasicAuthPassword string
+	MetricsAllowedNetworks   []string
+}
+
+func (suite *MetricsSuite) SetupSuite() {
+	os.Clearenv()
+	suite.MetricsPath = "/internal/metrics"
+	suite.MetricsBasicAuthUsername = "testMetricsUsername"
+	suite.MetricsBasicAuthPassword = "testMetricsPassword"
+	suite.MetricsAllowedNetworks = []string{"10.0.0.0/8", "192.168.1.10"}
+	suite.NoError(os.Setenv("METRICS_PATH", suite.MetricsPath))
+	suite.NoError(os.Setenv("METRICS_BASIC_AUTH_USERNAME", suite.MetricsBasicAuthUsername))
+	suite.NoError(os.Setenv("METRICS_BASIC_AUTH_PASSWORD", suite.MetricsBasicAuthPassword))
+	suite.NoError(os.Setenv("METRICS_ALLOWED_NETWORKS", strings.Join(suite.MetricsAllowedNetworks, ",")))
+}
+
+func (suite *MetricsSuite) TestDefaultOption() {
+	metrics := &Metrics{}
+	suite.NoError(LoadFromEnv(metrics))
+	suite.Equal(suite.MetricsPath, metrics.MetricsPath)
+	suite.Equal(suite.MetricsBasicAuthUsername, metrics.MetricsBasicAuthUsername)
+	suite.Equal(suite.MetricsBasicAuthPassword, metrics.MetricsBasicAuthPassword)
+	suite.Equal(suite.MetricsAllowedNetworks, metrics.MetricsAllowedNetworks)
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
@@ -11,17 +11,19 @@ import (
 
 type PostgresSuite struct {
 	suite.Suite
-	PostgresUsername        string
-	PostgresPassword        string
-	PostgresHost            string
-	PostgresPort            string
-	PostgresDatabase        string
-	PostgresSSLMode         string
-	PostgresURL             string
-	PostgresTxTimeout       time.Duration
-	PostgresMaxOpenConns    int
-	PostgresMaxIdleConns    int
-	PostgresConnMaxLifetime time.Duration
+	PostgresUsername           string
+	PostgresPassword           string
+	PostgresHost               string
+	PostgresPort               string
+	PostgresDatabase           string
+	PostgresSSLMode            string
+	PostgresURL                string
+	PostgresTxTimeout          time.Duration
+	PostgresMaxOpenConns       int
+	PostgresMaxIdleConns       int
+	PostgresConnMaxLifetime    time.Duration
+	PostgresMigrateOnStartup   bool
+	PostgresMigrateLockTimeout time.Duration
 }
 
 func (suite *PostgresSuite) SetupSuite() {
@@ -37,6 +39,8 @@ func (suite *PostgresSuite) SetupSuite() {
 	suite.PostgresMaxOpenConns = 25
 	suite.PostgresMaxIdleConns = 5
 	suite.PostgresConnMaxLifetime = 5 * time.Minute
+	suite.PostgresMigrateOnStartup = true
+	suite.PostgresMigrateLockTimeout = 30 * time.Second
 	suite.NoError(os.Setenv("POSTGRES_USERNAME", suite.PostgresUsername))
 	suite.NoError(os.Setenv("POSTGRES_PASSWORD", suite.PostgresPassword))
 	suite.NoError(os.Setenv("POSTGRES_HOST", suite.PostgresHost))
@@ -48,6 +52,8 @@ func (suite *PostgresSuite) SetupSuite() {
 	suite.NoError(os.Setenv("POSTGRES_MAX_OPEN_CONNS", fmt.Sprint(suite.PostgresMaxOpenConns)))
 	suite.NoError(os.Setenv("POSTGRES_MAX_IDLE_CONNS", fmt.Sprint(suite.PostgresMaxIdleConns)))
 	suite.NoError(os.Setenv("POSTGRES_CONN_MAX_LIFETIME", fmt.Sprint(suite.PostgresConnMaxLifetime)))
+	suite.NoError(os.Setenv("POSTGRES_MIGRATE_ON_STARTUP", fmt.Sprint(suite.PostgresMigrateOnStartup)))
+	suite.NoError(os.Setenv("POSTGRES_MIGRATE_LOCK_TIMEOUT", fmt.Sprint(suite.PostgresMigrateLockTimeout)))
 }
 
 func (suite *PostgresSuite) TestDefaultOption() {
@@ -64,6 +70,8 @@ func (suite *PostgresSuite) TestDefaultOption() {
 	suite.Equal(suite.PostgresMaxOpenConns, postgres.PostgresMaxOpenConns)
 	suite.Equal(suite.PostgresMaxIdleConns, postgres.PostgresMaxIdleConns)
 	suite.Equal(suite.PostgresConnMaxLifetime, postgres.PostgresConnMaxLifetime)
+	suite.Equal(suite.PostgresMigrateOnStartup, postgres.PostgresMigrateOnStartup)
+	suite.Equal(suite.PostgresMigrateLockTimeout, postgres.PostgresMigrateLockTimeout)
 }
 
 func TestPostgresSuite(t *testing.T) {
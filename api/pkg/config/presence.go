@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// Presence type
+type Presence struct {
+	// PresenceTimeout is how long an event stream may go without a
+	// heartbeat before its device counts as offline
+	PresenceTimeout time.Duration `split_words:"true" default:"1m"`
+	// TypingThrottle is how often at most the typing starts a client repeats
+	// are passed on to the conversation partner
+	TypingThrottle time.Duration `split_words:"true" default:"3s"`
+	// TypingTimeout is how long typing lasts after the last start when the
+	// client never says it stopped
+	TypingTimeout time.Duration `split_words:"true" default:"6s"`
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PresenceSuite struct {
+	suite.Suite
+	PresenceTimeout time.Duration
+	TypingThrottle  time.Duration
+	TypingTimeout   time.Duration
+}
+
+func (suite *PresenceSuite) SetupSuite() {
+	os.Clearenv()
+	suite.PresenceTimeout = 2 * time.Minute
+	suite.TypingThrottle = 2 * time.Second
+	suite.TypingTimeout = 10 * time.Second
+	suite.NoError(os.Setenv("PRESENCE_TIMEOUT", suite.PresenceTimeout.String()))
+	suite.NoError(os.Setenv("TYPING_THROTTLE", suite.TypingThrottle.String()))
+	suite.NoError(os.Setenv("TYPING_TIMEOUT", suite.TypingTimeout.String()))
+}
+
+func (suite *PresenceSuite) TestDefaultOption() {
+	presence := &Presence{}
+	suite.NoError(LoadFromEnv(presence))
+	suite.Equal(suite.PresenceTimeout, presence.PresenceTimeout)
+	suite.Equal(suite.TypingThrottle, presence.TypingThrottle)
+	suite.Equal(suite.TypingTimeout, presence.TypingTimeout)
+}
+
+func TestPresenceSuite(t *testing.T) {
+	suite.Run(t, new(PresenceSuite))
+}
@@ -0,0 +1,10 @@
+package config
+
+// RateLimit type
+// policies are LIMIT/PERIOD token buckets, e.g. 60/1m; 0 turns one off.
+// The postgres backend shares the buckets between nodes.
+type RateLimit struct {
+	RateLimitBackend  string `split_words:"true" default:"memory"`
+	RateLimitDefault  string `split_words:"true" default:"300/1m"`
+	RateLimitMessages string `split_words:"true" default:"60/1m"`
+}
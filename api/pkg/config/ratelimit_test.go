@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitSuite struct {
+	suite.Suite
+	RateLimitBackend  string
+	RateLimitDefault  string
+	RateLimitMessages string
+}
+
+func (suite *RateLimitSuite) SetupSuite() {
+	os.Clearenv()
+	suite.RateLimitBackend = "postgres"
+	suite.RateLimitDefault = "100/1m"
+	suite.RateLimitMessages = "0"
+	suite.NoError(os.Setenv("RATE_LIMIT_BACKEND", suite.RateLimitBackend))
+	suite.NoError(os.Setenv("RATE_LIMIT_DEFAULT", suite.RateLimitDefault))
+	suite.NoError(os.Setenv("RATE_LIMIT_MESSAGES", suite.RateLimitMessages))
+}
+
+func (suite *RateLimitSuite) TestDefaultOption() {
+	rateLimit := &RateLimit{}
+	suite.NoError(LoadFromEnv(rateLimit))
+	suite.Equal(suite.RateLimitBackend, rateLimit.RateLimitBackend)
+	suite.Equal(suite.RateLimitDefault, rateLimit.RateLimitDefault)
+	suite.Equal(suite.RateLimitMessages, rateLimit.RateLimitMessages)
+}
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitSuite))
+}
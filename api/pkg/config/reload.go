@@ -0,0 +1,276 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Change describes one reload: the keys taken over from the new
+// configuration and those that changed but only take effect on restart.
+// Subscribers are only notified when Applied is not empty.
+type Change struct {
+	Previous Set
+	Current  Set
+	Applied  []string
+	Ignored  []string
+}
+
+// Has reports whether key was applied
+func (c Change) Has(keys ...string) bool {
+	for _, applied := range c.Applied {
+		for _, key := range keys {
+			if applied == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Subscriber is called after the new configuration has been swapped in
+type Subscriber func(change Change)
+
+// ReloaderOption interface
+type ReloaderOption interface {
+	Apply(*Reloader)
+}
+
+// WithReloadLoader method
+// replaces NewSet as the way a fresh configuration is read
+func WithReloadLoader(load func() (Set, error)) ReloaderOption {
+	return withReloadLoader{load: load}
+}
+
+type withReloadLoader struct {
+	load func() (Set, error)
+}
+
+// Apply method
+func (w withReloadLoader) Apply(r *Reloader) {
+	r.load = w.load
+}
+
+// WithReloadFiles method
+// sets the files polled for changes, CONFIG_FILE by default
+func WithReloadFiles(paths ...string) ReloaderOption {
+	return withReloadFiles{paths: paths}
+}
+
+type withReloadFiles struct {
+	paths []string
+}
+
+// Apply method
+func (w withReloadFiles) Apply(r *Reloader) {
+	r.files = w.paths
+}
+
+// WithReloadInterval method
+// sets how often the files are polled, zero disables polling
+func WithReloadInterval(interval time.Duration) ReloaderOption {
+	return withReloadInterval{interval: interval}
+}
+
+type withReloadInterval struct {
+	interval time.Duration
+}
+
+// Apply method
+func (w withReloadInterval) Apply(r *Reloader) {
+	r.interval = w.interval
+}
+
+// WithReloadSignals method
+// sets the signals that trigger a reload, SIGHUP by default
+func WithReloadSignals(signals ...os.Signal) ReloaderOption {
+	return withReloadSignals{signals: signals}
+}
+
+type withReloadSignals struct {
+	signals []os.Signal
+}
+
+// Apply method
+func (w withReloadSignals) Apply(r *Reloader) {
+	r.signals = w.signals
+}
+
+// WithReloadLogger method
+func WithReloadLogger(logger *zap.Logger) ReloaderOption {
+	return withReloadLogger{logger: logger}
+}
+
+type withReloadLogger struct {
+	logger *zap.Logger
+}
+
+// Apply method
+func (w withReloadLogger) Apply(r *Reloader) {
+	r.logger = w.logger
+}
+
+// Reloader holds the live configuration. Only fields tagged reload:"true"
+// are ever swapped in; every other change is reported and waits for a
+// restart, so the rest of Set stays exactly as the process started with.
+type Reloader struct {
+	current atomic.Pointer[Set]
+
+	// mu serialises reloads so subscribers see changes in order
+	mu          sync.Mutex
+	subscribers []Subscriber
+
+	load     func() (Set, error)
+	files    []string
+	interval time.Duration
+	signals  []os.Signal
+	logger   *zap.Logger
+}
+
+// NewReloader method
+func NewReloader(set Set, options ...ReloaderOption) *Reloader {
+	env, _ := EnvSource(os.Environ())
+	r := &Reloader{
+		load:     NewSet,
+		files:    configFiles(env),
+		interval: 5 * time.Second,
+		signals:  []os.Signal{syscall.SIGHUP},
+		logger:   zap.NewNop(),
+	}
+	for _, option := range options {
+		option.Apply(r)
+	}
+	r.current.Store(&set)
+	return r
+}
+
+// Current returns the live configuration
+func (r *Reloader) Current() Set {
+	return *r.current.Load()
+}
+
+// Subscribe registers fn for every applied change
+func (r *Reloader) Subscribe(fn Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload reads the configuration again and swaps in the reloadable fields.
+// An invalid configuration is rejected as a whole and the live one kept.
+func (r *Reloader) Reload() (Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.Current()
+	loaded, err := r.load()
+	if err != nil {
+		return Change{Previous: previous, Current: previous}, fmt.Errorf("%w: %w", ErrConfigLoad, err)
+	}
+
+	next := previous
+	change := Change{Previous: previous}
+	nextSections, loadedSections := next.sections(), loaded.sections()
+	for i := range nextSections {
+		nextFields, _ := fieldsOf(nextSections[i].ptr)
+		loadedFields, _ := fieldsOf(loadedSections[i].ptr)
+		for j, f := range nextFields {
+			if format(f.value.Interface()) == format(loadedFields[j].value.Interface()) {
+				continue
+			}
+			if !f.reload {
+				change.Ignored = append(change.Ignored, f.key)
+				continue
+			}
+			f.value.Set(loadedFields[j].value)
+			change.Applied = append(change.Applied, f.key)
+		}
+	}
+	sort.Strings(change.Applied)
+	sort.Strings(change.Ignored)
+	change.Current = next
+
+	if len(change.Ignored) > 0 {
+		r.logger.Warn("config changes need a restart",
+			zap.String("system", "Config"),
+			zap.Strings("keys", change.Ignored),
+		)
+	}
+	if len(change.Applied) == 0 {
+		return change, nil
+	}
+
+	r.current.Store(&next)
+	r.logger.Info("config reloaded",
+		zap.String("system", "Config"),
+		zap.Strings("keys", change.Applied),
+	)
+	for _, fn := range r.subscribers {
+		fn(change)
+	}
+	return change, nil
+}
+
+// Watch reloads on the configured signals and whenever a config file
+// changes, until ctx is done. Failed reloads are logged and the live
+// configuration kept.
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	if len(r.signals) > 0 {
+		signal.Notify(hup, r.signals...)
+		defer signal.Stop(hup)
+	}
+
+	var tick <-chan time.Time
+	if r.interval > 0 && len(r.files) > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	stamps := r.stamps()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-hup:
+			r.logger.Info("config reload requested", zap.String("system", "Config"), zap.Any("signal", sig))
+		case <-tick:
+			next := r.stamps()
+			if next == stamps {
+				continue
+			}
+			stamps = next
+			r.logger.Info("config file changed", zap.String("system", "Config"), zap.Strings("files", r.files))
+		}
+		if _, err := r.Reload(); err != nil {
+			r.logger.Error("config reload failed, keeping the live configuration",
+				zap.String("system", "Config"),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// stamps fingerprints the watched files by size and modification time;
+// a missing file fingerprints as empty so its return is noticed too
+func (r *Reloader) stamps() string {
+	stamps := ""
+	for _, path := range r.files {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps += path + ":missing;"
+			continue
+		}
+		stamps += fmt.Sprintf("%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return stamps
+}
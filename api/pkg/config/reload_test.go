@@ -0,0 +1,154 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloaderSuite struct {
+	suite.Suite
+}
+
+func (suite *ReloaderSuite) SetupTest() {
+	os.Clearenv()
+}
+
+func (suite *ReloaderSuite) load(source Source) func() (Set, error) {
+	return func() (Set, error) {
+		set, err := LoadSet(source)
+		if err != nil {
+			return set, err
+		}
+		return set, set.Validate()
+	}
+}
+
+func (suite *ReloaderSuite) TestReloadAppliesOnlyReloadableKeys() {
+	initial, err := LoadSet()
+	suite.Require().NoError(err)
+
+	reloader := NewReloader(initial, WithReloadLoader(suite.load(Source{
+		"LOG_LEVEL":     "debug",
+		"ALLOW_ORIGINS": "https://example.com",
+		"PORT":          "8080",
+	})))
+	var notified []Change
+	reloader.Subscribe(func(change Change) { notified = append(notified, change) })
+
+	change, err := reloader.Reload()
+	suite.NoError(err)
+	suite.Equal([]string{"ALLOW_ORIGINS", "LOG_LEVEL"}, change.Applied)
+	suite.Equal([]string{"PORT"}, change.Ignored)
+	suite.True(change.Has("LOG_LEVEL"))
+
+	current := reloader.Current()
+	suite.Equal("debug", current.Core.LogLevel)
+	suite.Equal([]string{"https://example.com"}, current.Server.AllowOrigins)
+	suite.Equal("3000", current.Server.Port)
+	suite.Equal("INFO", change.Previous.Core.LogLevel)
+	suite.Len(notified, 1)
+
+	// nothing reloadable changed the second time, so nobody is told
+	_, err = reloader.Reload()
+	suite.NoError(err)
+	suite.Len(notified, 1)
+}
+
+func (suite *ReloaderSuite) TestReloadRejectsInvalidConfig() {
+	initial, err := LoadSet()
+	suite.Require().NoError(err)
+
+	reloader := NewReloader(initial, WithReloadLoader(suite.load(Source{"LOG_LEVEL": "loud"})))
+	reloader.Subscribe(func(change Change) { suite.Fail("subscriber called for rejected config") })
+
+	_, err = reloader.Reload()
+	suite.ErrorIs(err, ErrInvalidConfig)
+	suite.Equal("INFO", reloader.Current().Core.LogLevel)
+}
+
+func (suite *ReloaderSuite) TestReloadKeepsLiveSetUntouched() {
+	initial, err := LoadSet()
+	suite.Require().NoError(err)
+	before := initial.Server.AllowedPaths
+
+	reloader := NewReloader(initial, WithReloadLoader(suite.load(Source{"ALLOWED_PATHS": "/ping"})))
+	_, err = reloader.Reload()
+	suite.NoError(err)
+	suite.Equal(before, initial.Server.AllowedPaths)
+	suite.Equal([]string{"/ping"}, reloader.Current().Server.AllowedPaths)
+}
+
+func (suite *ReloaderSuite) TestWatchFile() {
+	path := filepath.Join(suite.T().TempDir(), "config.yaml")
+	suite.Require().NoError(os.WriteFile(path, []byte("log_level: info\n"), 0o600))
+
+	initial, err := LoadSet()
+	suite.Require().NoError(err)
+	reloader := NewReloader(initial,
+		WithReloadFiles(path),
+		WithReloadInterval(10*time.Millisecond),
+		WithReloadSignals(),
+		WithReloadLoader(func() (Set, error) {
+			source, err := FileSource(path)
+			if err != nil {
+				return Set{}, err
+			}
+			return suite.load(source)()
+		}),
+	)
+	changed := make(chan Change, 1)
+	reloader.Subscribe(func(change Change) { changed <- change })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	suite.Require().NoError(os.WriteFile(path, []byte("log_level: warn\nallow_all_origins: true\n"), 0o600))
+
+	select {
+	case change := <-changed:
+		suite.Equal([]string{"ALLOW_ALL_ORIGINS", "LOG_LEVEL"}, change.Applied)
+	case <-time.After(2 * time.Second):
+		suite.Fail("file change not picked up")
+	}
+}
+
+func (suite *ReloaderSuite) TestWatchSignal() {
+	initial, err := LoadSet()
+	suite.Require().NoError(err)
+	calls := make(chan struct{}, 1)
+	reloader := NewReloader(initial,
+		WithReloadInterval(0),
+		WithReloadSignals(syscall.SIGUSR1),
+		WithReloadLoader(func() (Set, error) {
+			calls <- struct{}{}
+			return Set{}, errors.New("unreadable")
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx)
+
+	// give Watch time to install the handler before signalling
+	time.Sleep(30 * time.Millisecond)
+	suite.Require().NoError(syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case <-calls:
+	case <-time.After(2 * time.Second):
+		suite.Fail("signal did not trigger a reload")
+	}
+}
+
+func TestReloaderSuite(t *testing.T) {
+	suite.Run(t, new(ReloaderSuite))
+}
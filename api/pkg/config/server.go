@@ -11,9 +11,12 @@ type Server struct {
 	ServerTimeout        time.Duration `split_words:"true" default:"5s"`
 	PrefixMessage        string        `split_words:"true" default:"[Gin]"`
 	CustomizedRender     bool          `split_words:"true" default:"false"`
-	AllowAllOrigins      bool          `split_words:"true" default:"false"`
-	AllowOrigins         []string      `split_words:"true" default:"http://localhost,https://localhost"`
-	AllowedPaths         []string      `split_words:"true" default:"/favicon.ico,/ping,/api/v1/auth/register,/api/v1/auth/login"`
+	AllowAllOrigins      bool          `split_words:"true" default:"false" reload:"true"`
+	AllowOrigins         []string      `split_words:"true" default:"http://localhost,https://localhost" reload:"true"`
+	AllowedPaths         []string      `split_words:"true" default:"/favicon.ico,/ping,/healthz,/readyz,/api/v1/auth/register,/api/v1/auth/login,/api/v1/blobs" reload:"true"`
 	JWTGuard             bool          `split_words:"true" default:"true"`
 	MaxMultipartMemoryMB int64         `split_words:"true" default:"8"`
+	ReadinessTimeout     time.Duration `split_words:"true" default:"2s"`
+	ShutdownDrainDelay   time.Duration `split_words:"true" default:"0s"`
+	ShutdownTimeout      time.Duration `split_words:"true" default:"30s"`
 }
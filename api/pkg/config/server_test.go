@@ -23,6 +23,9 @@ type ServerSuite struct {
 	AllowedPaths         []string
 	JWTGuard             bool
 	MaxMultipartMemoryMB int64
+	ReadinessTimeout     time.Duration
+	ShutdownDrainDelay   time.Duration
+	ShutdownTimeout      time.Duration
 }
 
 func (suite *ServerSuite) SetupSuite() {
@@ -37,6 +40,9 @@ func (suite *ServerSuite) SetupSuite() {
 	suite.AllowedPaths = []string{"/api/v1/auth/register", "/api/v1/auth/login"}
 	suite.JWTGuard = false
 	suite.MaxMultipartMemoryMB = 16
+	suite.ReadinessTimeout = 3 * time.Second
+	suite.ShutdownDrainDelay = 10 * time.Second
+	suite.ShutdownTimeout = 45 * time.Second
 
 	suite.NoError(os.Setenv("RELEASE_MODE", strconv.FormatBool(suite.ReleaseMode)))
 	suite.NoError(os.Setenv("PORT", suite.Port))
@@ -48,6 +54,9 @@ func (suite *ServerSuite) SetupSuite() {
 	suite.NoError(os.Setenv("ALLOWED_PATHS", strings.Join(suite.AllowedPaths, ",")))
 	suite.NoError(os.Setenv("JWT_GUARD", strconv.FormatBool(suite.JWTGuard)))
 	suite.NoError(os.Setenv("MAX_MULTIPART_MEMORY_MB", strconv.FormatInt(suite.MaxMultipartMemoryMB, 10)))
+	suite.NoError(os.Setenv("READINESS_TIMEOUT", fmt.Sprint(suite.ReadinessTimeout)))
+	suite.NoError(os.Setenv("SHUTDOWN_DRAIN_DELAY", fmt.Sprint(suite.ShutdownDrainDelay)))
+	suite.NoError(os.Setenv("SHUTDOWN_TIMEOUT", fmt.Sprint(suite.ShutdownTimeout)))
 
 }
 
@@ -64,6 +73,9 @@ func (suite *ServerSuite) TestDefaultOption() {
 	suite.Equal(suite.AllowedPaths, server.AllowedPaths)
 	suite.Equal(suite.JWTGuard, server.JWTGuard)
 	suite.Equal(suite.MaxMultipartMemoryMB, server.MaxMultipartMemoryMB)
+	suite.Equal(suite.ReadinessTimeout, server.ReadinessTimeout)
+	suite.Equal(suite.ShutdownDrainDelay, server.ShutdownDrainDelay)
+	suite.Equal(suite.ShutdownTimeout, server.ShutdownTimeout)
 }
 
 func TestServerSuite(t *testing.T) {
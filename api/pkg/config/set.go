@@ -2,36 +2,150 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"strings"
 )
 
-func NewCore(set Set) Core         { return set.Core }
-func NewJWT(set Set) JWT           { return set.JWT }
-func NewPostgres(set Set) Postgres { return set.Postgres }
-func NewServer(set Set) Server     { return set.Server }
+func NewCore(set Set) Core             { return set.Core }
+func NewJWT(set Set) JWT               { return set.JWT }
+func NewPostgres(set Set) Postgres     { return set.Postgres }
+func NewServer(set Set) Server         { return set.Server }
+func NewMetrics(set Set) Metrics       { return set.Metrics }
+func NewTracing(set Set) Tracing       { return set.Tracing }
+func NewRateLimit(set Set) RateLimit   { return set.RateLimit }
+func NewMessage(set Set) Message       { return set.Message }
+func NewAttachment(set Set) Attachment { return set.Attachment }
+func NewPresence(set Set) Presence     { return set.Presence }
 
+// NewSet loads the configuration from defaults, then the files named by
+// CONFIG_FILE, then the environment, and validates the result
 func NewSet() (Set, error) {
-	set := Set{}
+	sources, err := Sources()
+	if err != nil {
+		return Set{}, err
+	}
 
-	// Load each configuration section
-	configs := []interface{}{
-		&set.Core,
-		&set.JWT,
-		&set.Postgres,
-		&set.Server,
+	set, err := LoadSet(sources...)
+	if err != nil {
+		return set, err
 	}
 
-	for _, cfg := range configs {
-		if err := LoadFromEnv(cfg); err != nil {
-			return set, fmt.Errorf("failed to load config for %T: %w", cfg, err)
+	// a misspelt key in a file would otherwise be silently ignored; the
+	// last source is the environment, which holds plenty of unrelated keys
+	known := set.keys()
+	var problems []string
+	for _, file := range sources[:len(sources)-1] {
+		for _, key := range unknownKeys(file, known) {
+			problems = append(problems, fmt.Sprintf("%s: unknown key in %s", key, ConfigFileKey))
 		}
 	}
+	return set, set.validate(problems...)
+}
+
+// Sources returns the layers NewSet reads: the files named by CONFIG_FILE,
+// then the environment
+func Sources() ([]Source, error) {
+	env, err := EnvSource(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	files, err := FileSources(env)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, env), nil
+}
 
+// LoadSet fills every section from sources, later sources winning
+func LoadSet(sources ...Source) (Set, error) {
+	set := Set{}
+	for _, cfg := range set.sections() {
+		if err := Load(cfg.ptr, sources...); err != nil {
+			return set, fmt.Errorf("failed to load config for %T: %w", cfg.ptr, err)
+		}
+	}
 	return set, nil
 }
 
 type Set struct {
-	Core     Core
-	JWT      JWT
-	Postgres Postgres
-	Server   Server
+	Core       Core
+	JWT        JWT
+	Postgres   Postgres
+	Server     Server
+	Metrics    Metrics
+	Tracing    Tracing
+	RateLimit  RateLimit
+	Message    Message
+	Attachment Attachment
+	Presence   Presence
+}
+
+type section struct {
+	name string
+	ptr  interface{}
+}
+
+func (s *Set) sections() []section {
+	return []section{
+		{"core", &s.Core},
+		{"jwt", &s.JWT},
+		{"postgres", &s.Postgres},
+		{"server", &s.Server},
+		{"metrics", &s.Metrics},
+		{"tracing", &s.Tracing},
+		{"rate_limit", &s.RateLimit},
+		{"message", &s.Message},
+		{"attachment", &s.Attachment},
+		{"presence", &s.Presence},
+	}
+}
+
+func (s Set) keys() map[string]bool {
+	known := map[string]bool{ConfigFileKey: true}
+	for _, section := range s.sections() {
+		fields, _ := fieldsOf(section.ptr)
+		for _, f := range fields {
+			known[f.key] = true
+		}
+	}
+	return known
+}
+
+// Value is one printed setting
+type Value struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Section is the printed settings of one part of Set
+type Section struct {
+	Name   string  `json:"name"`
+	Values []Value `json:"values"`
+}
+
+// Values lists every setting by section, in the format the environment and
+// config files accept. With redacted, secrets are masked.
+func (s Set) Values(redacted bool) []Section {
+	sections := s.sections()
+	printed := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		fields, _ := fieldsOf(section.ptr)
+		values := make([]Value, 0, len(fields))
+		for _, f := range fields {
+			value := format(f.value.Interface())
+			if redacted && f.secret {
+				value = redact(value)
+			}
+			values = append(values, Value{Key: strings.ToLower(f.key), Value: value})
+		}
+		printed = append(printed, Section{Name: section.name, Values: values})
+	}
+	return printed
+}
+
+func format(value interface{}) string {
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(value)
 }
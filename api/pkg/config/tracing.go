@@ -0,0 +1,10 @@
+package config
+
+// Tracing type
+// TracingExporter is one of otlp, stdout or none
+type Tracing struct {
+	TracingExporter    string  `split_words:"true" default:"none"`
+	TracingEndpoint    string  `split_words:"true" default:"localhost:4318"`
+	TracingInsecure    bool    `split_words:"true" default:"true"`
+	TracingSampleRatio float64 `split_words:"true" default:"1"`
+}
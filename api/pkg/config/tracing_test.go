@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TracingSuite struct {
+	suite.Suite
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingInsecure    bool
+	TracingSampleRatio float64
+}
+
+func (suite *TracingSuite) SetupSuite() {
+	os.Clearenv()
+	suite.TracingExporter = "otlp"
+	suite.TracingEndpoint = "collector:4318"
+	suite.TracingInsecure = false
+	suite.TracingSampleRatio = 0.25
+	suite.NoError(os.Setenv("TRACING_EXPORTER", suite.TracingExporter))
+	suite.NoError(os.Setenv("TRACING_ENDPOINT", suite.TracingEndpoint))
+	suite.NoError(os.Setenv("TRACING_INSECURE", strconv.FormatBool(suite.TracingInsecure)))
+	suite.NoError(os.Setenv("TRACING_SAMPLE_RATIO", fmt.Sprint(suite.TracingSampleRatio)))
+}
+
+func (suite *TracingSuite) TestDefaultOption() {
+	tracing := &Tracing{}
+	suite.NoError(LoadFromEnv(tracing))
+	suite.Equal(suite.TracingExporter, tracing.TracingExporter)
+	suite.Equal(suite.TracingEndpoint, tracing.TracingEndpoint)
+	suite.Equal(suite.TracingInsecure, tracing.TracingInsecure)
+	suite.Equal(suite.TracingSampleRatio, tracing.TracingSampleRatio)
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingSuite))
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"hilo-api/pkg/ratelimit"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedValue = "******"
+
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ValidationError lists every problem found, so one restart fixes them all
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s:\n  - %s", ErrInvalidConfig, strings.Join(e.Problems, "\n  - "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Validate checks the whole set and reports every problem at once
+func (s Set) Validate() error {
+	return s.validate()
+}
+
+func (s Set) validate(problems ...string) error {
+	problems = append(problems, s.Core.problems()...)
+	problems = append(problems, s.JWT.problems()...)
+	problems = append(problems, s.Postgres.problems()...)
+	problems = append(problems, s.Server.problems()...)
+	problems = append(problems, s.Metrics.problems()...)
+	problems = append(problems, s.Tracing.problems()...)
+	problems = append(problems, s.RateLimit.problems()...)
+	problems = append(problems, s.Message.problems()...)
+	problems = append(problems, s.Attachment.problems()...)
+	problems = append(problems, s.Presence.problems()...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+func (c Core) problems() []string {
+	var problems []string
+	var level zapcore.Level
+	if err := level.Set(c.LogLevel); c.LogLevel != "" && err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL: %q is not one of debug, info, warn, error, dpanic, panic, fatal", c.LogLevel))
+	}
+	return problems
+}
+
+func (c JWT) problems() []string {
+	if c.PrivateKeyPath == "" && c.PrivateKey == "" {
+		return []string{"PRIVATE_KEY_PATH: set it or PRIVATE_KEY (or PRIVATE_KEY_FILE)"}
+	}
+	return nil
+}
+
+func (c Postgres) problems() []string {
+	var problems []string
+	if c.PostgresURL == "" {
+		problems = append(problems, portProblems("POSTGRES_PORT", c.PostgresPort)...)
+	} else {
+		problems = append(problems, c.urlProblems()...)
+	}
+	if c.PostgresMaxOpenConns > 0 && c.PostgresMaxIdleConns > c.PostgresMaxOpenConns {
+		problems = append(problems, fmt.Sprintf("POSTGRES_MAX_IDLE_CONNS: %d is above POSTGRES_MAX_OPEN_CONNS %d", c.PostgresMaxIdleConns, c.PostgresMaxOpenConns))
+	}
+	if c.PostgresTxTimeout < 0 {
+		problems = append(problems, "POSTGRES_TX_TIMEOUT: must not be negative")
+	}
+	return problems
+}
+
+// urlProblems reports host fields that disagree with POSTGRES_URL; the URL
+// wins when connecting, so a differing field means the operator edited the
+// wrong one
+func (c Postgres) urlProblems() []string {
+	u, err := url.Parse(c.PostgresURL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return []string{"POSTGRES_URL: must be a postgres:// URL"}
+	}
+
+	// fields left at their default were not meant to be used
+	defaults := defaultsOf(&Postgres{})
+	var problems []string
+	conflict := func(key, field, fromURL string) {
+		if fromURL != "" && field != "" && field != defaults[key] && field != fromURL {
+			problems = append(problems, fmt.Sprintf("%s: %q conflicts with %q from POSTGRES_URL, set only one of them", key, field, fromURL))
+		}
+	}
+	conflict("POSTGRES_HOST", c.PostgresHost, u.Hostname())
+	conflict("POSTGRES_PORT", c.PostgresPort, u.Port())
+	conflict("POSTGRES_DATABASE", c.PostgresDatabase, strings.TrimPrefix(u.Path, "/"))
+	conflict("POSTGRES_USERNAME", c.PostgresUsername, u.User.Username())
+	conflict("POSTGRES_SSL_MODE", c.PostgresSSLMode, u.Query().Get("sslmode"))
+	if password, ok := u.User.Password(); ok {
+		// never echo passwords
+		if c.PostgresPassword != "" && c.PostgresPassword != defaults["POSTGRES_PASSWORD"] && c.PostgresPassword != password {
+			problems = append(problems, "POSTGRES_PASSWORD: conflicts with the password in POSTGRES_URL, set only one of them")
+		}
+	}
+	return problems
+}
+
+func (c Server) problems() []string {
+	problems := portProblems("PORT", c.Port)
+	if c.ServerTimeout <= 0 {
+		problems = append(problems, "SERVER_TIMEOUT: must be positive")
+	}
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT: must be positive")
+	}
+	if c.ReadinessTimeout < 0 || c.ShutdownDrainDelay < 0 {
+		problems = append(problems, "READINESS_TIMEOUT, SHUTDOWN_DRAIN_DELAY: must not be negative")
+	}
+	if c.ShutdownTimeout > 0 && c.ShutdownDrainDelay+c.ServerTimeout > c.ShutdownTimeout {
+		problems = append(problems, fmt.Sprintf("SHUTDOWN_TIMEOUT: %s leaves no time after SHUTDOWN_DRAIN_DELAY %s and SERVER_TIMEOUT %s", c.ShutdownTimeout, c.ShutdownDrainDelay, c.ServerTimeout))
+	}
+	if c.MaxMultipartMemoryMB <= 0 {
+		problems = append(problems, "MAX_MULTIPART_MEMORY_MB: must be positive")
+	}
+	for _, origin := range c.AllowOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("ALLOW_ORIGINS: %q is not a scheme://host origin", origin))
+		}
+	}
+	for _, path := range c.AllowedPaths {
+		if !strings.HasPrefix(path, "/") {
+			problems = append(problems, fmt.Sprintf("ALLOWED_PATHS: %q must start with /", path))
+		}
+	}
+	return problems
+}
+
+func (c Metrics) problems() []string {
+	var problems []string
+	if !strings.HasPrefix(c.MetricsPath, "/") {
+		problems = append(problems, fmt.Sprintf("METRICS_PATH: %q must start with /", c.MetricsPath))
+	}
+	if (c.MetricsBasicAuthUsername == "") != (c.MetricsBasicAuthPassword == "") {
+		problems = append(problems, "METRICS_BASIC_AUTH_USERNAME, METRICS_BASIC_AUTH_PASSWORD: set both or neither")
+	}
+	for _, network := range c.MetricsAllowedNetworks {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(network)); err != nil {
+			problems = append(problems, fmt.Sprintf("METRICS_ALLOWED_NETWORKS: %q is not a CIDR", network))
+		}
+	}
+	return problems
+}
+
+func (c Tracing) problems() []string {
+	var problems []string
+	switch c.TracingExporter {
+	case "otlp", "stdout", "none", "":
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER: %q is not one of otlp, stdout, none", c.TracingExporter))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("TRACING_SAMPLE_RATIO: %v is outside 0..1", c.TracingSampleRatio))
+	}
+	return problems
+}
+
+func (c RateLimit) problems() []string {
+	var problems []string
+	switch c.RateLimitBackend {
+	case "memory", "postgres":
+	default:
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_BACKEND: %q is not one of memory, postgres", c.RateLimitBackend))
+	}
+	for _, policy := range [][2]string{
+		{"RATE_LIMIT_DEFAULT", c.RateLimitDefault},
+		{"RATE_LIMIT_MESSAGES", c.RateLimitMessages},
+	} {
+		if _, err := ratelimit.ParsePolicy(policy[0], policy[1]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", policy[0], err))
+		}
+	}
+	return problems
+}
+
+func (c Message) problems() []string {
+	var problems []string
+	if c.MessageIdempotencyTTL <= 0 {
+		problems = append(problems, fmt.Sprintf("MESSAGE_IDEMPOTENCY_TTL: %s must be positive", c.MessageIdempotencyTTL))
+	}
+	if c.MessageEditWindow < 0 {
+		problems = append(problems, fmt.Sprintf("MESSAGE_EDIT_WINDOW: %s cannot be negative", c.MessageEditWindow))
+	}
+	if c.MessageRetractWindow < 0 {
+		problems = append(problems, fmt.Sprintf("MESSAGE_RETRACT_WINDOW: %s cannot be negative", c.MessageRetractWindow))
+	}
+	return problems
+}
+
+func (c Attachment) problems() []string {
+	var problems []string
+	switch c.AttachmentBackend {
+	case "local":
+		if c.AttachmentLocalDir == "" {
+			problems = append(problems, "ATTACHMENT_LOCAL_DIR: required by the local backend")
+		}
+	case "s3":
+		if u, err := url.Parse(c.AttachmentS3Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("ATTACHMENT_S3_ENDPOINT: %q is not an http(s) URL", c.AttachmentS3Endpoint))
+		}
+		if c.AttachmentS3Bucket == "" || c.AttachmentS3AccessKey == "" || c.AttachmentS3SecretKey == "" {
+			problems = append(problems, "ATTACHMENT_S3_BUCKET, ATTACHMENT_S3_ACCESS_KEY, ATTACHMENT_S3_SECRET_KEY: required by the s3 backend")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_BACKEND: %q is not one of local, s3", c.AttachmentBackend))
+	}
+	if c.AttachmentUploadDir == "" {
+		problems = append(problems, "ATTACHMENT_UPLOAD_DIR: must not be empty")
+	}
+	// presigned S3 URLs live seven days at most
+	if c.AttachmentURLExpiry <= 0 || c.AttachmentURLExpiry > 7*24*time.Hour {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_URL_EXPIRY: %s is outside 1s..168h", c.AttachmentURLExpiry))
+	}
+	if c.AttachmentMaxSizeMB <= 0 {
+		problems = append(problems, "ATTACHMENT_MAX_SIZE_MB: must be positive")
+	}
+	if c.AttachmentQuotaMB < c.AttachmentMaxSizeMB {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_QUOTA_MB: %d is below ATTACHMENT_MAX_SIZE_MB %d", c.AttachmentQuotaMB, c.AttachmentMaxSizeMB))
+	}
+	for _, pattern := range c.AttachmentAllowedTypes {
+		major, minor, ok := strings.Cut(pattern, "/")
+		if !ok || major == "" || minor == "" || major == "*" {
+			problems = append(problems, fmt.Sprintf("ATTACHMENT_ALLOWED_TYPES: %q is not type/subtype or type/*", pattern))
+		}
+	}
+	if c.AttachmentUploadTTL <= 0 {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_UPLOAD_TTL: %s must be positive", c.AttachmentUploadTTL))
+	}
+	if c.AttachmentProcessInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_PROCESS_INTERVAL: %s must be positive", c.AttachmentProcessInterval))
+	}
+	if c.AttachmentProcessAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_PROCESS_ATTEMPTS: %d must be at least 1", c.AttachmentProcessAttempts))
+	}
+	if c.AttachmentMaxMegapixels < 1 {
+		problems = append(problems, fmt.Sprintf("ATTACHMENT_MAX_MEGAPIXELS: %d must be at least 1", c.AttachmentMaxMegapixels))
+	}
+	return problems
+}
+
+func (c Presence) problems() []string {
+	var problems []string
+	if c.PresenceTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("PRESENCE_TIMEOUT: %s must be positive", c.PresenceTimeout))
+	}
+	if c.TypingThrottle <= 0 {
+		problems = append(problems, fmt.Sprintf("TYPING_THROTTLE: %s must be positive", c.TypingThrottle))
+	}
+	if c.TypingTimeout <= c.TypingThrottle {
+		problems = append(problems, fmt.Sprintf("TYPING_TIMEOUT: %s must be longer than TYPING_THROTTLE", c.TypingTimeout))
+	}
+	return problems
+}
+
+// defaultsOf maps the keys of cfg to their default tags
+func defaultsOf(cfg interface{}) map[string]string {
+	fields, _ := fieldsOf(cfg)
+	defaults := make(map[string]string, len(fields))
+	for _, f := range fields {
+		defaults[f.key] = f.def
+	}
+	return defaults
+}
+
+func portProblems(key, port string) []string {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return []string{fmt.Sprintf("%s: %q is not a port between 1 and 65535", key, port)}
+	}
+	return nil
+}
+
+// redact masks a secret, keeping the rest of a URL readable
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.User != nil {
+		// Redacted masks the password as xxxxx, keep the mask uniform
+		return strings.Replace(u.Redacted(), ":xxxxx@", ":"+redactedValue+"@", 1)
+	}
+	return redactedValue
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func defaultSet(t *testing.T, sources ...Source) Set {
+	t.Helper()
+	set, err := LoadSet(sources...)
+	require.NoError(t, err)
+	return set
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	assert.NoError(t, defaultSet(t).Validate())
+}
+
+func TestPostgresURLConflicts(t *testing.T) {
+	// the URL alone is fine, the untouched host fields keep their defaults
+	set := defaultSet(t, Source{"POSTGRES_URL": "postgres://app:pw@db.internal:6432/hilo?sslmode=require"})
+	assert.NoError(t, set.Validate())
+
+	// fields agreeing with the URL are fine too
+	set = defaultSet(t, Source{"POSTGRES_URL": "postgres://app:pw@db.internal:6432/hilo", "POSTGRES_HOST": "db.internal"})
+	assert.NoError(t, set.Validate())
+
+	set = defaultSet(t, Source{"POSTGRES_URL": "postgres://app:pw@db.internal:6432/hilo", "POSTGRES_DATABASE": "other", "POSTGRES_PASSWORD": "nope"})
+	err := set.Validate()
+	assert.ErrorContains(t, err, "POSTGRES_DATABASE")
+	assert.ErrorContains(t, err, "POSTGRES_PASSWORD")
+	assert.NotContains(t, err.Error(), "pw", "passwords are never echoed")
+
+	set = defaultSet(t, Source{"POSTGRES_URL": "mysql://db"})
+	assert.ErrorContains(t, set.Validate(), "POSTGRES_URL")
+}
+
+func TestValidationProblems(t *testing.T) {
+	set := defaultSet(t, Source{
+		"LOG_LEVEL":                   "loud",
+		"PRIVATE_KEY_PATH":            "",
+		"POSTGRES_MAX_IDLE_CONNS":     "50",
+		"METRICS_BASIC_AUTH_USERNAME": "prometheus",
+		"METRICS_ALLOWED_NETWORKS":    "10.0.0.0/8,office",
+		"TRACING_EXPORTER":            "jaeger",
+		"ALLOWED_PATHS":               "/ping,healthz",
+		"SHUTDOWN_TIMEOUT":            "1s",
+		"RATE_LIMIT_BACKEND":          "redis",
+		"RATE_LIMIT_MESSAGES":         "10 per minute",
+		"MESSAGE_IDEMPOTENCY_TTL":     "0s",
+		"MESSAGE_EDIT_WINDOW":         "-1m",
+		"MESSAGE_RETRACT_WINDOW":      "-1h",
+		"ATTACHMENT_BACKEND":          "gcs",
+		"ATTACHMENT_QUOTA_MB":         "10",
+		"ATTACHMENT_ALLOWED_TYPES":    "image/*,pdf",
+	})
+
+	err := set.Validate()
+	validation := &ValidationError{}
+	require.ErrorAs(t, err, &validation)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Equal(t, []string{
+		`LOG_LEVEL: "loud" is not one of debug, info, warn, error, dpanic, panic, fatal`,
+		"PRIVATE_KEY_PATH: set it or PRIVATE_KEY (or PRIVATE_KEY_FILE)",
+		"POSTGRES_MAX_IDLE_CONNS: 50 is above POSTGRES_MAX_OPEN_CONNS 25",
+		"SHUTDOWN_TIMEOUT: 1s leaves no time after SHUTDOWN_DRAIN_DELAY 0s and SERVER_TIMEOUT 5s",
+		`ALLOWED_PATHS: "healthz" must start with /`,
+		"METRICS_BASIC_AUTH_USERNAME, METRICS_BASIC_AUTH_PASSWORD: set both or neither",
+		`METRICS_ALLOWED_NETWORKS: "office" is not a CIDR`,
+		`TRACING_EXPORTER: "jaeger" is not one of otlp, stdout, none`,
+		`RATE_LIMIT_BACKEND: "redis" is not one of memory, postgres`,
+		`RATE_LIMIT_MESSAGES: invalid rate limit policy: "10 per minute" is not LIMIT/PERIOD`,
+		"MESSAGE_IDEMPOTENCY_TTL: 0s must be positive",
+		"MESSAGE_EDIT_WINDOW: -1m0s cannot be negative",
+		"MESSAGE_RETRACT_WINDOW: -1h0m0s cannot be negative",
+		`ATTACHMENT_BACKEND: "gcs" is not one of local, s3`,
+		"ATTACHMENT_QUOTA_MB: 10 is below ATTACHMENT_MAX_SIZE_MB 25",
+		`ATTACHMENT_ALLOWED_TYPES: "pdf" is not type/subtype or type/*`,
+	}, validation.Problems)
+}
+
+func TestAttachmentS3Problems(t *testing.T) {
+	set := defaultSet(t, Source{"ATTACHMENT_BACKEND": "s3", "ATTACHMENT_S3_ENDPOINT": "minio:9000"})
+	err := set.Validate()
+	assert.ErrorContains(t, err, `ATTACHMENT_S3_ENDPOINT: "minio:9000" is not an http(s) URL`)
+	assert.ErrorContains(t, err, "ATTACHMENT_S3_BUCKET, ATTACHMENT_S3_ACCESS_KEY, ATTACHMENT_S3_SECRET_KEY: required by the s3 backend")
+
+	set = defaultSet(t, Source{
+		"ATTACHMENT_BACKEND":       "s3",
+		"ATTACHMENT_S3_ENDPOINT":   "http://minio:9000",
+		"ATTACHMENT_S3_BUCKET":     "chat",
+		"ATTACHMENT_S3_ACCESS_KEY": "minio",
+		"ATTACHMENT_S3_SECRET_KEY": "minio-secret",
+	})
+	assert.NoError(t, set.Validate())
+}
+
+func TestAttachmentProcessingProblems(t *testing.T) {
+	set := defaultSet(t, Source{
+		"ATTACHMENT_PROCESS_INTERVAL": "0s",
+		"ATTACHMENT_PROCESS_ATTEMPTS": "0",
+		"ATTACHMENT_MAX_MEGAPIXELS":   "0",
+	})
+	err := set.Validate()
+	assert.ErrorContains(t, err, "ATTACHMENT_PROCESS_INTERVAL: 0s must be positive")
+	assert.ErrorContains(t, err, "ATTACHMENT_PROCESS_ATTEMPTS: 0 must be at least 1")
+	assert.ErrorContains(t, err, "ATTACHMENT_MAX_MEGAPIXELS: 0 must be at least 1")
+}
+
+func TestPresenceProblems(t *testing.T) {
+	set := defaultSet(t, Source{
+		"PRESENCE_TIMEOUT": "0s",
+		"TYPING_THROTTLE":  "5s",
+		"TYPING_TIMEOUT":   "5s",
+	})
+	err := set.Validate()
+	assert.ErrorContains(t, err, "PRESENCE_TIMEOUT: 0s must be positive")
+	assert.ErrorContains(t, err, "TYPING_TIMEOUT: 5s must be longer than TYPING_THROTTLE")
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "", redact(""))
+	assert.Equal(t, "******", redact("plain secret"))
+	assert.Equal(t, "postgres://app:******@db/hilo", redact("postgres://app:pw@db/hilo"))
+	assert.Equal(t, "postgres://app@db/hilo", redact("postgres://app@db/hilo"))
+}
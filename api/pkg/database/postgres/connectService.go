@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"hilo-api/pkg/config"
+	"hilo-api/pkg/metrics"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -34,11 +35,18 @@ func NewPostgresDB(logger *zap.Logger, opt config.Postgres) (*sqlx.DB, func(), e
 		return nil, nil, fmt.Errorf("postgres ping failed: %w", err)
 	}
 
+	unregisterStats, err := metrics.RegisterDBStats(db.DB, opt.PostgresDatabase)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("postgres metrics register failed: %w", err)
+	}
+
 	logger.Info("postgres connected",
 		zap.String("host", opt.PostgresHost),
 		zap.String("database", opt.PostgresDatabase))
 
 	cleanup := func() {
+		unregisterStats()
 		if err := db.Close(); err != nil {
 			logger.Error("postgres cleanup failed", zap.Error(err))
 		}
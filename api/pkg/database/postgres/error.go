@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hilo-api/pkg/errorCatcher"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPostgresExecute     = errors.New("[Postgres Execute Failed]")
+	ErrPostgresTransaction = errors.New("[Postgres Transaction Failed]")
+)
+
+// SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	sqlStateNotNullViolation    = "23502"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateUniqueViolation     = "23505"
+	sqlStateCheckViolation      = "23514"
+	sqlStateQueryCanceled       = "57014"
+	sqlStateAdminShutdown       = "57P01"
+	sqlStateCannotConnectNow    = "57P03"
+
+	sqlStateClassDataException       = "22"
+	sqlStateClassConnectionException = "08"
+	sqlStateClassInsufficientRes     = "53"
+	sqlStateClassSyntaxOrAccessRule  = "42"
+)
+
+// ConstraintError describes the constraint Postgres reported as violated
+type ConstraintError struct {
+	Code       string
+	Constraint string
+	Table      string
+	Column     string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("constraint %q on %q violated (SQLSTATE %s): %v", e.Constraint, e.Table, e.Code, e.Err)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError maps a driver error onto the errorCatcher category it belongs to
+func ClassifyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errorCatcher.ErrDatabaseRowNotFound
+	case errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, driver.ErrBadConn):
+		return errorCatcher.ErrDatabaseConnection
+	case errors.Is(err, sql.ErrTxDone),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return errorCatcher.ErrDatabaseExecute
+	}
+
+	code := SQLState(err)
+	switch code {
+	case sqlStateUniqueViolation:
+		return errorCatcher.ErrDatabaseExecuteUniqueViolation
+	case sqlStateForeignKeyViolation:
+		return errorCatcher.ErrDatabaseExecuteForeignKeyViolation
+	case sqlStateNotNullViolation:
+		return errorCatcher.ErrDatabaseExecuteNotNullViolation
+	case sqlStateCheckViolation:
+		return errorCatcher.ErrDatabaseExecuteCheckViolation
+	case sqlStateAdminShutdown, sqlStateCannotConnectNow:
+		return errorCatcher.ErrDatabaseConnection
+	case sqlStateQueryCanceled:
+		return errorCatcher.ErrDatabaseExecute
+	}
+
+	switch {
+	case strings.HasPrefix(code, sqlStateClassConnectionException),
+		strings.HasPrefix(code, sqlStateClassInsufficientRes):
+		return errorCatcher.ErrDatabaseConnection
+	case strings.HasPrefix(code, sqlStateClassDataException):
+		return errorCatcher.ErrDatabaseFormat
+	case strings.HasPrefix(code, sqlStateClassSyntaxOrAccessRule):
+		return errorCatcher.ErrDatabaseVariable
+	}
+	return errorCatcher.ErrDatabaseExecute
+}
+
+// SQLState extracts the SQLSTATE code from a pgx or lib/pq error
+func SQLState(err error) string {
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
+
+// ConstraintName returns the name of the violated constraint, if Postgres reported one
+func ConstraintName(err error) string {
+	if c := constraintOf(err); c != nil {
+		return c.Constraint
+	}
+	return ""
+}
+
+// WrapError classifies err and concatenates it with subject, keeping the
+// violated constraint reachable through errors.As(*ConstraintError). The
+// subject comes first so a catalogued subject outranks the category in
+// errorCatcher.LookupProblem.
+func WrapError(err, subject error) error {
+	if err == nil {
+		return nil
+	}
+	if subject == nil {
+		subject = ErrPostgresExecute
+	}
+
+	cause := err
+	if c := constraintOf(err); c != nil {
+		cause = c
+	}
+	return fmt.Errorf("%w: %w: %w", subject, ClassifyError(err), cause)
+}
+
+func constraintOf(err error) *ConstraintError {
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) {
+		return constraintErr
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName != "" {
+		return &ConstraintError{
+			Code:       pgErr.Code,
+			Constraint: pgErr.ConstraintName,
+			Table:      pgErr.TableName,
+			Column:     pgErr.ColumnName,
+			Err:        err,
+		}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint != "" {
+		return &ConstraintError{
+			Code:       string(pqErr.Code),
+			Constraint: pqErr.Constraint,
+			Table:      pqErr.Table,
+			Column:     pqErr.Column,
+			Err:        err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"hilo-api/pkg/errorCatcher"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no rows", sql.ErrNoRows, errorCatcher.ErrDatabaseRowNotFound},
+		{"wrapped no rows", fmt.Errorf("scan: %w", sql.ErrNoRows), errorCatcher.ErrDatabaseRowNotFound},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, errorCatcher.ErrDatabaseExecuteUniqueViolation},
+		{"foreign key violation", &pq.Error{Code: "23503"}, errorCatcher.ErrDatabaseExecuteForeignKeyViolation},
+		{"not null violation", &pgconn.PgError{Code: "23502"}, errorCatcher.ErrDatabaseExecuteNotNullViolation},
+		{"check violation", &pgconn.PgError{Code: "23514"}, errorCatcher.ErrDatabaseExecuteCheckViolation},
+		{"invalid text representation", &pgconn.PgError{Code: "22P02"}, errorCatcher.ErrDatabaseFormat},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, errorCatcher.ErrDatabaseConnection},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, errorCatcher.ErrDatabaseConnection},
+		{"undefined column", &pgconn.PgError{Code: "42703"}, errorCatcher.ErrDatabaseVariable},
+		{"connection done", sql.ErrConnDone, errorCatcher.ErrDatabaseConnection},
+		{"deadline exceeded", context.DeadlineExceeded, errorCatcher.ErrDatabaseExecute},
+		{"unknown", errors.New("boom"), errorCatcher.ErrDatabaseExecute},
+		{"nil", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestConstraintName(t *testing.T) {
+	assert.Equal(t, "users_email_key", ConstraintName(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}))
+	assert.Equal(t, "users_username_key", ConstraintName(&pq.Error{Code: "23505", Constraint: "users_username_key"}))
+	assert.Equal(t, "", ConstraintName(&pgconn.PgError{Code: "23505"}))
+	assert.Equal(t, "", ConstraintName(errors.New("boom")))
+}
+
+func TestWrapError(t *testing.T) {
+	errSubject := errors.New("username already taken")
+
+	t.Run("nil stays nil", func(t *testing.T) {
+		assert.NoError(t, WrapError(nil, errSubject))
+	})
+
+	t.Run("keeps category, subject, cause and constraint", func(t *testing.T) {
+		pgErr := &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key", TableName: "users"}
+		err := WrapError(pgErr, errSubject)
+
+		assert.ErrorIs(t, err, errorCatcher.ErrDatabaseExecuteUniqueViolation)
+		assert.ErrorIs(t, err, errSubject)
+		assert.ErrorIs(t, err, pgErr)
+
+		var constraintErr *ConstraintError
+		assert.ErrorAs(t, err, &constraintErr)
+		assert.Equal(t, "users_username_key", constraintErr.Constraint)
+		assert.Equal(t, "users", constraintErr.Table)
+		assert.Equal(t, "users_username_key", ConstraintName(err))
+	})
+
+	t.Run("defaults subject", func(t *testing.T) {
+		err := WrapError(sql.ErrNoRows, nil)
+
+		assert.ErrorIs(t, err, errorCatcher.ErrDatabaseRowNotFound)
+		assert.ErrorIs(t, err, ErrPostgresExecute)
+	})
+}
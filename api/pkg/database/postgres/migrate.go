@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"hilo-api/pkg/config"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var ErrPostgresMigrate = errors.New("[Postgres Migrate Failed]")
+
+// Migrator applies golang-migrate files from an fs.FS. It owns a dedicated
+// connection so closing it never touches the application pool.
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// NewMigrator method
+// concurrent migrators serialise on a Postgres advisory lock and give up
+// after opt.PostgresMigrateLockTimeout
+func NewMigrator(logger *zap.Logger, opt config.Postgres, source fs.FS, dir string) (*Migrator, error) {
+	sourceDriver, err := iofs.New(source, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPostgresMigrate, err)
+	}
+
+	db, err := sql.Open("pgx", buildDSN(opt))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPostgresMigrate, err)
+	}
+	databaseDriver, err := migratepgx.WithInstance(db, &migratepgx.Config{
+		LockStrategy: migratepgx.LockStrategyAdvisory,
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrPostgresMigrate, err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", sourceDriver, "pgx", databaseDriver)
+	if err != nil {
+		databaseDriver.Close()
+		return nil, fmt.Errorf("%w: %w", ErrPostgresMigrate, err)
+	}
+	if opt.PostgresMigrateLockTimeout > 0 {
+		m.LockTimeout = opt.PostgresMigrateLockTimeout
+	}
+	if logger != nil {
+		m.Log = migrateLogger{logger: logger}
+	}
+	return &Migrator{migrate: m}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	return migrateError(m.migrate.Up())
+}
+
+// Down rolls back the last n migrations
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: down needs a positive number of steps, got %d", ErrPostgresMigrate, n)
+	}
+	return migrateError(m.migrate.Steps(-n))
+}
+
+// Goto migrates up or down to version
+func (m *Migrator) Goto(version uint) error {
+	return migrateError(m.migrate.Migrate(version))
+}
+
+// Force records version as applied and clean without running anything,
+// the way out of a dirty schema once it has been repaired by hand
+func (m *Migrator) Force(version int) error {
+	return migrateError(m.migrate.Force(version))
+}
+
+// Version returns the applied version; zero means nothing has been applied yet
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, migrateError(err)
+}
+
+// Close releases the dedicated connection
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+func migrateError(err error) error {
+	if err == nil || errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrPostgresMigrate, err)
+}
+
+// CheckSchema reads the applied version and refuses a schema the binary must
+// not run against: dirty or newer than want. An outdated schema is only
+// reported, readiness keeps the instance out of rotation until it catches up.
+func CheckSchema(ctx context.Context, logger *zap.Logger, db *sqlx.DB, want uint) error {
+	current, dirty, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	err = CompareSchemaVersion(current, dirty, want)
+	if errors.Is(err, ErrSchemaOutdated) {
+		logger.Warn("postgres schema is behind the binary", zap.Error(err))
+		return nil
+	}
+	return err
+}
+
+type migrateLogger struct {
+	logger *zap.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), zap.String("system", "migrate"))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateError(t *testing.T) {
+	assert.NoError(t, migrateError(nil))
+	assert.NoError(t, migrateError(migrate.ErrNoChange))
+
+	err := migrateError(migrate.ErrLockTimeout)
+	assert.ErrorIs(t, err, ErrPostgresMigrate)
+	assert.ErrorIs(t, err, migrate.ErrLockTimeout)
+}
+
+func TestMigratorDownNeedsSteps(t *testing.T) {
+	m := &Migrator{}
+	assert.ErrorIs(t, m.Down(0), ErrPostgresMigrate)
+	assert.True(t, errors.Is(m.Down(-1), ErrPostgresMigrate))
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"hilo-api/pkg/ratelimit"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrPostgresRateLimit = errors.New("[Postgres Rate Limit Failed]")
+)
+
+// RateLimitStore shares token buckets between nodes through the rate_limits
+// table, using the database clock so nodes with skewed clocks agree
+type RateLimitStore struct {
+	db *sqlx.DB
+}
+
+// NewRateLimitStore method
+func NewRateLimitStore(db *sqlx.DB) *RateLimitStore {
+	return &RateLimitStore{db: db}
+}
+
+// Take method
+// spends a token in one statement: the update only happens when the refilled
+// bucket holds a token, so a denied request returns no row and leaves the
+// bucket refilling from the last spend
+func (s *RateLimitStore) Take(ctx context.Context, key string, policy ratelimit.Policy) (ratelimit.Result, error) {
+	key = policy.Name + ":" + key
+	capacity, rate := float64(policy.Limit), policy.Rate()
+
+	var tokens float64
+	err := Conn(ctx, s.db).QueryRowContext(ctx, `
+		INSERT INTO rate_limits AS b (key, tokens, updated_at)
+		VALUES ($1, $2::float8 - 1, now())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) - 1,
+		    updated_at = now()
+		WHERE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3::float8) >= 1
+		RETURNING b.tokens
+	`, key, capacity, rate).Scan(&tokens)
+	if err == nil {
+		return ratelimit.Outcome(policy, tokens, true), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, WrapError(err, ErrPostgresRateLimit)
+	}
+
+	err = Conn(ctx, s.db).QueryRowContext(ctx, `
+		SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM now() - updated_at)::float8 * $3::float8)
+		FROM rate_limits
+		WHERE key = $1
+	`, key, capacity, rate).Scan(&tokens)
+	if err != nil {
+		return ratelimit.Result{}, WrapError(err, ErrPostgresRateLimit)
+	}
+	return ratelimit.Outcome(policy, tokens, false), nil
+}
+
+// Prune deletes buckets untouched for longer than olderThan; pass the
+// longest policy period, by then they have refilled and act like new ones
+func (s *RateLimitStore) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	result, err := Conn(ctx, s.db).ExecContext(ctx, `
+		DELETE FROM rate_limits
+		WHERE updated_at < now() - make_interval(secs => $1)
+	`, olderThan.Seconds())
+	if err != nil {
+		return 0, WrapError(err, ErrPostgresRateLimit)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrSchemaDirty    = errors.New("schema is dirty, a migration failed half way")
+	ErrSchemaOutdated = errors.New("schema is older than the binary")
+	ErrSchemaNewer    = errors.New("schema is newer than the binary")
+)
+
+// SchemaVersion reads the version golang-migrate recorded in schema_migrations
+func SchemaVersion(ctx context.Context, db *sqlx.DB) (version uint, dirty bool, err error) {
+	row := Conn(ctx, db).QueryRowxContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if err := row.Scan(&version, &dirty); err != nil {
+		return 0, false, WrapError(err, ErrPostgresExecute)
+	}
+	return version, dirty, nil
+}
+
+// CompareSchemaVersion reports whether a schema at current can serve a binary built for want
+func CompareSchemaVersion(current uint, dirty bool, want uint) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("%w: version %d", ErrSchemaDirty, current)
+	case current < want:
+		return fmt.Errorf("%w: version %d, want %d", ErrSchemaOutdated, current, want)
+	case current > want:
+		return fmt.Errorf("%w: version %d, want %d", ErrSchemaNewer, current, want)
+	}
+	return nil
+}
+
+// PingCheck is a readiness check for the connection pool
+func PingCheck(db *sqlx.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// SchemaCheck is a readiness check that the applied migrations match want
+func SchemaCheck(db *sqlx.DB, want uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		current, dirty, err := SchemaVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		return CompareSchemaVersion(current, dirty, want)
+	}
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSchemaVersion(t *testing.T) {
+	assert.NoError(t, CompareSchemaVersion(20, false, 20))
+	assert.ErrorIs(t, CompareSchemaVersion(20, true, 20), ErrSchemaDirty)
+	assert.ErrorIs(t, CompareSchemaVersion(10, false, 20), ErrSchemaOutdated)
+	assert.ErrorIs(t, CompareSchemaVersion(30, false, 20), ErrSchemaNewer)
+}
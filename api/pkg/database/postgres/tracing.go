@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"hilo-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`([^\w$.])\d+(?:\.\d+)?\b`)
+	sqlWhitespace     = regexp.MustCompile(`\s+`)
+)
+
+// tracedExecutor opens a client span around every statement
+type tracedExecutor struct {
+	Executor
+}
+
+func traced(executor Executor) Executor {
+	return tracedExecutor{Executor: executor}
+}
+
+// ExecContext method
+func (t tracedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer endQuerySpan(span, &err)
+	return t.Executor.ExecContext(ctx, query, args...)
+}
+
+// QueryContext method
+func (t tracedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer endQuerySpan(span, &err)
+	return t.Executor.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext method
+func (t tracedExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.Executor.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	endQuerySpan(span, &err)
+	return row
+}
+
+// QueryxContext method
+func (t tracedExecutor) QueryxContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer endQuerySpan(span, &err)
+	return t.Executor.QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext method
+func (t tracedExecutor) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.Executor.QueryRowxContext(ctx, query, args...)
+	err := row.Err()
+	endQuerySpan(span, &err)
+	return row
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := SanitizeQuery(query)
+	operation := strings.ToUpper(strings.SplitN(statement, " ", 2)[0])
+	_, inTx := TxFromContext(ctx)
+	return tracing.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(statement),
+			attribute.Bool("db.transaction", inTx),
+		),
+	)
+}
+
+func endQuerySpan(span trace.Span, err *error) {
+	// an empty result is an answer, not a failed statement
+	if err != nil && *err != nil && !errors.Is(*err, sql.ErrNoRows) {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, ClassifyError(*err).Error())
+	}
+	span.End()
+}
+
+// SanitizeQuery collapses whitespace and masks inline literals, so statements
+// recorded on spans never carry values even when a query does not use
+// placeholders
+func SanitizeQuery(query string) string {
+	query = sqlStringLiteral.ReplaceAllString(query, "?")
+	query = sqlNumericLiteral.ReplaceAllString(" "+query, "$1?")
+	return strings.TrimSpace(sqlWhitespace.ReplaceAllString(query, " "))
+}
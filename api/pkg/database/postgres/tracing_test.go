@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"SELECT id FROM users WHERE email = $1":                    "SELECT id FROM users WHERE email = $1",
+		"SELECT *\n\t FROM users\n WHERE email = 'a@b.c' LIMIT 10": "SELECT * FROM users WHERE email = ? LIMIT ?",
+		"SELECT 'it''s' AS quote, t1.id FROM t1 WHERE score > 1.5": "SELECT ? AS quote, t1.id FROM t1 WHERE score > ?",
+		"UPDATE messages SET read_at = $2 WHERE id = $1":           "UPDATE messages SET read_at = $2 WHERE id = $1",
+	}
+	for query, want := range tests {
+		assert.Equal(t, want, SanitizeQuery(query))
+	}
+}
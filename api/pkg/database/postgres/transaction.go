@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/logger"
+	"hilo-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// SQLSTATE codes worth retrying a whole transaction for
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+
+	defaultTxMaxRetries = 3
+	defaultTxRetryDelay = 20 * time.Millisecond
+)
+
+type txContextKey struct{}
+
+// Executor is the query surface shared by *sqlx.DB and *sqlx.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+}
+
+// Conn returns the transaction bound to ctx, or db when there is none,
+// traced so every statement becomes a span
+func Conn(ctx context.Context, db *sqlx.DB) Executor {
+	if tx, ok := TxFromContext(ctx); ok {
+		return traced(tx)
+	}
+	return traced(db)
+}
+
+// TxFromContext returns the transaction started by TxManager.WithinTx
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx)
+	return tx, ok
+}
+
+// TxOption interface
+type TxOption interface {
+	Apply(*txConfig)
+}
+
+type txConfig struct {
+	isolation  sql.IsolationLevel
+	readOnly   bool
+	maxRetries int
+}
+
+// WithIsolationLevel method
+func WithIsolationLevel(level sql.IsolationLevel) TxOption {
+	return withIsolationLevel{level: level}
+}
+
+type withIsolationLevel struct {
+	level sql.IsolationLevel
+}
+
+// Apply method
+func (w withIsolationLevel) Apply(c *txConfig) {
+	c.isolation = w.level
+}
+
+// WithReadOnly method
+func WithReadOnly() TxOption {
+	return withReadOnly{}
+}
+
+type withReadOnly struct{}
+
+// Apply method
+func (w withReadOnly) Apply(c *txConfig) {
+	c.readOnly = true
+}
+
+// WithMaxRetries method
+func WithMaxRetries(n int) TxOption {
+	return withMaxRetries{n: n}
+}
+
+type withMaxRetries struct {
+	n int
+}
+
+// Apply method
+func (w withMaxRetries) Apply(c *txConfig) {
+	c.maxRetries = w.n
+}
+
+// TxManager runs functions inside a database transaction carried by the context
+type TxManager struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+// NewTxManager method
+func NewTxManager(db *sqlx.DB, opt config.Postgres) *TxManager {
+	return &TxManager{
+		db:      db,
+		timeout: opt.PostgresTxTimeout,
+	}
+}
+
+// WithinTx runs fn in a transaction. Repositories called with the ctx passed to
+// fn join the transaction through Conn. A nested call joins the outer
+// transaction instead of opening a new one, and serialization failures or
+// deadlocks restart fn from the beginning up to the configured retry count.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, options ...TxOption) (err error) {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "postgres transaction", trace.WithSpanKind(trace.SpanKindClient))
+	defer tracing.End(span, &err)
+
+	cfg := &txConfig{
+		isolation:  sql.LevelDefault,
+		maxRetries: defaultTxMaxRetries,
+	}
+	for _, option := range options {
+		option.Apply(cfg)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = m.run(ctx, fn, cfg)
+		if err == nil || !IsRetryable(err) || attempt >= cfg.maxRetries {
+			return err
+		}
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("sqlstate", SQLState(err)),
+		))
+
+		logger.FromContext(ctx).Warn("retrying postgres transaction",
+			zap.Int("attempt", attempt+1),
+			zap.String("sqlstate", SQLState(err)),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(time.Duration(attempt+1) * defaultTxRetryDelay):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error, cfg *txConfig) (err error) {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	tx, err := m.db.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: cfg.isolation,
+		ReadOnly:  cfg.readOnly,
+	})
+	if err != nil {
+		return WrapError(err, ErrPostgresTransaction)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("postgres rollback failed: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return WrapError(err, ErrPostgresTransaction)
+	}
+	return nil
+}
+
+// IsRetryable reports whether err is a serialization failure or deadlock,
+// in which case the whole transaction may be safely replayed
+func IsRetryable(err error) bool {
+	switch SQLState(err) {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	}
+	return false
+}
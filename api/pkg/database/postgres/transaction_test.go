@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLState(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "pgx error",
+			err:  &pgconn.PgError{Code: "23505"},
+			want: "23505",
+		},
+		{
+			name: "lib/pq error",
+			err:  &pq.Error{Code: "40001"},
+			want: "40001",
+		},
+		{
+			name: "wrapped error",
+			err:  fmt.Errorf("insert failed: %w", &pgconn.PgError{Code: "40P01"}),
+			want: "40P01",
+		},
+		{
+			name: "plain error",
+			err:  errors.New("boom"),
+			want: "",
+		},
+		{
+			name: "nil error",
+			err:  nil,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SQLState(tt.err))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&pgconn.PgError{Code: sqlStateSerializationFailure}))
+	assert.True(t, IsRetryable(&pq.Error{Code: sqlStateDeadlockDetected}))
+	assert.False(t, IsRetryable(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsRetryable(errors.New("boom")))
+}
+
+func TestConn(t *testing.T) {
+	db := &sqlx.DB{}
+	tx := &sqlx.Tx{}
+
+	t.Run("without transaction returns db", func(t *testing.T) {
+		assert.Same(t, db, Conn(context.Background(), db).(tracedExecutor).Executor)
+	})
+
+	t.Run("with transaction returns tx", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), txContextKey{}, tx)
+		assert.Same(t, tx, Conn(ctx, db).(tracedExecutor).Executor)
+	})
+}
+
+func TestWithinTxJoinsOuterTransaction(t *testing.T) {
+	tx := &sqlx.Tx{}
+	ctx := context.WithValue(context.Background(), txContextKey{}, tx)
+
+	// no db is needed: a nested call must not begin a new transaction
+	manager := &TxManager{}
+	called := false
+	err := manager.WithinTx(ctx, func(ctx context.Context) error {
+		called = true
+		got, ok := TxFromContext(ctx)
+		assert.True(t, ok)
+		assert.Same(t, tx, got)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,10 @@
+package definition
+
+const (
+	RequestIDHeader          = "X-Request-ID"
+	RequestIDKey             = "request_id"
+	ContextUserIDKey         = "user_id"
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	UploadOffsetHeader       = "Upload-Offset"
+)
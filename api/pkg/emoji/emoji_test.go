@@ -0,0 +1,46 @@
+package emoji
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	for _, s := range []string{
+		"👍",
+		"❤️",
+		"❤",
+		"😂",
+		"👍🏽",
+		"👨‍👩‍👧‍👦",
+		"🏳️‍🌈",
+		"🇹🇼",
+		"1️⃣",
+		"#⃣",
+		"🏴󠁧󠁢󠁥󠁮󠁧󠁿",
+		"🫠",
+	} {
+		assert.True(t, Valid(s), "%q (%U)", s, []rune(s))
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"a",
+		"ok",
+		"1",
+		"字",
+		"👍👍",
+		"👍 ",
+		"🇹",
+		"👨‍",
+		"‍👍",
+		"🏴󠁧󠁢",
+		strings.Repeat("👨‍", 20) + "👨",
+	} {
+		assert.False(t, Valid(s), "%q (%U)", s, []rune(s))
+	}
+}
@@ -0,0 +1,35 @@
+// Code generated by errcatalog. DO NOT EDIT.
+
+package errorCatcher
+
+func init() {
+	RegisterProblems(
+		ProblemEntry{Err: ErrAuthenticate, Code: "AUTHENTICATE", Title: "Authenticate failed", Status: 401},
+		ProblemEntry{Err: ErrDatabaseConnection, Code: "DATABASE_CONNECTION", Title: "Database connection failed", Status: 503},
+		ProblemEntry{Err: ErrDatabaseDisconnect, Code: "DATABASE_DISCONNECT", Title: "Database disconnect failed", Status: 503},
+		ProblemEntry{Err: ErrDatabaseExecute, Code: "DATABASE_EXECUTE", Title: "Database execute failed", Status: 422},
+		ProblemEntry{Err: ErrDatabaseExecuteCheckViolation, Code: "DATABASE_EXECUTE_CHECK_VIOLATION", Title: "Database execute check violation failed", Status: 422},
+		ProblemEntry{Err: ErrDatabaseExecuteCursor, Code: "DATABASE_EXECUTE_CURSOR", Title: "Database execute cursor failed", Status: 500},
+		ProblemEntry{Err: ErrDatabaseExecuteForeignKeyViolation, Code: "DATABASE_EXECUTE_FOREIGN_KEY_VIOLATION", Title: "Database execute foreign key violation failed", Status: 422},
+		ProblemEntry{Err: ErrDatabaseExecuteMultipleColumnUpdateMustSubSelect, Code: "DATABASE_EXECUTE_MULTIPLE_COLUMN_UPDATE_MUST_SUB_SELECT", Title: "Database execute multiple column update must sub select failed", Status: 422},
+		ProblemEntry{Err: ErrDatabaseExecuteNotNullViolation, Code: "DATABASE_EXECUTE_NOT_NULL_VIOLATION", Title: "Database execute not null violation failed", Status: 422},
+		ProblemEntry{Err: ErrDatabaseExecuteUniqueViolation, Code: "DATABASE_EXECUTE_UNIQUE_VIOLATION", Title: "Database execute unique violation failed", Status: 409},
+		ProblemEntry{Err: ErrDatabaseFormat, Code: "DATABASE_FORMAT", Title: "Database format failed", Status: 400},
+		ProblemEntry{Err: ErrDatabaseMarshal, Code: "DATABASE_MARSHAL", Title: "Database marshal failed", Status: 500},
+		ProblemEntry{Err: ErrDatabaseRowNotFound, Code: "DATABASE_ROW_NOT_FOUND", Title: "Database row not found", Status: 404},
+		ProblemEntry{Err: ErrDatabaseStartSession, Code: "DATABASE_START_SESSION", Title: "Database start session failed", Status: 503},
+		ProblemEntry{Err: ErrDatabaseVariable, Code: "DATABASE_VARIABLE", Title: "Database variable failed", Status: 500},
+		ProblemEntry{Err: ErrExecute, Code: "EXECUTE", Title: "Execute failed", Status: 422},
+		ProblemEntry{Err: ErrGenerateAuthorizationToken, Code: "GENERATE_AUTHORIZATION_TOKEN", Title: "Generate authorization token failed", Status: 500},
+		ProblemEntry{Err: ErrGinBindingAndValidate, Code: "GIN_BINDING_AND_VALIDATE", Title: "Gin binding and validate failed", Status: 400},
+		ProblemEntry{Err: ErrInvalidArguments, Code: "INVALID_ARGUMENTS", Title: "Invalid arguments", Status: 400},
+		ProblemEntry{Err: ErrJSONMarshal, Code: "JSON_MARSHAL", Title: "Json marshal failed", Status: 500},
+		ProblemEntry{Err: ErrJSONUnmarshal, Code: "JSON_UNMARSHAL", Title: "Json unmarshal failed", Status: 500},
+		ProblemEntry{Err: ErrJWTExecute, Code: "JWT_EXECUTE", Title: "Jwt execute failed", Status: 403},
+		ProblemEntry{Err: ErrJWTInitialize, Code: "JWT_INITIALIZE", Title: "Jwt initialize failed", Status: 500},
+		ProblemEntry{Err: ErrPermissionDeny, Code: "PERMISSION_DENY", Title: "Permission deny", Status: 403},
+		ProblemEntry{Err: ErrTooManyRequests, Code: "TOO_MANY_REQUESTS", Title: "Too many requests", Status: 429},
+		ProblemEntry{Err: ErrValidate, Code: "VALIDATE", Title: "Validate failed", Status: 400},
+		ProblemEntry{Err: ErrVariable, Code: "VARIABLE", Title: "Variable type failed", Status: 400},
+	)
+}
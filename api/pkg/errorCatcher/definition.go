@@ -2,31 +2,36 @@ package errorCatcher
 
 import "errors"
 
+//go:generate go run hilo-api/tools/errcatalog -out catalog_gen.go definition.go
+
+// Sentinel error categories; the problem:<status> annotation feeds the
+// generated problem catalog and sets the HTTP status it is rendered with
 var (
-	ErrAuthenticate                                     = errors.New("[AUTHENTICATE FAILED]")
-	ErrPermissionDeny                                   = errors.New("[PERMISSION DENY]")
-	ErrJWTExecute                                       = errors.New("[JWT EXECUTE FAILED]")
-	ErrJWTInitialize                                    = errors.New("[JWT INITIALIZE FAILED]")
-	ErrDatabaseConnection                               = errors.New("[DATABASE CONNECTION FAILED]")
-	ErrDatabaseDisconnect                               = errors.New("[DATABASE DISCONNECT FAILED]")
-	ErrDatabaseStartSession                             = errors.New("[DATABASE START SESSION FAILED]")
-	ErrDatabaseFormat                                   = errors.New("[DATABASE FORMAT FAILED]")
-	ErrDatabaseVariable                                 = errors.New("[DATABASE VARIABLE FAILED]")
-	ErrDatabaseExecute                                  = errors.New("[DATABASE EXECUTE FAILED]")
-	ErrDatabaseExecuteNotNullViolation                  = errors.New("[DATABASE EXECUTE NOT NULL VIOLATION FAILED]")
-	ErrDatabaseExecuteForeignKeyViolation               = errors.New("[DATABASE EXECUTE FOREIGN KEY VIOLATION FAILED]")
-	ErrDatabaseExecuteUniqueViolation                   = errors.New("[DATABASE EXECUTE UNIQUE VIOLATION FAILED]")
-	ErrDatabaseExecuteCheckViolation                    = errors.New("[DATABASE EXECUTE CHECK VIOLATION FAILED]")
-	ErrDatabaseExecuteMultipleColumnUpdateMustSubSelect = errors.New("[DATABASE EXECUTE MULTIPLE COLUMN UPDATE MUST SUB SELECT FAILED]")
-	ErrDatabaseExecuteCursor                            = errors.New("[DATABASE EXECUTE CURSOR FAILED]")
-	ErrDatabaseMarshal                                  = errors.New("[DATABASE MARSHAL FAILED]")
-	ErrDatabaseRowNotFound                              = errors.New("[DATABASE ROW NOT FOUND]")
-	ErrExecute                                          = errors.New("[EXECUTE FAILED]")
-	ErrInvalidArguments                                 = errors.New("[INVALID ARGUMENTS]")
-	ErrValidate                                         = errors.New("[VALIDATE FAILED]")
-	ErrVariable                                         = errors.New("[VARIABLE TYPE FAILED]")
-	ErrGinBindingAndValidate                            = errors.New("[GIN BINDING AND VALIDATE FAILED]")
-	ErrGenerateAuthorizationToken                       = errors.New("[Generate Authorization Token FAILED]")
-	ErrJSONMarshal                                      = errors.New("[JSON MARSHAL FAILED]")
-	ErrJSONUnmarshal                                    = errors.New("[JSON UNMARSHAL FAILED]")
+	ErrAuthenticate                                     = errors.New("[AUTHENTICATE FAILED]")                                            // problem:401
+	ErrPermissionDeny                                   = errors.New("[PERMISSION DENY]")                                                // problem:403
+	ErrJWTExecute                                       = errors.New("[JWT EXECUTE FAILED]")                                             // problem:403
+	ErrJWTInitialize                                    = errors.New("[JWT INITIALIZE FAILED]")                                          // problem:500
+	ErrDatabaseConnection                               = errors.New("[DATABASE CONNECTION FAILED]")                                     // problem:503
+	ErrDatabaseDisconnect                               = errors.New("[DATABASE DISCONNECT FAILED]")                                     // problem:503
+	ErrDatabaseStartSession                             = errors.New("[DATABASE START SESSION FAILED]")                                  // problem:503
+	ErrDatabaseFormat                                   = errors.New("[DATABASE FORMAT FAILED]")                                         // problem:400
+	ErrDatabaseVariable                                 = errors.New("[DATABASE VARIABLE FAILED]")                                       // problem:500
+	ErrDatabaseExecute                                  = errors.New("[DATABASE EXECUTE FAILED]")                                        // problem:422
+	ErrDatabaseExecuteNotNullViolation                  = errors.New("[DATABASE EXECUTE NOT NULL VIOLATION FAILED]")                     // problem:422
+	ErrDatabaseExecuteForeignKeyViolation               = errors.New("[DATABASE EXECUTE FOREIGN KEY VIOLATION FAILED]")                  // problem:422
+	ErrDatabaseExecuteUniqueViolation                   = errors.New("[DATABASE EXECUTE UNIQUE VIOLATION FAILED]")                       // problem:409
+	ErrDatabaseExecuteCheckViolation                    = errors.New("[DATABASE EXECUTE CHECK VIOLATION FAILED]")                        // problem:422
+	ErrDatabaseExecuteMultipleColumnUpdateMustSubSelect = errors.New("[DATABASE EXECUTE MULTIPLE COLUMN UPDATE MUST SUB SELECT FAILED]") // problem:422
+	ErrDatabaseExecuteCursor                            = errors.New("[DATABASE EXECUTE CURSOR FAILED]")                                 // problem:500
+	ErrDatabaseMarshal                                  = errors.New("[DATABASE MARSHAL FAILED]")                                        // problem:500
+	ErrDatabaseRowNotFound                              = errors.New("[DATABASE ROW NOT FOUND]")                                         // problem:404
+	ErrExecute                                          = errors.New("[EXECUTE FAILED]")                                                 // problem:422
+	ErrInvalidArguments                                 = errors.New("[INVALID ARGUMENTS]")                                              // problem:400
+	ErrValidate                                         = errors.New("[VALIDATE FAILED]")                                                // problem:400
+	ErrVariable                                         = errors.New("[VARIABLE TYPE FAILED]")                                           // problem:400
+	ErrGinBindingAndValidate                            = errors.New("[GIN BINDING AND VALIDATE FAILED]")                                // problem:400
+	ErrGenerateAuthorizationToken                       = errors.New("[Generate Authorization Token FAILED]")                            // problem:500
+	ErrJSONMarshal                                      = errors.New("[JSON MARSHAL FAILED]")                                            // problem:500
+	ErrJSONUnmarshal                                    = errors.New("[JSON UNMARSHAL FAILED]")                                          // problem:500
+	ErrTooManyRequests                                  = errors.New("[TOO MANY REQUESTS]")                                              // problem:429
 )
@@ -3,7 +3,9 @@ package errorCatcher
 import (
 	"errors"
 	"fmt"
-	"net/http"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/i18n"
+	zapLogger "hilo-api/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -23,57 +25,58 @@ func PanicErrorHandler(logger *zap.Logger, prefixMessage string) {
 	}
 }
 
+// GinPanicErrorHandler func
+// recovers panics raised by handlers and renders them as application/problem+json
 func GinPanicErrorHandler(logger *zap.Logger, prefixMessage string) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				logger := requestLogger(c, logger)
 				switch e := err.(type) {
 				case error:
-					statusCode := http.StatusUnauthorized
 					logger.Error(prefixMessage, zap.Error(e))
-					switch {
-					case errors.Is(e, ErrValidate),
-						errors.Is(e, ErrVariable),
-						errors.Is(e, ErrGinBindingAndValidate),
-						errors.Is(e, ErrInvalidArguments):
-						statusCode = http.StatusBadRequest
-						break
-					case errors.Is(e, ErrAuthenticate):
-						statusCode = http.StatusUnauthorized
-						break
-					case errors.Is(e, ErrPermissionDeny),
-						errors.Is(e, ErrJWTExecute):
-						statusCode = http.StatusForbidden
-						break
-					case errors.Is(e, ErrDatabaseRowNotFound):
-						statusCode = http.StatusNotFound
-						break
-					case errors.Is(e, ErrExecute),
-						errors.Is(e, ErrDatabaseExecute),
-						errors.Is(e, ErrDatabaseExecuteNotNullViolation),
-						errors.Is(e, ErrDatabaseExecuteForeignKeyViolation),
-						errors.Is(e, ErrDatabaseExecuteUniqueViolation),
-						errors.Is(e, ErrDatabaseExecuteCheckViolation),
-						errors.Is(e, ErrDatabaseExecuteMultipleColumnUpdateMustSubSelect):
-						statusCode = http.StatusUnprocessableEntity
-						break
-					case errors.Is(e, ErrJSONMarshal),
-						errors.Is(e, ErrJSONUnmarshal):
-						statusCode = http.StatusInternalServerError
-						break
-					case errors.Is(e, ErrDatabaseConnection),
-						errors.Is(e, ErrDatabaseDisconnect):
-						statusCode = http.StatusServiceUnavailable
-						break
-					}
-					c.AbortWithError(statusCode, fmt.Errorf("%s: %w", prefixMessage, err.(error)))
-					break
+					abortWithProblem(c, e, fmt.Errorf("%s: %w", prefixMessage, e))
 				default:
 					logger.Error(prefixMessage, zap.Any("data", e))
-					c.AbortWithError(http.StatusInternalServerError, errors.New(prefixMessage))
+					abortWithProblem(c, nil, errors.New(prefixMessage))
 				}
 			}
 		}()
 		c.Next()
 	}
 }
+
+// requestLogger prefers the request-scoped logger carrying the request id
+func requestLogger(c *gin.Context, fallback *zap.Logger) *zap.Logger {
+	if reqLogger, ok := zapLogger.Lookup(c.Request.Context()); ok {
+		return reqLogger
+	}
+	return fallback
+}
+
+// AbortWithProblem renders err as problem details in the locale negotiated
+// from Accept-Language and aborts the chain
+func AbortWithProblem(c *gin.Context, err error) {
+	abortWithProblem(c, err, err)
+}
+
+func abortWithProblem(c *gin.Context, cause, err error) {
+	_ = c.Error(err)
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+
+	locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	problem := NewLocalizedProblem(cause, locale)
+
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = c.Writer.Header().Get(definition.RequestIDHeader)
+	if problem.RequestID == "" {
+		problem.RequestID = c.GetHeader(definition.RequestIDHeader)
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", string(locale))
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
@@ -1,6 +1,7 @@
 package errorCatcher
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -204,6 +205,33 @@ func (suite *HandlerSuite) TestGinPanicErrorHandler_PassErrDatabaseRowNotFound_S
 	suite.Equal("[DATABASE ROW NOT FOUND]: test error subject: got error", firstLog.Context[0].Interface.(error).Error())
 }
 
+func (suite *HandlerSuite) TestGinPanicErrorHandler_PassErrDatabaseExecuteUniqueViolation_ShouldStatusCodeGetStatusConflict() {
+	gin.SetMode(gin.ReleaseMode)
+	route := gin.New()
+	route.Use(gin.Logger(), GinPanicErrorHandler(suite.logger, "error Gin mock"))
+	route.GET("/", func(c *gin.Context) {
+		PanicIfErr(errors.New("got error"), ErrDatabaseExecuteUniqueViolation, errors.New("test error subject"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, req)
+	result := w.Result()
+	defer result.Body.Close()
+	suite.Equal(http.StatusConflict, result.StatusCode)
+	suite.Equal(ProblemContentType, result.Header.Get("Content-Type"))
+
+	problem := Problem{}
+	suite.NoError(json.NewDecoder(result.Body).Decode(&problem))
+	suite.Equal("DATABASE_EXECUTE_UNIQUE_VIOLATION", problem.Code)
+	suite.Equal(http.StatusConflict, problem.Status)
+	suite.Equal("/", problem.Instance)
+
+	suite.Equal(1, suite.obLog.Len())
+	firstLog := suite.obLog.All()[0]
+	suite.Equal("error Gin mock", firstLog.Message)
+	suite.Equal("[DATABASE EXECUTE UNIQUE VIOLATION FAILED]: test error subject: got error", firstLog.Context[0].Interface.(error).Error())
+}
+
 func (suite *HandlerSuite) TestGinPanicErrorHandler_PassErrExecute_ShouldStatusCodeGetStatusUnprocessableEntity() {
 	gin.SetMode(gin.ReleaseMode)
 	route := gin.New()
@@ -327,3 +355,25 @@ func (suite *HandlerSuite) TestGinPanicErrorHandler_PassStringError_ShouldStatus
 func TestHandlerSuite(t *testing.T) {
 	suite.Run(t, new(HandlerSuite))
 }
+
+func (suite *HandlerSuite) TestGinPanicErrorHandler_AcceptLanguage_ShouldLocalizeProblem() {
+	gin.SetMode(gin.ReleaseMode)
+	route := gin.New()
+	route.Use(GinPanicErrorHandler(suite.logger, "error Gin mock"))
+	route.GET("/", func(c *gin.Context) {
+		PanicIfErr(errors.New("got error"), ErrDatabaseRowNotFound, errors.New("test error subject"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "zh-TW,zh;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, req)
+	result := w.Result()
+	defer result.Body.Close()
+	suite.Equal(http.StatusNotFound, result.StatusCode)
+	suite.Equal("zh-TW", result.Header.Get("Content-Language"))
+
+	problem := Problem{}
+	suite.NoError(json.NewDecoder(result.Body).Decode(&problem))
+	suite.Equal("DATABASE_ROW_NOT_FOUND", problem.Code)
+	suite.Equal("找不到指定的資源", problem.Title)
+}
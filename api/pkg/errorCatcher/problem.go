@@ -0,0 +1,196 @@
+package errorCatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hilo-api/pkg/i18n"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:hilo:problem:"
+)
+
+// ProblemEntry binds a sentinel error to its stable code, title and HTTP status
+type ProblemEntry struct {
+	Err    error
+	Code   string
+	Title  string
+	Status int
+}
+
+var (
+	problemMu      sync.RWMutex
+	problemCatalog = map[error]ProblemEntry{}
+
+	// InternalProblem is used for anything that is not in the catalog
+	InternalProblem = ProblemEntry{Code: "INTERNAL_ERROR", Title: "Internal server error", Status: 500}
+)
+
+// RegisterProblems adds entries to the catalog, usually from generated catalog_gen.go files
+func RegisterProblems(entries ...ProblemEntry) {
+	problemMu.Lock()
+	defer problemMu.Unlock()
+	for _, entry := range entries {
+		problemCatalog[entry.Err] = entry
+	}
+}
+
+// Problems returns a snapshot of the catalog
+func Problems() []ProblemEntry {
+	problemMu.RLock()
+	defer problemMu.RUnlock()
+	entries := make([]ProblemEntry, 0, len(problemCatalog))
+	for _, entry := range problemCatalog {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// LookupProblem walks the error tree depth first and returns the first
+// catalogued error, so the outermost (most specific) wrap wins
+func LookupProblem(err error) (ProblemEntry, bool) {
+	problemMu.RLock()
+	defer problemMu.RUnlock()
+	return lookupProblem(err)
+}
+
+func lookupProblem(err error) (ProblemEntry, bool) {
+	if err == nil {
+		return ProblemEntry{}, false
+	}
+	if reflect.TypeOf(err).Comparable() {
+		if entry, ok := problemCatalog[err]; ok {
+			return entry, true
+		}
+	}
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return lookupProblem(x.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			if entry, ok := lookupProblem(e); ok {
+				return entry, true
+			}
+		}
+	}
+	return ProblemEntry{}, false
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem builds the problem details for err from the catalog in the default locale
+func NewProblem(err error) *Problem {
+	return NewLocalizedProblem(err, i18n.Default)
+}
+
+// NewLocalizedProblem builds the problem details for err with the title,
+// detail and field messages translated into locale. Codes missing from the
+// message catalog keep the title generated from the sentinel error.
+func NewLocalizedProblem(err error, locale i18n.Locale) *Problem {
+	entry, ok := LookupProblem(err)
+	if !ok {
+		entry = InternalProblem
+	}
+
+	problem := &Problem{
+		Type:   problemTypePrefix + strings.ToLower(strings.ReplaceAll(entry.Code, "_", "-")),
+		Title:  entry.Title,
+		Status: entry.Status,
+		Detail: entry.Title,
+		Code:   entry.Code,
+	}
+	if title, translated := i18n.Lookup(locale, "problem."+entry.Code); translated {
+		problem.Title = title
+		problem.Detail = title
+	} else if ok && !strings.HasPrefix(entry.Err.Error(), "[") {
+		problem.Detail = entry.Err.Error()
+	}
+
+	if fields := LocalizedFieldErrors(err, locale); len(fields) > 0 {
+		problem.Errors = fields
+		problem.Detail = i18n.T(locale, "problem.detail.validation", map[string]string{"count": strconv.Itoa(len(fields))})
+	} else if syntaxErr := new(json.SyntaxError); errors.As(err, &syntaxErr) {
+		problem.Detail = i18n.T(locale, "problem.detail.malformed_json", nil)
+	}
+	return problem
+}
+
+// FieldErrors translates validator and JSON decoding errors into field errors
+// in the default locale
+func FieldErrors(err error) []FieldError {
+	return LocalizedFieldErrors(err, i18n.Default)
+}
+
+// LocalizedFieldErrors translates validator and JSON decoding errors into field errors
+func LocalizedFieldErrors(err error, locale i18n.Locale) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: fieldMessage(fe, locale),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Param:   typeErr.Type.String(),
+			Message: i18n.T(locale, "validation.type", map[string]string{"param": typeErr.Type.String()}),
+		}}
+	}
+	return nil
+}
+
+// fieldMessage picks validation.<tag>, or validation.<tag>_string when the
+// rule counts characters, falling back to validation.default
+func fieldMessage(fe validator.FieldError, locale i18n.Locale) string {
+	params := map[string]string{"tag": fe.Tag(), "param": fe.Param()}
+
+	tag := fe.Tag()
+	switch tag {
+	case "uuid4":
+		tag = "uuid"
+	case "min", "max", "len":
+		if fe.Kind() == reflect.String {
+			tag += "_string"
+		}
+	}
+	if message, ok := i18n.Lookup(locale, "validation."+tag); ok {
+		return i18n.Format(message, params)
+	}
+	return i18n.T(locale, "validation.default", params)
+}
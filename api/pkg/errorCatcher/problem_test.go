@@ -0,0 +1,104 @@
+package errorCatcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"hilo-api/pkg/i18n"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProblemSuite struct {
+	suite.Suite
+	errSpecific error
+}
+
+func (suite *ProblemSuite) SetupSuite() {
+	suite.errSpecific = errors.New("name already taken")
+	RegisterProblems(ProblemEntry{Err: suite.errSpecific, Code: "NAME_ALREADY_TAKEN", Title: "Name already taken", Status: http.StatusConflict})
+}
+
+func (suite *ProblemSuite) TestCatalogGeneratedFromDefinition() {
+	entry, ok := LookupProblem(ErrDatabaseRowNotFound)
+	suite.True(ok)
+	suite.Equal("DATABASE_ROW_NOT_FOUND", entry.Code)
+	suite.Equal(http.StatusNotFound, entry.Status)
+
+	entry, ok = LookupProblem(ErrJWTExecute)
+	suite.True(ok)
+	suite.Equal("JWT_EXECUTE", entry.Code)
+}
+
+func (suite *ProblemSuite) TestLookupProblemOutermostWins() {
+	err := fmt.Errorf("%w: %w", suite.errSpecific, ConcatError(ErrDatabaseExecuteUniqueViolation, errors.New("subject"), errors.New("cause")))
+	entry, ok := LookupProblem(err)
+	suite.True(ok)
+	suite.Equal("NAME_ALREADY_TAKEN", entry.Code)
+}
+
+func (suite *ProblemSuite) TestLookupProblemNested() {
+	err := fmt.Errorf("handler: %w", ConcatError(ErrPermissionDeny, errors.New("subject"), errors.New("cause")))
+	entry, ok := LookupProblem(err)
+	suite.True(ok)
+	suite.Equal(http.StatusForbidden, entry.Status)
+}
+
+func (suite *ProblemSuite) TestNewProblemUnknownError() {
+	problem := NewProblem(errors.New("something broke"))
+	suite.Equal(http.StatusInternalServerError, problem.Status)
+	suite.Equal("INTERNAL_ERROR", problem.Code)
+	suite.Equal("urn:hilo:problem:internal-error", problem.Type)
+	suite.NotContains(problem.Detail, "something broke")
+}
+
+func (suite *ProblemSuite) TestNewProblemDetail() {
+	problem := NewProblem(fmt.Errorf("%w: %w", suite.errSpecific, errors.New("pq: duplicate key")))
+	suite.Equal("name already taken", problem.Detail)
+	suite.NotContains(problem.Detail, "pq")
+}
+
+func (suite *ProblemSuite) TestNewProblemFieldErrors() {
+	type request struct {
+		Content string `validate:"required,max=5"`
+	}
+	err := validator.New().Struct(request{Content: "too long"})
+	problem := NewProblem(ConcatError(ErrGinBindingAndValidate, errors.New("subject"), err))
+
+	suite.Equal(http.StatusBadRequest, problem.Status)
+	suite.Len(problem.Errors, 1)
+	suite.Equal("Content", problem.Errors[0].Field)
+	suite.Equal("max", problem.Errors[0].Code)
+	suite.Equal("must be at most 5 characters", problem.Errors[0].Message)
+}
+
+func (suite *ProblemSuite) TestNewLocalizedProblem() {
+	type request struct {
+		Content string `validate:"required,max=5"`
+	}
+	err := validator.New().Struct(request{Content: "too long"})
+	problem := NewLocalizedProblem(ConcatError(ErrGinBindingAndValidate, errors.New("subject"), err), i18n.TraditionalChinese)
+
+	suite.Equal("請求驗證失敗", problem.Title)
+	suite.Equal("共有 1 個欄位驗證失敗", problem.Detail)
+	suite.Equal("長度不可超過 5 個字元", problem.Errors[0].Message)
+}
+
+func (suite *ProblemSuite) TestCatalogTranslated() {
+	for _, locale := range i18n.Locales() {
+		for _, entry := range Problems() {
+			if entry.Err == suite.errSpecific {
+				continue
+			}
+			_, ok := i18n.Lookup(locale, "problem."+entry.Code)
+			suite.True(ok, "%s has no %s translation", entry.Code, locale)
+		}
+	}
+}
+
+func TestProblemSuite(t *testing.T) {
+	suite.Run(t, new(ProblemSuite))
+}
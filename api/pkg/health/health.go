@@ -0,0 +1,178 @@
+// Package health serves liveness and readiness probes. Liveness only says the
+// process is running; readiness runs every registered dependency check and
+// turns not-ready once Drain is called so load balancers stop routing to a
+// pod that is shutting down.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusAlive    = "alive"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+	StatusUp       = "up"
+	StatusDown     = "down"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check reports a dependency failure by returning an error
+type Check func(ctx context.Context) error
+
+// Option interface
+type Option interface {
+	Apply(*Health)
+}
+
+// WithCheck method
+func WithCheck(name string, check Check) Option {
+	return withCheck{name: name, check: check}
+}
+
+type withCheck struct {
+	name  string
+	check Check
+}
+
+// Apply method
+func (w withCheck) Apply(h *Health) {
+	h.checks = append(h.checks, namedCheck{name: w.name, check: w.check})
+}
+
+// WithTimeout method
+func WithTimeout(timeout time.Duration) Option {
+	return withTimeout{timeout: timeout}
+}
+
+type withTimeout struct {
+	timeout time.Duration
+}
+
+// Apply method
+func (w withTimeout) Apply(h *Health) {
+	h.timeout = w.timeout
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the readiness response body
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Health type
+type Health struct {
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewHealth method
+func NewHealth(options ...Option) *Health {
+	h := &Health{timeout: defaultTimeout}
+	for _, option := range options {
+		option.Apply(h)
+	}
+	return h
+}
+
+// Drain marks the service as not ready for the rest of its life
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Report runs all checks concurrently, each bounded by the configured timeout
+func (h *Health) Report(ctx context.Context) Report {
+	if h.Draining() {
+		return Report{Status: StatusDraining}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func(i int, c namedCheck) {
+			defer wg.Done()
+			results[i] = run(ctx, c.check)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: make(map[string]CheckResult, len(h.checks))}
+	for i, c := range h.checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, check Check) CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// a check ignoring ctx must not hold the probe past its deadline
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:     StatusUp,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness answers 200 as long as the process can serve HTTP
+func (h *Health) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusAlive})
+}
+
+// Readiness answers 200 with the check report when every check passes and
+// 503 otherwise, including while draining
+func (h *Health) Readiness(c *gin.Context) {
+	report := h.Report(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthSuite struct {
+	suite.Suite
+}
+
+func (suite *HealthSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *HealthSuite) readyz(h *Health) (int, Report) {
+	route := gin.New()
+	route.GET("/readyz", h.Readiness)
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	report := Report{}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func (suite *HealthSuite) TestLiveness() {
+	route := gin.New()
+	route.GET("/healthz", NewHealth(WithCheck("broken", func(ctx context.Context) error {
+		return errors.New("down")
+	})).Liveness)
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"status":"alive"}`, w.Body.String())
+}
+
+func (suite *HealthSuite) TestReady() {
+	code, report := suite.readyz(NewHealth(
+		WithCheck("postgres", func(ctx context.Context) error { return nil }),
+		WithCheck("keyring", func(ctx context.Context) error { return nil }),
+	))
+	suite.Equal(http.StatusOK, code)
+	suite.Equal(StatusReady, report.Status)
+	suite.Equal(StatusUp, report.Checks["postgres"].Status)
+	suite.Equal(StatusUp, report.Checks["keyring"].Status)
+}
+
+func (suite *HealthSuite) TestNotReady() {
+	code, report := suite.readyz(NewHealth(
+		WithCheck("postgres", func(ctx context.Context) error { return errors.New("connection refused") }),
+		WithCheck("keyring", func(ctx context.Context) error { return nil }),
+	))
+	suite.Equal(http.StatusServiceUnavailable, code)
+	suite.Equal(StatusNotReady, report.Status)
+	suite.Equal(StatusDown, report.Checks["postgres"].Status)
+	suite.Equal("connection refused", report.Checks["postgres"].Error)
+	suite.Equal(StatusUp, report.Checks["keyring"].Status)
+}
+
+func (suite *HealthSuite) TestCheckTimeout() {
+	block := make(chan struct{})
+	defer close(block)
+
+	start := time.Now()
+	code, report := suite.readyz(NewHealth(
+		WithTimeout(20*time.Millisecond),
+		WithCheck("stuck", func(ctx context.Context) error { <-block; return nil }),
+	))
+	suite.Less(time.Since(start), time.Second)
+	suite.Equal(http.StatusServiceUnavailable, code)
+	suite.Equal(context.DeadlineExceeded.Error(), report.Checks["stuck"].Error)
+}
+
+func (suite *HealthSuite) TestDrain() {
+	called := false
+	h := NewHealth(WithCheck("postgres", func(ctx context.Context) error { called = true; return nil }))
+	h.Drain()
+
+	code, report := suite.readyz(h)
+	suite.Equal(http.StatusServiceUnavailable, code)
+	suite.Equal(StatusDraining, report.Status)
+	suite.False(called)
+}
+
+func TestHealthSuite(t *testing.T) {
+	suite.Run(t, new(HealthSuite))
+}
@@ -0,0 +1,126 @@
+// Package i18n holds the message catalogs shipped with the API and picks the
+// one a client asked for through Accept-Language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Locale is a BCP 47 tag of a shipped catalog
+type Locale string
+
+const (
+	English            Locale = "en"
+	TraditionalChinese Locale = "zh-TW"
+
+	// Default is served to clients asking for a language we do not ship
+	Default = English
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	catalogs = mustLoadCatalogs()
+
+	// the first tag is the fallback of the matcher
+	supported = []language.Tag{language.English, language.TraditionalChinese}
+	matcher   = language.NewMatcher(supported)
+	locales   = map[language.Tag]Locale{
+		language.English:            English,
+		language.TraditionalChinese: TraditionalChinese,
+	}
+)
+
+func mustLoadCatalogs() map[Locale]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Errorf("i18n: %w", err))
+	}
+
+	loaded := make(map[Locale]map[string]string, len(entries))
+	for _, entry := range entries {
+		raw, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Errorf("i18n: %w", err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Errorf("i18n: %s: %w", entry.Name(), err))
+		}
+		loaded[Locale(strings.TrimSuffix(entry.Name(), ".json"))] = messages
+	}
+	return loaded
+}
+
+// Locales returns the shipped locales in a stable order
+func Locales() []Locale {
+	list := make([]Locale, 0, len(catalogs))
+	for locale := range catalogs {
+		list = append(list, locale)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// Keys returns the sorted message keys of a locale
+func Keys(locale Locale) []string {
+	keys := make([]string, 0, len(catalogs[locale]))
+	for key := range catalogs[locale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Negotiate picks the best shipped locale for an Accept-Language header,
+// falling back to Default for unknown or malformed values
+func Negotiate(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return locales[supported[index]]
+}
+
+// Lookup returns the message of key in locale, trying Default before giving up
+func Lookup(locale Locale, key string) (string, bool) {
+	if message, ok := catalogs[locale][key]; ok {
+		return message, true
+	}
+	message, ok := catalogs[Default][key]
+	return message, ok
+}
+
+// T translates key and fills its {name} placeholders from params.
+// Unknown keys are returned as is so a missing translation stays visible.
+func T(locale Locale, key string, params map[string]string) string {
+	message, ok := Lookup(locale, key)
+	if !ok {
+		return key
+	}
+	return Format(message, params)
+}
+
+// Format replaces the {name} placeholders of message
+func Format(message string, params map[string]string) string {
+	if len(params) == 0 {
+		return message
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	locales := Locales()
+	assert.Contains(t, locales, English)
+	assert.Contains(t, locales, TraditionalChinese)
+
+	want := Keys(Default)
+	for _, locale := range locales {
+		assert.Equal(t, want, Keys(locale), "catalog %s", locale)
+	}
+}
+
+func TestCatalogsHaveSamePlaceholders(t *testing.T) {
+	for _, locale := range Locales() {
+		for _, key := range Keys(Default) {
+			want, _ := Lookup(Default, key)
+			got, _ := Lookup(locale, key)
+			assert.ElementsMatch(t, placeholder.FindAllString(want, -1), placeholder.FindAllString(got, -1), "%s in %s", key, locale)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]Locale{
+		"":                            English,
+		"zh-TW":                       TraditionalChinese,
+		"zh-Hant":                     TraditionalChinese,
+		"zh-Hant-HK,zh;q=0.8":         TraditionalChinese,
+		"en-US,en;q=0.9":              English,
+		"fr-FR,de;q=0.8":              English,
+		"ja,zh-TW;q=0.9,en;q=0.8":     TraditionalChinese,
+		"not a language header;;;q=x": English,
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, Negotiate(header), header)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "must be at most 5 characters", T(English, "validation.max_string", map[string]string{"param": "5"}))
+	assert.Equal(t, "長度不可超過 5 個字元", T(TraditionalChinese, "validation.max_string", map[string]string{"param": "5"}))
+	assert.Equal(t, "unknown.key", T(TraditionalChinese, "unknown.key", nil))
+}
@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// base83 is the alphabet of blurhash strings
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Blurhash encodes img as a blurhash (https://blurha.sh) of xComponents by
+// yComponents cosine components, each 1 to 9. It visits every pixel per
+// component, so callers pass a thumbnail, not the original.
+func Blurhash(img *image.NRGBA, xComponents, yComponents int) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", fmt.Errorf("blurhash components must be 1 to 9, got %dx%d", xComponents, yComponents)
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return "", fmt.Errorf("blurhash of an empty image")
+	}
+
+	// linear light once per pixel instead of once per pixel and component
+	pixels := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl := opaque(img.NRGBAAt(b.Min.X+x, b.Min.Y+y))
+			pixels[y*w+x] = [3]float64{linear(r), linear(g), linear(bl)}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := 0; y < h; y++ {
+				cy := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * cy
+					for c := range factor {
+						factor[c] += basis * pixels[y*w+x][c]
+					}
+				}
+			}
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	encode83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximum := 1.0
+	if len(ac) > 0 {
+		actual := 0.0
+		for _, factor := range ac {
+			actual = max(actual, math.Abs(factor[0]), math.Abs(factor[1]), math.Abs(factor[2]))
+		}
+		quantised := int(max(0, min(82, math.Floor(actual*166-0.5))))
+		maximum = float64(quantised+1) / 166
+		encode83(&hash, quantised, 1)
+	} else {
+		encode83(&hash, 0, 1)
+	}
+
+	encode83(&hash, srgb(dc[0])<<16|srgb(dc[1])<<8|srgb(dc[2]), 4)
+	for _, factor := range ac {
+		quant := func(v float64) int {
+			return int(max(0, min(18, math.Floor(signPow(v/maximum, 0.5)*9+9.5))))
+		}
+		encode83(&hash, quant(factor[0])*19*19+quant(factor[1])*19+quant(factor[2]), 2)
+	}
+	return hash.String(), nil
+}
+
+// srgb converts linear light back to an 8 bit sRGB channel
+func srgb(v float64) int {
+	v = max(0, min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+func encode83(b *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := value / int(math.Pow(83, float64(length-i))) % 83
+		b.WriteByte(base83[digit])
+	}
+}
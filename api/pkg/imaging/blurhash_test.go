@@ -0,0 +1,25 @@
+package imaging
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlurhash(t *testing.T) {
+	// sampled cosines of odd frequency do not cancel out, so even a solid
+	// image has some AC; the DC, TSUA, is white
+	hash, err := Blurhash(solid(32, 24, color.NRGBA{R: 255, G: 255, B: 255, A: 255}), 4, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "LDTSUA_3fQ_3~qoffQoffQfQfQfQ", hash)
+
+	// 1 + 1 + 4 characters and 2 per AC component
+	hash, err = Blurhash(solid(10, 10, color.NRGBA{R: 200, G: 30, B: 90, A: 255}), 3, 4)
+	require.NoError(t, err)
+	assert.Len(t, hash, 6+2*11)
+
+	_, err = Blurhash(solid(4, 4, color.NRGBA{}), 10, 3)
+	assert.Error(t, err)
+}
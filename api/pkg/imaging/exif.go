@@ -0,0 +1,217 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+// ScrubLocation blanks the GPS block of the EXIF metadata in a JPEG, PNG
+// or WebP file in place, so offsets in the rest of the file stay valid. It
+// reports whether there was a location to remove.
+func ScrubLocation(data []byte) bool {
+	tiff, fix := locateExif(data)
+	if tiff == nil {
+		return false
+	}
+	if !scrubGPS(tiff) {
+		return false
+	}
+	if fix != nil {
+		fix()
+	}
+	return true
+}
+
+// Orientation reads the EXIF orientation, 1 to 8, of a JPEG, PNG or WebP
+// file; 1, upright, when there is none
+func Orientation(data []byte) int {
+	tiff, _ := locateExif(data)
+	if tiff == nil {
+		return 1
+	}
+	t, ok := parseTIFF(tiff)
+	if !ok {
+		return 1
+	}
+	entry, ok := t.find(t.u32(4), tagOrientation)
+	if !ok || t.u16(entry+2) != 3 {
+		return 1
+	}
+	if o := int(t.u16(entry + 8)); o >= 1 && o <= 8 {
+		return o
+	}
+	return 1
+}
+
+// locateExif finds the TIFF structure holding the EXIF metadata, and what
+// has to run after it changed, the PNG chunk checksum
+func locateExif(data []byte) (tiff []byte, fix func()) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return jpegExif(data), nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngExif(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpExif(data), nil
+	}
+	return nil, nil
+}
+
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		// start of scan, the metadata segments all come before it
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+func pngExif(data []byte) ([]byte, func()) {
+	for i := 8; i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, nil
+		}
+		if string(data[i+4:i+8]) == "eXIf" {
+			chunk := data[i+4 : i+8+length]
+			crc := data[i+8+length : end]
+			return chunk[4:], func() {
+				binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk))
+			}
+		}
+		if string(data[i+4:i+8]) == "IDAT" {
+			return nil, nil
+		}
+		i = end
+	}
+	return nil, nil
+}
+
+func webpExif(data []byte) []byte {
+	for i := 12; i+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + length
+		if length < 0 || end > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == "EXIF" {
+			// some writers keep the JPEG prefix
+			return bytes.TrimPrefix(data[i+8:end], []byte("Exif\x00\x00"))
+		}
+		i = end + length%2
+	}
+	return nil
+}
+
+// tiff reads the header and directories of EXIF metadata
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func parseTIFF(data []byte) (tiff, bool) {
+	if len(data) < 8 {
+		return tiff{}, false
+	}
+	switch string(data[:4]) {
+	case "II*\x00":
+		return tiff{data: data, order: binary.LittleEndian}, true
+	case "MM\x00*":
+		return tiff{data: data, order: binary.BigEndian}, true
+	}
+	return tiff{}, false
+}
+
+func (t tiff) u16(at int) uint16 {
+	if at < 0 || at+2 > len(t.data) {
+		return 0
+	}
+	return t.order.Uint16(t.data[at:])
+}
+
+func (t tiff) u32(at int) int {
+	if at < 0 || at+4 > len(t.data) {
+		return 0
+	}
+	return int(t.order.Uint32(t.data[at:]))
+}
+
+// entries is the number of entries of the directory at offset, 0 when it
+// does not fit the data
+func (t tiff) entries(offset int) int {
+	if offset < 8 || offset+2 > len(t.data) {
+		return 0
+	}
+	n := int(t.u16(offset))
+	if offset+2+12*n+4 > len(t.data) {
+		return 0
+	}
+	return n
+}
+
+// find returns the position of the entry with tag in the directory at offset
+func (t tiff) find(offset int, tag uint16) (int, bool) {
+	for i := range t.entries(offset) {
+		entry := offset + 2 + 12*i
+		if t.u16(entry) == tag {
+			return entry, true
+		}
+	}
+	return 0, false
+}
+
+// typeSizes are the byte sizes of the TIFF field types
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// scrubGPS zeroes the values the GPS directory points at and then the
+// directory itself, leaving an empty directory behind
+func scrubGPS(data []byte) bool {
+	t, ok := parseTIFF(data)
+	if !ok {
+		return false
+	}
+	pointer, ok := t.find(t.u32(4), tagGPSInfo)
+	if !ok {
+		return false
+	}
+	gps := t.u32(pointer + 8)
+	n := t.entries(gps)
+	if n == 0 {
+		return false
+	}
+
+	for i := range n {
+		entry := gps + 2 + 12*i
+		size := typeSizes[t.u16(entry+2)] * t.u32(entry+4)
+		if size <= 4 {
+			continue
+		}
+		if offset := t.u32(entry + 8); offset >= 8 && size <= len(data)-offset {
+			clear(data[offset : offset+size])
+		}
+	}
+	clear(data[gps : gps+2+12*n+4])
+	return true
+}
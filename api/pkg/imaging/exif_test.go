@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// latitude is what the GPS block of exifWithGPS holds, 25°2'1.5"
+var latitude = []uint32{25, 1, 2, 1, 15, 10}
+
+// exifWithGPS builds little endian EXIF metadata with an orientation and a
+// GPS block whose latitude lies outside its directory
+func exifWithGPS() []byte {
+	le := binary.LittleEndian
+	b := []byte("II*\x00")
+	b = le.AppendUint32(b, 8)
+
+	// IFD0 at 8: orientation and the GPS pointer
+	b = le.AppendUint16(b, 2)
+	b = append(le.AppendUint16(le.AppendUint16(b, tagOrientation), 3), 1, 0, 0, 0, 6, 0, 0, 0)
+	b = le.AppendUint32(le.AppendUint32(le.AppendUint16(le.AppendUint16(b, tagGPSInfo), 4), 1), 38)
+	b = le.AppendUint32(b, 0)
+
+	// GPS IFD at 38: latitude ref in line, latitude at 68
+	b = le.AppendUint16(b, 2)
+	b = append(le.AppendUint32(le.AppendUint16(le.AppendUint16(b, 1), 2), 2), 'N', 0, 0, 0)
+	b = le.AppendUint32(le.AppendUint32(le.AppendUint16(le.AppendUint16(b, 2), 5), 3), 68)
+	b = le.AppendUint32(b, 0)
+
+	for _, v := range latitude {
+		b = le.AppendUint32(b, v)
+	}
+	return b
+}
+
+func assertScrubbed(t *testing.T, data []byte) {
+	t.Helper()
+	size := len(data)
+	require.Equal(t, 6, Orientation(data))
+
+	assert.True(t, ScrubLocation(data))
+	assert.Len(t, data, size)
+	assert.False(t, bytes.Contains(data, []byte{15, 0, 0, 0, 10, 0, 0, 0}))
+	// the rest of the metadata is untouched
+	assert.Equal(t, 6, Orientation(data))
+	assert.False(t, ScrubLocation(data))
+}
+
+func TestScrubLocationJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, solid(8, 8, color.NRGBA{R: 90, A: 255}), nil))
+	encoded := buf.Bytes()
+
+	exif := append([]byte("Exif\x00\x00"), exifWithGPS()...)
+	data := append([]byte{0xFF, 0xD8, 0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(exif)+2))...)
+	data = append(append(data, exif...), encoded[2:]...)
+
+	assertScrubbed(t, data)
+	_, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+func TestScrubLocationPNG(t *testing.T) {
+	encoded := encodePNG(t, solid(8, 8, color.NRGBA{B: 90, A: 255}))
+
+	// eXIf goes after the 8 byte signature and the 25 byte IHDR chunk
+	exif := exifWithGPS()
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(exif)))
+	chunk = append(append(chunk, "eXIf"...), exif...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	data := append(append(append([]byte{}, encoded[:33]...), chunk...), encoded[33:]...)
+
+	assertScrubbed(t, data)
+	// the decoder verifies every chunk checksum
+	_, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+func TestScrubLocationWebP(t *testing.T) {
+	exif := append([]byte("Exif\x00\x00"), exifWithGPS()...)
+	chunk := binary.LittleEndian.AppendUint32([]byte("EXIF"), uint32(len(exif)))
+	chunk = append(chunk, exif...)
+	data := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(4+len(chunk)))
+	data = append(append(data, "WEBP"...), chunk...)
+
+	assertScrubbed(t, data)
+}
+
+func TestNoExif(t *testing.T) {
+	data := encodePNG(t, solid(2, 2, color.NRGBA{A: 255}))
+	assert.False(t, ScrubLocation(data))
+	assert.Equal(t, 1, Orientation(data))
+	assert.Equal(t, 1, Orientation([]byte("garbage")))
+}
@@ -0,0 +1,142 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers GIF for Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers WebP for Decode
+)
+
+var (
+	ErrUndecodable   = errors.New("image cannot be decoded")
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// jpegQuality balances size and artefacts for thumbnails
+const jpegQuality = 80
+
+// Decodable reports whether Decode reads the media type
+func Decodable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Decode reads a JPEG, PNG, GIF or WebP image, the first frame of an
+// animation. The header is checked first so an image claiming more than
+// maxPixels is refused before any memory is spent on it.
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUndecodable, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUndecodable, err)
+	}
+	return img, nil
+}
+
+// Fit scales img down to fit a box of edge pixels, keeping its aspect
+// ratio; smaller images are copied as they are, never scaled up
+func Fit(img image.Image, edge int) *image.NRGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > edge || h > edge {
+		if w >= h {
+			w, h = edge, max(1, h*edge/w)
+		} else {
+			w, h = max(1, w*edge/h), edge
+		}
+	}
+
+	// a cheap pass first keeps the quality pass small for large photos
+	src := img
+	if b.Dx() > 4*w && b.Dy() > 4*h {
+		half := image.NewNRGBA(image.Rect(0, 0, 2*w, 2*h))
+		draw.ApproxBiLinear.Scale(half, half.Bounds(), img, b, draw.Src, nil)
+		src = half
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// Orient turns img upright according to an EXIF orientation, 1 to 8
+func Orient(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.SetNRGBA(dx, dy, img.NRGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// Rotated reports whether an EXIF orientation swaps width and height
+func Rotated(orientation int) bool {
+	return orientation >= 5 && orientation <= 8
+}
+
+// Encode writes img as JPEG, or as PNG when it has transparency, and
+// returns the media type it chose
+func Encode(w io.Writer, img *image.NRGBA) (string, error) {
+	if img.Opaque() {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return "image/png", png.Encode(w, img)
+}
+
+// linear converts an 8 bit sRGB channel to linear light
+func linear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// opaque reads a pixel as it looks composited over black
+func opaque(c color.NRGBA) (r, g, b uint8) {
+	return uint8(uint16(c.R) * uint16(c.A) / 255), uint8(uint16(c.G) * uint16(c.A) / 255), uint8(uint16(c.B) * uint16(c.A) / 255)
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func solid(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	data := encodePNG(t, solid(40, 30, color.NRGBA{R: 255, A: 255}))
+
+	img, err := Decode(data, 40*30)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 30), img.Bounds())
+
+	_, err = Decode(data, 40*30-1)
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+
+	_, err = Decode([]byte("not an image"), 1<<20)
+	assert.ErrorIs(t, err, ErrUndecodable)
+
+	assert.True(t, Decodable("image/webp"))
+	assert.False(t, Decodable("image/svg+xml"))
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, image.Rect(0, 0, 160, 40), Fit(solid(2000, 500, color.NRGBA{A: 255}), 160).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 120, 480), Fit(solid(300, 1200, color.NRGBA{A: 255}), 480).Bounds())
+	// never scaled up
+	assert.Equal(t, image.Rect(0, 0, 50, 20), Fit(solid(50, 20, color.NRGBA{A: 255}), 160).Bounds())
+	// a sliver keeps at least a pixel
+	assert.Equal(t, image.Rect(0, 0, 160, 1), Fit(solid(5000, 2, color.NRGBA{A: 255}), 160).Bounds())
+}
+
+func TestOrient(t *testing.T) {
+	// a 2x1 image, red on the left
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	img.SetNRGBA(0, 0, red)
+	img.SetNRGBA(1, 0, blue)
+
+	assert.Same(t, img, Orient(img, 1))
+
+	mirrored := Orient(img, 2)
+	assert.Equal(t, blue, mirrored.NRGBAAt(0, 0))
+
+	clockwise := Orient(img, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), clockwise.Bounds())
+	assert.Equal(t, red, clockwise.NRGBAAt(0, 0))
+	assert.Equal(t, blue, clockwise.NRGBAAt(0, 1))
+
+	counter := Orient(img, 8)
+	assert.Equal(t, blue, counter.NRGBAAt(0, 0))
+	assert.Equal(t, red, counter.NRGBAAt(0, 1))
+
+	assert.True(t, Rotated(6))
+	assert.False(t, Rotated(3))
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := Encode(&buf, solid(4, 4, color.NRGBA{G: 255, A: 255}))
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	buf.Reset()
+	contentType, err = Encode(&buf, solid(4, 4, color.NRGBA{G: 255, A: 128}))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	_, err = png.Decode(&buf)
+	assert.NoError(t, err)
+}
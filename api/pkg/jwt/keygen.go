@@ -0,0 +1,74 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// KeyAlgorithm names a key pair GenerateKeyPair can produce
+type KeyAlgorithm string
+
+const (
+	KeyES256   KeyAlgorithm = "ES256"
+	KeyEd25519 KeyAlgorithm = "Ed25519"
+)
+
+var (
+	ErrUnknownKeyAlgorithm = errors.New("unknown key algorithm")
+)
+
+// KeyPair is a PEM encoded private key and its public half
+type KeyPair struct {
+	Algorithm  KeyAlgorithm
+	PrivatePEM []byte
+	PublicPEM  []byte
+}
+
+// GenerateKeyPair creates a fresh key pair. ES256 private keys are SEC 1
+// "EC PRIVATE KEY" blocks, the format NewES256JWT reads; Ed25519 keys are PKCS #8.
+func GenerateKeyPair(algorithm KeyAlgorithm) (*KeyPair, error) {
+	var (
+		private      []byte
+		privateType  string
+		public       crypto.PublicKey
+		errGenerated error
+	)
+	switch algorithm {
+	case KeyES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private, errGenerated = x509.MarshalECPrivateKey(key)
+		privateType, public = "EC PRIVATE KEY", key.Public()
+	case KeyEd25519:
+		publicKey, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private, errGenerated = x509.MarshalPKCS8PrivateKey(key)
+		privateType, public = "PRIVATE KEY", publicKey
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyAlgorithm, algorithm)
+	}
+	if errGenerated != nil {
+		return nil, errGenerated
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		Algorithm:  algorithm,
+		PrivatePEM: pem.EncodeToMemory(&pem.Block{Type: privateType, Bytes: private}),
+		PublicPEM:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}),
+	}, nil
+}
@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKeyPairES256(t *testing.T) {
+	pair, err := GenerateKeyPair(KeyES256)
+	require.NoError(t, err)
+
+	es256, err := NewES256JWT(string(pair.PrivatePEM))
+	require.NoError(t, err)
+	assert.NoError(t, Probe(es256))
+
+	block, _ := pem.Decode(pair.PublicPEM)
+	require.NotNil(t, block)
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.True(t, es256.SigningKey.PublicKey.Equal(public))
+}
+
+func TestGenerateKeyPairEd25519(t *testing.T) {
+	pair, err := GenerateKeyPair(KeyEd25519)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(pair.PrivatePEM)
+	require.NotNil(t, block)
+	assert.Equal(t, "PRIVATE KEY", block.Type)
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	block, _ = pem.Decode(pair.PublicPEM)
+	require.NotNil(t, block)
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.True(t, private.(ed25519.PrivateKey).Public().(ed25519.PublicKey).Equal(public))
+}
+
+func TestGenerateKeyPairUnknown(t *testing.T) {
+	_, err := GenerateKeyPair("RS256")
+	assert.ErrorIs(t, err, ErrUnknownKeyAlgorithm)
+}
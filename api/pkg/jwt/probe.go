@@ -0,0 +1,35 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrKeyringProbe = errors.New("keyring probe failed")
+)
+
+const probeSubject = "keyring-probe"
+
+// Probe signs and verifies a short-lived token to prove the key ring can
+// still issue and accept tokens
+func Probe(j IJWT) error {
+	if j == nil {
+		return fmt.Errorf("%w: %w", ErrKeyringProbe, ErrNoKey)
+	}
+	claims := NewCommon(NewClaimsBuilder().WithSubject(probeSubject).ExpiresAfter(time.Minute).Build())
+	token, err := j.GenerateToken(claims)
+	if err != nil {
+		return fmt.Errorf("%w: sign: %w", ErrKeyringProbe, err)
+	}
+
+	verified := NewCommon(NewClaimsBuilder().Build())
+	if err := j.VerifyToken(token, verified); err != nil {
+		return fmt.Errorf("%w: verify: %w", ErrKeyringProbe, err)
+	}
+	if verified.Subject != probeSubject {
+		return fmt.Errorf("%w: subject mismatch", ErrKeyringProbe)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type loggerContextKey struct{}
+
+// WithContext stores a request-scoped logger in ctx
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithContext, or the global logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := Lookup(ctx); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// Lookup returns the logger stored by WithContext, if any
+func Lookup(ctx context.Context) (*zap.Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	logger, ok := ctx.Value(loggerContextKey{}).(*zap.Logger)
+	return logger, ok
+}
@@ -8,8 +8,28 @@ import (
 )
 
 func NewZap(cfg config.Core) (*zap.Logger, error) {
-	var err error
+	return NewZapWithLevel(cfg, NewAtomicLevel(cfg))
+}
+
+// NewAtomicLevel returns the level LogLevel names, falling back to debug in
+// development and info in release mode. Changing it adjusts every logger
+// built from it at runtime.
+func NewAtomicLevel(cfg config.Core) zap.AtomicLevel {
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	if cfg.IsReleaseMode {
+		level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	}
+	if len(cfg.LogLevel) > 0 {
+		var lv zapcore.Level
+		if err := lv.Set(cfg.LogLevel); err == nil {
+			level.SetLevel(lv)
+		}
+	}
+	return level
+}
 
+// NewZapWithLevel builds the logger on top of level instead of a fixed one
+func NewZapWithLevel(cfg config.Core, level zap.AtomicLevel) (*zap.Logger, error) {
 	var logCfg zap.Config
 	if cfg.IsReleaseMode {
 		logCfg = zap.NewProductionConfig()
@@ -18,13 +38,7 @@ func NewZap(cfg config.Core) (*zap.Logger, error) {
 	}
 
 	logCfg.EncoderConfig.EncodeName = zapcore.FullNameEncoder
-	if len(cfg.LogLevel) > 0 {
-		var lv zapcore.Level
-		err := lv.Set(cfg.LogLevel)
-		if err == nil {
-			logCfg.Level.SetLevel(lv)
-		}
-	}
+	logCfg.Level = level
 	logger, err := logCfg.Build(zap.Fields(zap.String("system", cfg.SystemName)))
 	if err != nil {
 		return nil, err
@@ -34,3 +48,18 @@ func NewZap(cfg config.Core) (*zap.Logger, error) {
 	zap.ReplaceGlobals(logger)
 	return logger, nil
 }
+
+// FollowLevel keeps level in step with LogLevel across config reloads.
+// Only an applied change touches it, so a level set through the admin
+// endpoint survives reloads that leave LogLevel alone.
+func FollowLevel(reloader *config.Reloader, level zap.AtomicLevel) {
+	reloader.Subscribe(func(change config.Change) {
+		if !change.Has("LOG_LEVEL") {
+			return
+		}
+		var lv zapcore.Level
+		if err := lv.Set(change.Current.Core.LogLevel); err == nil {
+			level.SetLevel(lv)
+		}
+	})
+}
@@ -1,12 +1,14 @@
 package logger
 
 import (
+	"context"
 	"errors"
 	"hilo-api/pkg/config"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type LoggerTestSuite struct {
@@ -23,6 +25,39 @@ func (suite *LoggerTestSuite) TestNewZap() {
 	suite.Equal(logger, zap.L())
 }
 
+func (suite *LoggerTestSuite) TestAtomicLevel() {
+	level := NewAtomicLevel(config.Core{LogLevel: "ERROR"})
+	logger, err := NewZapWithLevel(config.Core{}, level)
+	suite.NoError(err)
+	suite.False(logger.Core().Enabled(zapcore.WarnLevel))
+
+	level.SetLevel(zapcore.DebugLevel)
+	suite.True(logger.Core().Enabled(zapcore.DebugLevel))
+
+	suite.Equal(zapcore.InfoLevel, NewAtomicLevel(config.Core{IsReleaseMode: true, LogLevel: "loud"}).Level())
+	suite.Equal(zapcore.DebugLevel, NewAtomicLevel(config.Core{}).Level())
+}
+
+func (suite *LoggerTestSuite) TestFollowLevel() {
+	level := NewAtomicLevel(config.Core{LogLevel: "INFO"})
+	reloader := config.NewReloader(config.Set{Core: config.Core{LogLevel: "INFO"}},
+		config.WithReloadLoader(func() (config.Set, error) {
+			return config.Set{Core: config.Core{LogLevel: "WARN"}}, nil
+		}),
+	)
+	FollowLevel(reloader, level)
+
+	_, err := reloader.Reload()
+	suite.NoError(err)
+	suite.Equal(zapcore.WarnLevel, level.Level())
+
+	// a level set at runtime survives a reload that leaves LOG_LEVEL alone
+	level.SetLevel(zapcore.DebugLevel)
+	_, err = reloader.Reload()
+	suite.NoError(err)
+	suite.Equal(zapcore.DebugLevel, level.Level())
+}
+
 func (suite *LoggerTestSuite) TestPanicLogger() {
 	logger, err := NewZap(config.Core{
 		LogLevel:      "WARN",
@@ -41,6 +76,16 @@ func (suite *LoggerTestSuite) TestPanicLogger() {
 	}()
 }
 
+func (suite *LoggerTestSuite) TestContextLogger() {
+	scoped := zap.NewNop().With(zap.String("request_id", "abc"))
+	ctx := WithContext(context.Background(), scoped)
+	suite.Same(scoped, FromContext(ctx))
+
+	_, ok := Lookup(context.Background())
+	suite.False(ok)
+	suite.Same(zap.L(), FromContext(context.Background()))
+}
+
 func TestLoggerTestSuite(t *testing.T) {
 	suite.Run(t, new(LoggerTestSuite))
 }
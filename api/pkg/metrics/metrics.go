@@ -0,0 +1,69 @@
+// Package metrics holds the Prometheus collectors shared by the transport and
+// storage layers. Everything registers with the default registry served by
+// restful.NewPromHTTP.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const Namespace = "hilo"
+
+var (
+	// HTTPRequestsTotal counts finished requests by route template, so path
+	// parameters never turn into label values
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes request latency in seconds
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestsInFlight tracks requests currently being served
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	// RealtimeStreamsOpen tracks open server-sent event streams
+	RealtimeStreamsOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "realtime",
+		Name:      "streams_open",
+		Help:      "Server-sent event streams currently open.",
+	})
+)
+
+// RegisterDBStats exposes the sql.DBStats of db as go_sql_* metrics labelled
+// with dbName. The returned function unregisters the collector.
+func RegisterDBStats(db *sql.DB, dbName string) (func(), error) {
+	collector := collectors.NewDBStatsCollector(db, dbName)
+	if err := prometheus.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			return nil, err
+		}
+		// a previous pool for the same database is still registered
+		prometheus.Unregister(already.ExistingCollector)
+		if err := prometheus.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return func() { prometheus.Unregister(collector) }, nil
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDBStats(t *testing.T) {
+	// sql.Open does not connect, which is all the collector needs
+	db, err := sql.Open("pgx", "postgres://localhost/metrics_test")
+	require.NoError(t, err)
+	defer db.Close()
+
+	unregister, err := RegisterDBStats(db, "metrics_test")
+	require.NoError(t, err)
+
+	// registering the same database again replaces the stale collector
+	unregisterAgain, err := RegisterDBStats(db, "metrics_test")
+	require.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, "go_sql_max_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	unregisterAgain()
+	unregister()
+	count, err = testutil.GatherAndCount(prometheus.DefaultGatherer, "go_sql_max_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryOption interface
+type MemoryOption interface {
+	Apply(*Memory)
+}
+
+// WithClock method
+func WithClock(now func() time.Time) MemoryOption {
+	return withClock{now: now}
+}
+
+type withClock struct {
+	now func() time.Time
+}
+
+// Apply method
+func (w withClock) Apply(m *Memory) {
+	m.now = w.now
+}
+
+// WithSweepInterval method
+// sets how often buckets that have refilled completely are dropped
+func WithSweepInterval(interval time.Duration) MemoryOption {
+	return withSweepInterval{interval: interval}
+}
+
+type withSweepInterval struct {
+	interval time.Duration
+}
+
+// Apply method
+func (w withSweepInterval) Apply(m *Memory) {
+	m.sweepInterval = w.interval
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// Memory keeps the buckets in process; every node limits on its own
+type Memory struct {
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	now           func() time.Time
+	sweepInterval time.Duration
+	swept         time.Time
+}
+
+// NewMemory method
+func NewMemory(options ...MemoryOption) *Memory {
+	m := &Memory{
+		buckets:       map[string]*bucket{},
+		now:           time.Now,
+		sweepInterval: time.Minute,
+	}
+	for _, option := range options {
+		option.Apply(m)
+	}
+	m.swept = m.now()
+	return m
+}
+
+// Take method
+func (m *Memory) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	key = policy.Name + ":" + key
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Limit), updated: now}
+		m.buckets[key] = b
+	}
+	tokens := math.Min(float64(policy.Limit), b.tokens+now.Sub(b.updated).Seconds()*policy.Rate())
+	allowed := tokens >= 1
+	if allowed {
+		// a denied request leaves the bucket alone so it keeps refilling
+		// from the last spend
+		tokens--
+		b.tokens, b.updated = tokens, now
+	}
+	result := Outcome(policy, tokens, allowed)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep drops buckets that are full by now, they behave like new ones
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < m.sweepInterval {
+		return
+	}
+	m.swept = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MemorySuite struct {
+	suite.Suite
+	now    time.Time
+	store  *Memory
+	policy Policy
+}
+
+func (suite *MemorySuite) SetupTest() {
+	suite.now = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	suite.store = NewMemory(
+		WithClock(func() time.Time { return suite.now }),
+		WithSweepInterval(time.Minute),
+	)
+	suite.policy = Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+}
+
+func (suite *MemorySuite) take(key string) Result {
+	result, err := suite.store.Take(context.Background(), key, suite.policy)
+	suite.Require().NoError(err)
+	return result
+}
+
+func (suite *MemorySuite) TestBurstThenRefill() {
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := suite.take("a")
+		suite.True(result.Allowed)
+		suite.Equal(remaining, result.Remaining)
+	}
+
+	result := suite.take("a")
+	suite.False(result.Allowed)
+	suite.Equal(time.Second, result.RetryAfter)
+	suite.Equal(3*time.Second, result.Reset)
+
+	// denied requests do not push the refill back
+	suite.now = suite.now.Add(500 * time.Millisecond)
+	suite.False(suite.take("a").Allowed)
+	suite.now = suite.now.Add(500 * time.Millisecond)
+	suite.True(suite.take("a").Allowed)
+}
+
+func (suite *MemorySuite) TestKeysAndPoliciesAreSeparate() {
+	for i := 0; i < 3; i++ {
+		suite.take("a")
+	}
+	suite.False(suite.take("a").Allowed)
+	suite.True(suite.take("b").Allowed)
+
+	suite.policy.Name = "other"
+	suite.True(suite.take("a").Allowed)
+}
+
+func (suite *MemorySuite) TestSweepDropsFullBuckets() {
+	suite.take("a")
+	suite.take("b")
+	suite.Len(suite.store.buckets, 2)
+
+	suite.now = suite.now.Add(2 * time.Minute)
+	suite.take("c")
+	suite.Len(suite.store.buckets, 1)
+}
+
+func TestMemorySuite(t *testing.T) {
+	suite.Run(t, new(MemorySuite))
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPolicy = errors.New("invalid rate limit policy")
+)
+
+// Policy is a token bucket holding Limit tokens that refills completely
+// over Period, so it allows bursts of Limit and Limit/Period sustained
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy reads a policy written as LIMIT/PERIOD, e.g. 60/1m.
+// An empty spec or a zero limit disables the policy.
+func ParsePolicy(name, spec string) (Policy, error) {
+	policy := Policy{Name: name}
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" {
+		return policy, nil
+	}
+	limit, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return policy, fmt.Errorf("%w: %q is not LIMIT/PERIOD", ErrInvalidPolicy, spec)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n < 0 {
+		return policy, fmt.Errorf("%w: %q has no valid limit", ErrInvalidPolicy, spec)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return policy, fmt.Errorf("%w: %q has no valid period", ErrInvalidPolicy, spec)
+	}
+	policy.Limit, policy.Period = n, d
+	return policy, nil
+}
+
+// Enabled reports whether the policy limits anything
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// Rate is the refill in tokens per second
+func (p Policy) Rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// String formats the policy the way RateLimit-Policy expects, e.g. 60;w=60
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(math.Ceil(p.Period.Seconds())))
+}
+
+// Result is the outcome of one Take
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next token, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take spends one token for key under policy.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Outcome derives the result from the tokens left in a bucket, for stores
+// that keep the bucket elsewhere and only report its level
+func Outcome(policy Policy, tokens float64, allowed bool) Result {
+	rate := policy.Rate()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(policy.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
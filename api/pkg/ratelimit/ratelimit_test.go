@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("auth", "10/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{Name: "auth", Limit: 10, Period: time.Minute}, policy)
+	assert.True(t, policy.Enabled())
+	assert.Equal(t, "10;w=60", policy.String())
+	assert.InDelta(t, 10.0/60, policy.Rate(), 1e-9)
+
+	for _, spec := range []string{"", "0"} {
+		policy, err := ParsePolicy("off", spec)
+		assert.NoError(t, err)
+		assert.False(t, policy.Enabled())
+	}
+
+	for _, spec := range []string{"10", "ten/1m", "-1/1m", "10/forever", "10/0s"} {
+		_, err := ParsePolicy("bad", spec)
+		assert.ErrorIs(t, err, ErrInvalidPolicy, spec)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	policy := Policy{Limit: 10, Period: 10 * time.Second}
+
+	result := Outcome(policy, 4.5, true)
+	assert.Equal(t, Result{Allowed: true, Limit: 10, Remaining: 4, Reset: 5500 * time.Millisecond}, result)
+
+	result = Outcome(policy, 0.25, false)
+	assert.False(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+	assert.Equal(t, 750*time.Millisecond, result.RetryAfter)
+}
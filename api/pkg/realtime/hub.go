@@ -0,0 +1,150 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event is something a connected user should hear about; Data is rendered
+// by whoever writes the event to the wire
+type Event struct {
+	Type string
+	Data any
+}
+
+// HubOption interface
+type HubOption interface {
+	Apply(*Hub)
+}
+
+// WithBuffer method
+// sets how many events a subscription holds before it counts as too slow
+func WithBuffer(size int) HubOption {
+	return withBuffer{size: size}
+}
+
+type withBuffer struct {
+	size int
+}
+
+// Apply method
+func (w withBuffer) Apply(h *Hub) {
+	h.buffer = w.size
+}
+
+// Hub fans events out to every stream a user has open, one per device.
+// It lives in process, so only streams connected to this node are reached.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	buffer      int
+	closed      bool
+}
+
+// NewHub method
+func NewHub(options ...HubOption) *Hub {
+	h := &Hub{
+		subscribers: map[uuid.UUID]map[*Subscription]struct{}{},
+		buffer:      64,
+	}
+	for _, option := range options {
+		option.Apply(h)
+	}
+	return h
+}
+
+// Subscription receives the events published to one user until closed
+type Subscription struct {
+	hub    *Hub
+	userID uuid.UUID
+	events chan Event
+	closed bool
+}
+
+// Events is closed when the subscription is closed, by its owner, by the hub
+// shutting down or because the reader fell too far behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close method
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Subscribe method
+// a hub that is already closed hands out a closed subscription
+func (h *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	s := &Subscription{hub: h, userID: userID, events: make(chan Event, h.buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.closed = true
+		close(s.events)
+		return s
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[*Subscription]struct{}{}
+	}
+	h.subscribers[userID][s] = struct{}{}
+	return s
+}
+
+// Publish method
+// never blocks; a subscription whose buffer is full is closed so its client
+// reconnects and catches up instead of silently missing events
+func (h *Hub) Publish(userID uuid.UUID, event Event) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for s := range h.subscribers[userID] {
+		select {
+		case s.events <- event:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, s := range slow {
+		s.Close()
+	}
+}
+
+// Connected reports how many streams userID has open on this node
+func (h *Hub) Connected(userID uuid.UUID) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[userID])
+}
+
+// Close ends every subscription and refuses new ones, letting long-lived
+// streams finish during shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subscriptions := range h.subscribers {
+		for s := range subscriptions {
+			h.remove(s)
+		}
+	}
+}
+
+// remove must be called with mu held
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.events)
+
+	delete(h.subscribers[s.userID], s)
+	if len(h.subscribers[s.userID]) == 0 {
+		delete(h.subscribers, s.userID)
+	}
+}
@@ -0,0 +1,73 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type HubSuite struct {
+	suite.Suite
+	hub   *Hub
+	alice uuid.UUID
+	bob   uuid.UUID
+}
+
+func (suite *HubSuite) SetupTest() {
+	suite.hub = NewHub(WithBuffer(2))
+	suite.alice, suite.bob = uuid.New(), uuid.New()
+}
+
+func (suite *HubSuite) TestPublishReachesEveryDevice() {
+	phone := suite.hub.Subscribe(suite.alice)
+	laptop := suite.hub.Subscribe(suite.alice)
+	other := suite.hub.Subscribe(suite.bob)
+	suite.Equal(2, suite.hub.Connected(suite.alice))
+
+	suite.hub.Publish(suite.alice, Event{Type: "test", Data: 1})
+	suite.Equal(Event{Type: "test", Data: 1}, <-phone.Events())
+	suite.Equal(Event{Type: "test", Data: 1}, <-laptop.Events())
+	suite.Empty(other.Events())
+}
+
+func (suite *HubSuite) TestCloseUnsubscribes() {
+	sub := suite.hub.Subscribe(suite.alice)
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.Events()
+	suite.False(ok)
+	suite.Zero(suite.hub.Connected(suite.alice))
+	suite.hub.Publish(suite.alice, Event{Type: "test"})
+}
+
+func (suite *HubSuite) TestSlowSubscriberIsDropped() {
+	sub := suite.hub.Subscribe(suite.alice)
+	for i := 0; i < 3; i++ {
+		suite.hub.Publish(suite.alice, Event{Type: "test", Data: i})
+	}
+	suite.Zero(suite.hub.Connected(suite.alice))
+
+	var received []any
+	for event := range sub.Events() {
+		received = append(received, event.Data)
+	}
+	suite.Equal([]any{0, 1}, received)
+}
+
+func (suite *HubSuite) TestHubClose() {
+	sub := suite.hub.Subscribe(suite.alice)
+	suite.hub.Close()
+	_, ok := <-sub.Events()
+	suite.False(ok)
+
+	late := suite.hub.Subscribe(suite.bob)
+	_, ok = <-late.Events()
+	suite.False(ok)
+	late.Close()
+}
+
+func TestHubSuite(t *testing.T) {
+	suite.Run(t, new(HubSuite))
+}
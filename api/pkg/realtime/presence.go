@@ -0,0 +1,114 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Presence tracks the sessions each user has open, one per device, and when
+// each last showed signs of life. It lives in process like Hub, so only
+// sessions connected to this node count.
+type Presence struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]map[*Session]time.Time
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// NewPresence method
+// a session without a heartbeat for timeout is gone
+func NewPresence(timeout time.Duration) *Presence {
+	return &Presence{
+		sessions: map[uuid.UUID]map[*Session]time.Time{},
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// Session is one device of a user being online
+type Session struct {
+	presence *Presence
+	userID   uuid.UUID
+}
+
+// UserID method
+func (s *Session) UserID() uuid.UUID {
+	return s.userID
+}
+
+// Join opens a session for userID; first tells whether the user had none
+// open, so just came online
+func (p *Presence) Join(userID uuid.UUID) (_ *Session, first bool) {
+	s := &Session{presence: p, userID: userID}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	first = len(p.sessions[userID]) == 0
+	if first {
+		p.sessions[userID] = map[*Session]time.Time{}
+	}
+	p.sessions[userID][s] = p.now()
+	return s, first
+}
+
+// Beat keeps the session alive; one that expired in the meantime is open
+// again
+func (s *Session) Beat() {
+	p := s.presence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sessions[s.userID] == nil {
+		p.sessions[s.userID] = map[*Session]time.Time{}
+	}
+	p.sessions[s.userID][s] = p.now()
+}
+
+// Leave closes the session; last tells whether it was the user's last one,
+// so they just went offline. Leaving a closed session reports false.
+func (s *Session) Leave() (last bool) {
+	p := s.presence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.remove(s)
+}
+
+// Online reports whether userID has a session open on this node
+func (p *Presence) Online(userID uuid.UUID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions[userID]) > 0
+}
+
+// Expire closes the sessions without a heartbeat for the timeout and
+// returns the users left without any
+func (p *Presence) Expire() []uuid.UUID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	deadline := p.now().Add(-p.timeout)
+	var offline []uuid.UUID
+	for userID, sessions := range p.sessions {
+		for s, beat := range sessions {
+			if beat.Before(deadline) && p.remove(s) {
+				offline = append(offline, userID)
+			}
+		}
+	}
+	return offline
+}
+
+// remove must be called with mu held
+func (p *Presence) remove(s *Session) (last bool) {
+	sessions := p.sessions[s.userID]
+	if _, ok := sessions[s]; !ok {
+		return false
+	}
+	delete(sessions, s)
+	if len(sessions) > 0 {
+		return false
+	}
+	delete(p.sessions, s.userID)
+	return true
+}
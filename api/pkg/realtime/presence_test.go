@@ -0,0 +1,113 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type PresenceSuite struct {
+	suite.Suite
+	now      time.Time
+	presence *Presence
+	alice    uuid.UUID
+}
+
+func (suite *PresenceSuite) SetupTest() {
+	suite.now = time.Now()
+	suite.presence = NewPresence(time.Minute)
+	suite.presence.now = func() time.Time { return suite.now }
+	suite.alice = uuid.New()
+}
+
+func (suite *PresenceSuite) TestEveryDeviceCounts() {
+	phone, first := suite.presence.Join(suite.alice)
+	suite.True(first)
+	laptop, first := suite.presence.Join(suite.alice)
+	suite.False(first, "already online on the phone")
+	suite.Equal(suite.alice, laptop.UserID())
+
+	suite.False(phone.Leave())
+	suite.True(suite.presence.Online(suite.alice))
+	suite.True(laptop.Leave(), "the last device went away")
+	suite.False(suite.presence.Online(suite.alice))
+	suite.False(laptop.Leave(), "leaving twice")
+}
+
+func (suite *PresenceSuite) TestExpire() {
+	phone, _ := suite.presence.Join(suite.alice)
+	laptop, _ := suite.presence.Join(suite.alice)
+
+	suite.now = suite.now.Add(45 * time.Second)
+	laptop.Beat()
+	suite.now = suite.now.Add(30 * time.Second)
+	suite.Empty(suite.presence.Expire(), "the laptop is still beating")
+	suite.True(suite.presence.Online(suite.alice))
+	suite.False(phone.Leave(), "the phone expired already")
+
+	suite.now = suite.now.Add(time.Minute)
+	suite.Equal([]uuid.UUID{suite.alice}, suite.presence.Expire())
+	suite.False(suite.presence.Online(suite.alice))
+
+	laptop.Beat()
+	suite.True(suite.presence.Online(suite.alice), "a late heartbeat opens the session again")
+}
+
+func TestPresenceSuite(t *testing.T) {
+	suite.Run(t, new(PresenceSuite))
+}
+
+type TypingSuite struct {
+	suite.Suite
+	now    time.Time
+	typing *Typing
+	pair   TypingPair
+}
+
+func (suite *TypingSuite) SetupTest() {
+	suite.now = time.Now()
+	suite.typing = NewTyping(3*time.Second, 6*time.Second)
+	suite.typing.now = func() time.Time { return suite.now }
+	suite.pair = TypingPair{From: uuid.New(), To: uuid.New()}
+}
+
+func (suite *TypingSuite) TestThrottle() {
+	suite.True(suite.typing.Start(suite.pair))
+	suite.now = suite.now.Add(time.Second)
+	suite.False(suite.typing.Start(suite.pair), "within the throttle")
+	suite.now = suite.now.Add(2 * time.Second)
+	suite.True(suite.typing.Start(suite.pair))
+
+	suite.True(suite.typing.Stop(suite.pair))
+	suite.False(suite.typing.Stop(suite.pair), "not typing any more")
+	suite.True(suite.typing.Start(suite.pair), "typing again is news")
+}
+
+func (suite *TypingSuite) TestStopFrom() {
+	other := TypingPair{From: suite.pair.From, To: uuid.New()}
+	incoming := TypingPair{From: suite.pair.To, To: suite.pair.From}
+	for _, pair := range []TypingPair{suite.pair, other, incoming} {
+		suite.typing.Start(pair)
+	}
+
+	suite.ElementsMatch([]TypingPair{suite.pair, other}, suite.typing.StopFrom(suite.pair.From))
+	suite.True(suite.typing.Stop(incoming), "the partner is still typing")
+}
+
+func (suite *TypingSuite) TestExpire() {
+	suite.typing.Start(suite.pair)
+	suite.now = suite.now.Add(5 * time.Second)
+	suite.typing.Start(suite.pair)
+	suite.now = suite.now.Add(5 * time.Second)
+	suite.Empty(suite.typing.Expire(), "the second start pushed the end back")
+
+	suite.now = suite.now.Add(time.Second)
+	suite.Equal([]TypingPair{suite.pair}, suite.typing.Expire())
+	suite.False(suite.typing.Stop(suite.pair))
+}
+
+func TestTypingSuite(t *testing.T) {
+	suite.Run(t, new(TypingSuite))
+}
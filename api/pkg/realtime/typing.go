@@ -0,0 +1,107 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TypingPair is a user typing in their conversation with another
+type TypingPair struct {
+	From uuid.UUID
+	To   uuid.UUID
+}
+
+// Typing tracks who is typing to whom. Clients repeat the start signal
+// while the user types; a start is passed on at most once per throttle, and
+// typing nobody stopped ends timeout after the last start.
+type Typing struct {
+	mu       sync.Mutex
+	active   map[TypingPair]typingState
+	throttle time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+type typingState struct {
+	relayedAt time.Time
+	expiresAt time.Time
+}
+
+// NewTyping method
+func NewTyping(throttle, timeout time.Duration) *Typing {
+	return &Typing{
+		active:   map[TypingPair]typingState{},
+		throttle: throttle,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// Timeout is how long typing lasts after a start
+func (t *Typing) Timeout() time.Duration {
+	return t.timeout
+}
+
+// Start records that pair.From is typing; relay tells whether to pass it
+// on, because they were not typing or the last start passed on is more
+// than throttle ago
+func (t *Typing) Start(pair TypingPair) (relay bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	state, typing := t.active[pair]
+	relay = !typing || now.Sub(state.relayedAt) >= t.throttle
+	if relay {
+		state.relayedAt = now
+	}
+	state.expiresAt = now.Add(t.timeout)
+	t.active[pair] = state
+	return relay
+}
+
+// Stop records that pair.From stopped typing; relay tells whether they
+// were typing, so the stop is worth passing on
+func (t *Typing) Stop(pair TypingPair) (relay bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, relay = t.active[pair]
+	delete(t.active, pair)
+	return relay
+}
+
+// StopFrom ends everything from is typing, as when they went offline, and
+// returns the pairs to pass a stop on for
+func (t *Typing) StopFrom(from uuid.UUID) []TypingPair {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stopped []TypingPair
+	for pair := range t.active {
+		if pair.From == from {
+			delete(t.active, pair)
+			stopped = append(stopped, pair)
+		}
+	}
+	return stopped
+}
+
+// Expire ends typing that was not started again within the timeout and
+// returns the pairs to pass a stop on for
+func (t *Typing) Expire() []TypingPair {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var expired []TypingPair
+	for pair, state := range t.active {
+		if !now.Before(state.expiresAt) {
+			delete(t.active, pair)
+			expired = append(expired, pair)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,176 @@
+package restful
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/logger"
+	"hilo-api/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	redacted           = "[REDACTED]"
+	maxRequestIDLength = 128
+)
+
+var (
+	// sensitiveHeaders are never written to the access log
+	sensitiveHeaders = map[string]struct{}{
+		"Authorization":       {},
+		"Proxy-Authorization": {},
+		"Cookie":              {},
+		"Set-Cookie":          {},
+		"X-Api-Key":           {},
+	}
+	// sensitiveQueries carry credentials, such as the JWT passed as ?tk=
+	sensitiveQueries = map[string]struct{}{
+		definition.QueryAuthKey: {},
+	}
+)
+
+// AccessLogOption interface
+type AccessLogOption interface {
+	Apply(*accessLogConfig)
+}
+
+type accessLogConfig struct {
+	skipPaths map[string]struct{}
+}
+
+// WithSkipPaths method
+func WithSkipPaths(paths ...string) AccessLogOption {
+	return withSkipPaths{paths: paths}
+}
+
+type withSkipPaths struct {
+	paths []string
+}
+
+// Apply method
+func (w withSkipPaths) Apply(c *accessLogConfig) {
+	for _, path := range w.paths {
+		c.skipPaths[path] = struct{}{}
+	}
+}
+
+// AccessLogger assigns or propagates X-Request-ID, stores a logger carrying
+// the request id in the request context and writes one structured line per
+// request through zap once the chain has finished
+func AccessLogger(log *zap.Logger, options ...AccessLogOption) gin.HandlerFunc {
+	cfg := &accessLogConfig{skipPaths: map[string]struct{}{}}
+	for _, option := range options {
+		option.Apply(cfg)
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(definition.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header(definition.RequestIDHeader, requestID)
+		c.Set(definition.RequestIDKey, requestID)
+
+		reqLogger := log.With(append(
+			tracing.ZapFields(c.Request.Context()),
+			zap.String(definition.RequestIDKey, requestID),
+		)...)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		if _, skip := cfg.skipPaths[c.Request.URL.Path]; skip {
+			return
+		}
+
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+
+		ce := reqLogger.Check(level, "http request")
+		if ce == nil {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", route),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("query", redactQuery(c.Request.URL.RawQuery)),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", max(c.Writer.Size(), 0)),
+			zap.String("user_id", c.GetString(definition.ContextUserIDKey)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+		}
+		if reqLogger.Core().Enabled(zapcore.DebugLevel) {
+			fields = append(fields, zap.Any("headers", RedactHeaders(c.Request.Header)))
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			fields = append(fields, zap.String("errors", errs.String()))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// RedactHeaders returns a copy of header with credentials replaced
+func RedactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for key, values := range header {
+		if _, ok := sensitiveHeaders[http.CanonicalHeaderKey(key)]; ok {
+			out[key] = redacted
+			continue
+		}
+		out[key] = strings.Join(values, ",")
+	}
+	return out
+}
+
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redacted
+	}
+	for key := range values {
+		if _, ok := sensitiveQueries[key]; ok {
+			values.Set(key, redacted)
+		}
+	}
+	return values.Encode()
+}
+
+// validRequestID accepts short printable ids so clients cannot inject log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) || r == ' ' {
+			return false
+		}
+	}
+	return true
+}
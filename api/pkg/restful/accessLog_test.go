@@ -0,0 +1,164 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type AccessLogSuite struct {
+	suite.Suite
+	obLog  *observer.ObservedLogs
+	logger *zap.Logger
+}
+
+func (suite *AccessLogSuite) SetupTest() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	suite.logger = zap.New(core)
+	suite.obLog = logs
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AccessLogSuite) serve(route *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AccessLogSuite) TestAssignsRequestID() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger))
+	route.GET("/users/:id", func(c *gin.Context) {
+		c.Set(definition.ContextUserIDKey, "user-1")
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := suite.serve(route, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	requestID := w.Header().Get(definition.RequestIDHeader)
+	suite.NotEmpty(requestID)
+
+	suite.Equal(1, suite.obLog.Len())
+	entry := suite.obLog.All()[0]
+	suite.Equal(zapcore.InfoLevel, entry.Level)
+	fields := entry.ContextMap()
+	suite.Equal(requestID, fields["request_id"])
+	suite.Equal("/users/:id", fields["route"])
+	suite.Equal("/users/42", fields["path"])
+	suite.Equal(int64(http.StatusOK), fields["status"])
+	suite.Equal(int64(2), fields["bytes"])
+	suite.Equal("user-1", fields["user_id"])
+}
+
+func (suite *AccessLogSuite) TestPropagatesRequestIDToContextLogger() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger))
+	route.GET("/", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("inside use case")
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(definition.RequestIDHeader, "abc-123")
+	w := suite.serve(route, req)
+	suite.Equal("abc-123", w.Header().Get(definition.RequestIDHeader))
+
+	suite.Equal(2, suite.obLog.Len())
+	for _, entry := range suite.obLog.All() {
+		suite.Equal("abc-123", entry.ContextMap()["request_id"])
+	}
+}
+
+func (suite *AccessLogSuite) TestRejectsUnsafeRequestID() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger))
+	route.GET("/", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(definition.RequestIDHeader, "forged\nline")
+	w := suite.serve(route, req)
+	suite.NotEqual("forged\nline", w.Header().Get(definition.RequestIDHeader))
+	suite.NotEmpty(w.Header().Get(definition.RequestIDHeader))
+}
+
+func (suite *AccessLogSuite) TestRedactsCredentials() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger))
+	route.GET("/", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/?tk=secret-token&page=2", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Accept", "application/json")
+	suite.serve(route, req)
+
+	fields := suite.obLog.All()[0].ContextMap()
+	suite.NotContains(fields["query"], "secret-token")
+	suite.Contains(fields["query"], "page=2")
+	headers := fields["headers"].(map[string]string)
+	suite.Equal(redacted, headers["Authorization"])
+	suite.Equal("application/json", headers["Accept"])
+}
+
+func (suite *AccessLogSuite) TestProblemCarriesRequestIDAndLevel() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger), errorCatcher.GinPanicErrorHandler(suite.logger, "error Gin mock"))
+	route.GET("/", func(c *gin.Context) {
+		panic(errorCatcher.ErrDatabaseConnection)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(definition.RequestIDHeader, "abc-123")
+	w := suite.serve(route, req)
+	suite.Equal(http.StatusServiceUnavailable, w.Code)
+	suite.Contains(w.Body.String(), `"request_id":"abc-123"`)
+
+	entries := suite.obLog.All()
+	suite.Len(entries, 2)
+	for _, entry := range entries {
+		suite.Equal(zapcore.ErrorLevel, entry.Level)
+		suite.Equal("abc-123", entry.ContextMap()["request_id"])
+	}
+}
+
+func (suite *AccessLogSuite) TestTraceparentPropagated() {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	route := gin.New()
+	route.Use(Tracing(), AccessLogger(suite.logger))
+	route.GET("/", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	suite.serve(route, req)
+
+	fields := suite.obLog.All()[0].ContextMap()
+	suite.Equal("4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"])
+	suite.NotEqual("00f067aa0ba902b7", fields["span_id"])
+}
+
+func (suite *AccessLogSuite) TestSkipPaths() {
+	route := gin.New()
+	route.Use(AccessLogger(suite.logger, WithSkipPaths("/ping")))
+	route.GET("/ping", func(c *gin.Context) {})
+
+	w := suite.serve(route, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	suite.NotEmpty(w.Header().Get(definition.RequestIDHeader))
+	suite.Equal(0, suite.obLog.Len())
+}
+
+func TestAccessLogSuite(t *testing.T) {
+	suite.Run(t, new(AccessLogSuite))
+}
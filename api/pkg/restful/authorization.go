@@ -0,0 +1,29 @@
+package restful
+
+import (
+	"errors"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrAuthorizationRequired = errors.New("[Authorization Required]")
+)
+
+// RequireAuthorization method
+// rejects requests the JWT guard did not authorize, so a route stays closed
+// even when JWT_GUARD is off or its prefix is added to ALLOWED_PATHS
+func RequireAuthorization(c *gin.Context) {
+	if _, ok := c.Get(definition.AuthorizationKey); !ok {
+		panic(
+			errorCatcher.ConcatError(
+				errorCatcher.ErrAuthenticate,
+				ErrAuthorizationRequired,
+				errors.New("route requires an authorized token"),
+			),
+		)
+	}
+	c.Next()
+}
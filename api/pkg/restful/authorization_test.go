@@ -0,0 +1,30 @@
+package restful
+
+import (
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRequireAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	route := gin.New()
+	route.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "authorization"))
+	route.GET("/open", RequireAuthorization, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	route.GET("/guarded", func(c *gin.Context) { c.Set(definition.AuthorizationKey, "claim") },
+		RequireAuthorization, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/open", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/guarded", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,63 @@
+package restful
+
+import (
+	"errors"
+	"hilo-api/pkg/errorCatcher"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrBindRequest = errors.New("[Bind Request Failed]")
+
+	registerTagNameOnce sync.Once
+)
+
+// RegisterValidatorTagName makes validation errors report the json/form/uri
+// field name a client sent instead of the Go struct field name
+func RegisterValidatorTagName() {
+	registerTagNameOnce.Do(func() {
+		engine, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	})
+}
+
+// MustBindJSON binds the request body, panicking with a binding error that
+// GinPanicErrorHandler renders as a 400 problem with field errors
+func MustBindJSON(c *gin.Context, obj interface{}) {
+	errorCatcher.PanicIfErr(c.ShouldBindJSON(obj), errorCatcher.ErrGinBindingAndValidate, ErrBindRequest)
+}
+
+// MustBindQuery binds the query string, see MustBindJSON
+func MustBindQuery(c *gin.Context, obj interface{}) {
+	errorCatcher.PanicIfErr(c.ShouldBindQuery(obj), errorCatcher.ErrGinBindingAndValidate, ErrBindRequest)
+}
+
+// MustBindUri binds the path parameters, see MustBindJSON
+func MustBindUri(c *gin.Context, obj interface{}) {
+	errorCatcher.PanicIfErr(c.ShouldBindUri(obj), errorCatcher.ErrGinBindingAndValidate, ErrBindRequest)
+}
+
+// MustBindHeader binds the request headers, see MustBindJSON
+func MustBindHeader(c *gin.Context, obj interface{}) {
+	errorCatcher.PanicIfErr(c.ShouldBindHeader(obj), errorCatcher.ErrGinBindingAndValidate, ErrBindRequest)
+}
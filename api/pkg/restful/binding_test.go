@@ -0,0 +1,80 @@
+package restful
+
+import (
+	"bytes"
+	"encoding/json"
+	"hilo-api/pkg/errorCatcher"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type bindingRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" binding:"required,min=3"`
+}
+
+type BindingSuite struct {
+	suite.Suite
+	route *gin.Engine
+}
+
+func (suite *BindingSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	RegisterValidatorTagName()
+	suite.route = gin.New()
+	suite.route.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "Gin Mock test binding"))
+	suite.route.POST("/bind", func(c *gin.Context) {
+		req := bindingRequest{}
+		MustBindJSON(c, &req)
+		c.JSON(http.StatusOK, req)
+	})
+}
+
+func (suite *BindingSuite) post(body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/bind", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.route.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BindingSuite) TestMustBindJSON() {
+	w := suite.post(`{"email":"test@example.com","username":"tester"}`)
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *BindingSuite) TestMustBindJSONValidationFailed() {
+	w := suite.post(`{"email":"not-an-email","username":"ab"}`)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(errorCatcher.ProblemContentType, w.Header().Get("Content-Type"))
+
+	problem := errorCatcher.Problem{}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+	suite.Equal("GIN_BINDING_AND_VALIDATE", problem.Code)
+	suite.Len(problem.Errors, 2)
+	suite.Equal("email", problem.Errors[0].Field)
+	suite.Equal("email", problem.Errors[0].Code)
+	suite.Equal("username", problem.Errors[1].Field)
+	suite.Equal("min", problem.Errors[1].Code)
+	suite.Equal("3", problem.Errors[1].Param)
+}
+
+func (suite *BindingSuite) TestMustBindJSONTypeMismatch() {
+	w := suite.post(`{"email":1,"username":"tester"}`)
+	suite.Equal(http.StatusBadRequest, w.Code)
+
+	problem := errorCatcher.Problem{}
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+	suite.Len(problem.Errors, 1)
+	suite.Equal("email", problem.Errors[0].Field)
+	suite.Equal("type", problem.Errors[0].Code)
+}
+
+func TestBindingSuite(t *testing.T) {
+	suite.Run(t, new(BindingSuite))
+}
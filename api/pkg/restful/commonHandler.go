@@ -1,6 +1,8 @@
 package restful
 
 import (
+	"hilo-api/pkg/config"
+
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
@@ -31,8 +33,25 @@ func NewPromHTTP() gin.HandlerFunc {
 	return gin.WrapH(promhttp.Handler())
 }
 
+// NewCommonHandler method
+func NewCommonHandler(cfg config.Metrics) (CommonHandler, error) {
+	guard, err := MetricsGuard(cfg)
+	if err != nil {
+		return CommonHandler{}, err
+	}
+	return CommonHandler{
+		Error404:    Error404Set,
+		QuickReply:  QuickReplySet,
+		PromHTTP:    NewPromHTTPSet,
+		PromGuard:   guard,
+		MetricsPath: cfg.MetricsPath,
+	}, nil
+}
+
 type CommonHandler struct {
-	Error404   gin.HandlerFunc
-	QuickReply gin.HandlerFunc
-	PromHTTP   gin.HandlerFunc
+	Error404    gin.HandlerFunc
+	QuickReply  gin.HandlerFunc
+	PromHTTP    gin.HandlerFunc
+	PromGuard   gin.HandlerFunc
+	MetricsPath string
 }
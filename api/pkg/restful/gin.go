@@ -1,19 +1,29 @@
 package restful
 
 import (
+	"errors"
+	"fmt"
 	"hilo-api/pkg/config"
+	"hilo-api/pkg/definition"
 	"hilo-api/pkg/errorCatcher"
 	"net/http"
+	"slices"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrCORSConfig = errors.New("[CORS Config Invalid]")
+)
+
 func NewGin(
 	logger *zap.Logger,
 	cfgServer config.Server,
+	cfgMetrics config.Metrics,
 	guarder *JWTGuarder,
+	reloader *config.Reloader,
 ) (*gin.Engine, error) {
 	if cfgServer.ReleaseMode {
 		gin.SetMode(gin.ReleaseMode)
@@ -21,14 +31,62 @@ func NewGin(
 		gin.SetMode(gin.DebugMode)
 	}
 	srv := gin.New()
+	RegisterValidatorTagName()
 
 	srv.MaxMultipartMemory = cfgServer.MaxMultipartMemoryMB << 20
 
+	corsHandler, err := newCORS(cfgServer)
+	if err != nil {
+		return nil, err
+	}
+	allowCORS := NewSwappable(corsHandler)
+	guard := NewSwappable(guarder.JWTGuarder(allowList(cfgServer, cfgMetrics)...))
+
+	fns := []gin.HandlerFunc{
+		allowCORS.Handle,
+		Tracing(),
+		AccessLogger(logger, WithSkipPaths("/ping", "/healthz", "/readyz", cfgMetrics.MetricsPath)),
+		Metrics(),
+		errorCatcher.GinPanicErrorHandler(logger, cfgServer.PrefixMessage),
+	}
+	if cfgServer.JWTGuard {
+		fns = append(fns, guard.Handle)
+	}
+	srv.Use(fns...)
+
+	if reloader != nil {
+		reloader.Subscribe(func(change config.Change) {
+			if change.Has("ALLOW_ALL_ORIGINS", "ALLOW_ORIGINS") {
+				corsHandler, err := newCORS(change.Current.Server)
+				if err != nil {
+					logger.Error("keeping previous CORS settings", zap.String("system", "Gin"), zap.Error(err))
+				} else {
+					allowCORS.Swap(corsHandler)
+				}
+			}
+			if change.Has("ALLOWED_PATHS") {
+				guard.Swap(guarder.JWTGuarder(allowList(change.Current.Server, cfgMetrics)...))
+			}
+		})
+	}
+
+	return srv, nil
+}
+
+// allowList is what the JWT guard lets through unauthenticated; /metrics is
+// protected by its own guard, see MetricsGuard
+func allowList(cfgServer config.Server, cfgMetrics config.Metrics) []string {
+	return slices.Concat(cfgServer.AllowedPaths, []string{cfgMetrics.MetricsPath})
+}
+
+// newCORS validates the origins first, cors.New panics on a bad config
+func newCORS(cfgServer config.Server) (gin.HandlerFunc, error) {
 	cf := cors.DefaultConfig()
 	cf.AllowMethods = []string{
 		http.MethodGet,
 		http.MethodPost,
 		http.MethodPut,
+		http.MethodPatch,
 		http.MethodDelete,
 		http.MethodOptions,
 		http.MethodHead,
@@ -56,12 +114,27 @@ func NewGin(
 		"Sec-WebSocket-Key",
 		"Sec-WebSocket-Version",
 		"Sec-WebSocket-Protocol",
+		"Traceparent",
+		"Tracestate",
+		definition.RequestIDHeader,
+		definition.IdempotencyKeyHeader,
+		definition.UploadOffsetHeader,
 	}
 
 	cf.ExposeHeaders = []string{
 		"Content-Length",
 		"Access-Control-Allow-Origin",
 		"Access-Control-Allow-Headers",
+		"Content-Language",
+		definition.RequestIDHeader,
+		definition.IdempotentReplayedHeader,
+		definition.UploadOffsetHeader,
+		"Location",
+		"RateLimit-Policy",
+		"RateLimit-Limit",
+		"RateLimit-Remaining",
+		"RateLimit-Reset",
+		"Retry-After",
 	}
 
 	if cfgServer.AllowAllOrigins {
@@ -69,15 +142,8 @@ func NewGin(
 	} else {
 		cf.AllowOrigins = cfgServer.AllowOrigins
 	}
-	fns := []gin.HandlerFunc{
-		cors.New(cf),
-		gin.Logger(),
-		errorCatcher.GinPanicErrorHandler(logger, cfgServer.PrefixMessage),
-	}
-	if cfgServer.JWTGuard {
-		fns = append(fns, guarder.JWTGuarder(cfgServer.AllowedPaths...))
+	if err := cf.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCORSConfig, err)
 	}
-	srv.Use(fns...)
-
-	return srv, nil
+	return cors.New(cf), nil
 }
@@ -2,10 +2,16 @@ package restful
 
 import (
 	"hilo-api/pkg/config"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
 	"hilo-api/pkg/logger"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 )
@@ -47,23 +53,58 @@ func (suite *GinSuite) SetupSuite() {
 }
 
 func (suite *GinSuite) TestNewGin() {
-	gin, err := NewGin(suite.logger, suite.originServerOption, &JWTGuarder{})
+	gin, err := NewGin(suite.logger, suite.originServerOption, config.Metrics{MetricsPath: "/metrics"}, &JWTGuarder{}, nil)
 	suite.NoError(err)
 	suite.Equal("*gin.Engine", reflect.TypeOf(gin).String())
 }
 
 func (suite *GinSuite) TestNewGinAllowOrigins() {
-	gin, err := NewGin(suite.logger, suite.originServerOption, &JWTGuarder{})
+	gin, err := NewGin(suite.logger, suite.originServerOption, config.Metrics{MetricsPath: "/metrics"}, &JWTGuarder{}, nil)
 	suite.NoError(err)
 	suite.Equal("*gin.Engine", reflect.TypeOf(gin).String())
 }
 
 func (suite *GinSuite) TestNewGinAllowOriginsReleaseAndLimitOrigin() {
-	gin, err := NewGin(suite.logger, suite.anotherServerOption, &JWTGuarder{})
+	gin, err := NewGin(suite.logger, suite.anotherServerOption, config.Metrics{MetricsPath: "/metrics"}, &JWTGuarder{}, nil)
 	suite.NoError(err)
 	suite.Equal("*gin.Engine", reflect.TypeOf(gin).String())
 }
 
+func (suite *GinSuite) TestNewGinInvalidOrigins() {
+	_, err := NewGin(suite.logger, config.Server{AllowOrigins: []string{"localhost"}}, config.Metrics{MetricsPath: "/metrics"}, &JWTGuarder{}, nil)
+	suite.ErrorIs(err, ErrCORSConfig)
+}
+
+func (suite *GinSuite) TestNewGinReload() {
+	next := config.Server{JWTGuard: true, AllowOrigins: []string{"https://app.example.org"}, AllowedPaths: []string{"/ping"}}
+	reloader := config.NewReloader(config.Set{Server: config.Server{JWTGuard: true, AllowOrigins: []string{"http://localhost"}}},
+		config.WithReloadLoader(func() (config.Set, error) {
+			return config.Set{Server: next}, nil
+		}),
+	)
+	validator := &testGuarderValidator{}
+	validator.On("Verify", mock.Anything, mock.Anything).Return(errorCatcher.ErrAuthenticate)
+	engine, err := NewGin(zap.NewNop(), reloader.Current().Server, config.Metrics{MetricsPath: "/metrics"}, NewJWTGuarder(validator), reloader)
+	suite.NoError(err)
+	engine.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("Origin", "https://app.example.org")
+		req.Header.Set(definition.AuthorizationKey, definition.AuthorizationType+"token")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	suite.Equal(http.StatusForbidden, request().Code)
+
+	_, err = reloader.Reload()
+	suite.NoError(err)
+	w := request()
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("https://app.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
 func TestGinSuite(t *testing.T) {
 	suite.Run(t, new(GinSuite))
 }
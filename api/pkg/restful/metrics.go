@@ -0,0 +1,110 @@
+package restful
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrGuardMetrics = errors.New("[Guard Metrics Failed]")
+)
+
+// Metrics records request count and latency labelled by route template
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsGuard protects the metrics endpoint with its own network allowlist
+// and basic auth. The allowlist is matched against the TCP peer rather than
+// X-Forwarded-For, so a reverse proxy in front of /metrics must be listed.
+func MetricsGuard(cfg config.Metrics) (gin.HandlerFunc, error) {
+	prefixes := make([]netip.Prefix, 0, len(cfg.MetricsAllowedNetworks))
+	for _, network := range cfg.MetricsAllowedNetworks {
+		network = strings.TrimSpace(network)
+		if network == "" {
+			continue
+		}
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			return nil, fmt.Errorf("metrics allowed network %q: %w", network, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	username, password := []byte(cfg.MetricsBasicAuthUsername), []byte(cfg.MetricsBasicAuthPassword)
+	basicAuth := len(username) > 0 || len(password) > 0
+
+	return func(c *gin.Context) {
+		if len(prefixes) > 0 && !allowedPeer(c.RemoteIP(), prefixes) {
+			panic(errorCatcher.ConcatError(
+				errorCatcher.ErrPermissionDeny,
+				ErrGuardMetrics,
+				fmt.Errorf("peer %s is not in the allowed networks", c.RemoteIP()),
+			))
+		}
+		if basicAuth {
+			user, pass, ok := c.Request.BasicAuth()
+			// evaluate both comparisons so timing does not reveal which one failed
+			userOK := subtle.ConstantTimeCompare([]byte(user), username) == 1
+			passOK := subtle.ConstantTimeCompare([]byte(pass), password) == 1
+			if !ok || !userOK || !passOK {
+				c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+				panic(errorCatcher.ConcatError(
+					errorCatcher.ErrAuthenticate,
+					ErrGuardMetrics,
+					errors.New("invalid metrics credentials"),
+				))
+			}
+		}
+		c.Next()
+	}, nil
+}
+
+func parseNetwork(network string) (netip.Prefix, error) {
+	if strings.Contains(network, "/") {
+		return netip.ParsePrefix(network)
+	}
+	addr, err := netip.ParseAddr(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func allowedPeer(ip string, prefixes []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
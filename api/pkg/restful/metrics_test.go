@@ -0,0 +1,105 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hilo-api/pkg/config"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+}
+
+func (suite *MetricsSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *MetricsSuite) guarded(cfg config.Metrics) *gin.Engine {
+	guard, err := MetricsGuard(cfg)
+	suite.NoError(err)
+	route := gin.New()
+	route.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "metrics"))
+	route.GET("/metrics", guard, func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return route
+}
+
+func (suite *MetricsSuite) request(route *gin.Engine, remoteAddr string, setup func(*http.Request)) int {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = remoteAddr
+	if setup != nil {
+		setup(req)
+	}
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (suite *MetricsSuite) TestMetricsLabelsRouteTemplate() {
+	route := gin.New()
+	route.Use(Metrics())
+	route.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	counter := metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/users/:id", "204")
+	before := testutil.ToFloat64(counter)
+	for _, path := range []string{"/users/1", "/users/2"} {
+		w := httptest.NewRecorder()
+		route.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	suite.Equal(before+2, testutil.ToFloat64(counter))
+	suite.Zero(testutil.ToFloat64(metrics.HTTPRequestsInFlight))
+}
+
+func (suite *MetricsSuite) TestGuardAllowedNetworks() {
+	route := suite.guarded(config.Metrics{MetricsAllowedNetworks: []string{"10.0.0.0/8", "192.168.1.10"}})
+	suite.Equal(http.StatusOK, suite.request(route, "10.1.2.3:4000", nil))
+	suite.Equal(http.StatusOK, suite.request(route, "192.168.1.10:4000", nil))
+	suite.Equal(http.StatusForbidden, suite.request(route, "203.0.113.7:4000", nil))
+	suite.Equal(http.StatusForbidden, suite.request(route, "203.0.113.7:4000", func(req *http.Request) {
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	}))
+}
+
+func (suite *MetricsSuite) TestGuardBasicAuth() {
+	route := suite.guarded(config.Metrics{
+		MetricsBasicAuthUsername: "prometheus",
+		MetricsBasicAuthPassword: "scrape",
+	})
+	suite.Equal(http.StatusUnauthorized, suite.request(route, "203.0.113.7:4000", nil))
+	suite.Equal(http.StatusUnauthorized, suite.request(route, "203.0.113.7:4000", func(req *http.Request) {
+		req.SetBasicAuth("prometheus", "wrong")
+	}))
+	suite.Equal(http.StatusOK, suite.request(route, "203.0.113.7:4000", func(req *http.Request) {
+		req.SetBasicAuth("prometheus", "scrape")
+	}))
+}
+
+func (suite *MetricsSuite) TestGuardInvalidNetwork() {
+	_, err := MetricsGuard(config.Metrics{MetricsAllowedNetworks: []string{"not-a-network"}})
+	suite.Error(err)
+}
+
+func (suite *MetricsSuite) TestNewGinSkipsJWTGuardForMetrics() {
+	validator := &testGuarderValidator{}
+	engine, err := NewGin(zap.NewNop(), config.Server{JWTGuard: true, AllowAllOrigins: true},
+		config.Metrics{MetricsPath: "/metrics"}, NewJWTGuarder(validator), nil)
+	suite.NoError(err)
+	handler, err := NewCommonHandler(config.Metrics{MetricsPath: "/metrics", MetricsAllowedNetworks: []string{"192.0.2.0/24"}})
+	suite.NoError(err)
+	engine.GET(handler.MetricsPath, handler.PromGuard, handler.PromHTTP)
+
+	suite.Equal(http.StatusOK, suite.request(engine, "192.0.2.1:4000", nil))
+	validator.AssertNotCalled(suite.T(), "Verify")
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
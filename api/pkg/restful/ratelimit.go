@@ -0,0 +1,93 @@
+package restful
+
+import (
+	"errors"
+	"fmt"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/ratelimit"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRateLimited = errors.New("[Rate Limited]")
+)
+
+// RateLimitKey picks the bucket a request spends from
+type RateLimitKey func(c *gin.Context) string
+
+// ByClientIP keys by the client address, for routes used before sign in
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUserOrClientIP keys by the user the JWT guard authenticated, falling
+// back to the client address; it must run after the guard
+func ByUserOrClientIP(c *gin.Context) string {
+	if id, ok := c.Get(definition.ContextUserIDKey); ok {
+		return fmt.Sprintf("user:%v", id)
+	}
+	return ByClientIP(c)
+}
+
+// NewRateLimiter method
+func NewRateLimiter(logger *zap.Logger, store ratelimit.Store) *RateLimiter {
+	return &RateLimiter{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// RateLimiter turns policies into middleware sharing one store
+type RateLimiter struct {
+	logger *zap.Logger
+	store  ratelimit.Store
+}
+
+// Limit method
+// sets the RateLimit-* headers on every response and rejects with 429 and
+// Retry-After once the bucket is empty. A failing store lets requests
+// through, an outage of the limiter should not become an outage of the API.
+func (r *RateLimiter) Limit(policy ratelimit.Policy, key RateLimitKey) gin.HandlerFunc {
+	if !policy.Enabled() {
+		return func(c *gin.Context) {}
+	}
+	return func(c *gin.Context) {
+		result, err := r.store.Take(c.Request.Context(), key(c), policy)
+		if err != nil {
+			r.logger.Warn("rate limit store failed, allowing request",
+				zap.String("system", "RateLimit"),
+				zap.String("policy", policy.Name),
+				zap.Error(err),
+			)
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Policy", policy.String())
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+		if result.Allowed {
+			return
+		}
+
+		header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+		panic(
+			errorCatcher.ConcatError(
+				errorCatcher.ErrTooManyRequests,
+				ErrRateLimited,
+				fmt.Errorf("policy %s allows %d per %s", policy.Name, policy.Limit, policy.Period),
+			),
+		)
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
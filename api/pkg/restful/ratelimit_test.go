@@ -0,0 +1,103 @@
+package restful
+
+import (
+	"context"
+	"errors"
+	"hilo-api/pkg/definition"
+	"hilo-api/pkg/errorCatcher"
+	"hilo-api/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+type RateLimitSuite struct {
+	suite.Suite
+	policy ratelimit.Policy
+}
+
+func (suite *RateLimitSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	suite.policy = ratelimit.Policy{Name: "test", Limit: 2, Period: time.Minute}
+}
+
+func (suite *RateLimitSuite) route(handler gin.HandlerFunc, before ...gin.HandlerFunc) *gin.Engine {
+	route := gin.New()
+	route.Use(errorCatcher.GinPanicErrorHandler(zap.NewNop(), "rate limit"))
+	route.Use(before...)
+	route.GET("/limited", handler, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return route
+}
+
+func (suite *RateLimitSuite) request(route *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	route.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *RateLimitSuite) TestLimitByClientIP() {
+	limiter := NewRateLimiter(zap.NewNop(), ratelimit.NewMemory())
+	route := suite.route(limiter.Limit(suite.policy, ByClientIP))
+
+	w := suite.request(route, "192.0.2.1:4000")
+	suite.Equal(http.StatusNoContent, w.Code)
+	suite.Equal("2;w=60", w.Header().Get("RateLimit-Policy"))
+	suite.Equal("2", w.Header().Get("RateLimit-Limit"))
+	suite.Equal("1", w.Header().Get("RateLimit-Remaining"))
+	suite.Equal("30", w.Header().Get("RateLimit-Reset"))
+
+	suite.Equal(http.StatusNoContent, suite.request(route, "192.0.2.1:4000").Code)
+	w = suite.request(route, "192.0.2.1:4000")
+	suite.Equal(http.StatusTooManyRequests, w.Code)
+	suite.Equal("0", w.Header().Get("RateLimit-Remaining"))
+	suite.Equal("30", w.Header().Get("Retry-After"))
+	suite.Contains(w.Body.String(), "TOO_MANY_REQUESTS")
+
+	suite.Equal(http.StatusNoContent, suite.request(route, "192.0.2.2:4000").Code)
+}
+
+func (suite *RateLimitSuite) TestLimitByUser() {
+	limiter := NewRateLimiter(zap.NewNop(), ratelimit.NewMemory())
+	user := "alice"
+	route := suite.route(limiter.Limit(suite.policy, ByUserOrClientIP), func(c *gin.Context) {
+		c.Set(definition.ContextUserIDKey, user)
+	})
+
+	suite.Equal(http.StatusNoContent, suite.request(route, "192.0.2.1:4000").Code)
+	// the same user from another address shares the bucket
+	suite.Equal(http.StatusNoContent, suite.request(route, "192.0.2.2:4000").Code)
+	suite.Equal(http.StatusTooManyRequests, suite.request(route, "192.0.2.3:4000").Code)
+
+	user = "bob"
+	suite.Equal(http.StatusNoContent, suite.request(route, "192.0.2.3:4000").Code)
+}
+
+func (suite *RateLimitSuite) TestDisabledPolicy() {
+	limiter := NewRateLimiter(zap.NewNop(), failingStore{})
+	w := suite.request(suite.route(limiter.Limit(ratelimit.Policy{Name: "off"}, ByClientIP)), "192.0.2.1:4000")
+	suite.Equal(http.StatusNoContent, w.Code)
+	suite.Empty(w.Header().Get("RateLimit-Limit"))
+}
+
+func (suite *RateLimitSuite) TestStoreFailureAllows() {
+	limiter := NewRateLimiter(zap.NewNop(), failingStore{})
+	w := suite.request(suite.route(limiter.Limit(suite.policy, ByClientIP)), "192.0.2.1:4000")
+	suite.Equal(http.StatusNoContent, w.Code)
+}
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitSuite))
+}
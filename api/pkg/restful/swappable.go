@@ -0,0 +1,31 @@
+package restful
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Swappable is a middleware whose handler can be replaced while serving,
+// the router itself cannot change its middleware after start
+type Swappable struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+// NewSwappable method
+func NewSwappable(handler gin.HandlerFunc) *Swappable {
+	s := &Swappable{}
+	s.Swap(handler)
+	return s
+}
+
+// Swap method
+// requests already inside the old handler finish with it
+func (s *Swappable) Swap(handler gin.HandlerFunc) {
+	s.handler.Store(&handler)
+}
+
+// Handle method
+func (s *Swappable) Handle(c *gin.Context) {
+	(*s.handler.Load())(c)
+}
@@ -1,17 +1,91 @@
 package shutdown
 
 import (
-	"go.uber.org/zap"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"sort"
+	"sync"
 	"syscall"
 	"time"
+
+	"go.uber.org/zap"
+)
+
+// Phase orders hooks; lower phases finish before higher ones start and hooks
+// sharing a phase run in parallel
+type Phase int
+
+const (
+	// PhaseNotReady flips readiness so load balancers stop routing here
+	PhaseNotReady Phase = iota * 10
+	// PhaseStopAccepting closes listeners so no new connections arrive
+	PhaseStopAccepting
+	// PhaseDrainConnections finishes in-flight requests, closes long-lived
+	// connections such as event streams and stops background workers
+	PhaseDrainConnections
+	// PhaseFlush pushes buffered work out, such as traces
+	PhaseFlush
+	// PhaseCloseResources releases pools and clients, such as the database
+	PhaseCloseResources
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	forcedExitCode = 1
 )
 
 var (
-	logger = zap.L()
+	ErrHookTimeout = errors.New("shutdown hook timed out")
+	ErrHookPanic   = errors.New("shutdown hook panicked")
 )
 
+// Hook is a named step of the shutdown sequence. A zero Timeout means the hook
+// may use whatever is left of the global deadline.
+type Hook struct {
+	Name    string
+	Phase   Phase
+	Timeout time.Duration
+	Fn      func(ctx context.Context) error
+}
+
+// HookResult reports how a hook finished
+type HookResult struct {
+	Name     string
+	Phase    Phase
+	Duration time.Duration
+	TimedOut bool
+	Err      error
+}
+
+// Report summarises a shutdown run
+type Report struct {
+	Elapsed time.Duration
+	Hooks   []HookResult
+}
+
+// TimedOut returns the hooks that did not finish before their deadline
+func (r Report) TimedOut() []HookResult {
+	var timedOut []HookResult
+	for _, hook := range r.Hooks {
+		if hook.TimedOut {
+			timedOut = append(timedOut, hook)
+		}
+	}
+	return timedOut
+}
+
+// Err joins the errors of every failed hook
+func (r Report) Err() error {
+	errs := make([]error, 0, len(r.Hooks))
+	for _, hook := range r.Hooks {
+		errs = append(errs, hook.Err)
+	}
+	return errors.Join(errs...)
+}
+
 // Option interface
 type Option interface {
 	Apply(*Shutdown)
@@ -46,6 +120,7 @@ func (w withDone) Apply(c *Shutdown) {
 }
 
 // WithServerTimeout method
+// sets the global deadline for running every hook
 func WithServerTimeout(duration time.Duration) Option {
 	return withServerTimeout{server: duration}
 }
@@ -60,6 +135,7 @@ func (w withServerTimeout) Apply(c *Shutdown) {
 }
 
 // WithEndTask method
+// endTask runs as a hook in PhaseCloseResources
 func WithEndTask(fn func()) Option {
 	return withEndTask{fn: fn}
 }
@@ -73,30 +149,211 @@ func (w withEndTask) Apply(c *Shutdown) {
 	c.endTask = w.fn
 }
 
+// WithHook method
+func WithHook(hook Hook) Option {
+	return withHook{hook: hook}
+}
+
+type withHook struct {
+	hook Hook
+}
+
+// Apply method
+func (w withHook) Apply(c *Shutdown) {
+	c.hooks = append(c.hooks, w.hook)
+}
+
+// WithLogger method
+func WithLogger(logger *zap.Logger) Option {
+	return withLogger{logger: logger}
+}
+
+type withLogger struct {
+	logger *zap.Logger
+}
+
+// Apply method
+func (w withLogger) Apply(c *Shutdown) {
+	c.logger = w.logger
+}
+
+// WithForceExit method
+// replaces os.Exit, called when a second signal arrives mid-shutdown
+func WithForceExit(fn func(code int)) Option {
+	return withForceExit{fn: fn}
+}
+
+type withForceExit struct {
+	fn func(code int)
+}
+
+// Apply method
+func (w withForceExit) Apply(c *Shutdown) {
+	c.forceExit = w.fn
+}
+
 // Shutdown type
 type Shutdown struct {
 	quit          chan os.Signal
 	done          chan bool
 	serverTimeout time.Duration
 	endTask       func()
+	logger        *zap.Logger
+	forceExit     func(code int)
+
+	mu    sync.Mutex
+	hooks []Hook
+}
+
+// Register adds hooks after construction, e.g. from components built by wire
+func (s *Shutdown) Register(hooks ...Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hooks...)
 }
 
 // Shutdown method
-func (s Shutdown) Shutdown() {
-	// Wait for interrupt signal to gracefully ShutdownTestSuite the server with
-	// a timeout of 5 seconds.
+// blocks until SIGINT or SIGTERM, then runs the hooks phase by phase within
+// the global deadline. A second signal exits immediately.
+func (s *Shutdown) Shutdown() Report {
+	logger := s.log()
 	signal.Notify(s.quit, syscall.SIGINT, syscall.SIGTERM)
 	<-s.quit
-	logger.Info("Start Shutdown server ...")
+	logger.Info("Start Shutdown server ...", zap.String("system", "Shutdown"))
 
-	if s.endTask != nil {
-		logger.Warn("ShutdownTestSuite endTask ...", zap.String("system", "Shutdown"))
-		s.endTask()
+	stopForce := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-s.quit:
+			logger.Warn("second signal received, forcing exit", zap.String("system", "Shutdown"), zap.Any("signal", sig))
+			s.exit(forcedExitCode)
+		case <-stopForce:
+		}
+	}()
+
+	report := s.Run(context.Background())
+	close(stopForce)
+	signal.Stop(s.quit)
+
+	for _, hook := range report.Hooks {
+		switch {
+		case hook.TimedOut:
+			logger.Warn("shutdown hook timed out", zap.String("system", "Shutdown"),
+				zap.String("hook", hook.Name), zap.Duration("duration", hook.Duration))
+		case hook.Err != nil:
+			logger.Warn("shutdown hook failed", zap.String("system", "Shutdown"),
+				zap.String("hook", hook.Name), zap.Error(hook.Err))
+		}
 	}
-	logger.Info("system Successfully Stop", zap.String("system", "Shutdown"))
+	logger.Info("system Successfully Stop", zap.String("system", "Shutdown"),
+		zap.Duration("elapsed", report.Elapsed), zap.Int("timed_out", len(report.TimedOut())))
 	if s.done != nil {
 		s.done <- true
 	}
+	return report
+}
+
+// Run executes the hooks without waiting for a signal
+func (s *Shutdown) Run(ctx context.Context) Report {
+	start := time.Now()
+	timeout := s.serverTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{}
+	for _, phase := range s.phases() {
+		report.Hooks = append(report.Hooks, runPhase(ctx, phase)...)
+	}
+	report.Elapsed = time.Since(start)
+	return report
+}
+
+func (s *Shutdown) phases() [][]Hook {
+	s.mu.Lock()
+	hooks := append([]Hook(nil), s.hooks...)
+	s.mu.Unlock()
+	if s.endTask != nil {
+		endTask := s.endTask
+		hooks = append(hooks, Hook{Name: "endTask", Phase: PhaseCloseResources, Fn: func(context.Context) error {
+			endTask()
+			return nil
+		}})
+	}
+
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Phase < hooks[j].Phase })
+	var phases [][]Hook
+	for i, hook := range hooks {
+		if i == 0 || hook.Phase != hooks[i-1].Phase {
+			phases = append(phases, nil)
+		}
+		phases[len(phases)-1] = append(phases[len(phases)-1], hook)
+	}
+	return phases
+}
+
+func runPhase(ctx context.Context, hooks []Hook) []HookResult {
+	results := make([]HookResult, len(hooks))
+	var wg sync.WaitGroup
+	for i, hook := range hooks {
+		wg.Add(1)
+		go func(i int, hook Hook) {
+			defer wg.Done()
+			results[i] = runHook(ctx, hook)
+		}(i, hook)
+	}
+	wg.Wait()
+	return results
+}
+
+func runHook(ctx context.Context, hook Hook) HookResult {
+	if hook.Fn == nil {
+		return HookResult{Name: hook.Name, Phase: hook.Phase}
+	}
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("%w: %v", ErrHookPanic, p)
+			}
+		}()
+		done <- hook.Fn(ctx)
+	}()
+
+	result := HookResult{Name: hook.Name, Phase: hook.Phase}
+	select {
+	case result.Err = <-done:
+	case <-ctx.Done():
+		// the hook keeps running in the background, but shutdown moves on
+		result.TimedOut = true
+		result.Err = errors.Join(ErrHookTimeout, ctx.Err())
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (s *Shutdown) log() *zap.Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return zap.L()
+}
+
+func (s *Shutdown) exit(code int) {
+	if s.forceExit != nil {
+		s.forceExit(code)
+		return
+	}
+	os.Exit(code)
 }
 
 // NewShutdown method
@@ -107,10 +364,11 @@ func NewShutdown(options ...Option) *Shutdown {
 	}
 
 	if shutdown.quit == nil {
-		shutdown.quit = make(chan os.Signal)
+		// buffered so a second signal is not dropped while hooks run
+		shutdown.quit = make(chan os.Signal, 2)
 	}
 	if shutdown.serverTimeout == 0 {
-		shutdown.serverTimeout = 5 * time.Second
+		shutdown.serverTimeout = defaultTimeout
 	}
 	return shutdown
 }
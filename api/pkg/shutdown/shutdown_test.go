@@ -1,9 +1,12 @@
 package shutdown
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/suite"
 	"os"
 	"reflect"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -53,3 +56,99 @@ func Test_Shutdown(t *testing.T) {
 		<-done
 	})
 }
+
+func (suite *ShutdownTestSuite) TestRunOrdersPhases() {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	s := NewShutdown(
+		WithEndTask(func() { _ = record("endTask")(context.Background()) }),
+		WithHook(Hook{Name: "close db", Phase: PhaseCloseResources, Fn: record("close db")}),
+		WithHook(Hook{Name: "http", Phase: PhaseStopAccepting, Fn: record("http")}),
+	)
+	s.Register(Hook{Name: "not ready", Phase: PhaseNotReady, Fn: record("not ready")})
+
+	report := s.Run(context.Background())
+	suite.NoError(report.Err())
+	suite.Len(report.Hooks, 4)
+	suite.Equal([]string{"not ready", "http"}, order[:2])
+	suite.ElementsMatch([]string{"close db", "endTask"}, order[2:])
+}
+
+func (suite *ShutdownTestSuite) TestRunPhaseInParallel() {
+	started := make(chan struct{}, 2)
+	wait := func(context.Context) error {
+		started <- struct{}{}
+		// each hook only returns once both have started
+		for len(started) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+	report := NewShutdown(
+		WithServerTimeout(time.Second),
+		WithHook(Hook{Name: "ws", Phase: PhaseDrainConnections, Fn: wait}),
+		WithHook(Hook{Name: "sse", Phase: PhaseDrainConnections, Fn: wait}),
+	).Run(context.Background())
+	suite.Empty(report.TimedOut())
+}
+
+func (suite *ShutdownTestSuite) TestRunReportsTimeouts() {
+	block := make(chan struct{})
+	defer close(block)
+
+	start := time.Now()
+	report := NewShutdown(
+		WithServerTimeout(200*time.Millisecond),
+		WithHook(Hook{Name: "stuck per hook", Phase: PhaseFlush, Timeout: 10 * time.Millisecond,
+			Fn: func(context.Context) error { <-block; return nil }}),
+		WithHook(Hook{Name: "stuck global", Phase: PhaseCloseResources,
+			Fn: func(context.Context) error { <-block; return nil }}),
+		WithHook(Hook{Name: "failing", Phase: PhaseCloseResources,
+			Fn: func(context.Context) error { return errors.New("boom") }}),
+		WithHook(Hook{Name: "panicking", Phase: PhaseCloseResources,
+			Fn: func(context.Context) error { panic("oops") }}),
+	).Run(context.Background())
+
+	suite.Less(time.Since(start), time.Second)
+	timedOut := report.TimedOut()
+	suite.Len(timedOut, 2)
+	suite.Equal("stuck per hook", timedOut[0].Name)
+	suite.Less(timedOut[0].Duration, 150*time.Millisecond)
+	suite.Equal("stuck global", timedOut[1].Name)
+	suite.ErrorIs(report.Err(), ErrHookTimeout)
+	suite.ErrorIs(report.Err(), ErrHookPanic)
+	suite.ErrorContains(report.Err(), "boom")
+}
+
+func (suite *ShutdownTestSuite) TestSecondSignalForcesExit() {
+	quit := make(chan os.Signal, 2)
+	exited := make(chan int, 1)
+	block := make(chan struct{})
+	defer close(block)
+
+	s := NewShutdown(
+		WithQuit(quit),
+		WithServerTimeout(time.Second),
+		WithForceExit(func(code int) { exited <- code }),
+		WithHook(Hook{Name: "slow", Phase: PhaseFlush, Fn: func(context.Context) error { <-block; return nil }}),
+	)
+	go s.Shutdown()
+	quit <- syscall.SIGTERM
+	quit <- syscall.SIGINT
+
+	select {
+	case code := <-exited:
+		suite.Equal(forcedExitCode, code)
+	case <-time.After(500 * time.Millisecond):
+		suite.Fail("second signal did not force exit")
+	}
+}
@@ -0,0 +1,111 @@
+// Package tracing configures the OpenTelemetry tracer provider and offers
+// small helpers for starting spans in use cases and repositories.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"hilo-api/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	instrumentationName = "hilo-api"
+
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+var (
+	ErrTracingInitialize = errors.New("[Tracing Initialize Failed]")
+)
+
+// NewTracerProvider installs the global tracer provider and W3C trace context
+// propagator. With the none exporter spans are still created, so trace ids
+// keep correlating logs and propagate downstream, they are just not exported.
+func NewTracerProvider(core config.Core, opt config.Tracing) (*sdktrace.TracerProvider, func(), error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(core.SystemName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opt.TracingSampleRatio))),
+	}
+
+	switch opt.TracingExporter {
+	case ExporterOTLP:
+		clientOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opt.TracingEndpoint)}
+		if opt.TracingInsecure {
+			clientOptions = append(clientOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), clientOptions...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTracingInitialize, err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTracingInitialize, err)
+		}
+		options = append(options, sdktrace.WithSyncer(exporter))
+	case ExporterNone, "":
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown exporter %q", ErrTracingInitialize, opt.TracingExporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	cleanup := func() {
+		_ = provider.Shutdown(context.Background())
+	}
+	return provider, cleanup, nil
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start opens a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, options...)
+}
+
+// End records *err on span, if any, and ends it. Use it deferred with a named
+// error result: defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// ZapFields returns the trace and span id of ctx for log correlation
+func ZapFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hilo-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	for _, exporter := range []string{ExporterNone, ExporterStdout, ExporterOTLP} {
+		t.Run(exporter, func(t *testing.T) {
+			provider, cleanup, err := NewTracerProvider(config.Core{SystemName: "test"}, config.Tracing{
+				TracingExporter:    exporter,
+				TracingEndpoint:    "localhost:4318",
+				TracingInsecure:    true,
+				TracingSampleRatio: 1,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Same(t, provider, otel.GetTracerProvider())
+		})
+	}
+
+	_, _, err := NewTracerProvider(config.Core{}, config.Tracing{TracingExporter: "jaeger"})
+	assert.ErrorIs(t, err, ErrTracingInitialize)
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	func() (err error) {
+		ctx, span := Start(context.Background(), "failing")
+		defer End(span, &err)
+		assert.Len(t, ZapFields(ctx), 2)
+		return errors.New("boom")
+	}()
+	func() (err error) {
+		_, span := Start(context.Background(), "passing")
+		defer End(span, &err)
+		return nil
+	}()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Empty(t, ZapFields(context.Background()))
+}
@@ -0,0 +1,193 @@
+// Command errcatalog generates the problem catalog registration for the
+// sentinel errors of a package.
+//
+// Every top-level `ErrXxx = errors.New("...")` annotated with a
+// `// problem:<status>` comment is registered with errorCatcher under a
+// stable code derived from its identifier (ErrEmailAlreadyExists becomes
+// EMAIL_ALREADY_EXISTS) and a title derived from its message.
+//
+// Usage, from a go:generate directive:
+//
+//	//go:generate go run hilo-api/tools/errcatalog -out catalog_gen.go error.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const errorCatcherImport = "hilo-api/pkg/errorCatcher"
+
+var annotation = regexp.MustCompile(`problem:(\d{3})`)
+
+type entry struct {
+	Name   string
+	Code   string
+	Title  string
+	Status int
+}
+
+func main() {
+	out := flag.String("out", "catalog_gen.go", "output file")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("errcatalog: no source files given")
+	}
+
+	pkgName, entries, err := collect(flag.Args())
+	if err != nil {
+		log.Fatalf("errcatalog: %v", err)
+	}
+
+	src, err := render(pkgName, entries)
+	if err != nil {
+		log.Fatalf("errcatalog: %v", err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("errcatalog: %v", err)
+	}
+}
+
+func collect(files []string) (string, []entry, error) {
+	fset := token.NewFileSet()
+	var (
+		pkgName string
+		entries []entry
+	)
+
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		if pkgName != "" && pkgName != f.Name.Name {
+			return "", nil, fmt.Errorf("%s: package %s differs from %s", file, f.Name.Name, pkgName)
+		}
+		pkgName = f.Name.Name
+
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				status, ok := statusOf(vs)
+				if !ok || len(vs.Names) != 1 || len(vs.Values) != 1 {
+					continue
+				}
+				message, ok := errorsNewMessage(vs.Values[0])
+				if !ok {
+					continue
+				}
+				name := vs.Names[0].Name
+				entries = append(entries, entry{
+					Name:   name,
+					Code:   codeOf(name),
+					Title:  titleOf(message),
+					Status: status,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return pkgName, entries, nil
+}
+
+func statusOf(vs *ast.ValueSpec) (int, bool) {
+	for _, group := range []*ast.CommentGroup{vs.Comment, vs.Doc} {
+		if group == nil {
+			continue
+		}
+		if m := annotation.FindStringSubmatch(group.Text()); m != nil {
+			status, _ := strconv.Atoi(m[1])
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+func errorsNewMessage(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "New" {
+		return "", false
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "errors" {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	message, err := strconv.Unquote(lit.Value)
+	return message, err == nil
+}
+
+// codeOf turns ErrJWTExecute into JWT_EXECUTE
+func codeOf(name string) string {
+	runes := []rune(strings.TrimPrefix(name, "Err"))
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// titleOf turns "[DATABASE ROW NOT FOUND]" into "Database row not found"
+func titleOf(message string) string {
+	title := strings.ToLower(strings.Trim(message, "[] "))
+	if title == "" {
+		return title
+	}
+	runes := []rune(title)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func render(pkgName string, entries []entry) ([]byte, error) {
+	qualifier := "errorCatcher."
+	if pkgName == "errorCatcher" {
+		qualifier = ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by errcatalog. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	if qualifier != "" {
+		fmt.Fprintf(&buf, "import %q\n\n", errorCatcherImport)
+	}
+	fmt.Fprintln(&buf, "func init() {")
+	fmt.Fprintf(&buf, "\t%sRegisterProblems(\n", qualifier)
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "\t\t%sProblemEntry{Err: %s, Code: %q, Title: %q, Status: %d},\n",
+			qualifier, e.Name, e.Code, e.Title, e.Status)
+	}
+	fmt.Fprintln(&buf, "\t)")
+	fmt.Fprintln(&buf, "}")
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, "EMAIL_ALREADY_EXISTS", codeOf("ErrEmailAlreadyExists"))
+	assert.Equal(t, "JWT_EXECUTE", codeOf("ErrJWTExecute"))
+	assert.Equal(t, "JSON_MARSHAL", codeOf("ErrJSONMarshal"))
+	assert.Equal(t, "DATABASE_ROW_NOT_FOUND", codeOf("ErrDatabaseRowNotFound"))
+}
+
+func TestTitleOf(t *testing.T) {
+	assert.Equal(t, "Database row not found", titleOf("[DATABASE ROW NOT FOUND]"))
+	assert.Equal(t, "Email already exists", titleOf("email already exists"))
+}
+
+func TestCollect(t *testing.T) {
+	src := `package sample
+
+import "errors"
+
+var (
+	ErrAnnotated   = errors.New("annotated error") // problem:409
+	ErrUnannotated = errors.New("unannotated error")
+	// problem:404
+	ErrDocAnnotated = errors.New("doc annotated")
+)
+`
+	file := filepath.Join(t.TempDir(), "error.go")
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+
+	pkgName, entries, err := collect([]string{file})
+	require.NoError(t, err)
+	assert.Equal(t, "sample", pkgName)
+	require.Len(t, entries, 2)
+	assert.Equal(t, entry{Name: "ErrAnnotated", Code: "ANNOTATED", Title: "Annotated error", Status: 409}, entries[0])
+	assert.Equal(t, entry{Name: "ErrDocAnnotated", Code: "DOC_ANNOTATED", Title: "Doc annotated", Status: 404}, entries[1])
+
+	out, err := render(pkgName, entries)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `errorCatcher.ProblemEntry{Err: ErrAnnotated, Code: "ANNOTATED"`)
+}